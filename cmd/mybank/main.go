@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"syscall"
 	"time"
+	// the alpine image has no time zone database, quiet hours are set in the user's time zone
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-migrate/migrate/v4"
@@ -23,7 +25,9 @@ import (
 
 	"github.com/marco-almeida/mybank/internal/config"
 	"github.com/marco-almeida/mybank/internal/handler"
+	"github.com/marco-almeida/mybank/internal/mail"
 	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
 	"github.com/marco-almeida/mybank/internal/postgresql"
 	redisRepo "github.com/marco-almeida/mybank/internal/redis"
 	"github.com/marco-almeida/mybank/internal/service"
@@ -37,6 +41,9 @@ var interruptSignals = []os.Signal{
 	syscall.SIGINT,
 }
 
+// capturedEmailsLimit is how many emails the capture email provider keeps
+const capturedEmailsLimit = 100
+
 func main() {
 	// get env vars
 	config, err := config.LoadConfig(".")
@@ -73,8 +80,16 @@ func main() {
 		Addr: config.RedisAddress,
 	}
 
-	runTaskProcessor(ctx, waitGroup, config, connPool, redisOpt)
-	runHTPPServer(ctx, waitGroup, config, connPool, redisOpt)
+	// init capture sender, the emails are kept in memory and listed by the admin emails endpoint instead of sent
+	var capture *service.CaptureSender
+	if config.NotificationEmailProvider == service.NotificationProviderCapture {
+		capture = service.NewCaptureSender(capturedEmailsLimit)
+	}
+
+	runTaskProcessor(ctx, waitGroup, config, connPool, redisOpt, capture)
+	runTaskScheduler(ctx, waitGroup, redisOpt)
+	runOutboxRelay(ctx, waitGroup, connPool, redisOpt)
+	runHTPPServer(ctx, waitGroup, config, connPool, redisOpt, capture)
 
 	err = waitGroup.Wait()
 	if err != nil {
@@ -118,7 +133,7 @@ func runDBMigration(migrationURL string, dbSource string) error {
 	return nil
 }
 
-func newServer(config config.Config, connPool *pgxpool.Pool, redisOpt asynq.RedisClientOpt) (*http.Server, error) {
+func newServer(ctx context.Context, config config.Config, connPool *pgxpool.Pool, redisOpt asynq.RedisClientOpt, capture *service.CaptureSender) (*http.Server, error) {
 	if config.Environment != "development" && config.Environment != "testing" {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -139,12 +154,56 @@ func newServer(config config.Config, connPool *pgxpool.Pool, redisOpt asynq.Redi
 		MaxHeaderBytes:    1 << 20,
 	}
 
-	// init token maker
-	tokenMaker, err := token.NewJWTMaker(config.JWTSecret)
+	// the signer secret is a separate key so that signed login challenges and payment links don't depend on the token key
+	signerSecret, signerPreviousSecrets, err := signerSecrets(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// init token maker of the token format
+	tokenFormat := token.ResolveFormat(config.TokenFormat, config.TokenKeyDir)
+	tokenMaker, es256Maker, err := newTokenMaker(config, tokenFormat, false)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	// init token maker of the previous token format, its tokens are accepted until they expire but no longer issued
+	if config.TokenPreviousFormat != "" {
+		previousTokenFormat := token.ResolveFormat(config.TokenPreviousFormat, config.TokenKeyDir)
+		if previousTokenFormat == tokenFormat {
+			return nil, fmt.Errorf("previous token format %s is the token format", previousTokenFormat)
+		}
+
+		previousTokenMaker, previousES256Maker, err := newTokenMaker(config, previousTokenFormat, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create previous token maker: %w", err)
+		}
+
+		tokenMaker = token.NewMigrationMaker(tokenMaker, previousTokenMaker)
+		if es256Maker == nil {
+			es256Maker = previousES256Maker
+		}
+	}
+
+	// init token revocation repo, revoked access tokens are kept until they expire
+	tokenRevocationRepo := redisRepo.NewTokenRevocationRepository(redisOpt, config.AccessTokenDuration)
+
+	// init token verifier, the authenticated routes reject revoked access tokens through it and the auth service revokes
+	// them through it, so that this instance doesn't keep accepting them from its cache
+	tokenVerifier := middleware.NewTokenVerifier(tokenMaker, tokenRevocationRepo)
+
+	// init currency repo
+	currencyRepo := postgresql.NewCurrencyRepository(connPool)
+
+	// init currency service and load the currency registry
+	currencyService := service.NewCurrencyService(currencyRepo, pkg.Currencies)
+	if err := currencyService.Load(ctx); err != nil {
+		return nil, fmt.Errorf("cannot load currencies: %w", err)
+	}
+
+	// init currency handler and register routes
+	handler.NewCurrencyHandler(currencyService).RegisterRoutes(router, tokenVerifier)
+
 	// init user repo
 	userRepo := postgresql.NewUserRepository(connPool)
 
@@ -154,17 +213,29 @@ func newServer(config config.Config, connPool *pgxpool.Pool, redisOpt asynq.Redi
 	// init verify email repo
 	verifyEmailRepo := postgresql.NewVerifyEmailRepository(connPool)
 
-	// init auth service
-	authService := service.NewAuthService(userRepo, sessionRepo, tokenMaker, config.AccessTokenDuration, config.RefreshTokenDuration, verifyEmailRepo)
+	// init password reset repo
+	passwordResetRepo := postgresql.NewPasswordResetRepository(connPool)
+
+	// init two factor repo
+	twoFactorRepo := postgresql.NewTwoFactorRepository(connPool)
 
-	// init userverifymail repo
-	userVerifyEmailRepo := redisRepo.NewUserMessageBrokerRepository(redisOpt)
+	// init user message broker repo
+	userBrokerRepo := redisRepo.NewUserMessageBrokerRepository(redisOpt)
+
+	// init login challenge signer
+	challengeSigner, err := token.NewLoginChallengeSigner(signerSecret, signerPreviousSecrets...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create login challenge signer: %w", err)
+	}
+
+	// init auth service
+	authService := service.NewAuthService(userRepo, sessionRepo, tokenMaker, config.AccessTokenDuration, config.RefreshTokenDuration, verifyEmailRepo, passwordResetRepo, userBrokerRepo, twoFactorRepo, challengeSigner, tokenVerifier)
 
 	// init user service
-	userService := service.NewUserService(userRepo, authService, userVerifyEmailRepo)
+	userService := service.NewUserService(userRepo, authService)
 
 	// init user handler and register routes
-	handler.NewUserHandler(userService).RegisterRoutes(router, tokenMaker)
+	handler.NewUserHandler(userService).RegisterRoutes(router, tokenVerifier)
 
 	// init account repo
 	accountRepo := postgresql.NewAccountRepository(connPool)
@@ -172,8 +243,17 @@ func newServer(config config.Config, connPool *pgxpool.Pool, redisOpt asynq.Redi
 	// init account service
 	accountService := service.NewAccountService(accountRepo)
 
+	// init pocket repo
+	pocketRepo := postgresql.NewPocketRepository(connPool)
+
+	// init pocket service
+	pocketService := service.NewPocketService(pocketRepo)
+
 	// init account handler and register routes
-	handler.NewAccountHandler(accountService).RegisterRoutes(router, tokenMaker)
+	handler.NewAccountHandler(accountService, pocketService).RegisterRoutes(router, tokenVerifier)
+
+	// init pocket handler and register routes
+	handler.NewPocketHandler(pocketService, accountService).RegisterRoutes(router, tokenVerifier)
 
 	// init transfer repo
 	transferRepo := postgresql.NewTransferRepository(connPool)
@@ -182,13 +262,173 @@ func newServer(config config.Config, connPool *pgxpool.Pool, redisOpt asynq.Redi
 	transferService := service.NewTransferService(transferRepo)
 
 	// init transfer handler and register routes
-	handler.NewTransferHandler(transferService, accountService).RegisterRoutes(router, tokenMaker)
+	handler.NewTransferHandler(transferService, accountService).RegisterRoutes(router, tokenVerifier)
+
+	// init loan repo
+	loanRepo := postgresql.NewLoanRepository(connPool)
+
+	// init loan service
+	loanService := service.NewLoanService(loanRepo)
+
+	// init loan handler and register routes
+	handler.NewLoanHandler(loanService, accountService).RegisterRoutes(router, tokenVerifier)
+
+	// init payment request repo
+	paymentRequestRepo := postgresql.NewPaymentRequestRepository(connPool)
+
+	// init payment request message broker repo
+	paymentRequestBrokerRepo := redisRepo.NewPaymentRequestMessageBrokerRepository(redisOpt)
+
+	// init payment request service
+	paymentRequestService := service.NewPaymentRequestService(paymentRequestRepo)
+
+	// init payment request handler and register routes
+	handler.NewPaymentRequestHandler(paymentRequestService, accountService).RegisterRoutes(router, tokenVerifier)
+
+	// init bill repo
+	billRepo := postgresql.NewBillRepository(connPool)
+
+	// init bill service
+	billService := service.NewBillService(billRepo, paymentRequestBrokerRepo)
+
+	// init bill handler and register routes
+	handler.NewBillHandler(billService, accountService).RegisterRoutes(router, tokenVerifier)
+
+	// init payment link repo
+	paymentLinkRepo := postgresql.NewPaymentLinkRepository(connPool)
+
+	// init payment link signer
+	paymentLinkSigner, err := token.NewPaymentLinkSigner(signerSecret, signerPreviousSecrets...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create payment link signer: %w", err)
+	}
+
+	// init payment link service
+	paymentLinkService := service.NewPaymentLinkService(paymentLinkRepo, paymentLinkSigner, config.PublicBaseURL)
+
+	// init payment link handler and register routes
+	handler.NewPaymentLinkHandler(paymentLinkService, accountService).RegisterRoutes(router, tokenVerifier)
+
+	// init statement repo
+	statementRepo := postgresql.NewStatementRepository(connPool)
+
+	// init statement service
+	statementService := service.NewStatementService(statementRepo, config.EmailSenderName)
+
+	// init statement handler and register routes
+	handler.NewStatementHandler(statementService, accountService).RegisterRoutes(router, tokenVerifier)
+
+	// init webhook repo
+	webhookRepo := postgresql.NewWebhookRepository(connPool)
+
+	// init webhook message broker repo
+	webhookBrokerRepo := redisRepo.NewWebhookMessageBrokerRepository(redisOpt)
+
+	// init webhook service
+	webhookService := service.NewWebhookService(webhookRepo, webhookBrokerRepo, config.Environment != "development")
+
+	// init webhook handler and register routes
+	handler.NewWebhookHandler(webhookService).RegisterRoutes(router, tokenVerifier)
+
+	// init notification repo
+	notificationRepo := postgresql.NewNotificationRepository(connPool)
+
+	// init notification service
+	notificationService := service.NewNotificationService(notificationRepo)
+
+	// init notification handler and register routes
+	handler.NewNotificationHandler(notificationService, accountService).RegisterRoutes(router, tokenVerifier)
+
+	// init outbox service, the server only looks after parked events so it doesn't publish
+	outboxService := service.NewOutboxService(postgresql.NewOutboxRepository(connPool), nil)
+
+	// init outbox handler and register routes
+	handler.NewOutboxHandler(outboxService).RegisterRoutes(router, tokenVerifier)
+
+	// init email handler and register routes, only when emails are captured
+	if capture != nil {
+		handler.NewEmailHandler(capture).RegisterRoutes(router, tokenVerifier)
+	}
+
+	// init jwks handler and register routes, only when the tokens are signed with asymmetric keys
+	if es256Maker != nil {
+		handler.NewJWKSHandler(es256Maker).RegisterRoutes(router)
+	}
 
 	return srv, nil
 }
 
-func runHTPPServer(ctx context.Context, waitGroup *errgroup.Group, config config.Config, connPool *pgxpool.Pool, redisOpt asynq.RedisClientOpt) {
-	server, err := newServer(config, connPool, redisOpt)
+// newTokenMaker creates the token maker of format, a maker that only verifies tokens if verifyOnly is true.
+// The ES256 maker is also returned when the tokens are JWTs signed with the keys of the token key dir, so that its
+// public keys can be served.
+// signerSecrets returns the key login challenges and payment links are signed with and the keys they are only verified
+// with. Deployments from before SIGNER_SECRET keep signing with JWT_SECRET until it is set, and moving off JWT_SECRET
+// is SIGNER_SECRET with SIGNER_PREVIOUS_SECRET=JWT_SECRET, so that the payment links already shared stay valid
+func signerSecrets(config config.Config) (string, []string, error) {
+	if config.SignerSecret == "" {
+		if config.SignerPreviousSecret != "" {
+			return "", nil, fmt.Errorf("SIGNER_PREVIOUS_SECRET is set without SIGNER_SECRET")
+		}
+		if config.JWTSecret == "" {
+			return "", nil, fmt.Errorf("SIGNER_SECRET is required")
+		}
+		log.Warn().Msg("SIGNER_SECRET is not set, login challenges and payment links are signed with JWT_SECRET")
+		return config.JWTSecret, nil, nil
+	}
+
+	if config.SignerSecret == config.JWTSecret {
+		return "", nil, fmt.Errorf("SIGNER_SECRET must not be JWT_SECRET")
+	}
+
+	if config.SignerPreviousSecret == "" {
+		return config.SignerSecret, nil, nil
+	}
+	if config.SignerPreviousSecret == config.SignerSecret {
+		return "", nil, fmt.Errorf("SIGNER_PREVIOUS_SECRET is SIGNER_SECRET")
+	}
+	return config.SignerSecret, []string{config.SignerPreviousSecret}, nil
+}
+
+func newTokenMaker(config config.Config, format string, verifyOnly bool) (token.Maker, *token.ES256Maker, error) {
+	tokenSigningKeyID := config.TokenSigningKeyID
+	pasetoSigningKeyID := config.PasetoSigningKeyID
+	if verifyOnly {
+		tokenSigningKeyID = token.VerifyOnly
+		pasetoSigningKeyID = token.VerifyOnly
+	}
+
+	switch format {
+	case token.FormatJWTHS256:
+		maker, err := token.NewJWTMaker(config.JWTSecret)
+		return maker, nil, err
+	case token.FormatJWTES256:
+		maker, err := token.NewES256Maker(config.TokenKeyDir, tokenSigningKeyID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return maker, maker, nil
+	case token.FormatPasetoV2Local:
+		maker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+		return maker, nil, err
+	case token.FormatPasetoV4Local:
+		maker, err := token.NewPasetoV4LocalMaker(config.PasetoKeyDir, pasetoSigningKeyID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return maker, nil, nil
+	case token.FormatPasetoV4Public:
+		maker, err := token.NewPasetoV4PublicMaker(config.PasetoKeyDir, pasetoSigningKeyID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return maker, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown token format %q", format)
+	}
+}
+
+func runHTPPServer(ctx context.Context, waitGroup *errgroup.Group, config config.Config, connPool *pgxpool.Pool, redisOpt asynq.RedisClientOpt, capture *service.CaptureSender) {
+	server, err := newServer(ctx, config, connPool, redisOpt, capture)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot create HTTP server")
 	}
@@ -222,9 +462,19 @@ func runTaskProcessor(
 	config config.Config,
 	pool *pgxpool.Pool,
 	redisOpt asynq.RedisClientOpt,
+	capture *service.CaptureSender,
 ) {
-	// init mailer service
-	mailer := service.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
+	// init notifiers of the email, sms and push channels with the providers set in config
+	notifiers, err := service.NewNotifiers(config, capture)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create notifiers")
+	}
+
+	// init email renderer, links in the emails point to the public base url
+	renderer, err := mail.NewRenderer(config.PublicBaseURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot parse email templates")
+	}
 
 	// init user repo
 	userRepo := postgresql.NewUserRepository(pool)
@@ -232,7 +482,46 @@ func runTaskProcessor(
 	// init verify email repo
 	verifyEmailRepo := postgresql.NewVerifyEmailRepository(pool)
 
-	taskProcessor := redisSvc.NewRedisTaskProcessor(redisOpt, mailer, userRepo, verifyEmailRepo)
+	// init password reset repo
+	passwordResetRepo := postgresql.NewPasswordResetRepository(pool)
+
+	// init loan repo
+	loanRepo := postgresql.NewLoanRepository(pool)
+
+	// init loan service
+	loanService := service.NewLoanService(loanRepo)
+
+	// init payment request repo
+	paymentRequestRepo := postgresql.NewPaymentRequestRepository(pool)
+
+	// init payment request service
+	paymentRequestService := service.NewPaymentRequestService(paymentRequestRepo)
+
+	// init statement repo
+	statementRepo := postgresql.NewStatementRepository(pool)
+
+	// init statement service, the bank name on the statements is the email sender name
+	statementService := service.NewStatementService(statementRepo, config.EmailSenderName)
+
+	// init webhook repo
+	webhookRepo := postgresql.NewWebhookRepository(pool)
+
+	// init webhook message broker repo
+	webhookBrokerRepo := redisRepo.NewWebhookMessageBrokerRepository(redisOpt)
+
+	// init webhook service
+	webhookService := service.NewWebhookService(webhookRepo, webhookBrokerRepo, config.Environment != "development")
+
+	// init notification message broker repo
+	notificationBrokerRepo := redisRepo.NewNotificationMessageBrokerRepository(redisOpt)
+
+	// init notification repo
+	notificationRepo := postgresql.NewNotificationRepository(pool)
+
+	// init notification service, every task checks the notification preferences with it before sending anything
+	notificationService := service.NewNotificationService(notificationRepo)
+
+	taskProcessor := redisSvc.NewRedisTaskProcessor(redisOpt, notifiers, renderer, userRepo, verifyEmailRepo, passwordResetRepo, loanService, paymentRequestRepo, paymentRequestService, statementService, webhookService, notificationBrokerRepo, notificationService)
 
 	waitGroup.Go(func() error {
 		log.Info().Msg("start task processor")
@@ -252,3 +541,46 @@ func runTaskProcessor(
 		return nil
 	})
 }
+
+func runTaskScheduler(ctx context.Context, waitGroup *errgroup.Group, redisOpt asynq.RedisClientOpt) {
+	taskScheduler := redisSvc.NewRedisTaskScheduler(redisOpt)
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start task scheduler")
+		if err := taskScheduler.Start(); err != nil {
+			return fmt.Errorf("failed to start task scheduler: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("shutting down task scheduler gracefully, press Ctrl+C again to force")
+
+		taskScheduler.Shutdown()
+		log.Info().Msg("task scheduler is stopped")
+
+		return nil
+	})
+}
+
+// outboxRelayInterval is how often the outbox is checked for events that weren't published yet
+const outboxRelayInterval = time.Second
+
+func runOutboxRelay(ctx context.Context, waitGroup *errgroup.Group, pool *pgxpool.Pool, redisOpt asynq.RedisClientOpt) {
+	// init outbox repo
+	outboxRepo := postgresql.NewOutboxRepository(pool)
+
+	// init outbox message broker repo
+	outboxBrokerRepo := redisRepo.NewOutboxMessageBrokerRepository(redisOpt)
+
+	// init outbox service
+	outboxService := service.NewOutboxService(outboxRepo, outboxBrokerRepo)
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start outbox relay")
+		outboxService.Run(ctx, outboxRelayInterval)
+		log.Info().Msg("outbox relay is stopped")
+		return nil
+	})
+}
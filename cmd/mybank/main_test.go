@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/marco-almeida/mybank/internal/config"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerSecrets(t *testing.T) {
+	jwtSecret := pkg.RandomString(32)
+	signerSecret := pkg.RandomString(32)
+
+	testCases := []struct {
+		name             string
+		config           config.Config
+		expectedSecret   string
+		expectedPrevious []string
+		expectError      bool
+	}{
+		{
+			name:           "SignerSecret",
+			config:         config.Config{JWTSecret: jwtSecret, SignerSecret: signerSecret},
+			expectedSecret: signerSecret,
+		},
+		{
+			name:           "WithoutJWTSecret",
+			config:         config.Config{SignerSecret: signerSecret},
+			expectedSecret: signerSecret,
+		},
+		{
+			name:           "UpgradeWithoutSignerSecret",
+			config:         config.Config{JWTSecret: jwtSecret},
+			expectedSecret: jwtSecret,
+		},
+		{
+			name:             "MigrateFromJWTSecret",
+			config:           config.Config{JWTSecret: jwtSecret, SignerSecret: signerSecret, SignerPreviousSecret: jwtSecret},
+			expectedSecret:   signerSecret,
+			expectedPrevious: []string{jwtSecret},
+		},
+		{
+			name:        "NoSecret",
+			config:      config.Config{},
+			expectError: true,
+		},
+		{
+			name:        "SignerSecretIsJWTSecret",
+			config:      config.Config{JWTSecret: jwtSecret, SignerSecret: jwtSecret},
+			expectError: true,
+		},
+		{
+			name:        "PreviousSecretIsSignerSecret",
+			config:      config.Config{SignerSecret: signerSecret, SignerPreviousSecret: signerSecret},
+			expectError: true,
+		},
+		{
+			name:        "PreviousSecretWithoutSignerSecret",
+			config:      config.Config{JWTSecret: jwtSecret, SignerPreviousSecret: jwtSecret},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secret, previous, err := signerSecrets(tc.config)
+			if tc.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSecret, secret)
+			require.Equal(t, tc.expectedPrevious, previous)
+		})
+	}
+}
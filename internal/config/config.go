@@ -19,14 +19,60 @@ type Config struct {
 	Environment       string `mapstructure:"MYBANK_ENV"`
 	RedisAddress      string `mapstructure:"REDIS_ADDRESS"`
 	HTTPServerAddress string `mapstructure:"MYBANK_HTTP_SERVER_ADDRESS"`
+	// PublicBaseURL is the address clients reach the server at, used to build links such as payment links
+	PublicBaseURL string `mapstructure:"MYBANK_PUBLIC_BASE_URL"`
 	// GRPCServerAddress    string        `mapstructure:"GRPC_SERVER_ADDRESS"`
 	MigrationURL string `mapstructure:"MIGRATION_URL"`
-	// TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"` // if using paseto
+	// TokenKeyDir holds the <kid>.pem ECDSA P-256 keys access and refresh tokens are signed with, when set the tokens
+	// are signed with ES256 instead of with JWTSecret and the public keys are served at /.well-known/jwks.json
+	TokenKeyDir string `mapstructure:"TOKEN_KEY_DIR"`
+	// TokenSigningKeyID is the key of TokenKeyDir that signs new tokens, it can be empty if there is only one private key
+	TokenSigningKeyID string `mapstructure:"TOKEN_SIGNING_KEY_ID"`
+	// TokenFormat is the format new tokens are issued in: jwt-hs256, jwt-es256, paseto.v2.local, paseto.v4.local or
+	// paseto.v4.public. jwt is jwt-es256 when TokenKeyDir is set and jwt-hs256 otherwise
+	TokenFormat string `mapstructure:"TOKEN_FORMAT"`
+	// TokenPreviousFormat is a format that is still accepted but no longer issued, set it when switching TokenFormat
+	// until every token of the previous format has expired
+	TokenPreviousFormat string `mapstructure:"TOKEN_PREVIOUS_FORMAT"`
+	// TokenSymmetricKey is the 32 character key of the paseto.v2.local tokens
+	TokenSymmetricKey string `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	// SignerSecret is the key login challenges and payment links are signed with, at least 32 characters and not the
+	// JWTSecret, so that it doesn't have to be kept once the tokens are signed with TokenKeyDir. Until it is set they
+	// are still signed with the JWTSecret, as they were before it existed
+	SignerSecret string `mapstructure:"SIGNER_SECRET"`
+	// SignerPreviousSecret is a key login challenges and payment links are still verified with but no longer signed
+	// with, set it to the old key when changing SignerSecret until every payment link signed with it has expired
+	SignerPreviousSecret string `mapstructure:"SIGNER_PREVIOUS_SECRET"`
+	// PasetoKeyDir holds the keys of the paseto.v4 tokens, <kid>.key hex encoded 32 byte keys for paseto.v4.local and
+	// <kid>.pem Ed25519 keys for paseto.v4.public, the key id is put in the footer of the tokens
+	PasetoKeyDir string `mapstructure:"PASETO_KEY_DIR"`
+	// PasetoSigningKeyID is the key of PasetoKeyDir that makes new tokens, it can be empty if there is only one key
+	PasetoSigningKeyID   string        `mapstructure:"PASETO_SIGNING_KEY_ID"`
 	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
 	EmailSenderName      string        `mapstructure:"EMAIL_SENDER_NAME"`
 	EmailSenderAddress   string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
 	EmailSenderPassword  string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	// SMTPUsername and SMTPPassword default to EmailSenderAddress and EmailSenderPassword
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     int    `mapstructure:"SMTP_PORT"`
+	SMTPTLSMode  string `mapstructure:"SMTP_TLS_MODE"`
+	SMTPAuth     string `mapstructure:"SMTP_AUTH"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	// NotificationEmailProvider, NotificationSMSProvider and NotificationPushProvider select how each channel is
+	// sent: smtp, gmail, capture, twilio, fcm, file or none
+	NotificationEmailProvider string `mapstructure:"NOTIFICATION_EMAIL_PROVIDER"`
+	NotificationSMSProvider   string `mapstructure:"NOTIFICATION_SMS_PROVIDER"`
+	NotificationPushProvider  string `mapstructure:"NOTIFICATION_PUSH_PROVIDER"`
+	// NotificationFileDir is where the file provider writes messages, stdout if empty
+	NotificationFileDir string `mapstructure:"NOTIFICATION_FILE_DIR"`
+	TwilioAccountSID    string `mapstructure:"TWILIO_ACCOUNT_SID"`
+	TwilioAuthToken     string `mapstructure:"TWILIO_AUTH_TOKEN"`
+	TwilioFromNumber    string `mapstructure:"TWILIO_FROM_NUMBER"`
+	// FCMProjectID defaults to the project of the service account in FCMCredentialsFile
+	FCMProjectID       string `mapstructure:"FCM_PROJECT_ID"`
+	FCMCredentialsFile string `mapstructure:"FCM_CREDENTIALS_FILE"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -43,6 +89,29 @@ func LoadConfig(path string) (Config, error) {
 	viper.SetConfigType("env")
 
 	viper.AutomaticEnv()
+	viper.SetDefault("MYBANK_PUBLIC_BASE_URL", "http://localhost:3000")
+	viper.SetDefault("TOKEN_KEY_DIR", "")
+	viper.SetDefault("TOKEN_SIGNING_KEY_ID", "")
+	viper.SetDefault("TOKEN_FORMAT", "jwt")
+	viper.SetDefault("TOKEN_PREVIOUS_FORMAT", "")
+	viper.SetDefault("TOKEN_SYMMETRIC_KEY", "")
+	viper.SetDefault("PASETO_KEY_DIR", "")
+	viper.SetDefault("PASETO_SIGNING_KEY_ID", "")
+	viper.SetDefault("SMTP_HOST", "smtp.gmail.com")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("SMTP_TLS_MODE", "starttls")
+	viper.SetDefault("SMTP_AUTH", "plain")
+	viper.SetDefault("SMTP_USERNAME", "")
+	viper.SetDefault("SMTP_PASSWORD", "")
+	viper.SetDefault("NOTIFICATION_EMAIL_PROVIDER", "smtp")
+	viper.SetDefault("NOTIFICATION_SMS_PROVIDER", "none")
+	viper.SetDefault("NOTIFICATION_PUSH_PROVIDER", "none")
+	viper.SetDefault("NOTIFICATION_FILE_DIR", "")
+	viper.SetDefault("TWILIO_ACCOUNT_SID", "")
+	viper.SetDefault("TWILIO_AUTH_TOKEN", "")
+	viper.SetDefault("TWILIO_FROM_NUMBER", "")
+	viper.SetDefault("FCM_PROJECT_ID", "")
+	viper.SetDefault("FCM_CREDENTIALS_FILE", "")
 
 	err := viper.ReadInConfig()
 	if err != nil {
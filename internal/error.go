@@ -30,11 +30,28 @@ var (
 	ErrInvalidFromAccount            = errors.New("invalid from account")
 	ErrUnverifiedAccount             = errors.New("unverified account")
 	ErrVerifyEmailNotSent            = errors.New("verify email not sent")
+	ErrInvalidVerifyEmail            = errors.New("invalid verification code")
+	ErrVerifyEmailExpired            = errors.New("verification code expired")
+	ErrVerifyEmailUsed               = errors.New("verification code already used")
+	ErrInvalidPasswordReset          = errors.New("invalid or expired password reset token")
+	ErrInvalidTwoFactorCode          = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled       = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnrolled          = errors.New("two-factor authentication not enrolled")
+	ErrTwoFactorLocked               = errors.New("too many two-factor attempts")
 	ErrInvalidToAccount              = errors.New("invalid to account")
 	ErrBalanceNotZero                = errors.New("balance not zero")
 	ErrAccountAlreadyExists          = errors.New("account already exists")
 	ErrCurrencyMismatch              = errors.New("currency mismatch")
 	ErrForbidden                     = errors.New("forbidden")
+	ErrInsufficientFunds             = errors.New("insufficient funds")
+	ErrInvalidAmount                 = errors.New("invalid amount")
+	ErrLoanNotPending                = errors.New("loan is not pending")
+	ErrPaymentRequestNotPending      = errors.New("payment request is not pending")
+	ErrPaymentRequestExpired         = errors.New("payment request expired")
+	ErrBillReminderTooSoon           = errors.New("bill reminder sent too recently")
+	ErrInvalidPaymentLink            = errors.New("invalid payment link")
+	ErrPaymentLinkExpired            = errors.New("payment link expired")
+	ErrPaymentLinkUsed               = errors.New("payment link already used")
 )
 
 // db error to internal error
@@ -26,27 +26,50 @@ type AccountService interface {
 // AccountHandler is the handler for the account service
 type AccountHandler struct {
 	accountSvc AccountService
+	pocketSvc  PocketService
 }
 
 // NewAccountHandler creates a new account handler
-func NewAccountHandler(accountSvc AccountService) *AccountHandler {
+func NewAccountHandler(accountSvc AccountService, pocketSvc PocketService) *AccountHandler {
 	return &AccountHandler{
 		accountSvc: accountSvc,
+		pocketSvc:  pocketSvc,
 	}
 }
 
 // RegisterRoutes connects the handlers to the router
-func (h *AccountHandler) RegisterRoutes(r *gin.Engine, tokenMaker token.Maker) {
-	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenMaker, []string{pkg.DepositorRole, pkg.BankerRole}))
+func (h *AccountHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
 	authRoutes.POST("/v1/accounts", h.handleCreateAccount)
 	authRoutes.GET("/v1/accounts/:id", h.handleGetAccount)
 	authRoutes.GET("/v1/accounts", h.handleListAccounts)
 	authRoutes.POST("/v1/accounts/:id/balance", h.handleUpdateAmount)
+	authRoutes.POST("/v2/accounts", h.handleCreateAccountV2)
+	authRoutes.GET("/v2/accounts/:id", h.handleGetAccountV2)
+	authRoutes.GET("/v2/accounts", h.handleListAccountsV2)
+	authRoutes.POST("/v2/accounts/:id/balance", h.handleUpdateAmountV2)
 
-	adminRoutes := r.Group("/api").Use(middleware.Authentication(tokenMaker, []string{pkg.BankerRole}))
+	adminRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.BankerRole}))
 	adminRoutes.DELETE("/v1/accounts/:id", h.handleDeleteAccount) // only accessible by bank workers (or admins)
 }
 
+// authorizedAccount returns the account if it belongs to the authenticated user, or if the user may override permissions
+func authorizedAccount(ctx *gin.Context, accountSvc AccountService, id int64) (db.Account, error) {
+	account, err := accountSvc.Get(ctx, id)
+	if err != nil {
+		return db.Account{}, err
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	overridePermission := ctx.MustGet(middleware.OverridePermissionKey).(bool)
+	if !overridePermission && account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		return db.Account{}, fmt.Errorf("%w: %s", internal.ErrNoRows, err.Error()) // user shouldnt know about other accounts
+	}
+
+	return account, nil
+}
+
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
 }
@@ -78,6 +101,39 @@ type getAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+type pocketBalance struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Balance int64  `json:"balance"`
+}
+
+// accountResponse is an account with its balance broken down by pocket.
+// Balance holds the money outside of pockets, TotalBalance includes the pockets.
+type accountResponse struct {
+	db.Account
+	FormattedBalance string          `json:"formatted_balance"`
+	TotalBalance     int64           `json:"total_balance"`
+	Pockets          []pocketBalance `json:"pockets"`
+}
+
+func newAccountResponse(account db.Account, pockets []db.Pocket) accountResponse {
+	rsp := accountResponse{
+		Account:      account,
+		TotalBalance: account.Balance,
+		Pockets:      make([]pocketBalance, 0, len(pockets)),
+	}
+	for _, pocket := range pockets {
+		rsp.TotalBalance += pocket.Balance
+		rsp.Pockets = append(rsp.Pockets, pocketBalance{
+			ID:      pocket.ID,
+			Name:    pocket.Name,
+			Balance: pocket.Balance,
+		})
+	}
+	rsp.FormattedBalance = pkg.FormatAmount(rsp.TotalBalance, account.Currency)
+	return rsp
+}
+
 func (h *AccountHandler) handleGetAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
@@ -102,7 +158,13 @@ func (h *AccountHandler) handleGetAccount(ctx *gin.Context) {
 		}
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	pockets, err := h.pocketSvc.List(ctx, account.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account, pockets))
 }
 
 type listAccountRequest struct {
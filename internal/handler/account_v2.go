@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+// API v2 exchanges amounts as decimal strings in major units, e.g. "10.50", instead of minor units.
+// Amounts are still stored as int64 minor units.
+
+// decimalAmount formats an amount in minor units as a decimal string
+func decimalAmount(amount int64, currency string) string {
+	return pkg.NewMoney(amount, currency).Decimal()
+}
+
+// parseDecimalAmount parses a decimal string into minor units, refusing amounts that would need rounding
+func parseDecimalAmount(amount string, currency string) (int64, error) {
+	money, err := pkg.ParseMoney(amount, currency, pkg.RoundUnnecessary)
+	if err != nil {
+		return 0, fmt.Errorf("%w; %w", internal.ErrInvalidAmount, err)
+	}
+	return money.Amount, nil
+}
+
+type accountResponseV2 struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	Balance   string    `json:"balance"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newAccountResponseV2(account db.Account) accountResponseV2 {
+	return accountResponseV2{
+		ID:        account.ID,
+		Owner:     account.Owner,
+		Balance:   decimalAmount(account.Balance, account.Currency),
+		Currency:  account.Currency,
+		CreatedAt: account.CreatedAt,
+	}
+}
+
+type pocketBalanceV2 struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Balance string `json:"balance"`
+}
+
+// accountDetailResponseV2 is the v2 counterpart of accountResponse
+type accountDetailResponseV2 struct {
+	accountResponseV2
+	FormattedBalance string            `json:"formatted_balance"`
+	TotalBalance     string            `json:"total_balance"`
+	Pockets          []pocketBalanceV2 `json:"pockets"`
+}
+
+func newAccountDetailResponseV2(account db.Account, pockets []db.Pocket) accountDetailResponseV2 {
+	rsp := newAccountResponse(account, pockets)
+	rspV2 := accountDetailResponseV2{
+		accountResponseV2: newAccountResponseV2(account),
+		FormattedBalance:  rsp.FormattedBalance,
+		TotalBalance:      decimalAmount(rsp.TotalBalance, account.Currency),
+		Pockets:           make([]pocketBalanceV2, 0, len(rsp.Pockets)),
+	}
+	for _, pocket := range rsp.Pockets {
+		rspV2.Pockets = append(rspV2.Pockets, pocketBalanceV2{
+			ID:      pocket.ID,
+			Name:    pocket.Name,
+			Balance: decimalAmount(pocket.Balance, account.Currency),
+		})
+	}
+	return rspV2
+}
+
+func (h *AccountHandler) handleCreateAccountV2(ctx *gin.Context) {
+	var req createAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	arg := db.CreateAccountParams{
+		Owner:    authPayload.Username,
+		Currency: req.Currency,
+		Balance:  0,
+	}
+
+	account, err := h.accountSvc.Create(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponseV2(account))
+}
+
+func (h *AccountHandler) handleGetAccountV2(ctx *gin.Context) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	account, err := authorizedAccount(ctx, h.accountSvc, req.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	pockets, err := h.pocketSvc.List(ctx, account.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountDetailResponseV2(account, pockets))
+}
+
+func (h *AccountHandler) handleListAccountsV2(ctx *gin.Context) {
+	var req listAccountRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	arg := db.ListAccountsParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	accounts, err := h.accountSvc.List(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]accountResponseV2, 0, len(accounts))
+	for _, account := range accounts {
+		rsp = append(rsp, newAccountResponseV2(account))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateAmountBodyRequestV2 struct {
+	Amount string `json:"amount" binding:"required"`
+}
+
+func (h *AccountHandler) handleUpdateAmountV2(ctx *gin.Context) {
+	var req2 updateAmountUriRequest
+	if err := ctx.ShouldBindUri(&req2); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req updateAmountBodyRequestV2
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	// the currency of the account decides how many decimal places the amount can have
+	account, err := authorizedAccount(ctx, h.accountSvc, req2.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	amount, err := parseDecimalAmount(req.Amount, account.Currency)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if amount == 0 {
+		ctx.Error(fmt.Errorf("%w; amount must not be zero", internal.ErrInvalidAmount))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	overridePermission := ctx.MustGet(middleware.OverridePermissionKey).(bool)
+	updatedAccount, err := h.accountSvc.AddBalance(ctx, authPayload.Username, overridePermission, db.AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: amount,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponseV2(updatedAccount))
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/service"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+// BillService defines the methods that the bill handler will use
+type BillService interface {
+	Create(ctx context.Context, arg db.CreateBillParams, participants []service.BillParticipant, expiresAt time.Time) (db.CreateBillTxResult, error)
+	ListByOrganizer(ctx context.Context, arg db.ListBillsByOrganizerParams) ([]db.Bill, error)
+	Summary(ctx context.Context, organizer string, id int64) (service.BillSummary, error)
+	Remind(ctx context.Context, organizer string, id int64) (int, error)
+}
+
+// BillHandler is the handler for the bill service
+type BillHandler struct {
+	billSvc    BillService
+	accountSvc AccountService
+}
+
+// NewBillHandler creates a new bill handler
+func NewBillHandler(billSvc BillService, accountSvc AccountService) *BillHandler {
+	return &BillHandler{
+		billSvc:    billSvc,
+		accountSvc: accountSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *BillHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
+	authRoutes.POST("/v1/bills", h.handleCreateBill)
+	authRoutes.GET("/v1/bills", h.handleListBills)
+	authRoutes.GET("/v1/bills/:id", h.handleGetBill)
+	authRoutes.POST("/v1/bills/:id/reminders", h.handleRemindBill)
+}
+
+type billParticipantRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Weight   int64  `json:"weight" binding:"min=0"`
+}
+
+type createBillRequest struct {
+	ToAccountID  int64                    `json:"to_account_id" binding:"required,min=1"`
+	Total        int64                    `json:"total" binding:"required,gt=0"`
+	Description  string                   `json:"description" binding:"max=140"`
+	SplitType    string                   `json:"split_type" binding:"required,oneof=equal custom"`
+	Participants []billParticipantRequest `json:"participants" binding:"required,min=1,max=50,dive"`
+	ExpiresAt    *time.Time               `json:"expires_at"`
+}
+
+func (h *BillHandler) handleCreateBill(ctx *gin.Context) {
+	var req createBillRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	expiresAt, err := expiresAtOrDefault(req.ExpiresAt, defaultPaymentRequestExpiry)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the shares are paid into one of the organizer's accounts, in its currency
+	account, err := authorizedAccount(ctx, h.accountSvc, req.ToAccountID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	participants := make([]service.BillParticipant, 0, len(req.Participants))
+	for _, participant := range req.Participants {
+		participants = append(participants, service.BillParticipant{
+			Username: participant.Username,
+			Weight:   participant.Weight,
+		})
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	result, err := h.billSvc.Create(ctx, db.CreateBillParams{
+		Organizer:   authPayload.Username,
+		ToAccountID: account.ID,
+		Total:       req.Total,
+		Currency:    account.Currency,
+		Description: req.Description,
+		SplitType:   req.SplitType,
+	}, participants, expiresAt)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type listBillsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (h *BillHandler) handleListBills(ctx *gin.Context) {
+	var req listBillsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	bills, err := h.billSvc.ListByOrganizer(ctx, db.ListBillsByOrganizerParams{
+		Organizer: authPayload.Username,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, bills)
+}
+
+type billUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (h *BillHandler) handleGetBill(ctx *gin.Context) {
+	var uri billUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	summary, err := h.billSvc.Summary(ctx, authPayload.Username, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summary)
+}
+
+type remindBillResponse struct {
+	Reminded int `json:"reminded"`
+}
+
+func (h *BillHandler) handleRemindBill(ctx *gin.Context) {
+	var uri billUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	reminded, err := h.billSvc.Remind(ctx, authPayload.Username, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, remindBillResponse{Reminded: reminded})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// CurrencyService defines the methods that the currency handler will use
+type CurrencyService interface {
+	List(ctx context.Context, enabledOnly bool) []pkg.Currency
+	SetEnabled(ctx context.Context, code string, enabled bool) (pkg.Currency, error)
+}
+
+// CurrencyHandler is the handler for the currency service
+type CurrencyHandler struct {
+	currencySvc CurrencyService
+}
+
+// NewCurrencyHandler creates a new currency handler
+func NewCurrencyHandler(currencySvc CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{
+		currencySvc: currencySvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *CurrencyHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	groupRoutes := r.Group("/api")
+	groupRoutes.GET("/v1/currencies", h.handleListCurrencies)
+
+	adminRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.AdminRole}))
+	adminRoutes.PATCH("/v1/currencies/:code", h.handleUpdateCurrency) // only accessible by admins
+}
+
+type listCurrenciesRequest struct {
+	All bool `form:"all"`
+}
+
+func (h *CurrencyHandler) handleListCurrencies(ctx *gin.Context) {
+	var req listCurrenciesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, h.currencySvc.List(ctx, !req.All))
+}
+
+type updateCurrencyUriRequest struct {
+	Code string `uri:"code" binding:"required,len=3,uppercase"`
+}
+
+type updateCurrencyBodyRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+func (h *CurrencyHandler) handleUpdateCurrency(ctx *gin.Context) {
+	var uri updateCurrencyUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req updateCurrencyBodyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	currency, err := h.currencySvc.SetEnabled(ctx, uri.Code, *req.Enabled)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, currency)
+}
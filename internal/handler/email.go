@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/service"
+)
+
+// CapturedEmailService defines the methods that the email handler will use
+type CapturedEmailService interface {
+	List(recipient string) []service.CapturedEmail
+	Clear()
+}
+
+// EmailHandler is the handler for the emails kept by the capture email provider
+type EmailHandler struct {
+	capturedEmailSvc CapturedEmailService
+}
+
+// NewEmailHandler creates a new email handler
+func NewEmailHandler(capturedEmailSvc CapturedEmailService) *EmailHandler {
+	return &EmailHandler{
+		capturedEmailSvc: capturedEmailSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *EmailHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	adminRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.AdminRole}))
+	adminRoutes.GET("/v1/admin/emails", h.handleListCapturedEmails) // only accessible by admins
+	adminRoutes.DELETE("/v1/admin/emails", h.handleClearCapturedEmails)
+}
+
+type listCapturedEmailsRequest struct {
+	To string `form:"to" binding:"omitempty,email"`
+}
+
+func (h *EmailHandler) handleListCapturedEmails(ctx *gin.Context) {
+	var req listCapturedEmailsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, h.capturedEmailSvc.List(req.To))
+}
+
+func (h *EmailHandler) handleClearCapturedEmails(ctx *gin.Context) {
+	h.capturedEmailSvc.Clear()
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
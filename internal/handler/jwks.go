@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+// KeySet defines the methods that the jwks handler will use
+type KeySet interface {
+	JWKS() token.JSONWebKeySet
+}
+
+// JWKSHandler serves the public keys the access tokens can be verified with
+type JWKSHandler struct {
+	keySet KeySet
+}
+
+// NewJWKSHandler creates a new jwks handler
+func NewJWKSHandler(keySet KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keySet: keySet,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *JWKSHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/.well-known/jwks.json", h.handleGetJWKS) // public, other services fetch it to verify our tokens
+}
+
+func (h *JWKSHandler) handleGetJWKS(ctx *gin.Context) {
+	// the keys only change on restart, a short cache keeps verifiers from fetching them on every token
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, h.keySet.JWKS())
+}
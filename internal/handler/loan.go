@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+// LoanService defines the methods that the loan handler will use
+type LoanService interface {
+	Apply(ctx context.Context, arg db.CreateLoanParams) (db.Loan, error)
+	Get(ctx context.Context, id int64) (db.Loan, error)
+	ListByOwner(ctx context.Context, arg db.ListLoansByOwnerParams) ([]db.Loan, error)
+	ListPending(ctx context.Context, limit int32, offset int32) ([]db.Loan, error)
+	ListInstalments(ctx context.Context, loanID int64) ([]db.LoanInstalment, error)
+	Approve(ctx context.Context, id int64, reviewedBy string, annualRateBps int32, lateFee int64) (db.DisburseLoanTxResult, error)
+	Reject(ctx context.Context, id int64, reviewedBy string) (db.Loan, error)
+}
+
+// LoanHandler is the handler for the loan service
+type LoanHandler struct {
+	loanSvc    LoanService
+	accountSvc AccountService
+}
+
+// NewLoanHandler creates a new loan handler
+func NewLoanHandler(loanSvc LoanService, accountSvc AccountService) *LoanHandler {
+	return &LoanHandler{
+		loanSvc:    loanSvc,
+		accountSvc: accountSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *LoanHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
+	authRoutes.POST("/v1/loans", h.handleApplyForLoan)
+	authRoutes.GET("/v1/loans", h.handleListLoans)
+	authRoutes.GET("/v1/loans/:id", h.handleGetLoan)
+
+	adminRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.BankerRole}))
+	adminRoutes.GET("/v1/loan-applications", h.handleListLoanApplications) // only accessible by bank workers (or admins)
+	adminRoutes.POST("/v1/loans/:id/approve", h.handleApproveLoan)
+	adminRoutes.POST("/v1/loans/:id/reject", h.handleRejectLoan)
+}
+
+type applyForLoanRequest struct {
+	AccountID    int64  `json:"account_id" binding:"required,min=1"`
+	Principal    int64  `json:"principal" binding:"required,gt=0"`
+	TermMonths   int32  `json:"term_months" binding:"required,min=1,max=360"`
+	ScheduleType string `json:"schedule_type" binding:"required,oneof=annuity linear"`
+}
+
+func (h *LoanHandler) handleApplyForLoan(ctx *gin.Context) {
+	var req applyForLoanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	account, err := authorizedAccount(ctx, h.accountSvc, req.AccountID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	loan, err := h.loanSvc.Apply(ctx, db.CreateLoanParams{
+		AccountID:    account.ID,
+		Principal:    req.Principal,
+		Currency:     account.Currency,
+		TermMonths:   req.TermMonths,
+		ScheduleType: req.ScheduleType,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loan)
+}
+
+type listLoansRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (h *LoanHandler) handleListLoans(ctx *gin.Context) {
+	var req listLoansRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	loans, err := h.loanSvc.ListByOwner(ctx, db.ListLoansByOwnerParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loans)
+}
+
+func (h *LoanHandler) handleListLoanApplications(ctx *gin.Context) {
+	var req listLoansRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	loans, err := h.loanSvc.ListPending(ctx, req.PageSize, (req.PageID-1)*req.PageSize)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loans)
+}
+
+type loanUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// loanResponse is a loan with its amortization schedule
+type loanResponse struct {
+	db.Loan
+	Instalments []db.LoanInstalment `json:"instalments"`
+}
+
+func (h *LoanHandler) handleGetLoan(ctx *gin.Context) {
+	var uri loanUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	loan, err := h.loanSvc.Get(ctx, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the loan is only visible to the owner of its account
+	if _, err := authorizedAccount(ctx, h.accountSvc, loan.AccountID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	instalments, err := h.loanSvc.ListInstalments(ctx, loan.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loanResponse{
+		Loan:        loan,
+		Instalments: instalments,
+	})
+}
+
+type approveLoanRequest struct {
+	AnnualRateBps int32 `json:"annual_rate_bps" binding:"min=0,max=10000"`
+	LateFee       int64 `json:"late_fee" binding:"min=0"`
+}
+
+func (h *LoanHandler) handleApproveLoan(ctx *gin.Context) {
+	var uri loanUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req approveLoanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	result, err := h.loanSvc.Approve(ctx, uri.ID, authPayload.Username, req.AnnualRateBps, req.LateFee)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *LoanHandler) handleRejectLoan(ctx *gin.Context) {
+	var uri loanUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	loan, err := h.loanSvc.Reject(ctx, uri.ID, authPayload.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loan)
+}
@@ -0,0 +1,496 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/service"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("notification_type", validNotificationType)
+		v.RegisterValidation("notification_channel", validNotificationChannel)
+		v.RegisterValidation("push_platform", validPushPlatform)
+	}
+}
+
+var validNotificationType validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if notificationType, ok := fieldLevel.Field().Interface().(string); ok {
+		return pkg.IsNotificationType(notificationType)
+	}
+	return false
+}
+
+var validNotificationChannel validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if channel, ok := fieldLevel.Field().Interface().(string); ok {
+		return pkg.IsNotificationChannel(channel)
+	}
+	return false
+}
+
+var validPushPlatform validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if platform, ok := fieldLevel.Field().Interface().(string); ok {
+		return pkg.IsPushPlatform(platform)
+	}
+	return false
+}
+
+// clockFormat is the format of the quiet hours in requests and responses
+const clockFormat = "15:04"
+
+// NotificationService defines the methods that the notification handler will use
+type NotificationService interface {
+	GetLowBalanceThreshold(ctx context.Context, accountID int64) (db.LowBalanceThreshold, error)
+	SetLowBalanceThreshold(ctx context.Context, accountID int64, amount int64) (db.LowBalanceThreshold, error)
+	DeleteLowBalanceThreshold(ctx context.Context, accountID int64) error
+	ListPreferences(ctx context.Context, username string) ([]service.NotificationPreference, error)
+	GetPreference(ctx context.Context, username string, notificationType string) (service.NotificationPreference, error)
+	SetPreference(ctx context.Context, arg service.SetNotificationPreferenceParams) (service.NotificationPreference, error)
+	DeletePreference(ctx context.Context, username string, notificationType string) error
+	RegisterPushDevice(ctx context.Context, username string, token string, platform string) (db.PushDevice, error)
+	GetPushDevice(ctx context.Context, id int64) (db.PushDevice, error)
+	ListPushDevices(ctx context.Context, username string) ([]db.PushDevice, error)
+	DeletePushDevice(ctx context.Context, id int64) error
+}
+
+// NotificationHandler is the handler for the notification service
+type NotificationHandler struct {
+	notificationSvc NotificationService
+	accountSvc      AccountService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationSvc NotificationService, accountSvc AccountService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationSvc: notificationSvc,
+		accountSvc:      accountSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *NotificationHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
+	authRoutes.GET("/v1/accounts/:id/low-balance-threshold", h.handleGetLowBalanceThreshold)
+	authRoutes.PUT("/v1/accounts/:id/low-balance-threshold", h.handleSetLowBalanceThreshold)
+	authRoutes.DELETE("/v1/accounts/:id/low-balance-threshold", h.handleDeleteLowBalanceThreshold)
+	authRoutes.GET("/v1/users/:username/notification-preferences", h.handleListNotificationPreferences)
+	authRoutes.GET("/v1/users/:username/notification-preferences/:notification_type", h.handleGetNotificationPreference)
+	authRoutes.PUT("/v1/users/:username/notification-preferences/:notification_type", h.handleSetNotificationPreference)
+	authRoutes.DELETE("/v1/users/:username/notification-preferences/:notification_type", h.handleDeleteNotificationPreference)
+	authRoutes.POST("/v1/users/:username/push-devices", h.handleRegisterPushDevice)
+	authRoutes.GET("/v1/users/:username/push-devices", h.handleListPushDevices)
+	authRoutes.DELETE("/v1/users/:username/push-devices/:id", h.handleDeletePushDevice)
+}
+
+// authorizeUser makes sure the username is the authenticated user, unless the user may override permissions
+func authorizeUser(ctx *gin.Context, username string) error {
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	overridePermission := ctx.MustGet(middleware.OverridePermissionKey).(bool)
+	if !overridePermission && username != authPayload.Username {
+		err := errors.New("user doesn't belong to the authenticated user")
+		return fmt.Errorf("%w; %w", internal.ErrForbidden, err)
+	}
+	return nil
+}
+
+type lowBalanceThresholdUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type lowBalanceThresholdResponse struct {
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+}
+
+func newLowBalanceThresholdResponse(account db.Account, threshold db.LowBalanceThreshold) lowBalanceThresholdResponse {
+	return lowBalanceThresholdResponse{
+		AccountID: threshold.AccountID,
+		Amount:    threshold.Amount,
+		Currency:  account.Currency,
+	}
+}
+
+func (h *NotificationHandler) handleGetLowBalanceThreshold(ctx *gin.Context) {
+	var uri lowBalanceThresholdUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	account, err := authorizedAccount(ctx, h.accountSvc, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	threshold, err := h.notificationSvc.GetLowBalanceThreshold(ctx, account.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newLowBalanceThresholdResponse(account, threshold))
+}
+
+type setLowBalanceThresholdRequest struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+func (h *NotificationHandler) handleSetLowBalanceThreshold(ctx *gin.Context) {
+	var uri lowBalanceThresholdUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req setLowBalanceThresholdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	account, err := authorizedAccount(ctx, h.accountSvc, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	threshold, err := h.notificationSvc.SetLowBalanceThreshold(ctx, account.ID, req.Amount)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newLowBalanceThresholdResponse(account, threshold))
+}
+
+func (h *NotificationHandler) handleDeleteLowBalanceThreshold(ctx *gin.Context) {
+	var uri lowBalanceThresholdUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	account, err := authorizedAccount(ctx, h.accountSvc, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.notificationSvc.DeleteLowBalanceThreshold(ctx, account.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+type notificationPreferencesUriRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type notificationPreferenceUriRequest struct {
+	Username         string `uri:"username" binding:"required,alphanum"`
+	NotificationType string `uri:"notification_type" binding:"required,notification_type"`
+}
+
+type quietHoursResponse struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+}
+
+type notificationPreferenceResponse struct {
+	NotificationType string              `json:"notification_type"`
+	Channels         []string            `json:"channels"`
+	QuietHours       *quietHoursResponse `json:"quiet_hours"`
+	Default          bool                `json:"default"`
+}
+
+func newNotificationPreferenceResponse(preference service.NotificationPreference) notificationPreferenceResponse {
+	rsp := notificationPreferenceResponse{
+		NotificationType: preference.NotificationType,
+		Channels:         preference.Channels,
+		Default:          preference.Default,
+	}
+	if preference.QuietHours != nil {
+		rsp.QuietHours = &quietHoursResponse{
+			Start:    formatClock(preference.QuietHours.Start),
+			End:      formatClock(preference.QuietHours.End),
+			Timezone: preference.QuietHours.Timezone,
+		}
+	}
+	return rsp
+}
+
+// formatClock formats minutes after midnight as clockFormat
+func formatClock(minutes int) string {
+	return time.Date(0, 1, 1, 0, minutes, 0, 0, time.UTC).Format(clockFormat)
+}
+
+// parseClock parses clockFormat into minutes after midnight
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse(clockFormat, clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (h *NotificationHandler) handleListNotificationPreferences(ctx *gin.Context) {
+	var uri notificationPreferencesUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := authorizeUser(ctx, uri.Username); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	preferences, err := h.notificationSvc.ListPreferences(ctx, uri.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]notificationPreferenceResponse, 0, len(preferences))
+	for _, preference := range preferences {
+		rsp = append(rsp, newNotificationPreferenceResponse(preference))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (h *NotificationHandler) handleGetNotificationPreference(ctx *gin.Context) {
+	var uri notificationPreferenceUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := authorizeUser(ctx, uri.Username); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	preference, err := h.notificationSvc.GetPreference(ctx, uri.Username, uri.NotificationType)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newNotificationPreferenceResponse(preference))
+}
+
+type quietHoursRequest struct {
+	Start    string `json:"start" binding:"required,datetime=15:04"`
+	End      string `json:"end" binding:"required,datetime=15:04,nefield=Start"`
+	Timezone string `json:"timezone" binding:"required,timezone"`
+}
+
+type setNotificationPreferenceRequest struct {
+	// an empty list turns the notification off
+	Channels   []string           `json:"channels" binding:"required,dive,notification_channel"`
+	QuietHours *quietHoursRequest `json:"quiet_hours"`
+}
+
+func (h *NotificationHandler) handleSetNotificationPreference(ctx *gin.Context) {
+	var uri notificationPreferenceUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req setNotificationPreferenceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := authorizeUser(ctx, uri.Username); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	arg := service.SetNotificationPreferenceParams{
+		Username:         uri.Username,
+		NotificationType: uri.NotificationType,
+		Channels:         req.Channels,
+	}
+	if req.QuietHours != nil {
+		start, err := parseClock(req.QuietHours.Start)
+		if err != nil {
+			ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+			return
+		}
+		end, err := parseClock(req.QuietHours.End)
+		if err != nil {
+			ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+			return
+		}
+		arg.QuietHours = &service.QuietHours{
+			Start:    start,
+			End:      end,
+			Timezone: req.QuietHours.Timezone,
+		}
+	}
+
+	preference, err := h.notificationSvc.SetPreference(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newNotificationPreferenceResponse(preference))
+}
+
+func (h *NotificationHandler) handleDeleteNotificationPreference(ctx *gin.Context) {
+	var uri notificationPreferenceUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := authorizeUser(ctx, uri.Username); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err := h.notificationSvc.DeletePreference(ctx, uri.Username, uri.NotificationType)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+type pushDevicesUriRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type pushDeviceUriRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+	ID       int64  `uri:"id" binding:"required,min=1"`
+}
+
+// pushDeviceResponse leaves the token out, it is only needed by the push provider
+type pushDeviceResponse struct {
+	ID        int64     `json:"id"`
+	Platform  string    `json:"platform"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newPushDeviceResponse(device db.PushDevice) pushDeviceResponse {
+	return pushDeviceResponse{
+		ID:        device.ID,
+		Platform:  device.Platform,
+		CreatedAt: device.CreatedAt,
+	}
+}
+
+type registerPushDeviceRequest struct {
+	Token    string `json:"token" binding:"required,max=4096"`
+	Platform string `json:"platform" binding:"required,push_platform"`
+}
+
+func (h *NotificationHandler) handleRegisterPushDevice(ctx *gin.Context) {
+	var uri pushDevicesUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req registerPushDeviceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := authorizeUser(ctx, uri.Username); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	device, err := h.notificationSvc.RegisterPushDevice(ctx, uri.Username, req.Token, req.Platform)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newPushDeviceResponse(device))
+}
+
+func (h *NotificationHandler) handleListPushDevices(ctx *gin.Context) {
+	var uri pushDevicesUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := authorizeUser(ctx, uri.Username); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	devices, err := h.notificationSvc.ListPushDevices(ctx, uri.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]pushDeviceResponse, 0, len(devices))
+	for _, device := range devices {
+		rsp = append(rsp, newPushDeviceResponse(device))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (h *NotificationHandler) handleDeletePushDevice(ctx *gin.Context) {
+	var uri pushDeviceUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := authorizeUser(ctx, uri.Username); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	device, err := h.notificationSvc.GetPushDevice(ctx, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	// devices of other users are reported as missing rather than forbidden, ids are sequential
+	if device.Username != uri.Username {
+		ctx.Error(fmt.Errorf("%w; push device %d not found", internal.ErrNoRows, uri.ID))
+		return
+	}
+
+	err = h.notificationSvc.DeletePushDevice(ctx, device.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// OutboxService defines the methods that the outbox handler will use
+type OutboxService interface {
+	ListParked(ctx context.Context, arg db.ListParkedOutboxEventsParams) ([]db.Outbox, error)
+	Requeue(ctx context.Context, id int64) (db.Outbox, error)
+}
+
+// OutboxHandler is the handler for the outbox events the relay gave up on
+type OutboxHandler struct {
+	outboxSvc OutboxService
+}
+
+// NewOutboxHandler creates a new outbox handler
+func NewOutboxHandler(outboxSvc OutboxService) *OutboxHandler {
+	return &OutboxHandler{
+		outboxSvc: outboxSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *OutboxHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	adminRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.AdminRole}))
+	adminRoutes.GET("/v1/admin/outbox/parked", h.handleListParkedOutboxEvents) // only accessible by admins
+	adminRoutes.POST("/v1/admin/outbox/:id/requeue", h.handleRequeueOutboxEvent)
+}
+
+// outboxEventResponse shows the payload as JSON rather than base64
+type outboxEventResponse struct {
+	ID            int64              `json:"id"`
+	EventType     string             `json:"event_type"`
+	AggregateID   string             `json:"aggregate_id"`
+	Payload       json.RawMessage    `json:"payload"`
+	Attempts      int32              `json:"attempts"`
+	LastError     string             `json:"last_error"`
+	ParkedAt      pgtype.Timestamptz `json:"parked_at"`
+	NextAttemptAt time.Time          `json:"next_attempt_at"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+func newOutboxEventResponse(event db.Outbox) outboxEventResponse {
+	return outboxEventResponse{
+		ID:            event.ID,
+		EventType:     event.EventType,
+		AggregateID:   event.AggregateID,
+		Payload:       event.Payload,
+		Attempts:      event.Attempts,
+		LastError:     event.LastError,
+		ParkedAt:      event.ParkedAt,
+		NextAttemptAt: event.NextAttemptAt,
+		CreatedAt:     event.CreatedAt,
+	}
+}
+
+type listParkedOutboxEventsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+func (h *OutboxHandler) handleListParkedOutboxEvents(ctx *gin.Context) {
+	var req listParkedOutboxEventsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	events, err := h.outboxSvc.ListParked(ctx, db.ListParkedOutboxEventsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]outboxEventResponse, 0, len(events))
+	for _, event := range events {
+		rsp = append(rsp, newOutboxEventResponse(event))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type outboxEventUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (h *OutboxHandler) handleRequeueOutboxEvent(ctx *gin.Context) {
+	var uri outboxEventUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	event, err := h.outboxSvc.Requeue(ctx, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newOutboxEventResponse(event))
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/service"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+// defaultPaymentLinkExpiry is how long a payment link can be used when no expiry is given
+const defaultPaymentLinkExpiry = 30 * 24 * time.Hour
+
+// PaymentLinkService defines the methods that the payment link handler will use
+type PaymentLinkService interface {
+	Create(ctx context.Context, arg db.CreatePaymentLinkParams) (service.SignedPaymentLink, error)
+	ListByOwner(ctx context.Context, arg db.ListPaymentLinksByOwnerParams) ([]service.SignedPaymentLink, error)
+	Get(ctx context.Context, owner string, id int64) (service.PaymentLinkDetails, error)
+	QRCode(ctx context.Context, owner string, id int64, size int) ([]byte, error)
+	Verify(link string) (*token.PaymentLinkPayload, error)
+	Redeem(ctx context.Context, payer string, link string, fromAccountID int64, amount int64) (db.RedeemPaymentLinkTxResult, error)
+}
+
+// PaymentLinkHandler is the handler for the payment link service
+type PaymentLinkHandler struct {
+	paymentLinkSvc PaymentLinkService
+	accountSvc     AccountService
+}
+
+// NewPaymentLinkHandler creates a new payment link handler
+func NewPaymentLinkHandler(paymentLinkSvc PaymentLinkService, accountSvc AccountService) *PaymentLinkHandler {
+	return &PaymentLinkHandler{
+		paymentLinkSvc: paymentLinkSvc,
+		accountSvc:     accountSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *PaymentLinkHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
+	authRoutes.POST("/v1/payment-links", h.handleCreatePaymentLink)
+	authRoutes.GET("/v1/payment-links", h.handleListPaymentLinks)
+	authRoutes.GET("/v1/payment-links/:id", h.handleGetPaymentLink)
+	authRoutes.GET("/v1/payment-links/:id/qr", h.handleGetPaymentLinkQRCode)
+	authRoutes.POST("/v1/payment-links/redeem", h.handleRedeemPaymentLink)
+}
+
+type createPaymentLinkRequest struct {
+	ToAccountID int64      `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64      `json:"amount" binding:"min=0"`
+	Reference   string     `json:"reference" binding:"max=140"`
+	SingleUse   bool       `json:"single_use"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func (h *PaymentLinkHandler) handleCreatePaymentLink(ctx *gin.Context) {
+	var req createPaymentLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	expiresAt, err := expiresAtOrDefault(req.ExpiresAt, defaultPaymentLinkExpiry)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// payments go into one of the caller's accounts, in its currency
+	account, err := authorizedAccount(ctx, h.accountSvc, req.ToAccountID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentLink, err := h.paymentLinkSvc.Create(ctx, db.CreatePaymentLinkParams{
+		Owner:       authPayload.Username,
+		ToAccountID: account.ID,
+		Amount:      pgtype.Int8{Int64: req.Amount, Valid: req.Amount > 0},
+		Currency:    account.Currency,
+		Reference:   req.Reference,
+		SingleUse:   req.SingleUse,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentLink)
+}
+
+type listPaymentLinksRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (h *PaymentLinkHandler) handleListPaymentLinks(ctx *gin.Context) {
+	var req listPaymentLinksRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentLinks, err := h.paymentLinkSvc.ListByOwner(ctx, db.ListPaymentLinksByOwnerParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentLinks)
+}
+
+type paymentLinkUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (h *PaymentLinkHandler) handleGetPaymentLink(ctx *gin.Context) {
+	var uri paymentLinkUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentLink, err := h.paymentLinkSvc.Get(ctx, authPayload.Username, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentLink)
+}
+
+type paymentLinkQRCodeRequest struct {
+	Size int `form:"size" binding:"omitempty,min=64,max=1024"`
+}
+
+func (h *PaymentLinkHandler) handleGetPaymentLinkQRCode(ctx *gin.Context) {
+	var uri paymentLinkUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req paymentLinkQRCodeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+	if req.Size == 0 {
+		req.Size = 256
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	png, err := h.paymentLinkSvc.QRCode(ctx, authPayload.Username, uri.ID, req.Size)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "image/png", png)
+}
+
+type redeemPaymentLinkRequest struct {
+	Token         string `json:"token" binding:"required"`
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	// Amount is only needed by links without a fixed amount
+	Amount int64 `json:"amount" binding:"min=0"`
+}
+
+func (h *PaymentLinkHandler) handleRedeemPaymentLink(ctx *gin.Context) {
+	var req redeemPaymentLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	payload, err := h.paymentLinkSvc.Verify(req.Token)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the payer pays from one of their own accounts, even when they are a banker
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	fromAccount, err := h.accountSvc.Get(ctx, req.FromAccountID)
+	if err != nil || fromAccount.Owner != authPayload.Username || fromAccount.ID == payload.ToAccountID {
+		ctx.Error(fmt.Errorf("%w: account [%d]", internal.ErrInvalidFromAccount, req.FromAccountID))
+		return
+	}
+
+	if fromAccount.Currency != payload.Currency {
+		ctx.Error(fmt.Errorf("%w: account [%d] currency mismatch: %s vs %s", internal.ErrCurrencyMismatch, fromAccount.ID, fromAccount.Currency, payload.Currency))
+		return
+	}
+
+	result, err := h.paymentLinkSvc.Redeem(ctx, authPayload.Username, req.Token, fromAccount.ID, req.Amount)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
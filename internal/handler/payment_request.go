@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+// defaultPaymentRequestExpiry is how long a payment request stays open when no expiry is given
+const defaultPaymentRequestExpiry = 7 * 24 * time.Hour
+
+// PaymentRequestService defines the methods that the payment request handler will use
+type PaymentRequestService interface {
+	Create(ctx context.Context, arg db.CreatePaymentRequestParams) (db.PaymentRequest, error)
+	Get(ctx context.Context, username string, id int64) (db.PaymentRequest, error)
+	ListIncoming(ctx context.Context, arg db.ListIncomingPaymentRequestsParams) ([]db.PaymentRequest, error)
+	ListOutgoing(ctx context.Context, arg db.ListOutgoingPaymentRequestsParams) ([]db.PaymentRequest, error)
+	Accept(ctx context.Context, payer string, id int64, fromAccountID int64) (db.AcceptPaymentRequestTxResult, error)
+	Decline(ctx context.Context, payer string, id int64) (db.PaymentRequest, error)
+}
+
+// PaymentRequestHandler is the handler for the payment request service
+type PaymentRequestHandler struct {
+	paymentRequestSvc PaymentRequestService
+	accountSvc        AccountService
+}
+
+// NewPaymentRequestHandler creates a new payment request handler
+func NewPaymentRequestHandler(paymentRequestSvc PaymentRequestService, accountSvc AccountService) *PaymentRequestHandler {
+	return &PaymentRequestHandler{
+		paymentRequestSvc: paymentRequestSvc,
+		accountSvc:        accountSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *PaymentRequestHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
+	authRoutes.POST("/v1/payment-requests", h.handleCreatePaymentRequest)
+	authRoutes.GET("/v1/payment-requests/incoming", h.handleListIncomingPaymentRequests)
+	authRoutes.GET("/v1/payment-requests/outgoing", h.handleListOutgoingPaymentRequests)
+	authRoutes.GET("/v1/payment-requests/:id", h.handleGetPaymentRequest)
+	authRoutes.POST("/v1/payment-requests/:id/accept", h.handleAcceptPaymentRequest)
+	authRoutes.POST("/v1/payment-requests/:id/decline", h.handleDeclinePaymentRequest)
+}
+
+// expiresAtOrDefault returns the requested expiry, or defaultExpiry from now if none was given
+func expiresAtOrDefault(expiresAt *time.Time, defaultExpiry time.Duration) (time.Time, error) {
+	if expiresAt == nil {
+		return time.Now().Add(defaultExpiry), nil
+	}
+	if !expiresAt.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("%w; expires_at must be in the future", internal.ErrInvalidParams)
+	}
+	return *expiresAt, nil
+}
+
+type createPaymentRequestRequest struct {
+	Payer       string     `json:"payer" binding:"required,alphanum"`
+	ToAccountID int64      `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64      `json:"amount" binding:"required,gt=0"`
+	Message     string     `json:"message" binding:"max=140"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func (h *PaymentRequestHandler) handleCreatePaymentRequest(ctx *gin.Context) {
+	var req createPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	expiresAt, err := expiresAtOrDefault(req.ExpiresAt, defaultPaymentRequestExpiry)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the money is paid into one of the requester's accounts, in its currency
+	account, err := authorizedAccount(ctx, h.accountSvc, req.ToAccountID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentRequest, err := h.paymentRequestSvc.Create(ctx, db.CreatePaymentRequestParams{
+		Requester:   authPayload.Username,
+		Payer:       req.Payer,
+		ToAccountID: account.ID,
+		Amount:      req.Amount,
+		Currency:    account.Currency,
+		Message:     req.Message,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentRequest)
+}
+
+type listPaymentRequestsRequest struct {
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Status   string `form:"status" binding:"omitempty,oneof=pending accepted declined expired"`
+}
+
+func (h *PaymentRequestHandler) handleListIncomingPaymentRequests(ctx *gin.Context) {
+	var req listPaymentRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentRequests, err := h.paymentRequestSvc.ListIncoming(ctx, db.ListIncomingPaymentRequestsParams{
+		Payer:  authPayload.Username,
+		Status: pgtype.Text{String: req.Status, Valid: req.Status != ""},
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentRequests)
+}
+
+func (h *PaymentRequestHandler) handleListOutgoingPaymentRequests(ctx *gin.Context) {
+	var req listPaymentRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentRequests, err := h.paymentRequestSvc.ListOutgoing(ctx, db.ListOutgoingPaymentRequestsParams{
+		Requester: authPayload.Username,
+		Status:    pgtype.Text{String: req.Status, Valid: req.Status != ""},
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentRequests)
+}
+
+type paymentRequestUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (h *PaymentRequestHandler) handleGetPaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentRequest, err := h.paymentRequestSvc.Get(ctx, authPayload.Username, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentRequest)
+}
+
+type acceptPaymentRequestRequest struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+}
+
+func (h *PaymentRequestHandler) handleAcceptPaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req acceptPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentRequest, err := h.paymentRequestSvc.Get(ctx, authPayload.Username, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the payer pays from one of their own accounts, even when they are a banker
+	fromAccount, err := h.accountSvc.Get(ctx, req.FromAccountID)
+	if err != nil || fromAccount.Owner != authPayload.Username {
+		ctx.Error(fmt.Errorf("%w: account [%d]", internal.ErrInvalidFromAccount, req.FromAccountID))
+		return
+	}
+
+	if fromAccount.Currency != paymentRequest.Currency {
+		ctx.Error(fmt.Errorf("%w: account [%d] currency mismatch: %s vs %s", internal.ErrCurrencyMismatch, fromAccount.ID, fromAccount.Currency, paymentRequest.Currency))
+		return
+	}
+
+	result, err := h.paymentRequestSvc.Accept(ctx, authPayload.Username, uri.ID, fromAccount.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *PaymentRequestHandler) handleDeclinePaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	paymentRequest, err := h.paymentRequestSvc.Decline(ctx, authPayload.Username, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentRequest)
+}
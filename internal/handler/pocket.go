@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/service"
+)
+
+// PocketService defines the methods that the pocket handler will use
+type PocketService interface {
+	Create(ctx context.Context, arg db.CreatePocketParams) (db.Pocket, error)
+	Get(ctx context.Context, accountID int64, id int64) (db.Pocket, error)
+	List(ctx context.Context, accountID int64) ([]db.Pocket, error)
+	Delete(ctx context.Context, accountID int64, id int64) error
+	Deposit(ctx context.Context, accountID int64, id int64, amount int64) (db.PocketTransferTxResult, error)
+	Withdraw(ctx context.Context, accountID int64, id int64, amount int64) (db.PocketTransferTxResult, error)
+	Progress(ctx context.Context, accountID int64, id int64) (service.PocketProgress, error)
+}
+
+// PocketHandler is the handler for the pocket service
+type PocketHandler struct {
+	pocketSvc  PocketService
+	accountSvc AccountService
+}
+
+// NewPocketHandler creates a new pocket handler
+func NewPocketHandler(pocketSvc PocketService, accountSvc AccountService) *PocketHandler {
+	return &PocketHandler{
+		pocketSvc:  pocketSvc,
+		accountSvc: accountSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *PocketHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
+	authRoutes.POST("/v1/accounts/:id/pockets", h.handleCreatePocket)
+	authRoutes.GET("/v1/accounts/:id/pockets", h.handleListPockets)
+	authRoutes.DELETE("/v1/accounts/:id/pockets/:pocket_id", h.handleDeletePocket)
+	authRoutes.GET("/v1/accounts/:id/pockets/:pocket_id/progress", h.handleGetPocketProgress)
+	authRoutes.POST("/v1/accounts/:id/pockets/:pocket_id/deposit", h.handleDepositIntoPocket)
+	authRoutes.POST("/v1/accounts/:id/pockets/:pocket_id/withdraw", h.handleWithdrawFromPocket)
+}
+
+// authorizeAccount makes sure the account exists and belongs to the authenticated user
+func (h *PocketHandler) authorizeAccount(ctx *gin.Context, accountID int64) error {
+	_, err := authorizedAccount(ctx, h.accountSvc, accountID)
+	return err
+}
+
+type pocketAccountUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type pocketUriRequest struct {
+	ID       int64 `uri:"id" binding:"required,min=1"`
+	PocketID int64 `uri:"pocket_id" binding:"required,min=1"`
+}
+
+type createPocketRequest struct {
+	Name         string     `json:"name" binding:"required,max=64"`
+	TargetAmount int64      `json:"target_amount" binding:"required,gt=0"`
+	TargetDate   *time.Time `json:"target_date"`
+}
+
+func (h *PocketHandler) handleCreatePocket(ctx *gin.Context) {
+	var uri pocketAccountUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req createPocketRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := h.authorizeAccount(ctx, uri.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	arg := db.CreatePocketParams{
+		AccountID:    uri.ID,
+		Name:         req.Name,
+		TargetAmount: req.TargetAmount,
+	}
+	if req.TargetDate != nil {
+		arg.TargetDate = pgtype.Timestamptz{Time: *req.TargetDate, Valid: true}
+	}
+
+	pocket, err := h.pocketSvc.Create(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pocket)
+}
+
+func (h *PocketHandler) handleListPockets(ctx *gin.Context) {
+	var uri pocketAccountUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := h.authorizeAccount(ctx, uri.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	pockets, err := h.pocketSvc.List(ctx, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pockets)
+}
+
+func (h *PocketHandler) handleDeletePocket(ctx *gin.Context) {
+	var uri pocketUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := h.authorizeAccount(ctx, uri.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err := h.pocketSvc.Delete(ctx, uri.ID, uri.PocketID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (h *PocketHandler) handleGetPocketProgress(ctx *gin.Context) {
+	var uri pocketUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := h.authorizeAccount(ctx, uri.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	progress, err := h.pocketSvc.Progress(ctx, uri.ID, uri.PocketID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, progress)
+}
+
+type pocketTransferRequest struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+func (h *PocketHandler) handleDepositIntoPocket(ctx *gin.Context) {
+	var uri pocketUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req pocketTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := h.authorizeAccount(ctx, uri.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	result, err := h.pocketSvc.Deposit(ctx, uri.ID, uri.PocketID, req.Amount)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *PocketHandler) handleWithdrawFromPocket(ctx *gin.Context) {
+	var uri pocketUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req pocketTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	if err := h.authorizeAccount(ctx, uri.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	result, err := h.pocketSvc.Withdraw(ctx, uri.ID, uri.PocketID, req.Amount)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
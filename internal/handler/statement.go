@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/statement"
+)
+
+// StatementService defines the methods that the statement handler will use
+type StatementService interface {
+	Export(ctx context.Context, account db.Account, from time.Time, to time.Time, enc statement.Encoder) error
+	List(ctx context.Context, arg db.ListStatementsParams) ([]db.ListStatementsRow, error)
+	Get(ctx context.Context, accountID int64, id int64) (db.Statement, error)
+}
+
+// StatementHandler is the handler for the statement service
+type StatementHandler struct {
+	statementSvc StatementService
+	accountSvc   AccountService
+}
+
+// NewStatementHandler creates a new statement handler
+func NewStatementHandler(statementSvc StatementService, accountSvc AccountService) *StatementHandler {
+	return &StatementHandler{
+		statementSvc: statementSvc,
+		accountSvc:   accountSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *StatementHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
+	// the format is the extension of the path, e.g. /v1/accounts/1/statement.csv
+	authRoutes.GET("/v1/accounts/:id/statement.csv", h.handleGetStatement)
+	authRoutes.GET("/v1/accounts/:id/statement.ofx", h.handleGetStatement)
+	authRoutes.GET("/v1/accounts/:id/statement.camt053", h.handleGetStatement)
+	// monthly PDF statements archived by the task processor
+	authRoutes.GET("/v1/accounts/:id/statements", h.handleListStatements)
+	authRoutes.GET("/v1/accounts/:id/statements/:statement_id", h.handleDownloadStatement)
+}
+
+type statementUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type statementRequest struct {
+	From time.Time `form:"from" binding:"required" time_format:"2006-01-02" time_utc:"1"`
+	To   time.Time `form:"to" binding:"required,gtefield=From" time_format:"2006-01-02" time_utc:"1"`
+}
+
+func (h *StatementHandler) handleGetStatement(ctx *gin.Context) {
+	var uri statementUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req statementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	format, ok := statement.LookupFormat(strings.TrimPrefix(path.Ext(ctx.FullPath()), "."))
+	if !ok {
+		ctx.Error(fmt.Errorf("%w; unknown statement format", internal.ErrInvalidParams))
+		return
+	}
+
+	account, err := authorizedAccount(ctx, h.accountSvc, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s.%s", account.ID, req.From.Format("20060102"), req.To.Format("20060102"), format.Extension)
+	ctx.Header("Content-Type", format.ContentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Status(http.StatusOK)
+
+	err = h.statementSvc.Export(ctx, account, req.From, req.To, format.NewEncoder(ctx.Writer))
+	if err != nil {
+		// the error response replaces the file if nothing was streamed yet
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+		}
+		ctx.Error(err)
+	}
+}
+
+type listStatementsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (h *StatementHandler) handleListStatements(ctx *gin.Context) {
+	var uri statementUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req listStatementsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	account, err := authorizedAccount(ctx, h.accountSvc, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	statements, err := h.statementSvc.List(ctx, db.ListStatementsParams{
+		AccountID: account.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, statements)
+}
+
+type downloadStatementUriRequest struct {
+	ID          int64 `uri:"id" binding:"required,min=1"`
+	StatementID int64 `uri:"statement_id" binding:"required,min=1"`
+}
+
+func (h *StatementHandler) handleDownloadStatement(ctx *gin.Context) {
+	var uri downloadStatementUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	account, err := authorizedAccount(ctx, h.accountSvc, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	archived, err := h.statementSvc.Get(ctx, account.ID, uri.StatementID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s.pdf", account.ID, archived.PeriodStart.Format("2006-01"))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, "application/pdf", archived.File)
+}
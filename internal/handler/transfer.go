@@ -49,9 +49,10 @@ func NewTransferHandler(transferSvc TransferService, accountSvc AccountService)
 }
 
 // RegisterRoutes connects the handlers to the router
-func (h *TransferHandler) RegisterRoutes(r *gin.Engine, tokenMaker token.Maker) {
-	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenMaker, []string{pkg.DepositorRole}))
+func (h *TransferHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole}))
 	authRoutes.POST("/v1/transfers", h.handleCreateTransfer)
+	authRoutes.POST("/v2/transfers", h.handleCreateTransferV2)
 }
 
 type transferRequest struct {
@@ -68,56 +69,59 @@ func (h *TransferHandler) handleCreateTransfer(ctx *gin.Context) {
 		return
 	}
 
-	fromAccount, err := h.accountSvc.Get(ctx, req.FromAccountID)
-	if err != nil {
-		if errors.Is(err, internal.ErrNoRows) {
-			ctx.Error(fmt.Errorf("%w: %w", internal.ErrInvalidFromAccount, err))
-			return
-		}
+	if err := h.checkTransferAccounts(ctx, req.FromAccountID, req.ToAccountID, req.Currency); err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	if fromAccount.Currency != req.Currency {
-		ctx.Error(internal.ErrCurrencyMismatch)
-		return
+	arg := db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
 	}
 
-	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
-	overridePermission := ctx.MustGet(middleware.OverridePermissionKey).(bool)
-	if !overridePermission && fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to the authenticated user")
-		ctx.Error(fmt.Errorf("%w; from account doesn't belong to the authenticated user: %w", internal.ErrForbidden, err))
+	result, err := h.transferSvc.CreateTx(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	toAccount, err := h.accountSvc.Get(ctx, req.ToAccountID)
+	ctx.JSON(http.StatusOK, result)
+}
+
+// checkTransferAccounts makes sure both accounts exist and use the currency, and that the
+// authenticated user may move money out of the from account
+func (h *TransferHandler) checkTransferAccounts(ctx *gin.Context, fromAccountID int64, toAccountID int64, currency string) error {
+	fromAccount, err := h.accountSvc.Get(ctx, fromAccountID)
 	if err != nil {
 		if errors.Is(err, internal.ErrNoRows) {
-			ctx.Error(fmt.Errorf("%w: %w", internal.ErrInvalidToAccount, err))
-			return
+			return fmt.Errorf("%w: %w", internal.ErrInvalidFromAccount, err)
 		}
-		ctx.Error(err)
-		return
+		return err
 	}
 
-	if toAccount.Currency != req.Currency {
-		err := fmt.Errorf("%w: account [%d] currency mismatch: %s vs %s", internal.ErrCurrencyMismatch, toAccount.ID, toAccount.Currency, req.Currency)
-		ctx.Error(err)
-		return
+	if fromAccount.Currency != currency {
+		return internal.ErrCurrencyMismatch
 	}
 
-	arg := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	overridePermission := ctx.MustGet(middleware.OverridePermissionKey).(bool)
+	if !overridePermission && fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		return fmt.Errorf("%w; from account doesn't belong to the authenticated user: %w", internal.ErrForbidden, err)
 	}
 
-	result, err := h.transferSvc.CreateTx(ctx, arg)
+	toAccount, err := h.accountSvc.Get(ctx, toAccountID)
 	if err != nil {
-		ctx.Error(err)
-		return
+		if errors.Is(err, internal.ErrNoRows) {
+			return fmt.Errorf("%w: %w", internal.ErrInvalidToAccount, err)
+		}
+		return err
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	if toAccount.Currency != currency {
+		return fmt.Errorf("%w: account [%d] currency mismatch: %s vs %s", internal.ErrCurrencyMismatch, toAccount.ID, toAccount.Currency, currency)
+	}
+
+	return nil
 }
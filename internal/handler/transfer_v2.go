@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+type transferRequestV2 struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        string `json:"amount" binding:"required"`
+	Currency      string `json:"currency" binding:"required,currency"`
+}
+
+type transferResponseV2 struct {
+	ID            int64     `json:"id"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        string    `json:"amount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type entryResponseV2 struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"account_id"`
+	Amount    string    `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// transferTxResultV2 is the v2 counterpart of db.TransferTxResult
+type transferTxResultV2 struct {
+	Transfer    transferResponseV2 `json:"transfer"`
+	FromAccount accountResponseV2  `json:"from_account"`
+	ToAccount   accountResponseV2  `json:"to_account"`
+	FromEntry   entryResponseV2    `json:"from_entry"`
+	ToEntry     entryResponseV2    `json:"to_entry"`
+}
+
+func newEntryResponseV2(entry db.Entry, currency string) entryResponseV2 {
+	return entryResponseV2{
+		ID:        entry.ID,
+		AccountID: entry.AccountID,
+		Amount:    decimalAmount(entry.Amount, currency),
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+func newTransferTxResultV2(result db.TransferTxResult, currency string) transferTxResultV2 {
+	return transferTxResultV2{
+		Transfer: transferResponseV2{
+			ID:            result.Transfer.ID,
+			FromAccountID: result.Transfer.FromAccountID,
+			ToAccountID:   result.Transfer.ToAccountID,
+			Amount:        decimalAmount(result.Transfer.Amount, currency),
+			CreatedAt:     result.Transfer.CreatedAt,
+		},
+		FromAccount: newAccountResponseV2(result.FromAccount),
+		ToAccount:   newAccountResponseV2(result.ToAccount),
+		FromEntry:   newEntryResponseV2(result.FromEntry, currency),
+		ToEntry:     newEntryResponseV2(result.ToEntry, currency),
+	}
+}
+
+func (h *TransferHandler) handleCreateTransferV2(ctx *gin.Context) {
+	var req transferRequestV2
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	amount, err := parseDecimalAmount(req.Amount, req.Currency)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if amount <= 0 {
+		ctx.Error(fmt.Errorf("%w; amount must be positive", internal.ErrInvalidAmount))
+		return
+	}
+
+	if err := h.checkTransferAccounts(ctx, req.FromAccountID, req.ToAccountID, req.Currency); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        amount,
+	}
+
+	result, err := h.transferSvc.CreateTx(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferTxResultV2(result, req.Currency))
+}
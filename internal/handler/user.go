@@ -8,7 +8,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/mail"
 	"github.com/marco-almeida/mybank/internal/middleware"
 	"github.com/marco-almeida/mybank/internal/pkg"
 	"github.com/marco-almeida/mybank/internal/postgresql/db"
@@ -16,6 +20,19 @@ import (
 	"github.com/marco-almeida/mybank/internal/token"
 )
 
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("locale", validLocale)
+	}
+}
+
+var validLocale validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if locale, ok := fieldLevel.Field().Interface().(string); ok {
+		return mail.IsLocale(locale)
+	}
+	return false
+}
+
 // UserService defines the methods that the user handler will use
 type UserService interface {
 	// Create(context context.Context, user service.CreateUserParams) (db.User, error)
@@ -24,7 +41,20 @@ type UserService interface {
 	Create(ctx context.Context, arg service.CreateUserParams) (db.User, error)
 	RenewAccessToken(context context.Context, req service.RenewAccessTokenParams) (service.RenewAccessTokenResponse, error)
 	VerifyEmail(ctx context.Context, req db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error)
+	ResendVerifyEmail(ctx context.Context, username string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, arg service.ResetPasswordParams) (db.User, error)
 	Update(ctx context.Context, arg service.UpdateUserParams) (db.User, error)
+	LoginTwoFactor(ctx context.Context, req service.LoginTwoFactorParams) (service.LoginUserResponse, error)
+	EnrollTwoFactor(ctx context.Context, username string) (service.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, username string, code string) ([]string, error)
+	ResetTwoFactor(ctx context.Context, actor string, username string) error
+	ListSessions(ctx context.Context, username string) ([]db.Session, error)
+	RevokeSession(ctx context.Context, username string, id uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, username string, currentID uuid.UUID) error
+	Logout(ctx context.Context, req service.LogoutParams) error
+	BlockUserSessions(ctx context.Context, actor string, username string) error
+	ChangeRole(ctx context.Context, actor string, req service.ChangeRoleParams) (db.User, error)
 }
 
 // UserHandler is the handler for the user service
@@ -40,14 +70,29 @@ func NewUserHandler(userSvc UserService) *UserHandler {
 }
 
 // RegisterRoutes connects the handlers to the router
-func (h *UserHandler) RegisterRoutes(r *gin.Engine, tokenMaker token.Maker) {
+func (h *UserHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
 	groupRoutes := r.Group("/api")
 	groupRoutes.POST("/v1/users", h.handleCreateUser)
 	groupRoutes.POST("/v1/users/login", h.handleLoginUser)
+	groupRoutes.POST("/v1/users/login/two_factor", h.handleLoginTwoFactor)
 	groupRoutes.POST("/v1/users/renew_access", h.handleRenewAccessToken)
 	groupRoutes.GET("/v1/users/verify_email", h.handleVerifyEmail)
-
-	groupRoutes.PATCH("/v1/users/:username", middleware.Authentication(tokenMaker, []string{pkg.DepositorRole, pkg.BankerRole}), h.handleUpdateUser)
+	groupRoutes.POST("/v1/users/verify_email/resend", h.handleResendVerifyEmail)
+	groupRoutes.POST("/v1/users/password/forgot", h.handleForgotPassword)
+	groupRoutes.POST("/v1/users/password/reset", h.handleResetPassword)
+
+	groupRoutes.POST("/v1/users/logout", middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}), h.handleLogout)
+	groupRoutes.PATCH("/v1/users/:username", middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}), h.handleUpdateUser)
+	groupRoutes.POST("/v1/users/:username/two_factor", middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}), h.handleEnrollTwoFactor)
+	groupRoutes.POST("/v1/users/:username/two_factor/confirm", middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}), h.handleConfirmTwoFactor)
+	groupRoutes.GET("/v1/users/:username/sessions", middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}), h.handleListSessions)
+	groupRoutes.DELETE("/v1/users/:username/sessions/:id", middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}), h.handleRevokeSession)
+	groupRoutes.POST("/v1/users/:username/sessions/revoke_others", middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}), h.handleRevokeOtherSessions)
+	groupRoutes.POST("/v1/users/:username/sessions/block", middleware.Authentication(tokenVerifier, []string{pkg.BankerRole}), h.handleBlockUserSessions) // only accessible by bankers and admins
+
+	adminRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.AdminRole}))
+	adminRoutes.DELETE("/v1/admin/users/:username/two_factor", h.handleResetTwoFactor) // only accessible by admins
+	adminRoutes.PATCH("/v1/admin/users/:username/role", h.handleChangeRole)            // only accessible by admins
 }
 
 type createUserRequest struct {
@@ -61,6 +106,8 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	PhoneNumber       string    `json:"phone_number"`
+	Locale            string    `json:"locale"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -70,6 +117,8 @@ func newUserResponse(user db.User) userResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		PhoneNumber:       user.PhoneNumber,
+		Locale:            user.Locale,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -123,6 +172,39 @@ func (h *UserHandler) handleLoginUser(ctx *gin.Context) {
 		return
 	}
 
+	// the tokens are only handed out once the second factor is entered too
+	if rsp.TwoFactor != nil {
+		ctx.JSON(http.StatusOK, rsp.TwoFactor)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type loginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code is a TOTP code or one of the recovery codes of the user
+	Code string `json:"code" binding:"required"`
+}
+
+func (h *UserHandler) handleLoginTwoFactor(ctx *gin.Context) {
+	var req loginTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	rsp, err := h.userSvc.LoginTwoFactor(ctx, service.LoginTwoFactorParams{
+		ChallengeToken: req.ChallengeToken,
+		Code:           req.Code,
+		UserAgent:      ctx.Request.UserAgent(),
+		ClientIP:       ctx.ClientIP(),
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
 	ctx.JSON(http.StatusOK, rsp)
 }
 
@@ -178,10 +260,81 @@ func (h *UserHandler) handleVerifyEmail(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, verifyEmailResponse{IsEmailVerified: result.User.IsEmailVerified})
 }
 
+type resendVerifyEmailRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+}
+
+func (h *UserHandler) handleResendVerifyEmail(ctx *gin.Context) {
+	var req resendVerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	// the response is the same whether the user exists, is verified or asked too recently
+	err := h.userSvc.ResendVerifyEmail(ctx, req.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, nil)
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (h *UserHandler) handleForgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	// the response is the same whether anyone has the email address or not
+	err := h.userSvc.ForgotPassword(ctx, req.Email)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, nil)
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+func (h *UserHandler) handleResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	_, err := h.userSvc.ResetPassword(ctx, service.ResetPasswordParams{
+		Token:             req.Token,
+		PlaintextPassword: req.Password,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
 type updateUserBodyRequest struct {
 	FullName string `json:"full_name" `
+	// Email only replaces the current address once the code sent to it is verified
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// PhoneNumber is where SMS notifications are sent, in E.164 format
+	PhoneNumber string `json:"phone_number" binding:"omitempty,e164"`
+	// Locale is the language emails are sent in
+	Locale string `json:"locale" binding:"omitempty,locale"`
 }
 
 type updateUserUriRequest struct {
@@ -206,6 +359,8 @@ func (h *UserHandler) handleUpdateUser(ctx *gin.Context) {
 		PlaintextPassword: args.Password,
 		FullName:          args.FullName,
 		Email:             args.Email,
+		PhoneNumber:       args.PhoneNumber,
+		Locale:            args.Locale,
 	}
 
 	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
@@ -223,3 +378,248 @@ func (h *UserHandler) handleUpdateUser(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, newUserResponse(user))
 }
+
+type userUriRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// bindOwnUserUri binds the username of the request, which must be the authenticated user: the second factor and
+// sessions of a user are theirs alone, so not even admins can manage them for someone else
+func bindOwnUserUri(ctx *gin.Context) (string, bool) {
+	var req userUriRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return "", false
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	if req.Username != authPayload.Username {
+		err := errors.New("user doesn't belong to the authenticated user")
+		ctx.Error(fmt.Errorf("%w; user doesn't belong to the authenticated user: %w", internal.ErrForbidden, err))
+		return "", false
+	}
+	return req.Username, true
+}
+
+func (h *UserHandler) handleEnrollTwoFactor(ctx *gin.Context) {
+	username, ok := bindOwnUserUri(ctx)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.userSvc.EnrollTwoFactor(ctx, username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+type confirmTwoFactorRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type confirmTwoFactorResponse struct {
+	// RecoveryCodes are only ever shown here, each can replace a code once
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *UserHandler) handleConfirmTwoFactor(ctx *gin.Context) {
+	username, ok := bindOwnUserUri(ctx)
+	if !ok {
+		return
+	}
+
+	var req confirmTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	recoveryCodes, err := h.userSvc.ConfirmTwoFactor(ctx, username, req.Code)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTwoFactorResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (h *UserHandler) handleResetTwoFactor(ctx *gin.Context) {
+	var req userUriRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	err := h.userSvc.ResetTwoFactor(ctx, authPayload.Username, req.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *UserHandler) handleLogout(ctx *gin.Context) {
+	var req logoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	err := h.userSvc.Logout(ctx, service.LogoutParams{
+		RefreshToken:       req.RefreshToken,
+		AccessTokenPayload: authPayload,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// sessionResponse describes a session without its refresh token
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIP  string    `json:"client_ip"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSessionResponse(session db.Session) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIP:  session.ClientIp,
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: session.CreatedAt,
+	}
+}
+
+func (h *UserHandler) handleListSessions(ctx *gin.Context) {
+	username, ok := bindOwnUserUri(ctx)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.userSvc.ListSessions(ctx, username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		rsp = append(rsp, newSessionResponse(session))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokeSessionRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+func (h *UserHandler) handleRevokeSession(ctx *gin.Context) {
+	username, ok := bindOwnUserUri(ctx)
+	if !ok {
+		return
+	}
+
+	var req revokeSessionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	err := h.userSvc.RevokeSession(ctx, username, uuid.MustParse(req.ID))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+type revokeOtherSessionsRequest struct {
+	// SessionID is the session the user keeps, the one they are using
+	SessionID string `json:"session_id" binding:"required,uuid"`
+}
+
+func (h *UserHandler) handleRevokeOtherSessions(ctx *gin.Context) {
+	username, ok := bindOwnUserUri(ctx)
+	if !ok {
+		return
+	}
+
+	var req revokeOtherSessionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	err := h.userSvc.RevokeOtherSessions(ctx, username, uuid.MustParse(req.SessionID))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (h *UserHandler) handleBlockUserSessions(ctx *gin.Context) {
+	var req userUriRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	err := h.userSvc.BlockUserSessions(ctx, authPayload.Username, req.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+type changeRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=depositor banker admin"`
+}
+
+func (h *UserHandler) handleChangeRole(ctx *gin.Context) {
+	var uri userUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req changeRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	_, err := h.userSvc.ChangeRole(ctx, authPayload.Username, service.ChangeRoleParams{
+		Username: uri.Username,
+		Role:     req.Role,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/middleware"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/service"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("webhook_event", validWebhookEvent)
+	}
+}
+
+var validWebhookEvent validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if eventType, ok := fieldLevel.Field().Interface().(string); ok {
+		return pkg.IsWebhookEventType(eventType)
+	}
+	return false
+}
+
+// WebhookService defines the methods that the webhook handler will use
+type WebhookService interface {
+	Create(ctx context.Context, arg service.CreateWebhookParams) (db.Webhook, error)
+	List(ctx context.Context, arg db.ListWebhooksParams) ([]db.Webhook, error)
+	Get(ctx context.Context, owner string, id int64) (db.Webhook, error)
+	Delete(ctx context.Context, owner string, id int64) error
+	ListDeliveries(ctx context.Context, owner string, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error)
+	Redeliver(ctx context.Context, owner string, webhookID int64, deliveryID int64) (db.WebhookDelivery, error)
+}
+
+// WebhookHandler is the handler for the webhook service
+type WebhookHandler struct {
+	webhookSvc WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookSvc WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookSvc: webhookSvc,
+	}
+}
+
+// RegisterRoutes connects the handlers to the router
+func (h *WebhookHandler) RegisterRoutes(r *gin.Engine, tokenVerifier *middleware.TokenVerifier) {
+	authRoutes := r.Group("/api").Use(middleware.Authentication(tokenVerifier, []string{pkg.DepositorRole, pkg.BankerRole}))
+	authRoutes.POST("/v1/webhooks", h.handleCreateWebhook)
+	authRoutes.GET("/v1/webhooks", h.handleListWebhooks)
+	authRoutes.GET("/v1/webhooks/:id", h.handleGetWebhook)
+	authRoutes.DELETE("/v1/webhooks/:id", h.handleDeleteWebhook)
+	authRoutes.GET("/v1/webhooks/:id/deliveries", h.handleListWebhookDeliveries)
+	authRoutes.POST("/v1/webhooks/:id/deliveries/:delivery_id/redeliver", h.handleRedeliverWebhook)
+}
+
+// webhookResponse leaves the secret out, it is only shown when the webhook is created
+type webhookResponse struct {
+	ID         int64     `json:"id"`
+	Owner      string    `json:"owner"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newWebhookResponse(webhook db.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:         webhook.ID,
+		Owner:      webhook.Owner,
+		URL:        webhook.Url,
+		EventTypes: webhook.EventTypes,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+type createWebhookResponse struct {
+	webhookResponse
+	Secret string `json:"secret"`
+}
+
+// webhookDeliveryResponse shows the payload as JSON rather than base64
+type webhookDeliveryResponse struct {
+	ID             int64              `json:"id"`
+	WebhookID      int64              `json:"webhook_id"`
+	EventID        int64              `json:"event_id"`
+	EventType      string             `json:"event_type"`
+	Payload        json.RawMessage    `json:"payload"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	ResponseStatus int32              `json:"response_status"`
+	LastError      string             `json:"last_error"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+func newWebhookDeliveryResponse(delivery db.WebhookDelivery) webhookDeliveryResponse {
+	return webhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+type createWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,webhook_event"`
+}
+
+func (h *WebhookHandler) handleCreateWebhook(ctx *gin.Context) {
+	var req createWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	webhook, err := h.webhookSvc.Create(ctx, service.CreateWebhookParams{
+		Owner:      authPayload.Username,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createWebhookResponse{
+		webhookResponse: newWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	})
+}
+
+type listWebhooksRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (h *WebhookHandler) handleListWebhooks(ctx *gin.Context) {
+	var req listWebhooksRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	webhooks, err := h.webhookSvc.List(ctx, db.ListWebhooksParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]webhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		rsp = append(rsp, newWebhookResponse(webhook))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type webhookUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (h *WebhookHandler) handleGetWebhook(ctx *gin.Context) {
+	var uri webhookUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	webhook, err := h.webhookSvc.Get(ctx, authPayload.Username, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newWebhookResponse(webhook))
+}
+
+func (h *WebhookHandler) handleDeleteWebhook(ctx *gin.Context) {
+	var uri webhookUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	err := h.webhookSvc.Delete(ctx, authPayload.Username, uri.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+type listWebhookDeliveriesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+func (h *WebhookHandler) handleListWebhookDeliveries(ctx *gin.Context) {
+	var uri webhookUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	var req listWebhookDeliveriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	deliveries, err := h.webhookSvc.ListDeliveries(ctx, authPayload.Username, db.ListWebhookDeliveriesParams{
+		WebhookID: uri.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		rsp = append(rsp, newWebhookDeliveryResponse(delivery))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type redeliverWebhookUriRequest struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+func (h *WebhookHandler) handleRedeliverWebhook(ctx *gin.Context) {
+	var uri redeliverWebhookUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(fmt.Errorf("%w; %w", internal.ErrInvalidParams, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(middleware.AuthorizationPayloadKey).(*token.Payload)
+	delivery, err := h.webhookSvc.Redeliver(ctx, authPayload.Username, uri.ID, uri.DeliveryID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, newWebhookDeliveryResponse(delivery))
+}
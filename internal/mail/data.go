@@ -0,0 +1,85 @@
+package mail
+
+import "time"
+
+// Templates of the messages sent to customers, each is rendered with the data type below it
+const (
+	TemplateVerifyEmail            = "verify_email"
+	TemplateTransferReceived       = "transfer_received"
+	TemplateTransferSent           = "transfer_sent"
+	TemplateLowBalance             = "low_balance"
+	TemplateNewDeviceLogin         = "new_device_login"
+	TemplateStatement              = "statement"
+	TemplatePaymentRequestNew      = "payment_request_new"
+	TemplatePaymentRequestReminder = "payment_request_reminder"
+	TemplatePaymentRequestAnswered = "payment_request_answered"
+	TemplatePasswordReset          = "password_reset"
+	TemplateVerifyEmailChange      = "verify_email_change"
+	TemplateEmailChangeRequested   = "email_change_requested"
+	TemplateSessionReuseDetected   = "session_reuse_detected"
+)
+
+// VerifyEmailData is rendered by TemplateVerifyEmail and TemplateVerifyEmailChange
+type VerifyEmailData struct {
+	EmailID    int64
+	SecretCode string
+}
+
+// TransferData is rendered by TemplateTransferReceived and TemplateTransferSent
+type TransferData struct {
+	AccountID    int64
+	Amount       string
+	Counterparty string
+}
+
+// LowBalanceData is rendered by TemplateLowBalance
+type LowBalanceData struct {
+	AccountID int64
+	Balance   string
+	Threshold string
+}
+
+// NewDeviceLoginData is rendered by TemplateNewDeviceLogin
+type NewDeviceLoginData struct {
+	LoggedInAt time.Time
+	UserAgent  string
+	ClientIP   string
+}
+
+// StatementData is rendered by TemplateStatement
+type StatementData struct {
+	AccountID      int64
+	PeriodStart    time.Time
+	OpeningBalance string
+	ClosingBalance string
+}
+
+// PaymentRequestData is rendered by the payment request templates
+type PaymentRequestData struct {
+	Requester string
+	Payer     string
+	Amount    string
+	Message   string
+	ExpiresAt time.Time
+	// Status is the answer to the request, only set for TemplatePaymentRequestAnswered
+	Status string
+}
+
+// PasswordResetData is rendered by TemplatePasswordReset. The link points to the reset_password page of the public
+// base URL, which posts the token with the new password to the reset endpoint
+type PasswordResetData struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// EmailChangeData is rendered by TemplateEmailChangeRequested, sent to the current address of the user
+type EmailChangeData struct {
+	NewEmail string
+}
+
+// SessionReuseData is rendered by TemplateSessionReuseDetected, the device is the one the session was opened on
+type SessionReuseData struct {
+	DetectedAt time.Time
+	UserAgent  string
+	ClientIP   string
+}
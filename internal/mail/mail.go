@@ -0,0 +1,185 @@
+// Package mail renders the emails sent to customers from the templates embedded in templates/<locale>. Every message
+// has an html/template file for the HTML part and a text/template file for the subject, the text/plain part and the
+// one line summary sent by SMS and push, both wrapped in the layout of their locale.
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// DefaultLocale is used for users whose locale has no templates
+const DefaultLocale = "en"
+
+// Locales are the languages emails can be rendered in
+var Locales = []string{DefaultLocale, "pt"}
+
+// IsLocale checks if emails can be rendered in the locale
+func IsLocale(locale string) bool {
+	return slices.Contains(Locales, locale)
+}
+
+// Message is a rendered email
+type Message struct {
+	Subject string
+	HTML    string
+	Text    string
+	// Summary is a one line version of the message for channels that can't show the whole of it
+	Summary string
+}
+
+// view is what the templates are executed with, the layout greets Name and the message template reads Data
+type view struct {
+	Name   string
+	Locale string
+	Data   any
+}
+
+type templates struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// Renderer renders messages in the locale of their recipient
+type Renderer struct {
+	baseURL   *url.URL
+	templates map[string]map[string]templates
+}
+
+// NewRenderer parses the templates of every locale. Links in the messages are made absolute with baseURL, the
+// address customers reach the server at.
+func NewRenderer(baseURL string) (*Renderer, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid public base url %q", baseURL)
+	}
+
+	r := &Renderer{
+		baseURL:   base,
+		templates: make(map[string]map[string]templates, len(Locales)),
+	}
+	funcs := map[string]any{
+		"link": r.link,
+	}
+
+	names, err := messageNames(DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, locale := range Locales {
+		localeNames, err := messageNames(locale)
+		if err != nil {
+			return nil, err
+		}
+		// a message missing from a locale would silently go out in another language
+		if !slices.Equal(names, localeNames) {
+			return nil, fmt.Errorf("locale %s has templates %v, want %v", locale, localeNames, names)
+		}
+
+		r.templates[locale] = make(map[string]templates, len(names))
+		for _, name := range names {
+			html, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFS(templatesFS,
+				path.Join("templates", locale, "layout.html"), path.Join("templates", locale, name+".html"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s/%s.html: %w", locale, name, err)
+			}
+			text, err := texttemplate.New("layout.txt").Funcs(funcs).ParseFS(templatesFS,
+				path.Join("templates", locale, "layout.txt"), path.Join("templates", locale, name+".txt"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s/%s.txt: %w", locale, name, err)
+			}
+			r.templates[locale][name] = templates{html: html, text: text}
+		}
+	}
+
+	return r, nil
+}
+
+// messageNames lists the messages with templates in the locale, the layouts left out
+func messageNames(locale string) ([]string, error) {
+	entries, err := fs.ReadDir(templatesFS, path.Join("templates", locale))
+	if err != nil {
+		return nil, fmt.Errorf("no templates for locale %s: %w", locale, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".html")
+		if !ok || name == "layout" {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Render renders the message name to the recipient called recipientName, in locale or the default locale if there
+// are no templates for it
+func (r *Renderer) Render(name string, locale string, recipientName string, data any) (Message, error) {
+	if !IsLocale(locale) {
+		locale = DefaultLocale
+	}
+	t, ok := r.templates[locale][name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	v := view{Name: recipientName, Locale: locale, Data: data}
+
+	var msg Message
+	var err error
+	if msg.Subject, err = executeText(t.text, "subject", v); err != nil {
+		return Message{}, err
+	}
+	if msg.Summary, err = executeText(t.text, "summary", v); err != nil {
+		return Message{}, err
+	}
+	if msg.Text, err = executeText(t.text, "layout", v); err != nil {
+		return Message{}, err
+	}
+
+	var html bytes.Buffer
+	if err := t.html.ExecuteTemplate(&html, "layout", v); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+	msg.HTML = html.String()
+
+	return msg, nil
+}
+
+func executeText(t *texttemplate.Template, name string, v view) (string, error) {
+	var b strings.Builder
+	if err := t.ExecuteTemplate(&b, name, v); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// link returns the absolute url of a path on the server, with the query built from key and value pairs
+func (r *Renderer) link(p string, pairs ...any) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("link %s has a query key without a value", p)
+	}
+
+	query := url.Values{}
+	for i := 0; i < len(pairs); i += 2 {
+		query.Set(fmt.Sprint(pairs[i]), fmt.Sprint(pairs[i+1]))
+	}
+
+	u := r.baseURL.JoinPath(p)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,104 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testData has data for every template, so the test fails when a template is added without it
+var testData = map[string]any{
+	TemplateVerifyEmail:            VerifyEmailData{EmailID: 7, SecretCode: "s3cr3t"},
+	TemplateTransferReceived:       TransferData{AccountID: 1, Amount: "10.00 EUR", Counterparty: "Bob"},
+	TemplateTransferSent:           TransferData{AccountID: 1, Amount: "10.00 EUR", Counterparty: "Bob"},
+	TemplateLowBalance:             LowBalanceData{AccountID: 1, Balance: "5.00 EUR", Threshold: "20.00 EUR"},
+	TemplateNewDeviceLogin:         NewDeviceLoginData{LoggedInAt: time.Now(), UserAgent: "curl/8.0", ClientIP: "127.0.0.1"},
+	TemplateSessionReuseDetected:   SessionReuseData{DetectedAt: time.Now(), UserAgent: "curl/8.0", ClientIP: "127.0.0.1"},
+	TemplateStatement:              StatementData{AccountID: 1, PeriodStart: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), OpeningBalance: "1.00 EUR", ClosingBalance: "2.00 EUR"},
+	TemplatePaymentRequestNew:      PaymentRequestData{Requester: "bob", Payer: "alice", Amount: "10.00 EUR", Message: "dinner", ExpiresAt: time.Now()},
+	TemplatePaymentRequestReminder: PaymentRequestData{Requester: "bob", Payer: "alice", Amount: "10.00 EUR", Message: "dinner", ExpiresAt: time.Now()},
+	TemplatePaymentRequestAnswered: PaymentRequestData{Requester: "bob", Payer: "alice", Amount: "10.00 EUR", Status: "accepted"},
+	TemplatePasswordReset:          PasswordResetData{Token: "t0k3n", ExpiresAt: time.Now()},
+	TemplateVerifyEmailChange:      VerifyEmailData{EmailID: 7, SecretCode: "s3cr3t"},
+	TemplateEmailChangeRequested:   EmailChangeData{NewEmail: "alice@example.com"},
+}
+
+func TestRenderEveryTemplate(t *testing.T) {
+	r, err := NewRenderer("https://bank.example.com")
+	require.NoError(t, err)
+
+	names, err := messageNames(DefaultLocale)
+	require.NoError(t, err)
+	require.Len(t, names, len(testData))
+
+	for _, locale := range Locales {
+		for _, name := range names {
+			data, ok := testData[name]
+			require.True(t, ok, "no test data for %s", name)
+
+			msg, err := r.Render(name, locale, "Alice", data)
+			require.NoError(t, err, "%s/%s", locale, name)
+			require.NotEmpty(t, msg.Subject)
+			require.NotEmpty(t, msg.Summary)
+			require.NotContains(t, msg.Subject, "\n")
+			require.Contains(t, msg.HTML, `<html lang="`+locale+`">`)
+			require.Contains(t, msg.Text, "Alice")
+			require.NotContains(t, msg.Text, "<p>")
+		}
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	r, err := NewRenderer("https://bank.example.com")
+	require.NoError(t, err)
+
+	msg, err := r.Render(TemplateTransferReceived, DefaultLocale, "<b>Alice</b>", TransferData{
+		AccountID:    1,
+		Amount:       "10.00 EUR",
+		Counterparty: `<script>alert("x")</script>`,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, msg.HTML, "<script>")
+	require.NotContains(t, msg.HTML, "<b>Alice</b>")
+	require.Contains(t, msg.HTML, "&lt;b&gt;Alice&lt;/b&gt;")
+	// the text part is sent as text/plain, so it is left as is
+	require.Contains(t, msg.Text, "<b>Alice</b>")
+}
+
+func TestRenderLinksUseBaseURL(t *testing.T) {
+	r, err := NewRenderer("https://bank.example.com/mybank/")
+	require.NoError(t, err)
+
+	msg, err := r.Render(TemplateVerifyEmail, DefaultLocale, "Alice", VerifyEmailData{EmailID: 7, SecretCode: "a&b"})
+	require.NoError(t, err)
+
+	link := "https://bank.example.com/mybank/api/v1/users/verify_email?email_id=7&secret_code=a%26b"
+	require.Contains(t, msg.Text, link)
+	require.Contains(t, msg.HTML, strings.ReplaceAll(link, "&", "&amp;"))
+	require.NotContains(t, msg.HTML, "localhost")
+}
+
+func TestRenderLocale(t *testing.T) {
+	r, err := NewRenderer("https://bank.example.com")
+	require.NoError(t, err)
+
+	data := PaymentRequestData{Payer: "alice", Amount: "10.00 EUR", Status: "declined"}
+
+	msg, err := r.Render(TemplatePaymentRequestAnswered, "pt", "Bob", data)
+	require.NoError(t, err)
+	require.Equal(t, "O seu pedido de pagamento foi recusado", msg.Subject)
+	require.True(t, strings.HasPrefix(msg.Text, "Olá Bob,"))
+
+	// locales without templates fall back to the default one
+	msg, err = r.Render(TemplatePaymentRequestAnswered, "xx", "Bob", data)
+	require.NoError(t, err)
+	require.Equal(t, "Your payment request was declined", msg.Subject)
+
+	_, err = r.Render("unknown", DefaultLocale, "Bob", data)
+	require.Error(t, err)
+
+	_, err = NewRenderer("localhost:3000")
+	require.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/marco-almeida/mybank/internal"
 	"github.com/marco-almeida/mybank/internal/pkg"
 	"github.com/marco-almeida/mybank/internal/token"
+	"github.com/rs/zerolog/log"
 )
 
 const (
@@ -20,7 +21,7 @@ const (
 )
 
 // Authentication creates a gin middleware for authorization
-func Authentication(tokenMaker token.Maker, rolesWithPermission []string) gin.HandlerFunc {
+func Authentication(tokenVerifier *TokenVerifier, rolesWithPermission []string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 
@@ -45,8 +46,14 @@ func Authentication(tokenMaker token.Maker, rolesWithPermission []string) gin.Ha
 		}
 
 		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		payload, err := tokenVerifier.VerifyToken(ctx, accessToken)
 		if err != nil {
+			if !errors.Is(err, token.ErrInvalidToken) && !errors.Is(err, token.ErrExpiredToken) && !errors.Is(err, token.ErrRevokedToken) {
+				// tokens aren't let through when it can't be told if they were revoked
+				log.Error().Err(err).Msg("failed to verify token")
+				ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, internal.RenderErrorResponse("cannot verify token"))
+				return
+			}
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, internal.RenderErrorResponse(err.Error()))
 			return
 		}
@@ -16,6 +16,12 @@ func ErrorHandler() gin.HandlerFunc {
 		c.Next()
 
 		for _, ginErr := range c.Errors {
+			// a streamed response can fail halfway, the status and part of the body are already sent
+			if c.Writer.Written() {
+				log.Error().Err(ginErr.Err).Msg("error after the response was written")
+				continue
+			}
+
 			logLevel := log.Info()
 
 			unwrappedErr := ginErr.Err
@@ -47,6 +53,22 @@ func ErrorHandler() gin.HandlerFunc {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to account"})
 			case errors.Is(unwrappedErr, internal.ErrVerifyEmailNotSent):
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "account verification email not sent"})
+			case errors.Is(unwrappedErr, internal.ErrInvalidVerifyEmail):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid verification code"})
+			case errors.Is(unwrappedErr, internal.ErrVerifyEmailExpired):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "verification code expired"})
+			case errors.Is(unwrappedErr, internal.ErrVerifyEmailUsed):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "verification code already used"})
+			case errors.Is(unwrappedErr, internal.ErrInvalidPasswordReset):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired password reset token"})
+			case errors.Is(unwrappedErr, internal.ErrInvalidTwoFactorCode):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid two-factor code"})
+			case errors.Is(unwrappedErr, internal.ErrTwoFactorAlreadyEnabled):
+				c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication already enabled"})
+			case errors.Is(unwrappedErr, internal.ErrTwoFactorNotEnrolled):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication not enrolled"})
+			case errors.Is(unwrappedErr, internal.ErrTwoFactorLocked):
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many two-factor attempts, try again later"})
 			case errors.Is(unwrappedErr, internal.ErrAccountAlreadyExists):
 				c.JSON(http.StatusBadRequest, gin.H{"error": "account already exists"})
 			case errors.Is(unwrappedErr, internal.ErrInvalidParams):
@@ -55,6 +77,24 @@ func ErrorHandler() gin.HandlerFunc {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			case errors.Is(unwrappedErr, internal.ErrCurrencyMismatch):
 				c.JSON(http.StatusBadRequest, gin.H{"error": "currency mismatch"})
+			case errors.Is(unwrappedErr, internal.ErrInsufficientFunds):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient funds"})
+			case errors.Is(unwrappedErr, internal.ErrInvalidAmount):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+			case errors.Is(unwrappedErr, internal.ErrLoanNotPending):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "loan is not pending"})
+			case errors.Is(unwrappedErr, internal.ErrPaymentRequestNotPending):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "payment request is not pending"})
+			case errors.Is(unwrappedErr, internal.ErrPaymentRequestExpired):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "payment request expired"})
+			case errors.Is(unwrappedErr, internal.ErrBillReminderTooSoon):
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "bill reminder sent too recently"})
+			case errors.Is(unwrappedErr, internal.ErrInvalidPaymentLink):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment link"})
+			case errors.Is(unwrappedErr, internal.ErrPaymentLinkExpired):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "payment link expired"})
+			case errors.Is(unwrappedErr, internal.ErrPaymentLinkUsed):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "payment link already used"})
 			case errors.Is(unwrappedErr, internal.ErrForbidden):
 				c.JSON(http.StatusForbidden, gin.H{"error": http.StatusText(http.StatusForbidden)})
 			case errors.Is(unwrappedErr, internal.ErrForeignKeyConstraintViolation):
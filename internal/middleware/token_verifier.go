@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+const (
+	// revocationCacheDuration is how long the revocation status of a token is cached, a token revoked meanwhile is
+	// still accepted by the instances that checked it until then
+	revocationCacheDuration = 5 * time.Second
+	// maxRevocationCacheEntries bounds the cache, expired entries are dropped once it is reached
+	maxRevocationCacheEntries = 10_000
+)
+
+// RevocationStore revokes access tokens before they expire and tells if they were.
+type RevocationStore interface {
+	Revoke(ctx context.Context, payload *token.Payload) error
+	SetNotBefore(ctx context.Context, username string, notBefore time.Time) error
+	IsRevoked(ctx context.Context, payload *token.Payload) (bool, error)
+}
+
+// TokenVerifier verifies access tokens with the token maker and rejects the ones revoked in the revocation store,
+// whose answers it caches for revocationCacheDuration. Revocations made through it are seen by this instance at once,
+// only the other instances wait for their cached answers to expire.
+type TokenVerifier struct {
+	tokenMaker token.Maker
+	store      RevocationStore
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedRevocation
+}
+
+type cachedRevocation struct {
+	username  string
+	revoked   bool
+	checkedAt time.Time
+}
+
+// NewTokenVerifier instantiates the TokenVerifier. Tokens can't be revoked if store is nil.
+func NewTokenVerifier(tokenMaker token.Maker, store RevocationStore) *TokenVerifier {
+	return &TokenVerifier{
+		tokenMaker: tokenMaker,
+		store:      store,
+		cache:      make(map[uuid.UUID]cachedRevocation),
+	}
+}
+
+// VerifyToken checks if the access token is valid and wasn't revoked. Errors other than token.ErrInvalidToken,
+// token.ErrExpiredToken and token.ErrRevokedToken mean the revocation store couldn't be reached.
+func (v *TokenVerifier) VerifyToken(ctx context.Context, accessToken string) (*token.Payload, error) {
+	payload, err := v.tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.store == nil {
+		return payload, nil
+	}
+
+	revoked, ok := v.cached(payload.ID)
+	if !ok {
+		revoked, err = v.store.IsRevoked(ctx, payload)
+		if err != nil {
+			return nil, fmt.Errorf("cannot check token revocation: %w", err)
+		}
+		v.remember(payload, revoked)
+	}
+
+	if revoked {
+		return nil, token.ErrRevokedToken
+	}
+	return payload, nil
+}
+
+// Revoke rejects the access token until it expires.
+func (v *TokenVerifier) Revoke(ctx context.Context, payload *token.Payload) error {
+	if v.store == nil {
+		return nil
+	}
+
+	err := v.store.Revoke(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.cache, payload.ID)
+	return nil
+}
+
+// SetNotBefore rejects every access token of the user issued before notBefore.
+func (v *TokenVerifier) SetNotBefore(ctx context.Context, username string, notBefore time.Time) error {
+	if v.store == nil {
+		return nil
+	}
+
+	err := v.store.SetNotBefore(ctx, username, notBefore)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for id, entry := range v.cache {
+		if entry.username == username {
+			delete(v.cache, id)
+		}
+	}
+	return nil
+}
+
+func (v *TokenVerifier) cached(id uuid.UUID) (bool, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entry, ok := v.cache[id]
+	if !ok || time.Since(entry.checkedAt) > revocationCacheDuration {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+func (v *TokenVerifier) remember(payload *token.Payload, revoked bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.cache) >= maxRevocationCacheEntries {
+		for id, entry := range v.cache {
+			if time.Since(entry.checkedAt) > revocationCacheDuration {
+				delete(v.cache, id)
+			}
+		}
+	}
+	// still full of fresh entries, start over rather than grow
+	if len(v.cache) >= maxRevocationCacheEntries {
+		clear(v.cache)
+	}
+
+	v.cache[payload.ID] = cachedRevocation{username: payload.Username, revoked: revoked, checkedAt: time.Now()}
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/token"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRevocationStore struct {
+	revoked   map[uuid.UUID]bool
+	notBefore map[string]time.Time
+	err       error
+	calls     int
+}
+
+func newFakeRevocationStore() *fakeRevocationStore {
+	return &fakeRevocationStore{revoked: map[uuid.UUID]bool{}, notBefore: map[string]time.Time{}}
+}
+
+func (s *fakeRevocationStore) Revoke(ctx context.Context, payload *token.Payload) error {
+	s.revoked[payload.ID] = true
+	return s.err
+}
+
+func (s *fakeRevocationStore) SetNotBefore(ctx context.Context, username string, notBefore time.Time) error {
+	s.notBefore[username] = notBefore
+	return s.err
+}
+
+func (s *fakeRevocationStore) IsRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	s.calls++
+	return s.revoked[payload.ID] || payload.IssuedAt.Before(s.notBefore[payload.Username]), s.err
+}
+
+func TestTokenVerifier(t *testing.T) {
+	tokenMaker, err := token.NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	store := newFakeRevocationStore()
+	verifier := NewTokenVerifier(tokenMaker, store)
+	ctx := context.Background()
+
+	accessToken, payload, err := tokenMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	revokedToken, revokedPayload, err := tokenMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	store.revoked[revokedPayload.ID] = true
+
+	verified, err := verifier.VerifyToken(ctx, accessToken)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+
+	_, err = verifier.VerifyToken(ctx, revokedToken)
+	require.ErrorIs(t, err, token.ErrRevokedToken)
+
+	// both answers are cached
+	_, err = verifier.VerifyToken(ctx, accessToken)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(ctx, revokedToken)
+	require.ErrorIs(t, err, token.ErrRevokedToken)
+	require.Equal(t, 2, store.calls)
+
+	// the store isn't asked about tokens that don't verify
+	_, err = verifier.VerifyToken(ctx, "not a token")
+	require.ErrorIs(t, err, token.ErrInvalidToken)
+	require.Equal(t, 2, store.calls)
+
+	// tokens aren't let through when the store can't be reached
+	store.err = errors.New("connection refused")
+	otherToken, _, err := tokenMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(ctx, otherToken)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, token.ErrInvalidToken)
+}
+
+func TestTokenVerifierRevokeEvictsCache(t *testing.T) {
+	tokenMaker, err := token.NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	store := newFakeRevocationStore()
+	verifier := NewTokenVerifier(tokenMaker, store)
+	ctx := context.Background()
+
+	username := pkg.RandomOwner()
+	accessToken, payload, err := tokenMaker.CreateToken(username, pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	otherToken, _, err := tokenMaker.CreateToken(username, pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	otherUserToken, _, err := tokenMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// all three are cached as not revoked
+	for _, accessToken := range []string{accessToken, otherToken, otherUserToken} {
+		_, err = verifier.VerifyToken(ctx, accessToken)
+		require.NoError(t, err)
+	}
+
+	// logging out is seen at once rather than when the cached answer expires
+	require.NoError(t, verifier.Revoke(ctx, payload))
+	_, err = verifier.VerifyToken(ctx, accessToken)
+	require.ErrorIs(t, err, token.ErrRevokedToken)
+	_, err = verifier.VerifyToken(ctx, otherToken)
+	require.NoError(t, err)
+
+	// and so is logging out everywhere, which leaves the tokens of the other users cached
+	require.NoError(t, verifier.SetNotBefore(ctx, username, time.Now().Add(time.Second)))
+	_, err = verifier.VerifyToken(ctx, otherToken)
+	require.ErrorIs(t, err, token.ErrRevokedToken)
+	calls := store.calls
+	_, err = verifier.VerifyToken(ctx, otherUserToken)
+	require.NoError(t, err)
+	require.Equal(t, calls, store.calls)
+
+	// the cache is left as it was when the store fails
+	store.err = errors.New("connection refused")
+	require.Error(t, verifier.SetNotBefore(ctx, pkg.RandomOwner(), time.Now()))
+	store.err = nil
+	_, err = verifier.VerifyToken(ctx, otherUserToken)
+	require.NoError(t, err)
+	require.Equal(t, calls, store.calls)
+}
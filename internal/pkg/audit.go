@@ -0,0 +1,8 @@
+package pkg
+
+// Audit log actions, recorded when staff change something about a user
+const (
+	AuditTwoFactorReset  = "user.two_factor_reset"
+	AuditSessionsBlocked = "user.sessions_blocked"
+	AuditRoleChanged     = "user.role_changed"
+)
@@ -0,0 +1,7 @@
+package pkg
+
+// Bill split types
+const (
+	BillSplitEqual  = "equal"
+	BillSplitCustom = "custom"
+)
@@ -1,15 +1,137 @@
 package pkg
 
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
 )
 
+// Currency holds the ISO 4217 metadata of a currency
+type Currency struct {
+	Code        string `json:"code"`
+	NumericCode string `json:"numeric_code"`
+	Name        string `json:"name"`
+	MinorUnits  int    `json:"minor_units"`
+	Symbol      string `json:"symbol"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// CurrencyRegistry is a concurrency safe, in-memory view of the known currencies
+type CurrencyRegistry struct {
+	mu         sync.RWMutex
+	currencies map[string]Currency
+}
+
+// NewCurrencyRegistry creates a registry holding the given currencies
+func NewCurrencyRegistry(currencies []Currency) *CurrencyRegistry {
+	registry := &CurrencyRegistry{}
+	registry.Load(currencies)
+	return registry
+}
+
+// Load replaces the contents of the registry
+func (r *CurrencyRegistry) Load(currencies []Currency) {
+	m := make(map[string]Currency, len(currencies))
+	for _, c := range currencies {
+		m[c.Code] = c
+	}
+
+	r.mu.Lock()
+	r.currencies = m
+	r.mu.Unlock()
+}
+
+// Set adds or replaces a single currency
+func (r *CurrencyRegistry) Set(currency Currency) {
+	r.mu.Lock()
+	r.currencies[currency.Code] = currency
+	r.mu.Unlock()
+}
+
+// Get returns the currency with the given code
+func (r *CurrencyRegistry) Get(code string) (Currency, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.currencies[code]
+	return c, ok
+}
+
+// List returns every currency in the registry ordered by code
+func (r *CurrencyRegistry) List() []Currency {
+	r.mu.RLock()
+	currencies := make([]Currency, 0, len(r.currencies))
+	for _, c := range r.currencies {
+		currencies = append(currencies, c)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+	return currencies
+}
+
+// IsEnabled reports whether the currency exists and accounts can use it
+func (r *CurrencyRegistry) IsEnabled(code string) bool {
+	c, ok := r.Get(code)
+	return ok && c.Enabled
+}
+
+// Currencies is the registry used by the application. Until it is loaded from the database it only
+// knows about the currencies that were supported before the registry existed.
+var Currencies = NewCurrencyRegistry([]Currency{
+	{Code: CAD, NumericCode: "124", Name: "Canadian Dollar", MinorUnits: 2, Symbol: "CA$", Enabled: true},
+	{Code: EUR, NumericCode: "978", Name: "Euro", MinorUnits: 2, Symbol: "€", Enabled: true},
+	{Code: USD, NumericCode: "840", Name: "US Dollar", MinorUnits: 2, Symbol: "$", Enabled: true},
+})
+
+// IsSupportedCurrency reports whether the currency is enabled in the registry
 func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, CAD:
-		return true
+	return Currencies.IsEnabled(currency)
+}
+
+// FormatAmount formats an amount in minor units using the currency symbol and minor units, e.g. 1050 EUR is "€10.50"
+func (c Currency) FormatAmount(amount int64) string {
+	return fmt.Sprintf("%s%s%s", amountSign(amount), c.Symbol, formatMinorUnits(amount, c.MinorUnits))
+}
+
+// FormatAmount formats an amount in minor units of the given currency. Unknown currencies
+// are formatted with two minor units followed by the currency code.
+func FormatAmount(amount int64, currency string) string {
+	c, ok := Currencies.Get(currency)
+	if !ok {
+		return fmt.Sprintf("%s%s %s", amountSign(amount), formatMinorUnits(amount, 2), currency)
 	}
-	return false
+	return c.FormatAmount(amount)
+}
+
+// amountSign returns the sign formatMinorUnits leaves out
+func amountSign(amount int64) string {
+	if amount < 0 {
+		return "-"
+	}
+	return ""
+}
+
+// formatMinorUnits returns the absolute value of amount as a decimal string with the given minor units
+func formatMinorUnits(amount int64, minorUnits int) string {
+	// work on the unsigned value so that math.MinInt64 does not overflow
+	abs := uint64(amount)
+	if amount < 0 {
+		abs = uint64(-(amount + 1)) + 1
+	}
+
+	digits := fmt.Sprintf("%0*d", minorUnits+1, abs)
+	if minorUnits == 0 {
+		return digits
+	}
+
+	split := len(digits) - minorUnits
+	return digits[:split] + "." + digits[split:]
 }
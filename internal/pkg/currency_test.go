@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyRegistry(t *testing.T) {
+	registry := NewCurrencyRegistry([]Currency{
+		{Code: "JPY", NumericCode: "392", MinorUnits: 0, Symbol: "¥", Enabled: true},
+		{Code: "KWD", NumericCode: "414", MinorUnits: 3, Symbol: "KD", Enabled: false},
+	})
+
+	require.True(t, registry.IsEnabled("JPY"))
+	require.False(t, registry.IsEnabled("KWD"))
+	require.False(t, registry.IsEnabled("XXX"))
+
+	kwd, ok := registry.Get("KWD")
+	require.True(t, ok)
+	kwd.Enabled = true
+	registry.Set(kwd)
+	require.True(t, registry.IsEnabled("KWD"))
+
+	currencies := registry.List()
+	require.Len(t, currencies, 2)
+	require.Equal(t, "JPY", currencies[0].Code)
+	require.Equal(t, "KWD", currencies[1].Code)
+}
+
+func TestDefaultCurrencies(t *testing.T) {
+	for _, c := range []string{USD, EUR, CAD} {
+		require.True(t, IsSupportedCurrency(c))
+	}
+	require.False(t, IsSupportedCurrency("JPY"))
+}
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		currency Currency
+		amount   int64
+		expected string
+	}{
+		{Currency{Code: "EUR", MinorUnits: 2, Symbol: "€"}, 1050, "€10.50"},
+		{Currency{Code: "EUR", MinorUnits: 2, Symbol: "€"}, 5, "€0.05"},
+		{Currency{Code: "EUR", MinorUnits: 2, Symbol: "€"}, -1050, "-€10.50"},
+		{Currency{Code: "JPY", MinorUnits: 0, Symbol: "¥"}, 1050, "¥1050"},
+		{Currency{Code: "KWD", MinorUnits: 3, Symbol: "KD"}, 1050, "KD1.050"},
+		{Currency{Code: "USD", MinorUnits: 2, Symbol: "$"}, math.MinInt64, "-$92233720368547758.08"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, tc.currency.FormatAmount(tc.amount))
+	}
+
+	require.Equal(t, "10.50 XXX", FormatAmount(1050, "XXX"))
+	require.Equal(t, "-10.50 XXX", FormatAmount(-1050, "XXX"))
+	require.Equal(t, "-92233720368547758.08 XXX", FormatAmount(math.MinInt64, "XXX"))
+}
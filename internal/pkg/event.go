@@ -0,0 +1,120 @@
+package pkg
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types, they are written to the outbox in the transaction of the change they describe
+const (
+	EventUserCreated                 = "user.created"
+	EventUserNewDeviceLogin          = "user.new_device_login"
+	EventUserVerifyEmailRequested    = "user.verify_email_requested"
+	EventUserEmailChangeRequested    = "user.email_change_requested"
+	EventUserSessionReuseDetected    = "user.session_reuse_detected"
+	EventAccountCreated              = "account.created"
+	EventAccountCredited             = "account.credited"
+	EventAccountLowBalance           = "account.low_balance"
+	EventTransferCompleted           = "transfer.completed"
+	EventPaymentRequestStatusChanged = "payment_request.status_changed"
+)
+
+// Event is the envelope an outbox event is published in, Data holds the payload of its type
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// UserCreatedEvent is the payload of EventUserCreated
+type UserCreatedEvent struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// UserVerifyEmailRequestedEvent is the payload of EventUserVerifyEmailRequested, sent when an unverified user asks
+// for a new verification email
+type UserVerifyEmailRequestedEvent struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// UserEmailChangeRequestedEvent is the payload of EventUserEmailChangeRequested, sent when a user asks to change
+// their email address. The address only changes once NewEmail is verified
+type UserEmailChangeRequestedEvent struct {
+	Username string `json:"username"`
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
+
+// UserNewDeviceLoginEvent is the payload of EventUserNewDeviceLogin, sent when a user logs in with a user agent
+// they never used before
+type UserNewDeviceLoginEvent struct {
+	Username  string    `json:"username"`
+	SessionID string    `json:"session_id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIP  string    `json:"client_ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserSessionReuseDetectedEvent is the payload of EventUserSessionReuseDetected, sent when a refresh token that
+// was already exchanged is presented again. Either the user or someone who stole the token used it first, so every
+// session of the family is blocked
+type UserSessionReuseDetectedEvent struct {
+	Username string `json:"username"`
+	// FamilyID is the session opened at login the token descends from
+	FamilyID  string `json:"family_id"`
+	UserAgent string `json:"user_agent"`
+	ClientIP  string `json:"client_ip"`
+}
+
+// AccountCreatedEvent is the payload of EventAccountCreated
+type AccountCreatedEvent struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Currency  string `json:"currency"`
+}
+
+// AccountCreditedEvent is the payload of EventAccountCredited, sent for deposits and incoming transfers
+type AccountCreditedEvent struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	EntryID   int64  `json:"entry_id"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Balance   int64  `json:"balance"`
+	// TransferID is set when the money comes from another account
+	TransferID int64 `json:"transfer_id,omitempty"`
+}
+
+// AccountLowBalanceEvent is the payload of EventAccountLowBalance, sent when a debit takes the balance below the
+// threshold set by the owner
+type AccountLowBalanceEvent struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Balance   int64  `json:"balance"`
+	Threshold int64  `json:"threshold"`
+	Currency  string `json:"currency"`
+}
+
+// TransferCompletedEvent is the payload of EventTransferCompleted
+type TransferCompletedEvent struct {
+	TransferID    int64  `json:"transfer_id"`
+	FromAccountID int64  `json:"from_account_id"`
+	FromOwner     string `json:"from_owner"`
+	ToAccountID   int64  `json:"to_account_id"`
+	ToOwner       string `json:"to_owner"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+}
+
+// PaymentRequestStatusChangedEvent is the payload of EventPaymentRequestStatusChanged
+type PaymentRequestStatusChangedEvent struct {
+	PaymentRequestID int64  `json:"payment_request_id"`
+	Requester        string `json:"requester"`
+	Payer            string `json:"payer"`
+	Amount           int64  `json:"amount"`
+	Currency         string `json:"currency"`
+	Status           string `json:"status"`
+}
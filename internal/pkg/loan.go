@@ -0,0 +1,22 @@
+package pkg
+
+// Loan amortization schedules
+const (
+	LoanScheduleAnnuity = "annuity" // equal instalments, the principal part grows over time
+	LoanScheduleLinear  = "linear"  // equal principal parts, the instalments shrink over time
+)
+
+// Loan statuses
+const (
+	LoanStatusPending  = "pending"
+	LoanStatusRejected = "rejected"
+	LoanStatusActive   = "active"
+	LoanStatusPaidOff  = "paid_off"
+)
+
+// Loan instalment statuses
+const (
+	InstalmentStatusPending = "pending"
+	InstalmentStatusOverdue = "overdue"
+	InstalmentStatusPaid    = "paid"
+)
@@ -0,0 +1,249 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Errors returned by Money operations
+var (
+	ErrAmountOverflow   = errors.New("amount overflow")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrRoundingRequired = errors.New("amount has more decimal places than the currency allows")
+)
+
+// RoundingMode defines how a value that falls between two minor units is rounded
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest minor unit, ties go to the even neighbour (banker's rounding)
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest minor unit, ties go away from zero
+	RoundHalfUp
+	// RoundDown rounds towards zero
+	RoundDown
+	// RoundUp rounds away from zero
+	RoundUp
+	// RoundUnnecessary refuses to round and returns ErrRoundingRequired instead
+	RoundUnnecessary
+)
+
+var decimalRegexp = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Money is an amount of money in the minor units of its currency, e.g. 1050 EUR is 10.50 EUR
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney creates a new Money value
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// MinorUnits returns the number of decimal places of the currency according to the registry
+func MinorUnits(currency string) (int, error) {
+	c, ok := Currencies.Get(currency)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+	return c.MinorUnits, nil
+}
+
+// ParseMoney parses a decimal string such as "10.50" into Money, rounding extra decimal places with mode
+func ParseMoney(decimal string, currency string, mode RoundingMode) (Money, error) {
+	minorUnits, err := MinorUnits(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	if !decimalRegexp.MatchString(decimal) {
+		return Money{}, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidAmount, decimal)
+	}
+
+	// scale the decimal to an integer numerator over a power of ten denominator
+	intPart, fracPart, _ := strings.Cut(decimal, ".")
+	num, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	den := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(len(fracPart))), nil)
+
+	// and bring it to minor units
+	num.Mul(num, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(minorUnits)), nil))
+
+	amount, err := divRound(num, den, mode)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return NewMoney(amount, currency), nil
+}
+
+// Add returns m + other, failing on currency mismatch or overflow
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(sum, m.Currency), nil
+}
+
+// Sub returns m - other, failing on currency mismatch or overflow
+func (m Money) Sub(other Money) (Money, error) {
+	negated, err := other.Negate()
+	if err != nil {
+		return Money{}, err
+	}
+	return m.Add(negated)
+}
+
+// Negate returns -m, failing on overflow
+func (m Money) Negate() (Money, error) {
+	if m.Amount == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+	return NewMoney(-m.Amount, m.Currency), nil
+}
+
+// MulRatio returns m * numerator / denominator rounded with mode, e.g. for interest and fees
+func (m Money) MulRatio(numerator int64, denominator int64, mode RoundingMode) (Money, error) {
+	if denominator == 0 {
+		return Money{}, fmt.Errorf("%w: zero denominator", ErrInvalidAmount)
+	}
+
+	num := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(numerator))
+	den := big.NewInt(denominator)
+	if den.Sign() < 0 {
+		num.Neg(num)
+		den.Neg(den)
+	}
+
+	amount, err := divRound(num, den, mode)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return NewMoney(amount, m.Currency), nil
+}
+
+// Allocate splits m according to ratios without losing any minor unit.
+// The remainder left by the integer division is handed out one minor unit at a time, starting with the first share.
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("%w: no ratios to allocate", ErrInvalidAmount)
+	}
+
+	total := new(big.Int)
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("%w: negative ratio", ErrInvalidAmount)
+		}
+		total.Add(total, big.NewInt(ratio))
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("%w: ratios sum to zero", ErrInvalidAmount)
+	}
+
+	shares := make([]Money, len(ratios))
+	remainder := m.Amount
+	for i, ratio := range ratios {
+		// truncating division keeps every share on the same side of zero as m
+		share := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(ratio))
+		share.Quo(share, total)
+		shares[i] = NewMoney(share.Int64(), m.Currency)
+		remainder -= shares[i].Amount
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(shares) {
+		if ratios[i] == 0 {
+			continue
+		}
+		shares[i].Amount += step
+		remainder -= step
+	}
+
+	return shares, nil
+}
+
+// Split divides m into n shares that differ by at most one minor unit
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: cannot split into %d shares", ErrInvalidAmount, n)
+	}
+
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// Decimal returns the amount as a decimal string in major units, e.g. "10.50"
+func (m Money) Decimal() string {
+	minorUnits, err := MinorUnits(m.Currency)
+	if err != nil {
+		minorUnits = 2
+	}
+
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	return sign + formatMinorUnits(m.Amount, minorUnits)
+}
+
+// String formats m with the currency symbol, e.g. "€10.50"
+func (m Money) String() string {
+	return FormatAmount(m.Amount, m.Currency)
+}
+
+// divRound divides num by a positive den, rounding the quotient with mode
+func divRound(num *big.Int, den *big.Int, mode RoundingMode) (int64, error) {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if rem.Sign() != 0 {
+		// the quotient was truncated towards zero, away from zero means one more unit with the sign of num
+		awayFromZero := big.NewInt(int64(num.Sign()))
+
+		// compare twice the remainder with the denominator to find out which neighbour is nearer
+		twiceRem := new(big.Int).Abs(rem)
+		twiceRem.Lsh(twiceRem, 1)
+		cmp := twiceRem.Cmp(den)
+
+		switch mode {
+		case RoundUnnecessary:
+			return 0, ErrRoundingRequired
+		case RoundDown:
+		case RoundUp:
+			quo.Add(quo, awayFromZero)
+		case RoundHalfUp:
+			if cmp >= 0 {
+				quo.Add(quo, awayFromZero)
+			}
+		case RoundHalfEven:
+			if cmp > 0 || (cmp == 0 && quo.Bit(0) == 1) {
+				quo.Add(quo, awayFromZero)
+			}
+		default:
+			return 0, fmt.Errorf("unknown rounding mode %d", mode)
+		}
+	}
+
+	if !quo.IsInt64() {
+		return 0, ErrAmountOverflow
+	}
+	return quo.Int64(), nil
+}
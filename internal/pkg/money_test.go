@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	Currencies.Set(Currency{Code: "JPY", NumericCode: "392", Name: "Yen", MinorUnits: 0, Symbol: "¥"})
+	Currencies.Set(Currency{Code: "KWD", NumericCode: "414", Name: "Kuwaiti Dinar", MinorUnits: 3, Symbol: "KD"})
+}
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		decimal  string
+		currency string
+		mode     RoundingMode
+		expected int64
+		err      error
+	}{
+		{"10.50", EUR, RoundUnnecessary, 1050, nil},
+		{"10.5", EUR, RoundUnnecessary, 1050, nil},
+		{"10", EUR, RoundUnnecessary, 1000, nil},
+		{"-0.01", EUR, RoundUnnecessary, -1, nil},
+		{"1050", "JPY", RoundUnnecessary, 1050, nil},
+		{"1.050", "KWD", RoundUnnecessary, 1050, nil},
+		{"10.505", EUR, RoundUnnecessary, 0, ErrRoundingRequired},
+		{"10.505", EUR, RoundHalfUp, 1051, nil},
+		{"10.505", EUR, RoundHalfEven, 1050, nil},
+		{"10.515", EUR, RoundHalfEven, 1052, nil},
+		{"10.501", EUR, RoundDown, 1050, nil},
+		{"10.501", EUR, RoundUp, 1051, nil},
+		{"-10.505", EUR, RoundHalfUp, -1051, nil},
+		{"-10.501", EUR, RoundDown, -1050, nil},
+		{"-10.501", EUR, RoundUp, -1051, nil},
+		{"92233720368547758.07", USD, RoundUnnecessary, math.MaxInt64, nil},
+		{"92233720368547758.08", USD, RoundUnnecessary, 0, ErrAmountOverflow},
+		{"10,50", EUR, RoundUnnecessary, 0, ErrInvalidAmount},
+		{"1e3", EUR, RoundUnnecessary, 0, ErrInvalidAmount},
+		{".5", EUR, RoundUnnecessary, 0, ErrInvalidAmount},
+		{"", EUR, RoundUnnecessary, 0, ErrInvalidAmount},
+		{"10.50", "XXX", RoundUnnecessary, 0, ErrUnknownCurrency},
+	}
+
+	for _, tc := range testCases {
+		money, err := ParseMoney(tc.decimal, tc.currency, tc.mode)
+		if tc.err != nil {
+			require.ErrorIs(t, err, tc.err, tc.decimal)
+			continue
+		}
+		require.NoError(t, err, tc.decimal)
+		require.Equal(t, NewMoney(tc.expected, tc.currency), money, tc.decimal)
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	require.Equal(t, "10.50", NewMoney(1050, EUR).Decimal())
+	require.Equal(t, "-0.05", NewMoney(-5, EUR).Decimal())
+	require.Equal(t, "1050", NewMoney(1050, "JPY").Decimal())
+	require.Equal(t, "1.050", NewMoney(1050, "KWD").Decimal())
+	require.Equal(t, "-92233720368547758.08", NewMoney(math.MinInt64, USD).Decimal())
+	require.Equal(t, "€10.50", NewMoney(1050, EUR).String())
+}
+
+func TestMoneyAddSub(t *testing.T) {
+	sum, err := NewMoney(1050, EUR).Add(NewMoney(-50, EUR))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(1000, EUR), sum)
+
+	diff, err := NewMoney(1050, EUR).Sub(NewMoney(2000, EUR))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(-950, EUR), diff)
+
+	_, err = NewMoney(1050, EUR).Add(NewMoney(1050, USD))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = NewMoney(math.MaxInt64, EUR).Add(NewMoney(1, EUR))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(math.MinInt64, EUR).Add(NewMoney(-1, EUR))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(0, EUR).Sub(NewMoney(math.MinInt64, EUR))
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(-1, EUR).Sub(NewMoney(math.MaxInt64, EUR))
+	require.NoError(t, err)
+}
+
+func TestMoneyMulRatio(t *testing.T) {
+	// 5% of 10.10 is 0.505
+	interest, err := NewMoney(1010, EUR).MulRatio(5, 100, RoundHalfEven)
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(50, EUR), interest)
+
+	interest, err = NewMoney(1010, EUR).MulRatio(5, 100, RoundHalfUp)
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(51, EUR), interest)
+
+	// does not overflow in the intermediate product
+	half, err := NewMoney(math.MaxInt64, EUR).MulRatio(1, 2, RoundDown)
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(math.MaxInt64/2, EUR), half)
+
+	_, err = NewMoney(math.MaxInt64, EUR).MulRatio(2, 1, RoundDown)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = NewMoney(100, EUR).MulRatio(1, 0, RoundDown)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		ratios   []int64
+		expected []int64
+	}{
+		{100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{5, []int64{3, 7}, []int64{2, 3}},
+		{-100, []int64{1, 1, 1}, []int64{-34, -33, -33}},
+		{1000, []int64{50, 30, 20}, []int64{500, 300, 200}},
+		{2, []int64{0, 1, 1, 1}, []int64{0, 1, 1, 0}},
+		{math.MaxInt64, []int64{1, 1}, []int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}},
+	}
+
+	for _, tc := range testCases {
+		shares, err := NewMoney(tc.amount, EUR).Allocate(tc.ratios...)
+		require.NoError(t, err)
+		require.Len(t, shares, len(tc.expected))
+
+		var total int64
+		for i, share := range shares {
+			require.Equal(t, EUR, share.Currency)
+			require.Equal(t, tc.expected[i], share.Amount)
+			total += share.Amount
+		}
+		require.Equal(t, tc.amount, total)
+	}
+
+	_, err := NewMoney(100, EUR).Allocate()
+	require.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = NewMoney(100, EUR).Allocate(0, 0)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = NewMoney(100, EUR).Allocate(1, -1)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestMoneySplit(t *testing.T) {
+	shares, err := NewMoney(1000, EUR).Split(3)
+	require.NoError(t, err)
+	require.Equal(t, []Money{NewMoney(334, EUR), NewMoney(333, EUR), NewMoney(333, EUR)}, shares)
+
+	_, err = NewMoney(1000, EUR).Split(0)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
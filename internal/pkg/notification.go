@@ -0,0 +1,74 @@
+package pkg
+
+import "slices"
+
+// Notification types users set their preferences for
+const (
+	NotificationTransferReceived = "transfer_received"
+	NotificationTransferSent     = "transfer_sent"
+	NotificationLowBalance       = "low_balance"
+	NotificationNewDeviceLogin   = "new_device_login"
+	NotificationStatement        = "statement"
+	NotificationPaymentRequest   = "payment_request"
+)
+
+// NotificationEmailVerification is sent regardless of preferences, users can't log in without it
+const NotificationEmailVerification = "email_verification"
+
+// NotificationSecurityAlert is sent regardless of preferences too, it tells users their account may be compromised
+const NotificationSecurityAlert = "security_alert"
+
+// Notification channels
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+	NotificationChannelPush  = "push"
+)
+
+// NotificationTypes are the notification types users can set their preferences for
+var NotificationTypes = []string{
+	NotificationTransferReceived,
+	NotificationTransferSent,
+	NotificationLowBalance,
+	NotificationNewDeviceLogin,
+	NotificationStatement,
+	NotificationPaymentRequest,
+}
+
+// NotificationChannels are the channels notifications can be sent on
+var NotificationChannels = []string{
+	NotificationChannelEmail,
+	NotificationChannelSMS,
+	NotificationChannelPush,
+}
+
+// Platforms of the devices push notifications are sent to
+const (
+	PushPlatformAndroid = "android"
+	PushPlatformIOS     = "ios"
+	PushPlatformWeb     = "web"
+)
+
+// IsPushPlatform checks if push notifications can be sent to devices of the platform
+func IsPushPlatform(platform string) bool {
+	return platform == PushPlatformAndroid || platform == PushPlatformIOS || platform == PushPlatformWeb
+}
+
+// IsNotificationType checks if users can set their preferences for the notification type
+func IsNotificationType(notificationType string) bool {
+	return slices.Contains(NotificationTypes, notificationType)
+}
+
+// IsNotificationChannel checks if notifications can be sent on the channel
+func IsNotificationChannel(channel string) bool {
+	return slices.Contains(NotificationChannels, channel)
+}
+
+// DefaultNotificationChannels returns the channels of a notification type for users that didn't set a preference.
+// Outgoing transfers are initiated by the user, so they aren't notified unless they ask for it
+func DefaultNotificationChannels(notificationType string) []string {
+	if notificationType == NotificationTransferSent {
+		return []string{}
+	}
+	return []string{NotificationChannelEmail}
+}
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHOTPRFC6238Vectors(t *testing.T) {
+	// the SHA1 test vectors of RFC 6238 appendix B
+	key := []byte("12345678901234567890")
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.code, hotp(key, TOTPStep(time.Unix(tc.unix, 0)), 8))
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := TOTPCode(secret, TOTPStep(now))
+	require.NoError(t, err)
+	require.Len(t, code, totpDigits)
+
+	step, ok := ValidateTOTP(secret, code, now)
+	require.True(t, ok)
+	require.Equal(t, TOTPStep(now), step)
+
+	// a step of drift either way is accepted
+	step, ok = ValidateTOTP(secret, code, now.Add(totpPeriod))
+	require.True(t, ok)
+	require.Equal(t, TOTPStep(now), step)
+	_, ok = ValidateTOTP(secret, code, now.Add(-totpPeriod))
+	require.True(t, ok)
+
+	_, ok = ValidateTOTP(secret, code, now.Add(3*totpPeriod))
+	require.False(t, ok)
+	_, ok = ValidateTOTP(secret, "12345", now)
+	require.False(t, ok)
+	_, ok = ValidateTOTP("not base32!", code, now)
+	require.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("mybank", "alice", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/mybank:alice", uri.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	require.Equal(t, "mybank", uri.Query().Get("issuer"))
+	require.Equal(t, "6", uri.Query().Get("digits"))
+}
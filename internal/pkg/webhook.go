@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader is the header that carries the signature of a webhook delivery
+const WebhookSignatureHeader = "Mybank-Signature"
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+// WebhookEventTypes are the event types webhooks can subscribe to
+var WebhookEventTypes = []string{
+	EventAccountCreated,
+	EventAccountCredited,
+	EventAccountLowBalance,
+	EventTransferCompleted,
+}
+
+// IsWebhookEventType checks if webhooks can subscribe to the event type
+func IsWebhookEventType(eventType string) bool {
+	return slices.Contains(WebhookEventTypes, eventType)
+}
+
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// SignWebhookPayload returns the signature header value of a payload sent at timestamp, in the form t=<unix>,v1=<hex>.
+// The signature is the HMAC-SHA256 of "<unix>.<payload>" keyed with the webhook secret.
+func SignWebhookPayload(secret string, timestamp time.Time, payload []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", unix, hex.EncodeToString(webhookMAC(secret, unix, payload)))
+}
+
+// VerifyWebhookSignature checks a signature header against the payload, and that it isn't older than tolerance
+// so that captured requests can't be replayed later
+func VerifyWebhookSignature(secret string, header string, payload []byte, now time.Time, tolerance time.Duration) error {
+	var unix string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			if signature, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, signature)
+			}
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing timestamp", ErrInvalidWebhookSignature)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside of the tolerance", ErrInvalidWebhookSignature)
+	}
+
+	expected := webhookMAC(secret, unix, payload)
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no matching signature", ErrInvalidWebhookSignature)
+}
+
+func webhookMAC(secret string, unix string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,27 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	secret := RandomString(32)
+	payload := []byte(`{"id":1,"type":"transfer.completed"}`)
+	now := time.Now()
+
+	header := SignWebhookPayload(secret, now, payload)
+	require.Regexp(t, `^t=\d+,v1=[0-9a-f]{64}$`, header)
+	require.NoError(t, VerifyWebhookSignature(secret, header, payload, now, 5*time.Minute))
+
+	// another secret, a changed payload or an old signature don't verify
+	require.ErrorIs(t, VerifyWebhookSignature(RandomString(32), header, payload, now, 5*time.Minute), ErrInvalidWebhookSignature)
+	require.ErrorIs(t, VerifyWebhookSignature(secret, header, []byte(`{"id":2}`), now, 5*time.Minute), ErrInvalidWebhookSignature)
+	require.ErrorIs(t, VerifyWebhookSignature(secret, header, payload, now.Add(10*time.Minute), 5*time.Minute), ErrInvalidWebhookSignature)
+	require.ErrorIs(t, VerifyWebhookSignature(secret, "v1=00", payload, now, 5*time.Minute), ErrInvalidWebhookSignature)
+
+	// the signature is stable for a timestamp, so receivers can compute it themselves
+	require.Equal(t, header, SignWebhookPayload(secret, now, payload))
+}
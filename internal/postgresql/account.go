@@ -21,7 +21,7 @@ func NewAccountRepository(connPool *pgxpool.Pool) *AccountRepository {
 }
 
 func (accountRepo *AccountRepository) Create(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
-	account, err := accountRepo.q.CreateAccount(ctx, arg)
+	account, err := accountRepo.q.CreateAccountTx(ctx, arg)
 	if err != nil {
 		return db.Account{}, internal.DBErrorToInternal(err)
 	}
@@ -45,7 +45,7 @@ func (accountRepo *AccountRepository) List(ctx context.Context, arg db.ListAccou
 }
 
 func (accountRepo *AccountRepository) Delete(ctx context.Context, id int64) error {
-	err := accountRepo.q.DeleteAccount(ctx, id)
+	_, err := accountRepo.q.DeleteAccount(ctx, id)
 	if err != nil {
 		return internal.DBErrorToInternal(err)
 	}
@@ -53,9 +53,9 @@ func (accountRepo *AccountRepository) Delete(ctx context.Context, id int64) erro
 }
 
 func (accountRepo *AccountRepository) AddBalance(ctx context.Context, arg db.AddAccountBalanceParams) (db.Account, error) {
-	acc, err := accountRepo.q.AddAccountBalance(ctx, arg)
+	result, err := accountRepo.q.AddAccountBalanceTx(ctx, arg)
 	if err != nil {
 		return db.Account{}, internal.DBErrorToInternal(err)
 	}
-	return acc, nil
+	return result.Account, nil
 }
@@ -0,0 +1,67 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// BillRepository represents the repository used for interacting with Bill records.
+type BillRepository struct {
+	q db.Store
+}
+
+// NewBillRepository instantiates the Bill repository.
+func NewBillRepository(connPool *pgxpool.Pool) *BillRepository {
+	return &BillRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (billRepo *BillRepository) CreateTx(ctx context.Context, arg db.CreateBillTxParams) (db.CreateBillTxResult, error) {
+	res, err := billRepo.q.CreateBillTx(ctx, arg)
+	if err != nil {
+		return db.CreateBillTxResult{}, internal.DBErrorToInternal(err)
+	}
+	return res, nil
+}
+
+func (billRepo *BillRepository) Get(ctx context.Context, id int64) (db.Bill, error) {
+	bill, err := billRepo.q.GetBill(ctx, id)
+	if err != nil {
+		return db.Bill{}, internal.DBErrorToInternal(err)
+	}
+	return bill, nil
+}
+
+func (billRepo *BillRepository) ListByOrganizer(ctx context.Context, arg db.ListBillsByOrganizerParams) ([]db.Bill, error) {
+	bills, err := billRepo.q.ListBillsByOrganizer(ctx, arg)
+	if err != nil {
+		return []db.Bill{}, internal.DBErrorToInternal(err)
+	}
+	return bills, nil
+}
+
+func (billRepo *BillRepository) ListPaymentRequests(ctx context.Context, billID int64) ([]db.PaymentRequest, error) {
+	paymentRequests, err := billRepo.q.ListBillPaymentRequests(ctx, pgtype.Int8{Int64: billID, Valid: true})
+	if err != nil {
+		return []db.PaymentRequest{}, internal.DBErrorToInternal(err)
+	}
+	return paymentRequests, nil
+}
+
+// MarkReminded records that the participants were reminded now, unless they were already reminded after remindedBefore.
+func (billRepo *BillRepository) MarkReminded(ctx context.Context, id int64, remindedBefore time.Time) (db.Bill, error) {
+	bill, err := billRepo.q.MarkBillReminded(ctx, db.MarkBillRemindedParams{
+		ID:             id,
+		RemindedBefore: pgtype.Timestamptz{Time: remindedBefore, Valid: true},
+	})
+	if err != nil {
+		return db.Bill{}, internal.DBErrorToInternal(err)
+	}
+	return bill, nil
+}
@@ -0,0 +1,45 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// CurrencyRepository represents the repository used for interacting with Currency records.
+type CurrencyRepository struct {
+	q db.Store
+}
+
+// NewCurrencyRepository instantiates the Currency repository.
+func NewCurrencyRepository(connPool *pgxpool.Pool) *CurrencyRepository {
+	return &CurrencyRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (currencyRepo *CurrencyRepository) List(ctx context.Context) ([]db.Currency, error) {
+	currencies, err := currencyRepo.q.ListCurrencies(ctx)
+	if err != nil {
+		return []db.Currency{}, internal.DBErrorToInternal(err)
+	}
+	return currencies, nil
+}
+
+func (currencyRepo *CurrencyRepository) Get(ctx context.Context, code string) (db.Currency, error) {
+	currency, err := currencyRepo.q.GetCurrency(ctx, code)
+	if err != nil {
+		return db.Currency{}, internal.DBErrorToInternal(err)
+	}
+	return currency, nil
+}
+
+func (currencyRepo *CurrencyRepository) UpdateEnabled(ctx context.Context, arg db.UpdateCurrencyEnabledParams) (db.Currency, error) {
+	currency, err := currencyRepo.q.UpdateCurrencyEnabled(ctx, arg)
+	if err != nil {
+		return db.Currency{}, internal.DBErrorToInternal(err)
+	}
+	return currency, nil
+}
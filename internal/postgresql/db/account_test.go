@@ -69,9 +69,10 @@ func TestUpdateAccount(t *testing.T) {
 }
 
 func TestDeleteAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
-	err := testStore.DeleteAccount(context.Background(), account1.ID)
+	account1 := createEmptyAccount(t)
+	deleted, err := testStore.DeleteAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
+	require.Equal(t, account1.ID, deleted.ID)
 
 	account2, err := testStore.GetAccount(context.Background(), account1.ID)
 	require.Error(t, err)
@@ -79,6 +80,36 @@ func TestDeleteAccount(t *testing.T) {
 	require.Empty(t, account2)
 }
 
+// createEmptyAccount creates an account with a zero balance, which is the only kind that can be deleted
+func createEmptyAccount(t *testing.T) Account {
+	account := createRandomAccount(t)
+	account, err := testStore.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+	return account
+}
+
+func TestDeleteAccountWithBalance(t *testing.T) {
+	account := createEmptyAccount(t)
+	_, err := testStore.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 10})
+	require.NoError(t, err)
+
+	_, err = testStore.DeleteAccount(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// moving the main balance into a pocket still keeps the account
+	pocket := createRandomPocket(t, account)
+	_, err = testStore.AddPocketBalance(context.Background(), AddPocketBalanceParams{ID: pocket.ID, Amount: 10})
+	require.NoError(t, err)
+	_, err = testStore.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: -10})
+	require.NoError(t, err)
+
+	_, err = testStore.DeleteAccount(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+}
+
 func TestListAccounts(t *testing.T) {
 	var lastAccount Account
 	for i := 0; i < 10; i++ {
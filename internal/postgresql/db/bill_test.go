@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomBill(t *testing.T, toAccount Account, payers ...string) CreateBillTxResult {
+	shares := make([]BillShare, 0, len(payers))
+	var total int64
+	for _, payer := range payers {
+		share := BillShare{Payer: payer, Amount: pkg.RandomInt(1, 100)}
+		total += share.Amount
+		shares = append(shares, share)
+	}
+
+	arg := CreateBillParams{
+		Organizer:   toAccount.Owner,
+		ToAccountID: toAccount.ID,
+		Total:       total,
+		Currency:    toAccount.Currency,
+		Description: pkg.RandomString(12),
+		SplitType:   pkg.BillSplitCustom,
+	}
+
+	result, err := testStore.CreateBillTx(context.Background(), CreateBillTxParams{
+		CreateBillParams: arg,
+		Shares:           shares,
+		ExpiresAt:        time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	bill := result.Bill
+	require.NotZero(t, bill.ID)
+	require.Equal(t, arg.Organizer, bill.Organizer)
+	require.Equal(t, arg.ToAccountID, bill.ToAccountID)
+	require.Equal(t, arg.Total, bill.Total)
+	require.Equal(t, arg.Currency, bill.Currency)
+	require.Equal(t, arg.Description, bill.Description)
+	require.Equal(t, arg.SplitType, bill.SplitType)
+	require.False(t, bill.RemindedAt.Valid)
+	require.NotZero(t, bill.CreatedAt)
+
+	require.Len(t, result.PaymentRequests, len(shares))
+	for i, paymentRequest := range result.PaymentRequests {
+		require.Equal(t, shares[i].Payer, paymentRequest.Payer)
+		require.Equal(t, shares[i].Amount, paymentRequest.Amount)
+		require.Equal(t, bill.Organizer, paymentRequest.Requester)
+		require.Equal(t, bill.ToAccountID, paymentRequest.ToAccountID)
+		require.Equal(t, bill.Description, paymentRequest.Message)
+		require.Equal(t, pgtype.Int8{Int64: bill.ID, Valid: true}, paymentRequest.BillID)
+	}
+
+	return result
+}
+
+func TestCreateBillTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	result := createRandomBill(t, toAccount, createRandomUser(t).Username, createRandomUser(t).Username)
+
+	paymentRequests, err := testStore.ListBillPaymentRequests(context.Background(), pgtype.Int8{Int64: result.Bill.ID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, result.PaymentRequests, paymentRequests)
+
+	// every payer is sent their payment request
+	for _, paymentRequest := range result.PaymentRequests {
+		event := relayUntil(t, pkg.EventPaymentRequestStatusChanged, strconv.FormatInt(paymentRequest.ID, 10))
+		var payload pkg.PaymentRequestStatusChangedEvent
+		require.NoError(t, json.Unmarshal(event.Payload, &payload))
+		require.Equal(t, paymentRequest.Payer, payload.Payer)
+		require.Equal(t, pkg.PaymentRequestStatusPending, payload.Status)
+	}
+}
+
+func TestCreateBillTxRollback(t *testing.T) {
+	toAccount := createRandomAccount(t)
+
+	// a participant that does not exist aborts the whole bill
+	_, err := testStore.CreateBillTx(context.Background(), CreateBillTxParams{
+		CreateBillParams: CreateBillParams{
+			Organizer:   toAccount.Owner,
+			ToAccountID: toAccount.ID,
+			Total:       20,
+			Currency:    toAccount.Currency,
+			SplitType:   pkg.BillSplitEqual,
+		},
+		Shares: []BillShare{
+			{Payer: createRandomUser(t).Username, Amount: 10},
+			{Payer: pkg.RandomOwner(), Amount: 10},
+		},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.Error(t, err)
+
+	bills, err := testStore.ListBillsByOrganizer(context.Background(), ListBillsByOrganizerParams{
+		Organizer: toAccount.Owner,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, bills)
+}
+
+func TestMarkBillReminded(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	result := createRandomBill(t, toAccount, createRandomUser(t).Username)
+
+	bill, err := testStore.MarkBillReminded(context.Background(), MarkBillRemindedParams{
+		ID:             result.Bill.ID,
+		RemindedBefore: pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, bill.RemindedAt.Valid)
+
+	// too soon for another reminder
+	_, err = testStore.MarkBillReminded(context.Background(), MarkBillRemindedParams{
+		ID:             result.Bill.ID,
+		RemindedBefore: pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true},
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -7,34 +7,51 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (account_id,
-                     amount)
-VALUES ($1, $2)
-RETURNING id, account_id, amount, created_at
+                     amount,
+                     pocket_id,
+                     transfer_id,
+                     description)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, account_id, amount, created_at, pocket_id, transfer_id, description
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID   int64       `json:"account_id"`
+	Amount      int64       `json:"amount"`
+	PocketID    pgtype.Int8 `json:"pocket_id"`
+	TransferID  pgtype.Int8 `json:"transfer_id"`
+	Description string      `json:"description"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRow(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.PocketID,
+		arg.TransferID,
+		arg.Description,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.PocketID,
+		&i.TransferID,
+		&i.Description,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, pocket_id, transfer_id, description
 FROM entries
 WHERE id = $1
 LIMIT 1
@@ -48,12 +65,15 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.PocketID,
+		&i.TransferID,
+		&i.Description,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at
+SELECT id, account_id, amount, created_at, pocket_id, transfer_id, description
 FROM entries
 WHERE account_id = $1
 ORDER BY id
@@ -80,6 +100,9 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.PocketID,
+			&i.TransferID,
+			&i.Description,
 		); err != nil {
 			return nil, err
 		}
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"errors"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
@@ -8,10 +10,37 @@ import (
 const (
 	ForeignKeyViolation = "23503"
 	UniqueViolation     = "23505"
+	CheckViolation      = "23514"
 )
 
 var ErrRecordNotFound = pgx.ErrNoRows
 
+// ErrInsufficientFunds is returned by transactions that would leave a balance negative
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrLoanNotPending is returned when a loan that was already reviewed is approved or rejected
+var ErrLoanNotPending = errors.New("loan is not pending")
+
+// ErrPaymentRequestNotPending is returned when a payment request that was already answered changes state again
+var ErrPaymentRequestNotPending = errors.New("payment request is not pending")
+
+// ErrPaymentRequestExpired is returned when an expired payment request is accepted
+var ErrPaymentRequestExpired = errors.New("payment request expired")
+
+// ErrPaymentLinkExpired is returned when an expired payment link is redeemed
+var ErrPaymentLinkExpired = errors.New("payment link expired")
+
+// ErrPaymentLinkUsed is returned when a single-use payment link is redeemed again
+var ErrPaymentLinkUsed = errors.New("payment link already used")
+
+// ErrBrokerUnavailable is wrapped by the publish errors that say nothing about the event, the message broker couldn't
+// be reached. The outbox relay stops without counting the attempt against the event
+var ErrBrokerUnavailable = errors.New("message broker unavailable")
+
+// ErrEventUnpublishable is wrapped by the publish errors that trying again won't fix, like an event that can't be
+// serialized. The outbox relay parks the event right away
+var ErrEventUnpublishable = errors.New("event can't be published")
+
 var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomLoan(t *testing.T, account Account) Loan {
+	arg := CreateLoanParams{
+		AccountID:    account.ID,
+		Principal:    pkg.RandomInt(1000, 100000),
+		Currency:     account.Currency,
+		TermMonths:   3,
+		ScheduleType: pkg.LoanScheduleLinear,
+	}
+
+	loan, err := testStore.CreateLoan(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, loan)
+
+	require.Equal(t, arg.AccountID, loan.AccountID)
+	require.Equal(t, arg.Principal, loan.Principal)
+	require.Equal(t, arg.Currency, loan.Currency)
+	require.Equal(t, arg.TermMonths, loan.TermMonths)
+	require.Equal(t, arg.ScheduleType, loan.ScheduleType)
+	require.Equal(t, pkg.LoanStatusPending, loan.Status)
+	require.Zero(t, loan.OutstandingPrincipal)
+	require.False(t, loan.DisbursedAt.Valid)
+
+	require.NotZero(t, loan.ID)
+	require.NotZero(t, loan.CreatedAt)
+
+	return loan
+}
+
+// disburseRandomLoan approves a loan with three equal instalments, all of them already due
+func disburseRandomLoan(t *testing.T, account Account, lateFee int64) DisburseLoanTxResult {
+	loan := createRandomLoan(t, account)
+	banker := createRandomUser(t)
+
+	principal := loan.Principal / 3
+	dueDate := time.Now().AddDate(0, 0, -2)
+	instalments := []CreateLoanInstalmentParams{
+		{Number: 1, DueDate: dueDate, Principal: principal, Interest: 10},
+		{Number: 2, DueDate: dueDate, Principal: principal, Interest: 10},
+		{Number: 3, DueDate: dueDate, Principal: loan.Principal - 2*principal, Interest: 10},
+	}
+
+	result, err := testStore.DisburseLoanTx(context.Background(), DisburseLoanTxParams{
+		LoanID:        loan.ID,
+		AnnualRateBps: 1200,
+		LateFee:       lateFee,
+		ReviewedBy:    banker.Username,
+		Instalments:   instalments,
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestCreateLoan(t *testing.T) {
+	account := createRandomAccount(t)
+	createRandomLoan(t, account)
+}
+
+func TestListLoansByOwner(t *testing.T) {
+	account := createRandomAccount(t)
+	for i := 0; i < 3; i++ {
+		createRandomLoan(t, account)
+	}
+
+	loans, err := testStore.ListLoansByOwner(context.Background(), ListLoansByOwnerParams{
+		Owner:  account.Owner,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, loans, 3)
+
+	for _, loan := range loans {
+		require.Equal(t, account.ID, loan.AccountID)
+	}
+}
+
+func TestDisburseLoanTx(t *testing.T) {
+	account := createRandomAccount(t)
+	result := disburseRandomLoan(t, account, 0)
+
+	require.Equal(t, pkg.LoanStatusActive, result.Loan.Status)
+	require.Equal(t, result.Loan.Principal, result.Loan.OutstandingPrincipal)
+	require.Equal(t, int32(1200), result.Loan.AnnualRateBps)
+	require.True(t, result.Loan.ReviewedBy.Valid)
+	require.True(t, result.Loan.DisbursedAt.Valid)
+	require.Equal(t, result.Entry.ID, result.Loan.DisbursementEntryID.Int64)
+
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, result.Loan.Principal, result.Entry.Amount)
+	require.Equal(t, account.Balance+result.Loan.Principal, result.Account.Balance)
+
+	require.Len(t, result.Instalments, 3)
+	for _, instalment := range result.Instalments {
+		require.Equal(t, result.Loan.ID, instalment.LoanID)
+		require.Equal(t, pkg.InstalmentStatusPending, instalment.Status)
+	}
+
+	// a loan can only be disbursed once
+	_, err := testStore.DisburseLoanTx(context.Background(), DisburseLoanTxParams{LoanID: result.Loan.ID})
+	require.ErrorIs(t, err, ErrLoanNotPending)
+}
+
+func TestRejectLoan(t *testing.T) {
+	account := createRandomAccount(t)
+	banker := createRandomUser(t)
+	loan := createRandomLoan(t, account)
+
+	rejected, err := testStore.RejectLoan(context.Background(), RejectLoanParams{
+		ReviewedBy: pgtype.Text{String: banker.Username, Valid: true},
+		ID:         loan.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, pkg.LoanStatusRejected, rejected.Status)
+
+	// rejecting twice finds no pending loan
+	_, err = testStore.RejectLoan(context.Background(), RejectLoanParams{
+		ReviewedBy: pgtype.Text{String: banker.Username, Valid: true},
+		ID:         loan.ID,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestCollectLoanInstalmentTx(t *testing.T) {
+	account := createRandomAccount(t)
+	disbursed := disburseRandomLoan(t, account, 0)
+
+	for i, instalment := range disbursed.Instalments {
+		result, err := testStore.CollectLoanInstalmentTx(context.Background(), CollectLoanInstalmentTxParams{
+			InstalmentID: instalment.ID,
+			MarkOverdue:  true,
+		})
+		require.NoError(t, err)
+		require.True(t, result.Collected)
+
+		due := instalment.Principal + instalment.Interest
+		require.Equal(t, -due, result.Entry.Amount)
+		require.Equal(t, pkg.InstalmentStatusPaid, result.Instalment.Status)
+		require.Equal(t, result.Entry.ID, result.Instalment.EntryID.Int64)
+		require.True(t, result.Instalment.PaidAt.Valid)
+
+		if i < len(disbursed.Instalments)-1 {
+			require.Equal(t, pkg.LoanStatusActive, result.Loan.Status)
+		} else {
+			require.Equal(t, pkg.LoanStatusPaidOff, result.Loan.Status)
+			require.Zero(t, result.Loan.OutstandingPrincipal)
+		}
+	}
+
+	// collecting a paid instalment does nothing
+	result, err := testStore.CollectLoanInstalmentTx(context.Background(), CollectLoanInstalmentTxParams{
+		InstalmentID: disbursed.Instalments[0].ID,
+	})
+	require.NoError(t, err)
+	require.False(t, result.Collected)
+}
+
+func TestCollectLoanInstalmentTxOverdue(t *testing.T) {
+	account := createRandomAccount(t)
+	disbursed := disburseRandomLoan(t, account, 500)
+
+	// empty the account so that the instalment cannot be collected
+	_, err := testStore.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -disbursed.Account.Balance,
+	})
+	require.NoError(t, err)
+
+	instalment := disbursed.Instalments[0]
+	result, err := testStore.CollectLoanInstalmentTx(context.Background(), CollectLoanInstalmentTxParams{
+		InstalmentID: instalment.ID,
+		MarkOverdue:  true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.Collected)
+	require.Equal(t, pkg.InstalmentStatusOverdue, result.Instalment.Status)
+	require.Equal(t, int64(500), result.Instalment.LateFee)
+
+	// the late fee is only charged once
+	result, err = testStore.CollectLoanInstalmentTx(context.Background(), CollectLoanInstalmentTxParams{
+		InstalmentID: instalment.ID,
+		MarkOverdue:  true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(500), result.Instalment.LateFee)
+
+	// once there is money the instalment is collected with its late fee
+	due := instalment.Principal + instalment.Interest + 500
+	_, err = testStore.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: due,
+	})
+	require.NoError(t, err)
+
+	result, err = testStore.CollectLoanInstalmentTx(context.Background(), CollectLoanInstalmentTxParams{
+		InstalmentID: instalment.ID,
+		MarkOverdue:  true,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Collected)
+	require.Equal(t, -due, result.Entry.Amount)
+	require.Zero(t, result.Account.Balance)
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// notifyLowBalance writes an EventAccountLowBalance to the outbox when debiting amount from the account took its
+// balance from at least its low balance threshold to below it, account being the one after the debit.
+// Accounts without a threshold are never reported, neither are credits
+func notifyLowBalance(ctx context.Context, q *Queries, account Account, amount int64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	threshold, err := q.GetLowBalanceThreshold(ctx, account.ID)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// only the debit that crosses the threshold is reported, not every debit below it
+	if account.Balance >= threshold.Amount || account.Balance+amount < threshold.Amount {
+		return nil
+	}
+
+	return writeOutboxEvent(ctx, q, pkg.EventAccountLowBalance, strconv.FormatInt(account.ID, 10), pkg.AccountLowBalanceEvent{
+		AccountID: account.ID,
+		Owner:     account.Owner,
+		Balance:   account.Balance,
+		Threshold: threshold.Amount,
+		Currency:  account.Currency,
+	})
+}
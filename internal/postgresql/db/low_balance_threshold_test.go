@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertLowBalanceThreshold(t *testing.T) {
+	account := createRandomAccount(t)
+
+	threshold, err := testStore.UpsertLowBalanceThreshold(context.Background(), UpsertLowBalanceThresholdParams{
+		AccountID: account.ID,
+		Amount:    100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), threshold.Amount)
+
+	updated, err := testStore.UpsertLowBalanceThreshold(context.Background(), UpsertLowBalanceThresholdParams{
+		AccountID: account.ID,
+		Amount:    200,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(200), updated.Amount)
+	require.Equal(t, threshold.CreatedAt, updated.CreatedAt)
+
+	require.NoError(t, testStore.DeleteLowBalanceThreshold(context.Background(), account.ID))
+	_, err = testStore.GetLowBalanceThreshold(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestLowBalanceEventOnlyWhenCrossingThreshold(t *testing.T) {
+	user := createRandomUser(t)
+	account, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  1000,
+		Currency: pkg.EUR,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.UpsertLowBalanceThreshold(context.Background(), UpsertLowBalanceThresholdParams{
+		AccountID: account.ID,
+		Amount:    500,
+	})
+	require.NoError(t, err)
+
+	// 1000 -> 600 stays above, 600 -> 400 crosses, 400 -> 300 was already below
+	for _, amount := range []int64{-400, -200, -100} {
+		_, err = testStore.AddAccountBalanceTx(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: amount})
+		require.NoError(t, err)
+	}
+
+	var events []pkg.AccountLowBalanceEvent
+	for {
+		result, err := testStore.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+			Limit: 100,
+			Publish: func(event Outbox) error {
+				if event.EventType == pkg.EventAccountLowBalance && event.AggregateID == strconv.FormatInt(account.ID, 10) {
+					var payload pkg.AccountLowBalanceEvent
+					require.NoError(t, json.Unmarshal(event.Payload, &payload))
+					events = append(events, payload)
+				}
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		if !result.Pending {
+			break
+		}
+	}
+
+	require.Len(t, events, 1)
+	require.Equal(t, user.Username, events[0].Owner)
+	require.Equal(t, int64(400), events[0].Balance)
+	require.Equal(t, int64(500), events[0].Threshold)
+	require.Equal(t, pkg.EUR, events[0].Currency)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
@@ -18,12 +19,231 @@ type Account struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type AuditLog struct {
+	ID int64 `json:"id"`
+	// user who performed the action
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	// username the action was performed on
+	Target    string    `json:"target"`
+	Details   []byte    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Bill struct {
+	ID        int64  `json:"id"`
+	Organizer string `json:"organizer"`
+	// organizer account that receives the shares
+	ToAccountID int64 `json:"to_account_id"`
+	// must be positive, includes the organizer's own share
+	Total       int64  `json:"total"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+	// equal or custom
+	SplitType string `json:"split_type"`
+	// last time the participants were reminded to pay
+	RemindedAt pgtype.Timestamptz `json:"reminded_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type Currency struct {
+	// ISO 4217 alphabetic code
+	Code string `json:"code"`
+	// ISO 4217 numeric code
+	NumericCode string `json:"numeric_code"`
+	Name        string `json:"name"`
+	// number of digits after the decimal separator
+	MinorUnits int32     `json:"minor_units"`
+	Symbol     string    `json:"symbol"`
+	Enabled    bool      `json:"enabled"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
 type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// pocket the entry is booked against, null for the account main balance
+	PocketID pgtype.Int8 `json:"pocket_id"`
+	// transfer that booked the entry, null for deposits, withdrawals, pocket moves and loans
+	TransferID  pgtype.Int8 `json:"transfer_id"`
+	Description string      `json:"description"`
+}
+
+type Loan struct {
+	ID int64 `json:"id"`
+	// account the principal is disbursed to and instalments are collected from
+	AccountID  int64  `json:"account_id"`
+	Principal  int64  `json:"principal"`
+	Currency   string `json:"currency"`
+	TermMonths int32  `json:"term_months"`
+	// annuity or linear
+	ScheduleType string `json:"schedule_type"`
+	// pending, rejected, active or paid_off
+	Status string `json:"status"`
+	// nominal annual interest rate in basis points
+	AnnualRateBps int32 `json:"annual_rate_bps"`
+	// fee added to an instalment when it becomes overdue
+	LateFee int64 `json:"late_fee"`
+	// balance of the loan account, principal not repaid yet
+	OutstandingPrincipal int64              `json:"outstanding_principal"`
+	ReviewedBy           pgtype.Text        `json:"reviewed_by"`
+	DisbursementEntryID  pgtype.Int8        `json:"disbursement_entry_id"`
+	DisbursedAt          pgtype.Timestamptz `json:"disbursed_at"`
+	CreatedAt            time.Time          `json:"created_at"`
+}
+
+type LoanInstalment struct {
+	ID        int64     `json:"id"`
+	LoanID    int64     `json:"loan_id"`
+	Number    int32     `json:"number"`
+	DueDate   time.Time `json:"due_date"`
+	Principal int64     `json:"principal"`
+	Interest  int64     `json:"interest"`
+	LateFee   int64     `json:"late_fee"`
+	// pending, overdue or paid
+	Status string `json:"status"`
+	// entry that debited the linked account
+	EntryID   pgtype.Int8        `json:"entry_id"`
+	PaidAt    pgtype.Timestamptz `json:"paid_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type LoginChallengeAttempt struct {
+	ChallengeID uuid.UUID `json:"challenge_id"`
+	Username    string    `json:"username"`
+	Attempts    int32     `json:"attempts"`
+	// when the challenge expires, the row is of no use afterwards
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type LowBalanceThreshold struct {
+	AccountID int64 `json:"account_id"`
+	// the owner is notified when a debit takes the balance from at least this amount to below it
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type NotificationPreference struct {
+	Username string `json:"username"`
+	// e.g. transfer_received, low_balance or new_device_login
+	NotificationType string `json:"notification_type"`
+	// channels the notification is sent on, empty to turn it off
+	Channels []string `json:"channels"`
+	// minutes after midnight in timezone, notifications are held until quiet_hours_end
+	QuietHoursStart pgtype.Int4 `json:"quiet_hours_start"`
+	QuietHoursEnd   pgtype.Int4 `json:"quiet_hours_end"`
+	// IANA time zone of the quiet hours, e.g. Europe/Lisbon
+	Timezone  string    `json:"timezone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Outbox struct {
+	ID        int64  `json:"id"`
+	EventType string `json:"event_type"`
+	// id of the user, account or transfer the event is about
+	AggregateID string `json:"aggregate_id"`
+	Payload     []byte `json:"payload"`
+	// failed attempts to publish the event
+	Attempts  int32  `json:"attempts"`
+	LastError string `json:"last_error"`
+	// when the event was handed to the message broker, null until then
+	PublishedAt pgtype.Timestamptz `json:"published_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+	// when the relay gave up on the event after too many failed attempts, clearing it publishes the event again
+	ParkedAt pgtype.Timestamptz `json:"parked_at"`
+	// the relay leaves the event alone until then, it backs off after each failed attempt
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+type PasswordReset struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// hex SHA-256 of the token emailed to the user, the token itself is never stored
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentLink struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	// owner account that receives the payments
+	ToAccountID int64 `json:"to_account_id"`
+	// fixed amount, the payer chooses it when null
+	Amount    pgtype.Int8 `json:"amount"`
+	Currency  string      `json:"currency"`
+	Reference string      `json:"reference"`
+	// single-use links can only be redeemed once
+	SingleUse bool      `json:"single_use"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentLinkRedemption struct {
+	ID            int64     `json:"id"`
+	PaymentLinkID int64     `json:"payment_link_id"`
+	Payer         string    `json:"payer"`
+	TransferID    int64     `json:"transfer_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	ID        int64  `json:"id"`
+	Requester string `json:"requester"`
+	Payer     string `json:"payer"`
+	// requester account that receives the money
+	ToAccountID int64 `json:"to_account_id"`
+	// must be positive
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Message  string `json:"message"`
+	// pending, accepted, declined or expired
+	Status string `json:"status"`
+	// transfer that paid the request once accepted
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	// bill this request is a share of
+	BillID pgtype.Int8 `json:"bill_id"`
+}
+
+type Pocket struct {
+	ID        int64  `json:"id"`
+	AccountID int64  `json:"account_id"`
+	Name      string `json:"name"`
+	Balance   int64  `json:"balance"`
+	// must be positive
+	TargetAmount int64              `json:"target_amount"`
+	TargetDate   pgtype.Timestamptz `json:"target_date"`
+	CreatedAt    time.Time          `json:"created_at"`
+	// when the pocket was deleted, archived pockets are empty and are not listed
+	ArchivedAt pgtype.Timestamptz `json:"archived_at"`
+}
+
+type PushDevice struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// registration token the push provider issued to the app on the device
+	Token string `json:"token"`
+	// android, ios or web
+	Platform  string    `json:"platform"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// hex SHA-256 of the recovery code shown to the user, the code itself is never stored
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
 }
 
 type Session struct {
@@ -35,6 +255,28 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	// session whose refresh token was rotated into this one, null for the session opened at login
+	ParentID pgtype.UUID `json:"parent_id"`
+	// session opened at login that this one descends from through rotations
+	FamilyID uuid.UUID `json:"family_id"`
+	// when the refresh token was exchanged for a new one, presenting it again revokes the family
+	RotatedAt pgtype.Timestamptz `json:"rotated_at"`
+}
+
+type Statement struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"`
+	// exclusive, the start of the next period
+	PeriodEnd      time.Time `json:"period_end"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	Currency       string    `json:"currency"`
+	// rendered PDF statement
+	File []byte `json:"file"`
+	// when the statement was emailed to the account owner, null until then
+	EmailedAt pgtype.Timestamptz `json:"emailed_at"`
+	CreatedAt time.Time          `json:"created_at"`
 }
 
 type Transfer struct {
@@ -55,6 +297,24 @@ type User struct {
 	CreatedAt         time.Time `json:"created_at"`
 	IsEmailVerified   bool      `json:"is_email_verified"`
 	Role              string    `json:"role"`
+	// E.164 number SMS notifications are sent to, empty if unknown
+	PhoneNumber string `json:"phone_number"`
+	// language emails are rendered in
+	Locale string `json:"locale"`
+	// when the user last asked for a new verification email, null if never
+	VerifyEmailRequestedAt pgtype.Timestamptz `json:"verify_email_requested_at"`
+	// base32 TOTP secret, set on enrolment and only checked at login once totp_enabled_at is set
+	TotpSecret pgtype.Text `json:"totp_secret"`
+	// when the user confirmed their TOTP secret, null if two-factor authentication is off
+	TotpEnabledAt pgtype.Timestamptz `json:"totp_enabled_at"`
+	// time step of the last TOTP code accepted, so that a code can not be used twice
+	TotpLastUsedStep pgtype.Int8 `json:"totp_last_used_step"`
+	// second factor attempts at login since the last accepted code
+	TotpAttempts int32 `json:"totp_attempts"`
+	// second factor login is refused until then after too many attempts, null if it never was
+	TotpLockedUntil pgtype.Timestamptz `json:"totp_locked_until"`
+	// when a password reset token was last emailed to the user, null if never
+	PasswordResetRequestedAt pgtype.Timestamptz `json:"password_reset_requested_at"`
 }
 
 type VerifyEmail struct {
@@ -66,3 +326,31 @@ type VerifyEmail struct {
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiredAt  time.Time `json:"expired_at"`
 }
+
+type Webhook struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	Url   string `json:"url"`
+	// event types the webhook is subscribed to, e.g. transfer.completed
+	EventTypes []string `json:"event_types"`
+	// key of the HMAC-SHA256 signature of the deliveries
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID        int64  `json:"id"`
+	WebhookID int64  `json:"webhook_id"`
+	EventID   int64  `json:"event_id"`
+	EventType string `json:"event_type"`
+	// request body, the event envelope
+	Payload []byte `json:"payload"`
+	// pending, succeeded or failed once the retries ran out
+	Status   string `json:"status"`
+	Attempts int32  `json:"attempts"`
+	// HTTP status of the last attempt, 0 when no response was received
+	ResponseStatus int32              `json:"response_status"`
+	LastError      string             `json:"last_error"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertNotificationPreference(t *testing.T) {
+	user := createRandomUser(t)
+
+	preference, err := testStore.UpsertNotificationPreference(context.Background(), UpsertNotificationPreferenceParams{
+		Username:         user.Username,
+		NotificationType: pkg.NotificationTransferReceived,
+		Channels:         []string{pkg.NotificationChannelEmail},
+		QuietHoursStart:  pgtype.Int4{Int32: 22 * 60, Valid: true},
+		QuietHoursEnd:    pgtype.Int4{Int32: 7 * 60, Valid: true},
+		Timezone:         "Europe/Lisbon",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{pkg.NotificationChannelEmail}, preference.Channels)
+	require.Equal(t, int32(22*60), preference.QuietHoursStart.Int32)
+
+	updated, err := testStore.UpsertNotificationPreference(context.Background(), UpsertNotificationPreferenceParams{
+		Username:         user.Username,
+		NotificationType: pkg.NotificationTransferReceived,
+		Channels:         []string{},
+		Timezone:         "UTC",
+	})
+	require.NoError(t, err)
+	require.Empty(t, updated.Channels)
+	require.False(t, updated.QuietHoursStart.Valid)
+
+	preferences, err := testStore.ListNotificationPreferences(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, preferences, 1)
+
+	err = testStore.DeleteNotificationPreference(context.Background(), DeleteNotificationPreferenceParams{
+		Username:         user.Username,
+		NotificationType: pkg.NotificationTransferReceived,
+	})
+	require.NoError(t, err)
+	_, err = testStore.GetNotificationPreference(context.Background(), GetNotificationPreferenceParams{
+		Username:         user.Username,
+		NotificationType: pkg.NotificationTransferReceived,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func createRandomSession(t *testing.T, username string, userAgent string) Session {
+	id := uuid.New()
+	session, err := testStore.CreateSessionTx(context.Background(), CreateSessionParams{
+		ID:           id,
+		Username:     username,
+		RefreshToken: pkg.RandomString(32),
+		UserAgent:    userAgent,
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		FamilyID:     id,
+	})
+	require.NoError(t, err)
+	return session
+}
+
+func TestCreateSessionTxNewDevice(t *testing.T) {
+	user := createRandomUser(t)
+
+	// neither the first login nor another one from the same device are reported
+	createRandomSession(t, user.Username, "curl/8.0")
+	createRandomSession(t, user.Username, "curl/8.0")
+	session := createRandomSession(t, user.Username, "Firefox/128.0")
+
+	event := relayUntil(t, pkg.EventUserNewDeviceLogin, user.Username)
+	var payload pkg.UserNewDeviceLoginEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, session.ID.String(), payload.SessionID)
+	require.Equal(t, "Firefox/128.0", payload.UserAgent)
+}
@@ -0,0 +1,244 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+// relayUntil relays the outbox until the event of the given type and aggregate was published, other tests
+// write events concurrently
+func relayUntil(t *testing.T, eventType string, aggregateID string) Outbox {
+	var found Outbox
+	for found.ID == 0 {
+		result, err := testStore.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+			Limit: 100,
+			Publish: func(event Outbox) error {
+				if event.EventType == eventType && event.AggregateID == aggregateID {
+					found = event
+				}
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		if found.ID == 0 && !result.Pending {
+			t.Fatalf("no %s event for %s in the outbox", eventType, aggregateID)
+		}
+	}
+	return found
+}
+
+func TestCreateUserTxWritesOutboxEvent(t *testing.T) {
+	arg := CreateUserTxParams{CreateUserParams: CreateUserParams{
+		Username:       pkg.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       pkg.RandomOwner(),
+		Email:          pkg.RandomEmail(),
+	}}
+	result, err := testStore.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	event := relayUntil(t, pkg.EventUserCreated, result.User.Username)
+	var payload pkg.UserCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, result.User.Username, payload.Username)
+	require.Equal(t, result.User.Email, payload.Email)
+
+	published, err := testStore.GetOutboxEvent(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.True(t, published.PublishedAt.Valid)
+}
+
+func TestCreateAccountTxWritesOutboxEvent(t *testing.T) {
+	user := createRandomUser(t)
+	account, err := testStore.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: pkg.EUR,
+	})
+	require.NoError(t, err)
+
+	event := relayUntil(t, pkg.EventAccountCreated, strconv.FormatInt(account.ID, 10))
+	var payload pkg.AccountCreatedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, account.ID, payload.AccountID)
+	require.Equal(t, user.Username, payload.Owner)
+}
+
+func TestTransferTxWritesOutboxEvent(t *testing.T) {
+	from := createRandomAccount(t)
+	to := createRandomAccount(t)
+	_, err := testStore.AddAccountBalanceTx(context.Background(), AddAccountBalanceParams{ID: from.ID, Amount: 10})
+	require.NoError(t, err)
+
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	event := relayUntil(t, pkg.EventTransferCompleted, strconv.FormatInt(result.Transfer.ID, 10))
+	var payload pkg.TransferCompletedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, from.ID, payload.FromAccountID)
+	require.Equal(t, to.ID, payload.ToAccountID)
+	require.Equal(t, int64(10), payload.Amount)
+}
+
+func TestPaymentRequestTxWritesOutboxEvents(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createPayerAccount(t, toAccount.Currency)
+	paymentRequest := createRandomPaymentRequest(t, toAccount, fromAccount.Owner, time.Now().Add(time.Hour))
+	aggregateID := strconv.FormatInt(paymentRequest.ID, 10)
+
+	event := relayUntil(t, pkg.EventPaymentRequestStatusChanged, aggregateID)
+	var payload pkg.PaymentRequestStatusChangedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, paymentRequest.ID, payload.PaymentRequestID)
+	require.Equal(t, toAccount.Owner, payload.Requester)
+	require.Equal(t, fromAccount.Owner, payload.Payer)
+	require.Equal(t, pkg.PaymentRequestStatusPending, payload.Status)
+
+	_, err := testStore.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            paymentRequest.ID,
+		FromAccountID: fromAccount.ID,
+	})
+	require.NoError(t, err)
+
+	event = relayUntil(t, pkg.EventPaymentRequestStatusChanged, aggregateID)
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, pkg.PaymentRequestStatusAccepted, payload.Status)
+}
+
+// relayFailing relays the outbox until publishing the account created event of the account failed with publishErr,
+// events of other tests before it are published. It returns the event and the result of the relay that failed
+func relayFailing(t *testing.T, accountID int64, publishErr error, maxAttempts int32, backoff time.Duration) (Outbox, RelayOutboxTxResult) {
+	aggregateID := strconv.FormatInt(accountID, 10)
+	for {
+		var failed Outbox
+		result, err := testStore.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+			Limit:       100,
+			MaxAttempts: maxAttempts,
+			Backoff: func(attempts int32) time.Duration {
+				return backoff
+			},
+			Publish: func(event Outbox) error {
+				if event.EventType == pkg.EventAccountCreated && event.AggregateID == aggregateID {
+					failed = event
+					return publishErr
+				}
+				return nil
+			},
+		})
+		if failed.ID != 0 {
+			require.ErrorIs(t, err, publishErr)
+			return failed, result
+		}
+		require.NoError(t, err)
+		require.True(t, result.Pending)
+	}
+}
+
+func createOutboxAccount(t *testing.T) Account {
+	user := createRandomUser(t)
+	account, err := testStore.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: pkg.EUR,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestRelayOutboxTxRecordsFailure(t *testing.T) {
+	account := createOutboxAccount(t)
+
+	failed, result := relayFailing(t, account.ID, errors.New("task rejected"), 10, time.Hour)
+	require.Nil(t, result.Parked)
+
+	event, err := testStore.GetOutboxEvent(context.Background(), failed.ID)
+	require.NoError(t, err)
+	require.False(t, event.PublishedAt.Valid)
+	require.Equal(t, int32(1), event.Attempts)
+	require.Contains(t, event.LastError, "task rejected")
+	// the relay backs off
+	require.WithinDuration(t, time.Now().Add(time.Hour), event.NextAttemptAt, time.Minute)
+}
+
+func TestRelayOutboxTxBrokerUnavailable(t *testing.T) {
+	account := createOutboxAccount(t)
+
+	// an outage of the broker isn't held against the event
+	failed, result := relayFailing(t, account.ID, ErrBrokerUnavailable, 1, time.Hour)
+	require.Nil(t, result.Parked)
+
+	event, err := testStore.GetOutboxEvent(context.Background(), failed.ID)
+	require.NoError(t, err)
+	require.Zero(t, event.Attempts)
+	require.Empty(t, event.LastError)
+	require.False(t, event.ParkedAt.Valid)
+
+	// the event is published by the next relay
+	relayUntil(t, pkg.EventAccountCreated, strconv.FormatInt(account.ID, 10))
+}
+
+func TestRelayOutboxTxParksFailingEvent(t *testing.T) {
+	account := createOutboxAccount(t)
+
+	relayFailing(t, account.ID, errors.New("task rejected"), 2, 0)
+	_, result := relayFailing(t, account.ID, errors.New("task rejected"), 2, 0)
+	parked := result.Parked
+	require.NotNil(t, parked)
+	require.Equal(t, strconv.FormatInt(account.ID, 10), parked.AggregateID)
+	require.Equal(t, int32(2), parked.Attempts)
+	require.True(t, parked.ParkedAt.Valid)
+
+	// parked events are no longer relayed
+	for {
+		result, err := testStore.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+			Limit: 100,
+			Publish: func(event Outbox) error {
+				require.NotEqual(t, parked.ID, event.ID)
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		if !result.Pending {
+			break
+		}
+	}
+
+	event, err := testStore.GetOutboxEvent(context.Background(), parked.ID)
+	require.NoError(t, err)
+	require.False(t, event.PublishedAt.Valid)
+
+	// until they are requeued
+	listed, err := testStore.ListParkedOutboxEvents(context.Background(), ListParkedOutboxEventsParams{Limit: 1000})
+	require.NoError(t, err)
+	require.Contains(t, listed, event)
+
+	requeued, err := testStore.RequeueOutboxEvent(context.Background(), parked.ID)
+	require.NoError(t, err)
+	require.Zero(t, requeued.Attempts)
+	require.False(t, requeued.ParkedAt.Valid)
+	_, err = testStore.RequeueOutboxEvent(context.Background(), parked.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	relayUntil(t, pkg.EventAccountCreated, parked.AggregateID)
+}
+
+func TestRelayOutboxTxParksUnpublishableEvent(t *testing.T) {
+	account := createOutboxAccount(t)
+
+	_, result := relayFailing(t, account.ID, fmt.Errorf("%w: bad payload", ErrEventUnpublishable), 10, 0)
+	require.NotNil(t, result.Parked)
+	require.Equal(t, int32(1), result.Parked.Attempts)
+}
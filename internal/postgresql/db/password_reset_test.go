@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePasswordResetTxExpiresEarlierTokens(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := CreatePasswordResetTxParams{
+		Username:        user.Username,
+		TokenHash:       pkg.RandomString(64),
+		ExpiresAt:       time.Now().Add(time.Hour),
+		RequestedBefore: time.Now().Add(time.Minute),
+	}
+	first, err := testStore.CreatePasswordResetTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	arg.TokenHash = pkg.RandomString(64)
+	second, err := testStore.CreatePasswordResetTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = testStore.UsePasswordReset(context.Background(), first.TokenHash)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	used, err := testStore.UsePasswordReset(context.Background(), second.TokenHash)
+	require.NoError(t, err)
+	require.True(t, used.IsUsed)
+	require.Equal(t, user.Username, used.Username)
+
+	// a token works once
+	_, err = testStore.UsePasswordReset(context.Background(), second.TokenHash)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestCreatePasswordResetTxThrottled(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := CreatePasswordResetTxParams{
+		Username:        user.Username,
+		TokenHash:       pkg.RandomString(64),
+		ExpiresAt:       time.Now().Add(time.Hour),
+		RequestedBefore: time.Now().Add(-time.Minute),
+	}
+	first, err := testStore.CreatePasswordResetTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	requested, err := testStore.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, requested.PasswordResetRequestedAt.Valid)
+
+	// asking again within the interval creates no token and leaves the one sent before alone
+	arg.TokenHash = pkg.RandomString(64)
+	_, err = testStore.CreatePasswordResetTx(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	_, err = testStore.UsePasswordReset(context.Background(), arg.TokenHash)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	used, err := testStore.UsePasswordReset(context.Background(), first.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, used.Username)
+}
+
+func TestUseExpiredPasswordReset(t *testing.T) {
+	user := createRandomUser(t)
+
+	passwordReset, err := testStore.CreatePasswordResetTx(context.Background(), CreatePasswordResetTxParams{
+		Username:        user.Username,
+		TokenHash:       pkg.RandomString(64),
+		ExpiresAt:       time.Now().Add(-time.Second),
+		RequestedBefore: time.Now(),
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.UsePasswordReset(context.Background(), passwordReset.TokenHash)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username, "curl/8.0")
+
+	passwordReset, err := testStore.CreatePasswordResetTx(context.Background(), CreatePasswordResetTxParams{
+		Username:        user.Username,
+		TokenHash:       pkg.RandomString(64),
+		ExpiresAt:       time.Now().Add(time.Hour),
+		RequestedBefore: time.Now(),
+	})
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		TokenHash:         passwordReset.TokenHash,
+		HashedPassword:    pkg.RandomString(60),
+		PasswordChangedAt: time.Now(),
+	}
+	reset, err := testStore.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, reset.Username)
+	require.Equal(t, arg.HashedPassword, reset.HashedPassword)
+	require.WithinDuration(t, arg.PasswordChangedAt, reset.PasswordChangedAt, time.Second)
+
+	blocked, err := testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	// a token works once, and a failed reset changes nothing
+	arg.HashedPassword = pkg.RandomString(60)
+	_, err = testStore.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	unchanged, err := testStore.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, reset.HashedPassword, unchanged.HashedPassword)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	user := createRandomUser(t)
+
+	found, err := testStore.GetUserByEmail(context.Background(), user.Email)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, found.Username)
+
+	_, err = testStore.GetUserByEmail(context.Background(), pkg.RandomEmail())
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+	session1 := createRandomSession(t, user.Username, "curl/8.0")
+	session2 := createRandomSession(t, user.Username, "Firefox")
+	otherSession := createRandomSession(t, other.Username, "curl/8.0")
+
+	require.NoError(t, testStore.BlockUserSessions(context.Background(), user.Username))
+
+	for _, session := range []Session{session1, session2} {
+		blocked, err := testStore.GetSession(context.Background(), session.ID)
+		require.NoError(t, err)
+		require.True(t, blocked.IsBlocked)
+	}
+
+	notBlocked, err := testStore.GetSession(context.Background(), otherSession.ID)
+	require.NoError(t, err)
+	require.False(t, notBlocked.IsBlocked)
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPaymentLink(t *testing.T, toAccount Account, singleUse bool, expiresAt time.Time) PaymentLink {
+	arg := CreatePaymentLinkParams{
+		Owner:       toAccount.Owner,
+		ToAccountID: toAccount.ID,
+		Amount:      pgtype.Int8{Int64: pkg.RandomInt(1, 10), Valid: true},
+		Currency:    toAccount.Currency,
+		Reference:   pkg.RandomString(12),
+		SingleUse:   singleUse,
+		ExpiresAt:   expiresAt,
+	}
+
+	paymentLink, err := testStore.CreatePaymentLink(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, paymentLink)
+
+	require.Equal(t, arg.Owner, paymentLink.Owner)
+	require.Equal(t, arg.ToAccountID, paymentLink.ToAccountID)
+	require.Equal(t, arg.Amount, paymentLink.Amount)
+	require.Equal(t, arg.Currency, paymentLink.Currency)
+	require.Equal(t, arg.Reference, paymentLink.Reference)
+	require.Equal(t, arg.SingleUse, paymentLink.SingleUse)
+	require.WithinDuration(t, arg.ExpiresAt, paymentLink.ExpiresAt, time.Second)
+
+	require.NotZero(t, paymentLink.ID)
+	require.NotZero(t, paymentLink.CreatedAt)
+
+	return paymentLink
+}
+
+func TestCreatePaymentLink(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	createRandomPaymentLink(t, toAccount, true, time.Now().Add(time.Hour))
+}
+
+func TestRedeemSingleUsePaymentLinkTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createPayerAccount(t, toAccount.Currency)
+	paymentLink := createRandomPaymentLink(t, toAccount, true, time.Now().Add(time.Hour))
+
+	arg := RedeemPaymentLinkTxParams{
+		PaymentLinkID: paymentLink.ID,
+		Payer:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		Amount:        paymentLink.Amount.Int64,
+	}
+
+	result, err := testStore.RedeemPaymentLinkTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, paymentLink.ID, result.Redemption.PaymentLinkID)
+	require.Equal(t, fromAccount.Owner, result.Redemption.Payer)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Redemption.TransferID)
+	require.Equal(t, toAccount.ID, result.Transfer.Transfer.ToAccountID)
+	require.Equal(t, arg.Amount, result.Transfer.Transfer.Amount)
+	require.Equal(t, toAccount.Balance+arg.Amount, result.Transfer.ToAccount.Balance)
+
+	_, err = testStore.RedeemPaymentLinkTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPaymentLinkUsed)
+
+	redemptions, err := testStore.ListPaymentLinkRedemptions(context.Background(), paymentLink.ID)
+	require.NoError(t, err)
+	require.Equal(t, []PaymentLinkRedemption{result.Redemption}, redemptions)
+}
+
+func TestRedeemMultiUsePaymentLinkTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	paymentLink := createRandomPaymentLink(t, toAccount, false, time.Now().Add(time.Hour))
+
+	n := 3
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		fromAccount := createPayerAccount(t, toAccount.Currency)
+		go func() {
+			_, err := testStore.RedeemPaymentLinkTx(context.Background(), RedeemPaymentLinkTxParams{
+				PaymentLinkID: paymentLink.ID,
+				Payer:         fromAccount.Owner,
+				FromAccountID: fromAccount.ID,
+				Amount:        paymentLink.Amount.Int64,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	redemptions, err := testStore.CountPaymentLinkRedemptions(context.Background(), paymentLink.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(n), redemptions)
+}
+
+func TestRedeemExpiredPaymentLinkTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createPayerAccount(t, toAccount.Currency)
+	paymentLink := createRandomPaymentLink(t, toAccount, false, time.Now().Add(-time.Minute))
+
+	_, err := testStore.RedeemPaymentLinkTx(context.Background(), RedeemPaymentLinkTxParams{
+		PaymentLinkID: paymentLink.ID,
+		Payer:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		Amount:        paymentLink.Amount.Int64,
+	})
+	require.ErrorIs(t, err, ErrPaymentLinkExpired)
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomPaymentRequest asks payer to pay into toAccount
+func createRandomPaymentRequest(t *testing.T, toAccount Account, payer string, expiresAt time.Time) PaymentRequest {
+	arg := CreatePaymentRequestParams{
+		Requester:   toAccount.Owner,
+		Payer:       payer,
+		ToAccountID: toAccount.ID,
+		Amount:      pkg.RandomInt(1, 10),
+		Currency:    toAccount.Currency,
+		Message:     pkg.RandomString(12),
+		ExpiresAt:   expiresAt,
+	}
+
+	result, err := testStore.CreatePaymentRequestTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	paymentRequest := result.PaymentRequest
+	require.NotEmpty(t, paymentRequest)
+	require.Equal(t, arg.Requester, paymentRequest.Requester)
+	require.Equal(t, arg.Payer, paymentRequest.Payer)
+	require.Equal(t, arg.ToAccountID, paymentRequest.ToAccountID)
+	require.Equal(t, arg.Amount, paymentRequest.Amount)
+	require.Equal(t, arg.Currency, paymentRequest.Currency)
+	require.Equal(t, arg.Message, paymentRequest.Message)
+	require.WithinDuration(t, arg.ExpiresAt, paymentRequest.ExpiresAt, time.Second)
+	require.Equal(t, pkg.PaymentRequestStatusPending, paymentRequest.Status)
+	require.False(t, paymentRequest.TransferID.Valid)
+
+	require.NotZero(t, paymentRequest.ID)
+	require.NotZero(t, paymentRequest.CreatedAt)
+
+	return paymentRequest
+}
+
+// createPayerAccount opens an account for a new user in the given currency
+func createPayerAccount(t *testing.T, currency string) Account {
+	user := createRandomUser(t)
+	account, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  pkg.RandomMoney(),
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestCreatePaymentRequest(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	payer := createRandomUser(t)
+	createRandomPaymentRequest(t, toAccount, payer.Username, time.Now().Add(time.Hour))
+}
+
+func TestListPaymentRequests(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	payer := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomPaymentRequest(t, toAccount, payer.Username, time.Now().Add(time.Hour))
+	}
+
+	incoming, err := testStore.ListIncomingPaymentRequests(context.Background(), ListIncomingPaymentRequestsParams{
+		Payer:  payer.Username,
+		Status: pgtype.Text{String: pkg.PaymentRequestStatusPending, Valid: true},
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, incoming, 3)
+
+	outgoing, err := testStore.ListOutgoingPaymentRequests(context.Background(), ListOutgoingPaymentRequestsParams{
+		Requester: toAccount.Owner,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, outgoing, 3)
+
+	declined, err := testStore.ListOutgoingPaymentRequests(context.Background(), ListOutgoingPaymentRequestsParams{
+		Requester: toAccount.Owner,
+		Status:    pgtype.Text{String: pkg.PaymentRequestStatusDeclined, Valid: true},
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, declined)
+}
+
+func TestAcceptPaymentRequestTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createPayerAccount(t, toAccount.Currency)
+	paymentRequest := createRandomPaymentRequest(t, toAccount, fromAccount.Owner, time.Now().Add(time.Hour))
+
+	result, err := testStore.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            paymentRequest.ID,
+		FromAccountID: fromAccount.ID,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, pkg.PaymentRequestStatusAccepted, result.PaymentRequest.Status)
+	require.True(t, result.PaymentRequest.TransferID.Valid)
+	require.Equal(t, result.Transfer.Transfer.ID, result.PaymentRequest.TransferID.Int64)
+
+	require.Equal(t, fromAccount.ID, result.Transfer.Transfer.FromAccountID)
+	require.Equal(t, toAccount.ID, result.Transfer.Transfer.ToAccountID)
+	require.Equal(t, paymentRequest.Amount, result.Transfer.Transfer.Amount)
+	require.Equal(t, fromAccount.Balance-paymentRequest.Amount, result.Transfer.FromAccount.Balance)
+	require.Equal(t, toAccount.Balance+paymentRequest.Amount, result.Transfer.ToAccount.Balance)
+
+	// a request can only be answered once
+	_, err = testStore.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            paymentRequest.ID,
+		FromAccountID: fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
+
+func TestAcceptExpiredPaymentRequestTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createPayerAccount(t, toAccount.Currency)
+	paymentRequest := createRandomPaymentRequest(t, toAccount, fromAccount.Owner, time.Now().Add(-time.Minute))
+
+	_, err := testStore.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID:            paymentRequest.ID,
+		FromAccountID: fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestExpired)
+
+	// nothing moved
+	account, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, account.Balance)
+
+	expired, err := testStore.ListExpiredPaymentRequests(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Contains(t, expired, paymentRequest)
+}
+
+func TestDeclinePaymentRequestTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	payer := createRandomUser(t)
+	paymentRequest := createRandomPaymentRequest(t, toAccount, payer.Username, time.Now().Add(time.Hour))
+
+	declined, err := testStore.UpdatePaymentRequestStatusTx(context.Background(), UpdatePaymentRequestStatusTxParams{
+		ID:     paymentRequest.ID,
+		Status: pkg.PaymentRequestStatusDeclined,
+	})
+	require.NoError(t, err)
+	require.Equal(t, pkg.PaymentRequestStatusDeclined, declined.Status)
+	require.False(t, declined.TransferID.Valid)
+
+	_, err = testStore.UpdatePaymentRequestStatusTx(context.Background(), UpdatePaymentRequestStatusTxParams{
+		ID:     paymentRequest.ID,
+		Status: pkg.PaymentRequestStatusExpired,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
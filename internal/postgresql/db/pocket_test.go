@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPocket(t *testing.T, account Account) Pocket {
+	arg := CreatePocketParams{
+		AccountID:    account.ID,
+		Name:         pkg.RandomString(8),
+		TargetAmount: pkg.RandomInt(1, 1000),
+		TargetDate:   pgtype.Timestamptz{Time: time.Now().Add(24 * time.Hour), Valid: true},
+	}
+
+	pocket, err := testStore.CreatePocket(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, pocket)
+
+	require.Equal(t, arg.AccountID, pocket.AccountID)
+	require.Equal(t, arg.Name, pocket.Name)
+	require.Equal(t, arg.TargetAmount, pocket.TargetAmount)
+	require.Zero(t, pocket.Balance)
+	require.WithinDuration(t, arg.TargetDate.Time, pocket.TargetDate.Time, time.Second)
+
+	require.NotZero(t, pocket.ID)
+	require.NotZero(t, pocket.CreatedAt)
+
+	return pocket
+}
+
+func TestCreatePocket(t *testing.T) {
+	account := createRandomAccount(t)
+	createRandomPocket(t, account)
+}
+
+func TestGetPocket(t *testing.T) {
+	account := createRandomAccount(t)
+	pocket1 := createRandomPocket(t, account)
+	pocket2, err := testStore.GetPocket(context.Background(), pocket1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, pocket2)
+
+	require.Equal(t, pocket1.ID, pocket2.ID)
+	require.Equal(t, pocket1.AccountID, pocket2.AccountID)
+	require.Equal(t, pocket1.Name, pocket2.Name)
+	require.Equal(t, pocket1.TargetAmount, pocket2.TargetAmount)
+	require.WithinDuration(t, pocket1.CreatedAt, pocket2.CreatedAt, time.Second)
+}
+
+func TestListPockets(t *testing.T) {
+	account := createRandomAccount(t)
+	for i := 0; i < 3; i++ {
+		createRandomPocket(t, account)
+	}
+
+	pockets, err := testStore.ListPockets(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, pockets, 3)
+
+	for _, pocket := range pockets {
+		require.Equal(t, account.ID, pocket.AccountID)
+	}
+}
+
+func TestArchivePocket(t *testing.T) {
+	account := createRandomAccount(t)
+	pocket1 := createRandomPocket(t, account)
+
+	// a pocket that held money has entries booked against it
+	_, err := testStore.PocketTransferTx(context.Background(), PocketTransferTxParams{AccountID: account.ID, PocketID: pocket1.ID, Amount: 10})
+	require.NoError(t, err)
+
+	// it cannot be archived while it isn't empty
+	_, err = testStore.ArchivePocket(context.Background(), pocket1.ID)
+	require.EqualError(t, err, ErrRecordNotFound.Error())
+
+	_, err = testStore.PocketTransferTx(context.Background(), PocketTransferTxParams{AccountID: account.ID, PocketID: pocket1.ID, Amount: -10})
+	require.NoError(t, err)
+
+	archived, err := testStore.ArchivePocket(context.Background(), pocket1.ID)
+	require.NoError(t, err)
+	require.True(t, archived.ArchivedAt.Valid)
+
+	pocket2, err := testStore.GetPocket(context.Background(), pocket1.ID)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrRecordNotFound.Error())
+	require.Empty(t, pocket2)
+
+	pockets, err := testStore.ListPockets(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Empty(t, pockets)
+
+	_, err = testStore.PocketTransferTx(context.Background(), PocketTransferTxParams{AccountID: account.ID, PocketID: pocket1.ID, Amount: 10})
+	require.EqualError(t, err, ErrRecordNotFound.Error())
+
+	// the name of an archived pocket can be used again
+	pocket3, err := testStore.CreatePocket(context.Background(), CreatePocketParams{AccountID: account.ID, Name: pocket1.Name, TargetAmount: 100})
+	require.NoError(t, err)
+	require.NotEqual(t, pocket1.ID, pocket3.ID)
+}
+
+func TestPocketChecks(t *testing.T) {
+	account := createRandomAccount(t)
+
+	var pgErr *pgconn.PgError
+	_, err := testStore.CreatePocket(context.Background(), CreatePocketParams{AccountID: account.ID, Name: pkg.RandomString(8), TargetAmount: 0})
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, CheckViolation, pgErr.Code)
+
+	pocket := createRandomPocket(t, account)
+	_, err = testStore.AddPocketBalance(context.Background(), AddPocketBalanceParams{ID: pocket.ID, Amount: -1})
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, CheckViolation, pgErr.Code)
+}
+
+func TestPocketTransferTx(t *testing.T) {
+	account := createRandomAccount(t)
+	pocket := createRandomPocket(t, account)
+	amount := account.Balance / 2
+
+	// move money into the pocket
+	result, err := testStore.PocketTransferTx(context.Background(), PocketTransferTxParams{
+		AccountID: account.ID,
+		PocketID:  pocket.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.Balance-amount, result.Account.Balance)
+	require.Equal(t, amount, result.Pocket.Balance)
+
+	require.Equal(t, account.ID, result.AccountEntry.AccountID)
+	require.Equal(t, -amount, result.AccountEntry.Amount)
+	require.False(t, result.AccountEntry.PocketID.Valid)
+
+	require.Equal(t, account.ID, result.PocketEntry.AccountID)
+	require.Equal(t, amount, result.PocketEntry.Amount)
+	require.Equal(t, pocket.ID, result.PocketEntry.PocketID.Int64)
+
+	// move it back
+	result, err = testStore.PocketTransferTx(context.Background(), PocketTransferTxParams{
+		AccountID: account.ID,
+		PocketID:  pocket.ID,
+		Amount:    -amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, result.Account.Balance)
+	require.Zero(t, result.Pocket.Balance)
+
+	// cannot take more than the pocket holds
+	_, err = testStore.PocketTransferTx(context.Background(), PocketTransferTxParams{
+		AccountID: account.ID,
+		PocketID:  pocket.ID,
+		Amount:    -1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// cannot use a pocket from another account
+	otherAccount := createRandomAccount(t)
+	_, err = testStore.PocketTransferTx(context.Background(), PocketTransferTxParams{
+		AccountID: otherAccount.ID,
+		PocketID:  pocket.ID,
+		Amount:    0,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
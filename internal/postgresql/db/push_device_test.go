@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePushDevice(t *testing.T) {
+	user := createRandomUser(t)
+	token := pkg.RandomString(64)
+
+	device, err := testStore.CreatePushDevice(context.Background(), CreatePushDeviceParams{
+		Username: user.Username,
+		Token:    token,
+		Platform: pkg.PushPlatformAndroid,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, device.Username)
+	require.Equal(t, token, device.Token)
+
+	// the same token registered by another user moves to them
+	other := createRandomUser(t)
+	moved, err := testStore.CreatePushDevice(context.Background(), CreatePushDeviceParams{
+		Username: other.Username,
+		Token:    token,
+		Platform: pkg.PushPlatformAndroid,
+	})
+	require.NoError(t, err)
+	require.Equal(t, device.ID, moved.ID)
+	require.Equal(t, other.Username, moved.Username)
+
+	devices, err := testStore.ListPushDevices(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, devices)
+
+	err = testStore.DeletePushDeviceByToken(context.Background(), token)
+	require.NoError(t, err)
+	_, err = testStore.GetPushDevice(context.Background(), device.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
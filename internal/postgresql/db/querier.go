@@ -6,31 +6,174 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddLoanOutstandingPrincipal(ctx context.Context, arg AddLoanOutstandingPrincipalParams) (Loan, error)
+	AddPocketBalance(ctx context.Context, arg AddPocketBalanceParams) (Pocket, error)
+	// the pocket is only archived if it is still empty, a deposit made since its balance was read keeps it
+	ArchivePocket(ctx context.Context, id int64) (Pocket, error)
+	// BlockOtherUserSessions blocks every session of a user except the family of the one they keep
+	BlockOtherUserSessions(ctx context.Context, arg BlockOtherUserSessionsParams) error
+	// BlockSessionFamily blocks a session opened at login and every session rotated from it
+	BlockSessionFamily(ctx context.Context, familyID uuid.UUID) error
+	// BlockUserSessions blocks every session of a user, their refresh tokens can't be used anymore
+	BlockUserSessions(ctx context.Context, username string) error
+	ClearTOTPAttempts(ctx context.Context, username string) error
+	// CountLoginChallengeAttempt counts an attempt at a second factor with the login challenge and returns the attempts
+	// made with it so far, this one included
+	CountLoginChallengeAttempt(ctx context.Context, arg CountLoginChallengeAttemptParams) (int32, error)
+	CountPaymentLinkRedemptions(ctx context.Context, paymentLinkID int64) (int64, error)
+	// CountTOTPAttempt counts a second factor attempt of the user, locking second factor login until locked_until once
+	// max_attempts are reached. It returns no rows while the user is locked
+	CountTOTPAttempt(ctx context.Context, arg CountTOTPAttemptParams) (User, error)
+	CountUnpaidLoanInstalments(ctx context.Context, loanID int64) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
+	CreateBill(ctx context.Context, arg CreateBillParams) (Bill, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateLoan(ctx context.Context, arg CreateLoanParams) (Loan, error)
+	CreateLoanInstalment(ctx context.Context, arg CreateLoanInstalmentParams) (LoanInstalment, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreatePaymentLink(ctx context.Context, arg CreatePaymentLinkParams) (PaymentLink, error)
+	CreatePaymentLinkRedemption(ctx context.Context, arg CreatePaymentLinkRedemptionParams) (PaymentLinkRedemption, error)
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error)
+	CreatePocket(ctx context.Context, arg CreatePocketParams) (Pocket, error)
+	// a token registered again, e.g. after another user logged in on the device, moves to the new user
+	CreatePushDevice(ctx context.Context, arg CreatePushDeviceParams) (PushDevice, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateStatement(ctx context.Context, arg CreateStatementParams) (Statement, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
-	DeleteAccount(ctx context.Context, id int64) error
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	// an event handled again returns the delivery created the first time
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	// the account is only deleted if it and its pockets are still empty, a deposit made since the balances were read keeps it
+	DeleteAccount(ctx context.Context, id int64) (Account, error)
+	DeleteExpiredLoginChallengeAttempts(ctx context.Context) error
+	DeleteLowBalanceThreshold(ctx context.Context, accountID int64) error
+	DeleteNotificationPreference(ctx context.Context, arg DeleteNotificationPreferenceParams) error
+	DeletePushDevice(ctx context.Context, id int64) error
+	DeletePushDeviceByToken(ctx context.Context, token string) error
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	DisburseLoan(ctx context.Context, arg DisburseLoanParams) (Loan, error)
+	// EnableTOTP turns two-factor authentication on once the user confirmed their secret with the code of used_step
+	EnableTOTP(ctx context.Context, arg EnableTOTPParams) (User, error)
+	// ExpirePasswordResets makes the unused tokens of a user expire, so that only the token sent last can be used
+	ExpirePasswordResets(ctx context.Context, username string) error
+	// ExpireVerifyEmails makes the unused codes of a user expire, so that only the code sent last can be used
+	ExpireVerifyEmails(ctx context.Context, username string) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetBill(ctx context.Context, id int64) (Bill, error)
+	GetCurrency(ctx context.Context, code string) (Currency, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetLoan(ctx context.Context, id int64) (Loan, error)
+	GetLoanForUpdate(ctx context.Context, id int64) (Loan, error)
+	GetLoanInstalmentForUpdate(ctx context.Context, id int64) (LoanInstalment, error)
+	GetLowBalanceThreshold(ctx context.Context, accountID int64) (LowBalanceThreshold, error)
+	GetNotificationPreference(ctx context.Context, arg GetNotificationPreferenceParams) (NotificationPreference, error)
+	GetOutboxEvent(ctx context.Context, id int64) (Outbox, error)
+	GetPaymentLink(ctx context.Context, id int64) (PaymentLink, error)
+	GetPaymentLinkForUpdate(ctx context.Context, id int64) (PaymentLink, error)
+	GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPocket(ctx context.Context, id int64) (Pocket, error)
+	GetPocketForUpdate(ctx context.Context, id int64) (Pocket, error)
+	GetPushDevice(ctx context.Context, id int64) (PushDevice, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	// counts the sessions of a user, and those opened with the given user agent
+	GetSessionDeviceCount(ctx context.Context, arg GetSessionDeviceCountParams) (GetSessionDeviceCountRow, error)
+	GetStatement(ctx context.Context, id int64) (Statement, error)
+	GetStatementByPeriod(ctx context.Context, arg GetStatementByPeriodParams) (Statement, error)
+	GetStatementSummary(ctx context.Context, arg GetStatementSummaryParams) (GetStatementSummaryRow, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetVerifyEmail(ctx context.Context, id int64) (VerifyEmail, error)
+	GetWebhook(ctx context.Context, id int64) (Webhook, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	// ListActiveSessions lists the sessions of a user whose refresh tokens can still be used, newest first
+	ListActiveSessions(ctx context.Context, username string) ([]Session, error)
+	ListAuditLogs(ctx context.Context, target string) ([]AuditLog, error)
+	ListBillPaymentRequests(ctx context.Context, billID pgtype.Int8) ([]PaymentRequest, error)
+	ListBillsByOrganizer(ctx context.Context, arg ListBillsByOrganizerParams) ([]Bill, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
+	ListDueLoanInstalments(ctx context.Context, dueDate time.Time) ([]LoanInstalment, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListExpiredPaymentRequests(ctx context.Context, expiresAt time.Time) ([]PaymentRequest, error)
+	ListIncomingPaymentRequests(ctx context.Context, arg ListIncomingPaymentRequestsParams) ([]PaymentRequest, error)
+	ListLoanInstalments(ctx context.Context, loanID int64) ([]LoanInstalment, error)
+	ListLoansByOwner(ctx context.Context, arg ListLoansByOwnerParams) ([]Loan, error)
+	ListLoansByStatus(ctx context.Context, arg ListLoansByStatusParams) ([]Loan, error)
+	ListNotificationPreferences(ctx context.Context, username string) ([]NotificationPreference, error)
+	ListOutgoingPaymentRequests(ctx context.Context, arg ListOutgoingPaymentRequestsParams) ([]PaymentRequest, error)
+	ListParkedOutboxEvents(ctx context.Context, arg ListParkedOutboxEventsParams) ([]Outbox, error)
+	ListPaymentLinkRedemptions(ctx context.Context, paymentLinkID int64) ([]PaymentLinkRedemption, error)
+	ListPaymentLinksByOwner(ctx context.Context, arg ListPaymentLinksByOwnerParams) ([]PaymentLink, error)
+	ListPendingOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error)
+	ListPockets(ctx context.Context, accountID int64) ([]Pocket, error)
+	ListPushDevices(ctx context.Context, username string) ([]PushDevice, error)
+	ListStatementAccounts(ctx context.Context, arg ListStatementAccountsParams) ([]Account, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	ListStatements(ctx context.Context, arg ListStatementsParams) ([]ListStatementsRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error)
+	ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error)
+	MarkBillReminded(ctx context.Context, arg MarkBillRemindedParams) (Bill, error)
+	MarkLoanInstalmentOverdue(ctx context.Context, arg MarkLoanInstalmentOverdueParams) (LoanInstalment, error)
+	MarkLoanInstalmentPaid(ctx context.Context, arg MarkLoanInstalmentPaidParams) (LoanInstalment, error)
+	// MarkOutboxEventFailed records a failed attempt to publish the event, which is retried at next_attempt_at. The event
+	// is parked if park is set or it reached max_attempts
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) (Outbox, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	MarkStatementEmailed(ctx context.Context, id int64) error
+	RejectLoan(ctx context.Context, arg RejectLoanParams) (Loan, error)
+	// RequestPasswordReset records that a password reset token is emailed to the user, unless one was emailed to them
+	// after requested_before
+	RequestPasswordReset(ctx context.Context, arg RequestPasswordResetParams) (User, error)
+	// RequestVerifyEmail records that an unverified user asked for a new verification email, unless they signed up or
+	// asked for one after requested_before
+	RequestVerifyEmail(ctx context.Context, arg RequestVerifyEmailParams) (User, error)
+	// RequeueOutboxEvent hands a parked event back to the relay with a fresh count of attempts, it returns no rows if
+	// the event isn't parked
+	RequeueOutboxEvent(ctx context.Context, id int64) (Outbox, error)
+	ResetTOTP(ctx context.Context, username string) (User, error)
+	ResetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	// RotateSession marks the refresh token of a session exchanged, no rows are returned when it already was
+	RotateSession(ctx context.Context, id uuid.UUID) (Session, error)
+	// SetTOTPSecret stores the secret of a TOTP enrolment, replacing an unconfirmed one. It returns no rows if two-factor
+	// authentication is already on
+	SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (User, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateCurrencyEnabled(ctx context.Context, arg UpdateCurrencyEnabledParams) (Currency, error)
+	UpdateLoanStatus(ctx context.Context, arg UpdateLoanStatusParams) (Loan, error)
+	UpdatePaymentRequestStatus(ctx context.Context, arg UpdatePaymentRequestStatusParams) (PaymentRequest, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
 	UpdateVerifyEmail(ctx context.Context, arg UpdateVerifyEmailParams) (VerifyEmail, error)
+	UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (WebhookDelivery, error)
+	UpsertLowBalanceThreshold(ctx context.Context, arg UpsertLowBalanceThresholdParams) (LowBalanceThreshold, error)
+	UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) (NotificationPreference, error)
+	// UsePasswordReset marks the token used, unless it was used before or expired
+	UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	// UseRecoveryCode marks the recovery code of the user used, it returns no rows if there is no such code or it was
+	// used before
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	// UseTOTPStep records that the code of used_step was accepted. It returns no rows if a code of that step or a later
+	// one was already used, so that codes can't be replayed
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
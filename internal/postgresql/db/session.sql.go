@@ -10,8 +10,54 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const blockOtherUserSessions = `-- name: BlockOtherUserSessions :exec
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE username = $1
+  AND family_id <> $2
+  AND is_blocked = FALSE
+`
+
+type BlockOtherUserSessionsParams struct {
+	Username     string    `json:"username"`
+	KeepFamilyID uuid.UUID `json:"keep_family_id"`
+}
+
+// BlockOtherUserSessions blocks every session of a user except the family of the one they keep
+func (q *Queries) BlockOtherUserSessions(ctx context.Context, arg BlockOtherUserSessionsParams) error {
+	_, err := q.db.Exec(ctx, blockOtherUserSessions, arg.Username, arg.KeepFamilyID)
+	return err
+}
+
+const blockSessionFamily = `-- name: BlockSessionFamily :exec
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE family_id = $1
+  AND is_blocked = FALSE
+`
+
+// BlockSessionFamily blocks a session opened at login and every session rotated from it
+func (q *Queries) BlockSessionFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, blockSessionFamily, familyID)
+	return err
+}
+
+const blockUserSessions = `-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE username = $1
+  AND is_blocked = FALSE
+`
+
+// BlockUserSessions blocks every session of a user, their refresh tokens can't be used anymore
+func (q *Queries) BlockUserSessions(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, blockUserSessions, username)
+	return err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
     id,
@@ -20,20 +66,24 @@ INSERT INTO sessions (
     user_agent,
     client_ip,
     is_blocked,
-    expires_at
+    expires_at,
+    family_id,
+    parent_id
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7
-         ) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+             $1, $2, $3, $4, $5, $6, $7, $8, $9
+         ) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, parent_id, family_id, rotated_at
 `
 
 type CreateSessionParams struct {
-	ID           uuid.UUID `json:"id"`
-	Username     string    `json:"username"`
-	RefreshToken string    `json:"refresh_token"`
-	UserAgent    string    `json:"user_agent"`
-	ClientIp     string    `json:"client_ip"`
-	IsBlocked    bool      `json:"is_blocked"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	ID           uuid.UUID   `json:"id"`
+	Username     string      `json:"username"`
+	RefreshToken string      `json:"refresh_token"`
+	UserAgent    string      `json:"user_agent"`
+	ClientIp     string      `json:"client_ip"`
+	IsBlocked    bool        `json:"is_blocked"`
+	ExpiresAt    time.Time   `json:"expires_at"`
+	FamilyID     uuid.UUID   `json:"family_id"`
+	ParentID     pgtype.UUID `json:"parent_id"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -45,6 +95,8 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.ClientIp,
 		arg.IsBlocked,
 		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.ParentID,
 	)
 	var i Session
 	err := row.Scan(
@@ -56,12 +108,15 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.ParentID,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, parent_id, family_id, rotated_at FROM sessions
 WHERE id = $1 LIMIT 1
 `
 
@@ -77,6 +132,108 @@ func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.ParentID,
+		&i.FamilyID,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const getSessionDeviceCount = `-- name: GetSessionDeviceCount :one
+SELECT count(*) AS sessions,
+       count(*) FILTER (WHERE user_agent = $1) AS device_sessions
+FROM sessions
+WHERE username = $2
+`
+
+type GetSessionDeviceCountRow struct {
+	Sessions       int64 `json:"sessions"`
+	DeviceSessions int64 `json:"device_sessions"`
+}
+
+type GetSessionDeviceCountParams struct {
+	UserAgent string `json:"user_agent"`
+	Username  string `json:"username"`
+}
+
+// counts the sessions of a user, and those opened with the given user agent
+func (q *Queries) GetSessionDeviceCount(ctx context.Context, arg GetSessionDeviceCountParams) (GetSessionDeviceCountRow, error) {
+	row := q.db.QueryRow(ctx, getSessionDeviceCount, arg.UserAgent, arg.Username)
+	var i GetSessionDeviceCountRow
+	err := row.Scan(
+		&i.Sessions,
+		&i.DeviceSessions,
+	)
+	return i, err
+}
+
+const listActiveSessions = `-- name: ListActiveSessions :many
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, parent_id, family_id, rotated_at FROM sessions
+WHERE username = $1
+  AND is_blocked = FALSE
+  AND rotated_at IS NULL
+  AND expires_at > now()
+ORDER BY created_at DESC
+`
+
+// ListActiveSessions lists the sessions of a user whose refresh tokens can still be used, newest first
+func (q *Queries) ListActiveSessions(ctx context.Context, username string) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listActiveSessions, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.ParentID,
+			&i.FamilyID,
+			&i.RotatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rotateSession = `-- name: RotateSession :one
+UPDATE sessions
+SET rotated_at = now()
+WHERE id = $1
+  AND rotated_at IS NULL
+  AND is_blocked = FALSE
+RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, parent_id, family_id, rotated_at
+`
+
+// RotateSession marks the refresh token of a session exchanged, no rows are returned when it already was
+func (q *Queries) RotateSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.db.QueryRow(ctx, rotateSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.ParentID,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
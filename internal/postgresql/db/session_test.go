@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockSessions(t *testing.T) {
+	user := createRandomUser(t)
+
+	current := createRandomSession(t, user.Username, "curl/8.0")
+	revoked := createRandomSession(t, user.Username, "curl/8.0")
+	kept := createRandomSession(t, user.Username, "curl/8.0")
+	// expired sessions aren't listed
+	expiredID := uuid.New()
+	_, err := testStore.CreateSession(context.Background(), CreateSessionParams{
+		ID:           expiredID,
+		Username:     user.Username,
+		RefreshToken: pkg.RandomString(32),
+		UserAgent:    "curl/8.0",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(-time.Hour),
+		FamilyID:     expiredID,
+	})
+	require.NoError(t, err)
+
+	sessions, err := testStore.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+
+	err = testStore.BlockSessionFamily(context.Background(), revoked.FamilyID)
+	require.NoError(t, err)
+
+	sessions, err = testStore.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	err = testStore.BlockOtherUserSessions(context.Background(), BlockOtherUserSessionsParams{Username: user.Username, KeepFamilyID: current.FamilyID})
+	require.NoError(t, err)
+
+	sessions, err = testStore.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, current.ID, sessions[0].ID)
+
+	session, err := testStore.GetSession(context.Background(), kept.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+}
+
+func TestBlockUserSessionsTx(t *testing.T) {
+	banker := createRandomUser(t)
+	user := createRandomUser(t)
+	createRandomSession(t, user.Username, "curl/8.0")
+
+	_, err := testStore.BlockUserSessionsTx(context.Background(), BlockUserSessionsTxParams{Username: pkg.RandomOwner(), Actor: banker.Username})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	_, err = testStore.BlockUserSessionsTx(context.Background(), BlockUserSessionsTxParams{Username: user.Username, Actor: banker.Username})
+	require.NoError(t, err)
+
+	sessions, err := testStore.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	auditLogs, err := testStore.ListAuditLogs(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 1)
+	require.Equal(t, banker.Username, auditLogs[0].Actor)
+	require.Equal(t, pkg.AuditSessionsBlocked, auditLogs[0].Action)
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	user := createRandomUser(t)
+	parent := createRandomSession(t, user.Username, "curl/8.0")
+
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: pkg.RandomString(32),
+		UserAgent:    parent.UserAgent,
+		ClientIp:     parent.ClientIp,
+		ExpiresAt:    parent.ExpiresAt,
+		FamilyID:     parent.FamilyID,
+		ParentID:     pgtype.UUID{Bytes: parent.ID, Valid: true},
+	}
+	session, err := testStore.RotateSessionTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, parent.FamilyID, session.FamilyID)
+	require.Equal(t, parent.ID, uuid.UUID(session.ParentID.Bytes))
+
+	rotated, err := testStore.GetSession(context.Background(), parent.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.RotatedAt.Valid)
+
+	// a refresh token can only be exchanged once
+	arg.ID = uuid.New()
+	_, err = testStore.RotateSessionTx(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	sessions, err := testStore.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, session.ID, sessions[0].ID)
+
+	err = testStore.RevokeSessionFamilyTx(context.Background(), rotated)
+	require.NoError(t, err)
+
+	session, err = testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+}
@@ -0,0 +1,195 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAccountBalanceTx(t *testing.T) {
+	account := createRandomAccount(t)
+
+	deposit, err := testStore.AddAccountBalanceTx(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+100, deposit.Account.Balance)
+	require.Equal(t, account.ID, deposit.Entry.AccountID)
+	require.Equal(t, int64(100), deposit.Entry.Amount)
+	require.Equal(t, "Deposit", deposit.Entry.Description)
+	require.False(t, deposit.Entry.TransferID.Valid)
+
+	withdrawal, err := testStore.AddAccountBalanceTx(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+70, withdrawal.Account.Balance)
+	require.Equal(t, int64(-30), withdrawal.Entry.Amount)
+	require.Equal(t, "Withdrawal", withdrawal.Entry.Description)
+
+	_, err = testStore.AddAccountBalanceTx(context.Background(), AddAccountBalanceParams{
+		ID:     -1,
+		Amount: 100,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestStatementTx(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+	startTime := time.Now().Add(-time.Minute)
+
+	_, err := testStore.AddAccountBalanceTx(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 100})
+	require.NoError(t, err)
+	_, err = testStore.AddAccountBalanceTx(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: -30})
+	require.NoError(t, err)
+	transfer, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        20,
+	})
+	require.NoError(t, err)
+
+	var summaries []GetStatementSummaryRow
+	var entries []ListStatementEntriesRow
+	err = testStore.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account.ID,
+		StartTime: startTime,
+		EndTime:   time.Now().Add(time.Minute),
+	}, func(summary GetStatementSummaryRow) error {
+		summaries = append(summaries, summary)
+		return nil
+	}, func(entry ListStatementEntriesRow) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, summaries, 1)
+	require.Equal(t, account.Balance, summaries[0].OpeningBalance)
+	require.Equal(t, account.Balance+50, summaries[0].ClosingBalance)
+	require.Equal(t, int64(1), summaries[0].CreditCount)
+	require.Equal(t, int64(100), summaries[0].CreditTotal)
+	require.Equal(t, int64(2), summaries[0].DebitCount)
+	require.Equal(t, int64(50), summaries[0].DebitTotal)
+
+	require.Len(t, entries, 3)
+	require.Equal(t, int64(100), entries[0].Amount)
+	require.Equal(t, int64(-30), entries[1].Amount)
+	require.Equal(t, int64(-20), entries[2].Amount)
+	require.Equal(t, transfer.Transfer.ID, entries[2].TransferID.Int64)
+	require.Equal(t, other.ID, entries[2].CounterpartyAccountID.Int64)
+	require.Equal(t, other.Owner, entries[2].CounterpartyOwner.String)
+
+	// a statement that ends before the entries has them all after its closing balance
+	err = testStore.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account.ID,
+		StartTime: startTime.Add(-time.Hour),
+		EndTime:   startTime,
+	}, func(summary GetStatementSummaryRow) error {
+		require.Equal(t, account.Balance, summary.OpeningBalance)
+		require.Equal(t, account.Balance, summary.ClosingBalance)
+		require.Zero(t, summary.CreditCount+summary.DebitCount)
+		return nil
+	}, func(entry ListStatementEntriesRow) error {
+		t.Fatalf("unexpected entry %d", entry.ID)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = testStore.StatementTx(context.Background(), StatementTxParams{
+		AccountID: -1,
+		StartTime: startTime,
+		EndTime:   time.Now(),
+	}, func(summary GetStatementSummaryRow) error {
+		return nil
+	}, func(entry ListStatementEntriesRow) error {
+		return nil
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestStatementArchive(t *testing.T) {
+	account := createRandomAccount(t)
+	periodStart := time.Date(2000+int(account.ID%1000), time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	arg := CreateStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodStart.AddDate(0, 1, 0),
+		OpeningBalance: 10,
+		ClosingBalance: account.Balance,
+		Currency:       account.Currency,
+		File:           []byte("%PDF-1.3"),
+	}
+	created, err := testStore.CreateStatement(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.AccountID, created.AccountID)
+	require.True(t, arg.PeriodStart.Equal(created.PeriodStart))
+	require.Equal(t, arg.ClosingBalance, created.ClosingBalance)
+	require.Equal(t, arg.File, created.File)
+	require.False(t, created.EmailedAt.Valid)
+
+	// a period is archived only once per account
+	_, err = testStore.CreateStatement(context.Background(), arg)
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, UniqueViolation, pgErr.Code)
+
+	byPeriod, err := testStore.GetStatementByPeriod(context.Background(), GetStatementByPeriodParams{
+		AccountID:   account.ID,
+		PeriodStart: periodStart,
+	})
+	require.NoError(t, err)
+	require.Equal(t, created.ID, byPeriod.ID)
+
+	err = testStore.MarkStatementEmailed(context.Background(), created.ID)
+	require.NoError(t, err)
+
+	statement, err := testStore.GetStatement(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.True(t, statement.EmailedAt.Valid)
+
+	statements, err := testStore.ListStatements(context.Background(), ListStatementsParams{
+		AccountID: account.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+	require.Equal(t, created.ID, statements[0].ID)
+}
+
+func TestListStatementAccounts(t *testing.T) {
+	account := createRandomAccount(t)
+	startTime := time.Now().Add(-time.Minute)
+
+	_, err := testStore.AddAccountBalanceTx(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 100})
+	require.NoError(t, err)
+
+	accounts, err := testStore.ListStatementAccounts(context.Background(), ListStatementAccountsParams{
+		AfterID:   account.ID - 1,
+		StartTime: startTime,
+		EndTime:   time.Now().Add(time.Minute),
+		PageSize:  1,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	accounts, err = testStore.ListStatementAccounts(context.Background(), ListStatementAccountsParams{
+		AfterID:   account.ID,
+		StartTime: startTime,
+		EndTime:   time.Now().Add(time.Minute),
+		PageSize:  100,
+	})
+	require.NoError(t, err)
+	for _, a := range accounts {
+		require.Greater(t, a.ID, account.ID)
+	}
+}
@@ -12,6 +12,32 @@ type Store interface {
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
 	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
 	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	CreateVerifyEmailTx(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserParams) (User, error)
+	RequestVerifyEmailTx(ctx context.Context, arg RequestVerifyEmailTxParams) (User, error)
+	RequestEmailChangeTx(ctx context.Context, arg RequestEmailChangeTxParams) (User, error)
+	CreatePasswordResetTx(ctx context.Context, arg CreatePasswordResetTxParams) (PasswordReset, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error)
+	ResetTOTPTx(ctx context.Context, arg ResetTOTPTxParams) (User, error)
+	CountSecondFactorAttemptTx(ctx context.Context, arg CountSecondFactorAttemptTxParams) (CountSecondFactorAttemptTxResult, error)
+	PocketTransferTx(ctx context.Context, arg PocketTransferTxParams) (PocketTransferTxResult, error)
+	DisburseLoanTx(ctx context.Context, arg DisburseLoanTxParams) (DisburseLoanTxResult, error)
+	CollectLoanInstalmentTx(ctx context.Context, arg CollectLoanInstalmentTxParams) (CollectLoanInstalmentTxResult, error)
+	CreatePaymentRequestTx(ctx context.Context, arg CreatePaymentRequestParams) (CreatePaymentRequestTxResult, error)
+	AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error)
+	UpdatePaymentRequestStatusTx(ctx context.Context, arg UpdatePaymentRequestStatusTxParams) (PaymentRequest, error)
+	CreateBillTx(ctx context.Context, arg CreateBillTxParams) (CreateBillTxResult, error)
+	RedeemPaymentLinkTx(ctx context.Context, arg RedeemPaymentLinkTxParams) (RedeemPaymentLinkTxResult, error)
+	AddAccountBalanceTx(ctx context.Context, arg AddAccountBalanceParams) (AddAccountBalanceTxResult, error)
+	StatementTx(ctx context.Context, arg StatementTxParams, onSummary func(GetStatementSummaryRow) error, onEntry func(ListStatementEntriesRow) error) error
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error)
+	RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (RelayOutboxTxResult, error)
+	CreateSessionTx(ctx context.Context, arg CreateSessionParams) (Session, error)
+	BlockUserSessionsTx(ctx context.Context, arg BlockUserSessionsTxParams) (User, error)
+	RotateSessionTx(ctx context.Context, arg CreateSessionParams) (Session, error)
+	RevokeSessionFamilyTx(ctx context.Context, session Session) error
+	ChangeUserRoleTx(ctx context.Context, arg ChangeUserRoleTxParams) (User, error)
 }
 
 // SQLStore provides all functions to execute SQL queries and transaction
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableTOTPTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	// there is nothing to confirm before enrolling
+	_, err := testStore.EnableTOTPTx(context.Background(), EnableTOTPTxParams{Username: user.Username, UsedStep: 1})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	_, err = testStore.SetTOTPSecret(context.Background(), SetTOTPSecretParams{TotpSecret: "JBSWY3DPEHPK3PXP", Username: user.Username})
+	require.NoError(t, err)
+
+	codeHashes := []string{pkg.RandomString(64), pkg.RandomString(64)}
+	enabled, err := testStore.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username:           user.Username,
+		UsedStep:           10,
+		RecoveryCodeHashes: codeHashes,
+	})
+	require.NoError(t, err)
+	require.True(t, enabled.TotpEnabledAt.Valid)
+	require.Equal(t, int64(10), enabled.TotpLastUsedStep.Int64)
+
+	// the secret can't be replaced while it is on
+	_, err = testStore.SetTOTPSecret(context.Background(), SetTOTPSecretParams{TotpSecret: "KRSXG5CTMVRXEZLU", Username: user.Username})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// codes of a step work once
+	_, err = testStore.UseTOTPStep(context.Background(), UseTOTPStepParams{UsedStep: 10, Username: user.Username})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = testStore.UseTOTPStep(context.Background(), UseTOTPStepParams{UsedStep: 11, Username: user.Username})
+	require.NoError(t, err)
+
+	// and so do recovery codes
+	used, err := testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{Username: user.Username, CodeHash: codeHashes[0]})
+	require.NoError(t, err)
+	require.True(t, used.UsedAt.Valid)
+	_, err = testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{Username: user.Username, CodeHash: codeHashes[0]})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestResetTOTPTx(t *testing.T) {
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+
+	_, err := testStore.SetTOTPSecret(context.Background(), SetTOTPSecretParams{TotpSecret: "JBSWY3DPEHPK3PXP", Username: user.Username})
+	require.NoError(t, err)
+	codeHash := pkg.RandomString(64)
+	_, err = testStore.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username:           user.Username,
+		UsedStep:           10,
+		RecoveryCodeHashes: []string{codeHash},
+	})
+	require.NoError(t, err)
+
+	reset, err := testStore.ResetTOTPTx(context.Background(), ResetTOTPTxParams{Username: user.Username, Actor: admin.Username})
+	require.NoError(t, err)
+	require.False(t, reset.TotpSecret.Valid)
+	require.False(t, reset.TotpEnabledAt.Valid)
+
+	_, err = testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{Username: user.Username, CodeHash: codeHash})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	auditLogs, err := testStore.ListAuditLogs(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 1)
+	require.Equal(t, admin.Username, auditLogs[0].Actor)
+	require.Equal(t, pkg.AuditTwoFactorReset, auditLogs[0].Action)
+}
+
+func TestCountSecondFactorAttemptTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := CountSecondFactorAttemptTxParams{
+		Username:             user.Username,
+		ChallengeID:          uuid.New(),
+		ChallengeExpiresAt:   time.Now().Add(time.Minute),
+		MaxChallengeAttempts: 2,
+		MaxUserAttempts:      3,
+		LockedUntil:          time.Now().Add(time.Minute),
+	}
+
+	for i := int32(1); i <= 2; i++ {
+		result, err := testStore.CountSecondFactorAttemptTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, i, result.ChallengeAttempts)
+		require.Equal(t, i, result.User.TotpAttempts)
+		require.False(t, result.User.TotpLockedUntil.Valid)
+	}
+
+	// attempts over the limit of the challenge aren't counted for the user
+	result, err := testStore.CountSecondFactorAttemptTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), result.ChallengeAttempts)
+	got, err := testStore.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), got.TotpAttempts)
+
+	// the attempt that reaches the limit of the user locks them
+	arg.ChallengeID = uuid.New()
+	result, err = testStore.CountSecondFactorAttemptTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), result.User.TotpAttempts)
+	require.True(t, result.User.TotpLockedUntil.Valid)
+
+	_, err = testStore.CountSecondFactorAttemptTx(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	require.NoError(t, testStore.ClearTOTPAttempts(context.Background(), user.Username))
+	result, err = testStore.CountSecondFactorAttemptTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), result.User.TotpAttempts)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// AddAccountBalanceTxResult is the result of the add account balance transaction
+type AddAccountBalanceTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// AddAccountBalanceTx deposits a positive amount into, or withdraws a negative amount from, an account.
+// It records the entry, updates the account balance and announces deposits and low balances within a database transaction
+func (store *SQLStore) AddAccountBalanceTx(ctx context.Context, arg AddAccountBalanceParams) (AddAccountBalanceTxResult, error) {
+	var result AddAccountBalanceTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		description := "Deposit"
+		if arg.Amount < 0 {
+			description = "Withdrawal"
+		}
+
+		// update the balance first so that a missing account is reported as not found
+		var err error
+		result.Account, err = q.AddAccountBalance(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   arg.ID,
+			Amount:      arg.Amount,
+			Description: description,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.Amount < 0 {
+			return notifyLowBalance(ctx, q, result.Account, -arg.Amount)
+		}
+
+		return writeOutboxEvent(ctx, q, pkg.EventAccountCredited, strconv.FormatInt(result.Account.ID, 10), pkg.AccountCreditedEvent{
+			AccountID: result.Account.ID,
+			Owner:     result.Account.Owner,
+			EntryID:   result.Entry.ID,
+			Amount:    arg.Amount,
+			Currency:  result.Account.Currency,
+			Balance:   result.Account.Balance,
+		})
+	})
+
+	return result, err
+}
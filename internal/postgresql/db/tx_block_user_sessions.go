@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// BlockUserSessionsTxParams contains the input parameters of the block user sessions transaction
+type BlockUserSessionsTxParams struct {
+	Username string
+	// Actor is the banker or admin who blocked them
+	Actor string
+}
+
+// BlockUserSessionsTx blocks every session of the user within a database transaction, recording who did it in the
+// audit log
+func (store *SQLStore) BlockUserSessionsTx(ctx context.Context, arg BlockUserSessionsTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.BlockUserSessions(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, q, arg.Actor, pkg.AuditSessionsBlocked, arg.Username, struct{}{})
+	})
+
+	return user, err
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// ChangeUserRoleTxParams contains the input parameters of the change user role transaction
+type ChangeUserRoleTxParams struct {
+	Username string
+	Role     string
+	// Actor is the admin who changed it
+	Actor string
+}
+
+// roleChange is the audit log detail of pkg.AuditRoleChanged
+type roleChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ChangeUserRoleTx changes the role of the user within a database transaction. Their sessions are blocked, the
+// refresh tokens carry the old role, and the change is recorded in the audit log with who did it
+func (store *SQLStore) ChangeUserRoleTx(ctx context.Context, arg ChangeUserRoleTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		previous, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserRole(ctx, UpdateUserRoleParams{
+			Role:     arg.Role,
+			Username: arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.BlockUserSessions(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, q, arg.Actor, pkg.AuditRoleChanged, arg.Username, roleChange{From: previous.Role, To: arg.Role})
+	})
+
+	return user, err
+}
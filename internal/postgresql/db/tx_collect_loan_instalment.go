@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// CollectLoanInstalmentTxParams contains the input parameters of the instalment collection transaction.
+// MarkOverdue tells the transaction to flag the instalment as overdue if it cannot be collected.
+type CollectLoanInstalmentTxParams struct {
+	InstalmentID int64 `json:"instalment_id"`
+	MarkOverdue  bool  `json:"mark_overdue"`
+}
+
+// CollectLoanInstalmentTxResult is the result of the instalment collection transaction.
+// Entry is only set when the instalment was collected.
+type CollectLoanInstalmentTxResult struct {
+	Instalment LoanInstalment `json:"instalment"`
+	Loan       Loan           `json:"loan"`
+	Account    Account        `json:"account"`
+	Entry      Entry          `json:"entry"`
+	Collected  bool           `json:"collected"`
+}
+
+// CollectLoanInstalmentTx debits an instalment, including any late fee, from the loan's linked account.
+// If the balance is not enough the instalment stays unpaid and is marked overdue when requested, adding the loan's late fee.
+// Paying the last instalment pays off the loan.
+func (store *SQLStore) CollectLoanInstalmentTx(ctx context.Context, arg CollectLoanInstalmentTxParams) (CollectLoanInstalmentTxResult, error) {
+	var result CollectLoanInstalmentTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		instalment, err := q.GetLoanInstalmentForUpdate(ctx, arg.InstalmentID)
+		if err != nil {
+			return err
+		}
+
+		result.Loan, err = q.GetLoanForUpdate(ctx, instalment.LoanID)
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.GetAccountForUpdate(ctx, result.Loan.AccountID)
+		if err != nil {
+			return err
+		}
+
+		result.Instalment = instalment
+		if instalment.Status == pkg.InstalmentStatusPaid {
+			return nil
+		}
+
+		due := instalment.Principal + instalment.Interest + instalment.LateFee
+		if result.Account.Balance < due {
+			if arg.MarkOverdue && instalment.Status == pkg.InstalmentStatusPending {
+				result.Instalment, err = q.MarkLoanInstalmentOverdue(ctx, MarkLoanInstalmentOverdueParams{
+					LateFee: result.Loan.LateFee,
+					ID:      instalment.ID,
+				})
+			}
+			return err
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   result.Account.ID,
+			Amount:      -due,
+			Description: fmt.Sprintf("Loan #%d instalment %d", instalment.LoanID, instalment.Number),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     result.Account.ID,
+			Amount: -due,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = notifyLowBalance(ctx, q, result.Account, due)
+		if err != nil {
+			return err
+		}
+
+		result.Loan, err = q.AddLoanOutstandingPrincipal(ctx, AddLoanOutstandingPrincipalParams{
+			Amount: -instalment.Principal,
+			ID:     result.Loan.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Instalment, err = q.MarkLoanInstalmentPaid(ctx, MarkLoanInstalmentPaidParams{
+			EntryID: pgtype.Int8{Int64: result.Entry.ID, Valid: true},
+			ID:      instalment.ID,
+		})
+		if err != nil {
+			return err
+		}
+		result.Collected = true
+
+		unpaid, err := q.CountUnpaidLoanInstalments(ctx, result.Loan.ID)
+		if err != nil {
+			return err
+		}
+
+		if unpaid == 0 {
+			result.Loan, err = q.UpdateLoanStatus(ctx, UpdateLoanStatusParams{
+				Status: pkg.LoanStatusPaidOff,
+				ID:     result.Loan.ID,
+			})
+		}
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// CreateAccountTx creates an account and writes its EventAccountCreated to the outbox within a database transaction
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return writeOutboxEvent(ctx, q, pkg.EventAccountCreated, strconv.FormatInt(account.ID, 10), pkg.AccountCreatedEvent{
+			AccountID: account.ID,
+			Owner:     account.Owner,
+			Currency:  account.Currency,
+		})
+	})
+
+	return account, err
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BillShare is the part of a bill a participant is asked to pay
+type BillShare struct {
+	Payer  string `json:"payer"`
+	Amount int64  `json:"amount"`
+}
+
+// CreateBillTxParams contains the input parameters of the create bill transaction
+type CreateBillTxParams struct {
+	CreateBillParams
+	Shares    []BillShare `json:"shares"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// CreateBillTxResult is the result of the create bill transaction
+type CreateBillTxResult struct {
+	Bill            Bill             `json:"bill"`
+	PaymentRequests []PaymentRequest `json:"payment_requests"`
+}
+
+// CreateBillTx records a bill and creates one payment request per share within a database transaction.
+// The event of each payment request is written to the outbox.
+func (store *SQLStore) CreateBillTx(ctx context.Context, arg CreateBillTxParams) (CreateBillTxResult, error) {
+	var result CreateBillTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Bill, err = q.CreateBill(ctx, arg.CreateBillParams)
+		if err != nil {
+			return err
+		}
+
+		result.PaymentRequests = make([]PaymentRequest, 0, len(arg.Shares))
+		for _, share := range arg.Shares {
+			paymentRequest, err := q.CreatePaymentRequest(ctx, CreatePaymentRequestParams{
+				Requester:   result.Bill.Organizer,
+				Payer:       share.Payer,
+				ToAccountID: result.Bill.ToAccountID,
+				Amount:      share.Amount,
+				Currency:    result.Bill.Currency,
+				Message:     result.Bill.Description,
+				ExpiresAt:   arg.ExpiresAt,
+				BillID:      pgtype.Int8{Int64: result.Bill.ID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := writePaymentRequestEvent(ctx, q, paymentRequest); err != nil {
+				return err
+			}
+
+			result.PaymentRequests = append(result.PaymentRequests, paymentRequest)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// CreateSessionTx creates a session within a database transaction. If the user logged in before, but never with
+// the user agent of the session, it also writes an EventUserNewDeviceLogin to the outbox
+func (store *SQLStore) CreateSessionTx(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	var session Session
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		count, err := q.GetSessionDeviceCount(ctx, GetSessionDeviceCountParams{
+			UserAgent: arg.UserAgent,
+			Username:  arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		session, err = q.CreateSession(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		// the first login of a user is not news to them
+		if count.Sessions == 0 || count.DeviceSessions > 0 {
+			return nil
+		}
+
+		return writeOutboxEvent(ctx, q, pkg.EventUserNewDeviceLogin, session.Username, pkg.UserNewDeviceLoginEvent{
+			Username:  session.Username,
+			SessionID: session.ID.String(),
+			UserAgent: session.UserAgent,
+			ClientIP:  session.ClientIp,
+			CreatedAt: session.CreatedAt,
+		})
+	})
+
+	return session, err
+}
@@ -1,10 +1,13 @@
 package db
 
-import "context"
+import (
+	"context"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
 
 type CreateUserTxParams struct {
 	CreateUserParams
-	AfterCreate func(user User) error
 }
 
 type CreateUserTxResult struct {
@@ -22,7 +25,10 @@ func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams)
 			return err
 		}
 
-		return arg.AfterCreate(result.User)
+		return writeOutboxEvent(ctx, q, pkg.EventUserCreated, result.User.Username, pkg.UserCreatedEvent{
+			Username: result.User.Username,
+			Email:    result.User.Email,
+		})
 	})
 
 	return result, err
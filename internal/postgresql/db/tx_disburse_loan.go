@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// DisburseLoanTxParams contains the input parameters of the loan disbursement transaction.
+// Instalments is the amortization schedule, their LoanID is filled in by the transaction.
+type DisburseLoanTxParams struct {
+	LoanID        int64                        `json:"loan_id"`
+	AnnualRateBps int32                        `json:"annual_rate_bps"`
+	LateFee       int64                        `json:"late_fee"`
+	ReviewedBy    string                       `json:"reviewed_by"`
+	Instalments   []CreateLoanInstalmentParams `json:"instalments"`
+}
+
+// DisburseLoanTxResult is the result of the loan disbursement transaction
+type DisburseLoanTxResult struct {
+	Loan        Loan             `json:"loan"`
+	Account     Account          `json:"account"`
+	Entry       Entry            `json:"entry"`
+	Instalments []LoanInstalment `json:"instalments"`
+}
+
+// DisburseLoanTx approves a pending loan. It credits the principal to the linked account,
+// opens the loan account with the principal as outstanding balance and stores the amortization
+// schedule within a database transaction
+func (store *SQLStore) DisburseLoanTx(ctx context.Context, arg DisburseLoanTxParams) (DisburseLoanTxResult, error) {
+	var result DisburseLoanTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		loan, err := q.GetLoanForUpdate(ctx, arg.LoanID)
+		if err != nil {
+			return err
+		}
+
+		if loan.Status != pkg.LoanStatusPending {
+			return ErrLoanNotPending
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   loan.AccountID,
+			Amount:      loan.Principal,
+			Description: fmt.Sprintf("Loan #%d disbursement", loan.ID),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     loan.AccountID,
+			Amount: loan.Principal,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Loan, err = q.DisburseLoan(ctx, DisburseLoanParams{
+			AnnualRateBps:       arg.AnnualRateBps,
+			LateFee:             arg.LateFee,
+			ReviewedBy:          pgtype.Text{String: arg.ReviewedBy, Valid: true},
+			DisbursementEntryID: pgtype.Int8{Int64: result.Entry.ID, Valid: true},
+			ID:                  loan.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Instalments = make([]LoanInstalment, 0, len(arg.Instalments))
+		for _, instalmentArg := range arg.Instalments {
+			instalmentArg.LoanID = loan.ID
+			instalment, err := q.CreateLoanInstalment(ctx, instalmentArg)
+			if err != nil {
+				return err
+			}
+			result.Instalments = append(result.Instalments, instalment)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RelayOutboxTxParams contains the input parameters of the outbox relay transaction
+type RelayOutboxTxParams struct {
+	// Limit is the maximum number of events published by one transaction
+	Limit int32
+	// MaxAttempts is how many times an event can fail to publish before it is parked, parked events are no longer
+	// relayed so that a poison event doesn't hold up the ones after it
+	MaxAttempts int32
+	// Backoff is how long the relay waits before publishing an event again after its attempts-th failure
+	Backoff func(attempts int32) time.Duration
+	// Publish hands an event to the message broker, it must be idempotent since an event is published again
+	// when the transaction fails to commit after it. Its errors can wrap ErrBrokerUnavailable or
+	// ErrEventUnpublishable
+	Publish func(event Outbox) error
+}
+
+// RelayOutboxTxResult is the result of the outbox relay transaction
+type RelayOutboxTxResult struct {
+	Published int `json:"published"`
+	// Pending is true when the batch was full and more events may be waiting
+	Pending bool `json:"pending"`
+	// Parked is set when the event that failed to publish reached MaxAttempts
+	Parked *Outbox `json:"parked,omitempty"`
+}
+
+// RelayOutboxTx publishes the oldest unpublished events that are due and marks them published within a database
+// transaction. Events are locked with SKIP LOCKED so that concurrent relays share the work. Publishing stops at the
+// first failure, which is returned after the others were committed. The failure is recorded on the event, which is
+// retried after Backoff, unless the broker was unavailable. An event that failed MaxAttempts times or that is
+// unpublishable is parked and skipped by later relays until it is requeued.
+func (store *SQLStore) RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (RelayOutboxTxResult, error) {
+	var result RelayOutboxTxResult
+	var publishErr error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		events, err := q.ListPendingOutboxEvents(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+		result.Pending = len(events) == int(arg.Limit)
+
+		for _, event := range events {
+			if publishErr = arg.Publish(event); publishErr != nil {
+				publishErr = fmt.Errorf("event %d: %w", event.ID, publishErr)
+				// an outage of the broker would otherwise park every event it lasts through
+				if errors.Is(publishErr, ErrBrokerUnavailable) {
+					return nil
+				}
+
+				failed, err := q.MarkOutboxEventFailed(ctx, MarkOutboxEventFailedParams{
+					LastError:     publishErr.Error(),
+					NextAttemptAt: time.Now().Add(arg.Backoff(event.Attempts + 1)),
+					Park:          errors.Is(publishErr, ErrEventUnpublishable),
+					MaxAttempts:   arg.MaxAttempts,
+					ID:            event.ID,
+				})
+				if err != nil {
+					return err
+				}
+				if failed.ParkedAt.Valid {
+					result.Parked = &failed
+				}
+				return nil
+			}
+
+			if err := q.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+				return err
+			}
+			result.Published++
+		}
+		return nil
+	})
+	if err != nil {
+		return RelayOutboxTxResult{}, err
+	}
+
+	return result, publishErr
+}
+
+// writeOutboxEvent writes an event to the outbox using q, so that it is only published if the transaction commits
+func writeOutboxEvent(ctx context.Context, q *Queries, eventType string, aggregateID string, payload any) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     jsonPayload,
+	})
+	return err
+}
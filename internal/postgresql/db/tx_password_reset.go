@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreatePasswordResetTxParams contains the input parameters of the create password reset transaction
+type CreatePasswordResetTxParams struct {
+	Username  string
+	TokenHash string
+	ExpiresAt time.Time
+	// RequestedBefore is the latest time a password reset token may have been emailed to the user at
+	RequestedBefore time.Time
+}
+
+// CreatePasswordResetTx creates a password reset token within a database transaction, the unused tokens of the
+// user expire so that only the new one can be used. It returns pgx.ErrNoRows, and the earlier tokens keep working,
+// if a token was created for the user after RequestedBefore
+func (store *SQLStore) CreatePasswordResetTx(ctx context.Context, arg CreatePasswordResetTxParams) (PasswordReset, error) {
+	var passwordReset PasswordReset
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.RequestPasswordReset(ctx, RequestPasswordResetParams{
+			Username:        arg.Username,
+			RequestedBefore: arg.RequestedBefore,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.ExpirePasswordResets(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		passwordReset, err = q.CreatePasswordReset(ctx, CreatePasswordResetParams{
+			Username:  arg.Username,
+			TokenHash: arg.TokenHash,
+			ExpiresAt: arg.ExpiresAt,
+		})
+		return err
+	})
+
+	return passwordReset, err
+}
+
+// ResetPasswordTxParams contains the input parameters of the reset password transaction
+type ResetPasswordTxParams struct {
+	TokenHash         string
+	HashedPassword    string
+	PasswordChangedAt time.Time
+}
+
+// ResetPasswordTx uses the password reset token, sets the password of its user and blocks all their sessions within a
+// database transaction, so that the token is only used up together with the new password
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		passwordReset, err := q.UsePasswordReset(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUser(ctx, UpdateUserParams{
+			HashedPassword:    pgtype.Text{String: arg.HashedPassword, Valid: true},
+			PasswordChangedAt: pgtype.Timestamptz{Time: arg.PasswordChangedAt, Valid: true},
+			Username:          passwordReset.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, user.Username)
+	})
+
+	return user, err
+}
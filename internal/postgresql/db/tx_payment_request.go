@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+type CreatePaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest `json:"payment_request"`
+}
+
+// CreatePaymentRequestTx creates a payment request and writes its event to the outbox within a database transaction
+func (store *SQLStore) CreatePaymentRequestTx(ctx context.Context, arg CreatePaymentRequestParams) (CreatePaymentRequestTxResult, error) {
+	var result CreatePaymentRequestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PaymentRequest, err = q.CreatePaymentRequest(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return writePaymentRequestEvent(ctx, q, result.PaymentRequest)
+	})
+
+	return result, err
+}
+
+// AcceptPaymentRequestTxParams contains the input parameters of the accept payment request transaction
+type AcceptPaymentRequestTxParams struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+}
+
+// AcceptPaymentRequestTxResult is the result of the accept payment request transaction
+type AcceptPaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest   `json:"payment_request"`
+	Transfer       TransferTxResult `json:"transfer"`
+}
+
+// AcceptPaymentRequestTx pays a pending payment request from the given account.
+// It transfers the requested amount to the requester's account, marks the request accepted and writes its event to the
+// outbox within a database transaction
+func (store *SQLStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		paymentRequest, err := q.GetPaymentRequestForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if paymentRequest.Status != pkg.PaymentRequestStatusPending {
+			return ErrPaymentRequestNotPending
+		}
+
+		if !time.Now().Before(paymentRequest.ExpiresAt) {
+			return ErrPaymentRequestExpired
+		}
+
+		result.Transfer, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   paymentRequest.ToAccountID,
+			Amount:        paymentRequest.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.PaymentRequest, err = q.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+			Status:     pkg.PaymentRequestStatusAccepted,
+			TransferID: pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true},
+			ID:         paymentRequest.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return writePaymentRequestEvent(ctx, q, result.PaymentRequest)
+	})
+
+	return result, err
+}
+
+// UpdatePaymentRequestStatusTxParams contains the input parameters of the payment request status transaction
+type UpdatePaymentRequestStatusTxParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+// UpdatePaymentRequestStatusTx moves a pending payment request to a final status that involves no money, such as declined or expired
+func (store *SQLStore) UpdatePaymentRequestStatusTx(ctx context.Context, arg UpdatePaymentRequestStatusTxParams) (PaymentRequest, error) {
+	var result PaymentRequest
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		paymentRequest, err := q.GetPaymentRequestForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if paymentRequest.Status != pkg.PaymentRequestStatusPending {
+			return ErrPaymentRequestNotPending
+		}
+
+		result, err = q.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+			Status: arg.Status,
+			ID:     paymentRequest.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return writePaymentRequestEvent(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// writePaymentRequestEvent writes the event of the payment request's status to the outbox, it tells the other party
+// about the request
+func writePaymentRequestEvent(ctx context.Context, q *Queries, paymentRequest PaymentRequest) error {
+	return writeOutboxEvent(ctx, q, pkg.EventPaymentRequestStatusChanged, strconv.FormatInt(paymentRequest.ID, 10), pkg.PaymentRequestStatusChangedEvent{
+		PaymentRequestID: paymentRequest.ID,
+		Requester:        paymentRequest.Requester,
+		Payer:            paymentRequest.Payer,
+		Amount:           paymentRequest.Amount,
+		Currency:         paymentRequest.Currency,
+		Status:           paymentRequest.Status,
+	})
+}
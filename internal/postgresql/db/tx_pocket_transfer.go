@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PocketTransferTxParams contains the input parameters of the pocket transfer transaction.
+// A positive amount moves money from the account into the pocket, a negative amount moves it back.
+type PocketTransferTxParams struct {
+	AccountID int64 `json:"account_id"`
+	PocketID  int64 `json:"pocket_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// PocketTransferTxResult is the result of the pocket transfer transaction
+type PocketTransferTxResult struct {
+	Account      Account `json:"account"`
+	Pocket       Pocket  `json:"pocket"`
+	AccountEntry Entry   `json:"account_entry"`
+	PocketEntry  Entry   `json:"pocket_entry"`
+}
+
+// PocketTransferTx moves money between an account and one of its pockets.
+// It records one entry against the account main balance and one against the pocket,
+// and updates both balances within a database transaction
+func (store *SQLStore) PocketTransferTx(ctx context.Context, arg PocketTransferTxParams) (PocketTransferTxResult, error) {
+	var result PocketTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// always lock the account before the pocket to keep a consistent lock order
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		pocket, err := q.GetPocketForUpdate(ctx, arg.PocketID)
+		if err != nil {
+			return err
+		}
+
+		if pocket.AccountID != account.ID {
+			return pgx.ErrNoRows
+		}
+
+		if account.Balance < arg.Amount || pocket.Balance < -arg.Amount {
+			return ErrInsufficientFunds
+		}
+
+		description := fmt.Sprintf("Moved to pocket %s", pocket.Name)
+		if arg.Amount < 0 {
+			description = fmt.Sprintf("Moved from pocket %s", pocket.Name)
+		}
+
+		result.AccountEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   arg.AccountID,
+			Amount:      -arg.Amount,
+			Description: description,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.PocketEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   arg.AccountID,
+			Amount:      arg.Amount,
+			PocketID:    pgtype.Int8{Int64: arg.PocketID, Valid: true},
+			Description: description,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: -arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		// money moved into a pocket is no longer available on the main balance
+		err = notifyLowBalance(ctx, q, result.Account, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.Pocket, err = q.AddPocketBalance(ctx, AddPocketBalanceParams{
+			ID:     arg.PocketID,
+			Amount: arg.Amount,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// RedeemPaymentLinkTxParams contains the input parameters of the redeem payment link transaction
+type RedeemPaymentLinkTxParams struct {
+	PaymentLinkID int64  `json:"payment_link_id"`
+	Payer         string `json:"payer"`
+	FromAccountID int64  `json:"from_account_id"`
+	Amount        int64  `json:"amount"`
+}
+
+// RedeemPaymentLinkTxResult is the result of the redeem payment link transaction
+type RedeemPaymentLinkTxResult struct {
+	PaymentLink PaymentLink           `json:"payment_link"`
+	Redemption  PaymentLinkRedemption `json:"redemption"`
+	Transfer    TransferTxResult      `json:"transfer"`
+}
+
+// RedeemPaymentLinkTx pays a payment link from the given account.
+// The link is locked so that a single-use link can't be redeemed twice by concurrent payers.
+func (store *SQLStore) RedeemPaymentLinkTx(ctx context.Context, arg RedeemPaymentLinkTxParams) (RedeemPaymentLinkTxResult, error) {
+	var result RedeemPaymentLinkTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PaymentLink, err = q.GetPaymentLinkForUpdate(ctx, arg.PaymentLinkID)
+		if err != nil {
+			return err
+		}
+
+		if !time.Now().Before(result.PaymentLink.ExpiresAt) {
+			return ErrPaymentLinkExpired
+		}
+
+		if result.PaymentLink.SingleUse {
+			redemptions, err := q.CountPaymentLinkRedemptions(ctx, result.PaymentLink.ID)
+			if err != nil {
+				return err
+			}
+			if redemptions > 0 {
+				return ErrPaymentLinkUsed
+			}
+		}
+
+		result.Transfer, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   result.PaymentLink.ToAccountID,
+			Amount:        arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Redemption, err = q.CreatePaymentLinkRedemption(ctx, CreatePaymentLinkRedemptionParams{
+			PaymentLinkID: result.PaymentLink.ID,
+			Payer:         arg.Payer,
+			TransferID:    result.Transfer.Transfer.ID,
+		})
+		return err
+	})
+
+	return result, err
+}
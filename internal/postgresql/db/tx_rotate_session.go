@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// RotateSessionTx exchanges the refresh token of the parent of the session for the one of the session within a
+// database transaction: the parent is marked rotated and the session created. It fails with pgx.ErrNoRows when the
+// parent was already rotated or blocked, so that two renewals racing with the same token can't both succeed
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	var session Session
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.RotateSession(ctx, uuid.UUID(arg.ParentID.Bytes))
+		if err != nil {
+			return err
+		}
+
+		session, err = q.CreateSession(ctx, arg)
+		return err
+	})
+
+	return session, err
+}
+
+// RevokeSessionFamilyTx blocks the family of a session whose rotated refresh token was presented again within a
+// database transaction, and writes an EventUserSessionReuseDetected to the outbox so that the user is told
+func (store *SQLStore) RevokeSessionFamilyTx(ctx context.Context, session Session) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		err := q.BlockSessionFamily(ctx, session.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		return writeOutboxEvent(ctx, q, pkg.EventUserSessionReuseDetected, session.Username, pkg.UserSessionReuseDetectedEvent{
+			Username:  session.Username,
+			FamilyID:  session.FamilyID.String(),
+			UserAgent: session.UserAgent,
+			ClientIP:  session.ClientIp,
+		})
+	})
+}
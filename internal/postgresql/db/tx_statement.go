@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// StatementTxParams contains the input parameters of the statement transaction.
+// Entries booked at or after StartTime and before EndTime are part of the statement.
+type StatementTxParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// StatementTx reads the statement of an account's main balance from a single snapshot, so that the
+// balances always add up with the entries. onSummary is called first with the opening and closing balances,
+// then onEntry is called for each entry as it is read from the database, so statements of any size
+// are exported in constant memory
+func (store *SQLStore) StatementTx(
+	ctx context.Context,
+	arg StatementTxParams,
+	onSummary func(GetStatementSummaryRow) error,
+	onEntry func(ListStatementEntriesRow) error,
+) error {
+	txOptions := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+
+	return store.execTxWithOptions(ctx, txOptions, func(q *Queries) error {
+		summary, err := q.GetStatementSummary(ctx, GetStatementSummaryParams{
+			EndTime:   arg.EndTime,
+			StartTime: arg.StartTime,
+			AccountID: arg.AccountID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := onSummary(summary); err != nil {
+			return err
+		}
+
+		return q.streamStatementEntries(ctx, ListStatementEntriesParams{
+			AccountID: arg.AccountID,
+			StartTime: arg.StartTime,
+			EndTime:   arg.EndTime,
+		}, onEntry)
+	})
+}
+
+// streamStatementEntries runs the ListStatementEntries query but hands each row to fn as soon
+// as it is scanned instead of collecting them all in a slice
+func (q *Queries) streamStatementEntries(ctx context.Context, arg ListStatementEntriesParams, fn func(ListStatementEntriesRow) error) error {
+	rows, err := q.db.Query(ctx, listStatementEntries, arg.AccountID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.TransferID,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyOwner,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
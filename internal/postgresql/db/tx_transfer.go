@@ -1,6 +1,13 @@
 package db
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
 
 // TransferTxParams contains the input parameters of the transfer transaction
 type TransferTxParams struct {
@@ -25,41 +32,84 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
-
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
-
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
-
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+// transfer moves money between two accounts using q, so that other transactions can embed a transfer
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
 
-		return err
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	transferID := pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   arg.FromAccountID,
+		Amount:      -arg.Amount,
+		TransferID:  transferID,
+		Description: fmt.Sprintf("Transfer to account #%d", arg.ToAccountID),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   arg.ToAccountID,
+		Amount:      arg.Amount,
+		TransferID:  transferID,
+		Description: fmt.Sprintf("Transfer from account #%d", arg.FromAccountID),
 	})
+	if err != nil {
+		return result, err
+	}
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
 
+	err = notifyLowBalance(ctx, q, result.FromAccount, arg.Amount)
+	if err != nil {
+		return result, err
+	}
+
+	err = writeOutboxEvent(ctx, q, pkg.EventTransferCompleted, strconv.FormatInt(result.Transfer.ID, 10), pkg.TransferCompletedEvent{
+		TransferID:    result.Transfer.ID,
+		FromAccountID: arg.FromAccountID,
+		FromOwner:     result.FromAccount.Owner,
+		ToAccountID:   arg.ToAccountID,
+		ToOwner:       result.ToAccount.Owner,
+		Amount:        arg.Amount,
+		Currency:      result.FromAccount.Currency,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = writeOutboxEvent(ctx, q, pkg.EventAccountCredited, strconv.FormatInt(result.ToAccount.ID, 10), pkg.AccountCreditedEvent{
+		AccountID:  result.ToAccount.ID,
+		Owner:      result.ToAccount.Owner,
+		EntryID:    result.ToEntry.ID,
+		Amount:     arg.Amount,
+		Currency:   result.ToAccount.Currency,
+		Balance:    result.ToAccount.Balance,
+		TransferID: result.Transfer.ID,
+	})
 	return result, err
 }
 
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// EnableTOTPTxParams contains the input parameters of the enable TOTP transaction
+type EnableTOTPTxParams struct {
+	Username string
+	// UsedStep is the time step of the code the user confirmed their secret with
+	UsedStep int64
+	// RecoveryCodeHashes replace the recovery codes of the user
+	RecoveryCodeHashes []string
+}
+
+// EnableTOTPTx turns two-factor authentication on and replaces the recovery codes of the user within a database
+// transaction. It returns pgx.ErrNoRows if the user has no secret or it is already on
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.EnableTOTP(ctx, EnableTOTPParams{
+			UsedStep: arg.UsedStep,
+			Username: arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return user, err
+}
+
+// ResetTOTPTxParams contains the input parameters of the reset TOTP transaction
+type ResetTOTPTxParams struct {
+	Username string
+	// Actor is the admin who reset it
+	Actor string
+}
+
+// ResetTOTPTx turns two-factor authentication off and deletes the recovery codes of the user within a database
+// transaction, recording who did it in the audit log
+func (store *SQLStore) ResetTOTPTx(ctx context.Context, arg ResetTOTPTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.ResetTOTP(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, q, arg.Actor, pkg.AuditTwoFactorReset, arg.Username, struct{}{})
+	})
+
+	return user, err
+}
+
+// CountSecondFactorAttemptTxParams contains the input parameters of the count second factor attempt transaction
+type CountSecondFactorAttemptTxParams struct {
+	Username    string
+	ChallengeID uuid.UUID
+	// ChallengeExpiresAt is when the login challenge the attempt is made with expires
+	ChallengeExpiresAt time.Time
+	// MaxChallengeAttempts is how many attempts can be made with a login challenge
+	MaxChallengeAttempts int32
+	// MaxUserAttempts is how many attempts the user can make before second factor login is locked until LockedUntil
+	MaxUserAttempts int32
+	LockedUntil     time.Time
+}
+
+// CountSecondFactorAttemptTxResult is the result of the count second factor attempt transaction
+type CountSecondFactorAttemptTxResult struct {
+	// ChallengeAttempts are the attempts made with the login challenge, this one included. The attempt isn't counted
+	// for the user once they are over MaxChallengeAttempts
+	ChallengeAttempts int32
+	User              User
+}
+
+// CountSecondFactorAttemptTx counts an attempt at a second factor for the login challenge and for the user within a
+// database transaction, before the code is checked so that concurrent attempts can't get past the limits. It returns
+// pgx.ErrNoRows while second factor login is locked for the user
+func (store *SQLStore) CountSecondFactorAttemptTx(ctx context.Context, arg CountSecondFactorAttemptTxParams) (CountSecondFactorAttemptTxResult, error) {
+	var result CountSecondFactorAttemptTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		err = q.DeleteExpiredLoginChallengeAttempts(ctx)
+		if err != nil {
+			return err
+		}
+
+		result.ChallengeAttempts, err = q.CountLoginChallengeAttempt(ctx, CountLoginChallengeAttemptParams{
+			ChallengeID: arg.ChallengeID,
+			Username:    arg.Username,
+			ExpiresAt:   arg.ChallengeExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		if result.ChallengeAttempts > arg.MaxChallengeAttempts {
+			return nil
+		}
+
+		result.User, err = q.CountTOTPAttempt(ctx, CountTOTPAttemptParams{
+			MaxAttempts: arg.MaxUserAttempts,
+			LockedUntil: arg.LockedUntil,
+			Username:    arg.Username,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// writeAuditLog records that actor performed action on target, with details marshalled to JSON
+func writeAuditLog(ctx context.Context, q *Queries, actor string, action string, target string, details any) error {
+	jsonDetails, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit log details: %w", err)
+	}
+
+	_, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+		Actor:   actor,
+		Action:  action,
+		Target:  target,
+		Details: jsonDetails,
+	})
+	return err
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+)
+
+// UpdateUserTx updates the user within a database transaction. A new password blocks all their sessions, which were
+// opened with the old one, so that the password only changes together with logging them out
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.UpdateUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		if !arg.HashedPassword.Valid {
+			return nil
+		}
+		return q.BlockUserSessions(ctx, user.Username)
+	})
+
+	return user, err
+}
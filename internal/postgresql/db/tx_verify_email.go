@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal/pkg"
 )
 
 type VerifyEmailTxParams struct {
@@ -16,6 +18,8 @@ type VerifyEmailTxResult struct {
 	VerifyEmail VerifyEmail
 }
 
+// VerifyEmailTx marks the code used and the email of its user verified within a database transaction. The code of
+// an email change also switches the email of the user to the address it was sent to
 func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
 	var result VerifyEmailTxResult
 
@@ -32,6 +36,10 @@ func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParam
 
 		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
 			Username: result.VerifyEmail.Username,
+			Email: pgtype.Text{
+				String: result.VerifyEmail.Email,
+				Valid:  true,
+			},
 			IsEmailVerified: pgtype.Bool{
 				Bool:  true,
 				Valid: true,
@@ -42,3 +50,83 @@ func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParam
 
 	return result, err
 }
+
+// CreateVerifyEmailTx creates a verification code within a database transaction, the unused codes of the user
+// expire so that only the new one can be used
+func (store *SQLStore) CreateVerifyEmailTx(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
+	var verifyEmail VerifyEmail
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.ExpireVerifyEmails(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		verifyEmail, err = q.CreateVerifyEmail(ctx, arg)
+		return err
+	})
+
+	return verifyEmail, err
+}
+
+// RequestVerifyEmailTxParams contains the input parameters of the request verify email transaction
+type RequestVerifyEmailTxParams struct {
+	Username string
+	// RequestedBefore is the latest time the user may have signed up or asked for a verification email at
+	RequestedBefore time.Time
+}
+
+// RequestVerifyEmailTx records that an unverified user asked for a new verification email and writes an
+// EventUserVerifyEmailRequested to the outbox within a database transaction. It returns pgx.ErrNoRows if the user
+// doesn't exist, is verified or signed up or asked for an email after RequestedBefore
+func (store *SQLStore) RequestVerifyEmailTx(ctx context.Context, arg RequestVerifyEmailTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.RequestVerifyEmail(ctx, RequestVerifyEmailParams{
+			Username:        arg.Username,
+			RequestedBefore: pgtype.Timestamptz{Time: arg.RequestedBefore, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return writeOutboxEvent(ctx, q, pkg.EventUserVerifyEmailRequested, user.Username, pkg.UserVerifyEmailRequestedEvent{
+			Username: user.Username,
+			Email:    user.Email,
+		})
+	})
+
+	return user, err
+}
+
+// RequestEmailChangeTxParams contains the input parameters of the request email change transaction
+type RequestEmailChangeTxParams struct {
+	Username string
+	NewEmail string
+}
+
+// RequestEmailChangeTx writes an EventUserEmailChangeRequested to the outbox within a database transaction, the
+// email of the user is left as is until the new address is verified
+func (store *SQLStore) RequestEmailChangeTx(ctx context.Context, arg RequestEmailChangeTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return writeOutboxEvent(ctx, q, pkg.EventUserEmailChangeRequested, user.Username, pkg.UserEmailChangeRequestedEvent{
+			Username: user.Username,
+			OldEmail: user.Email,
+			NewEmail: arg.NewEmail,
+		})
+	})
+
+	return user, err
+}
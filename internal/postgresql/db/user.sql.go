@@ -7,17 +7,74 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const clearTOTPAttempts = `-- name: ClearTOTPAttempts :exec
+UPDATE users
+SET totp_attempts     = 0,
+    totp_locked_until = NULL
+WHERE username = $1
+`
+
+func (q *Queries) ClearTOTPAttempts(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, clearTOTPAttempts, username)
+	return err
+}
+
+const countTOTPAttempt = `-- name: CountTOTPAttempt :one
+UPDATE users
+SET totp_attempts     = totp_attempts + 1,
+    totp_locked_until = CASE
+                            WHEN totp_attempts + 1 >= $1::int THEN $2::timestamptz
+                        END
+WHERE username = $3
+  AND (totp_locked_until IS NULL OR totp_locked_until <= now())
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
+`
+
+type CountTOTPAttemptParams struct {
+	MaxAttempts int32     `json:"max_attempts"`
+	LockedUntil time.Time `json:"locked_until"`
+	Username    string    `json:"username"`
+}
+
+// CountTOTPAttempt counts a second factor attempt of the user, locking second factor login until locked_until once
+// max_attempts are reached. It returns no rows while the user is locked
+func (q *Queries) CountTOTPAttempt(ctx context.Context, arg CountTOTPAttemptParams) (User, error) {
+	row := q.db.QueryRow(ctx, countTOTPAttempt, arg.MaxAttempts, arg.LockedUntil, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (username,
                    hashed_password,
                    full_name,
                    email)
 VALUES ($1, $2, $3, $4)
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
 `
 
 type CreateUserParams struct {
@@ -44,12 +101,62 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
+const enableTOTP = `-- name: EnableTOTP :one
+UPDATE users
+SET totp_enabled_at = now(),
+    totp_last_used_step = $1
+WHERE username = $2
+  AND totp_secret IS NOT NULL
+  AND totp_enabled_at IS NULL
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
+`
+
+type EnableTOTPParams struct {
+	UsedStep int64  `json:"used_step"`
+	Username string `json:"username"`
+}
+
+// EnableTOTP turns two-factor authentication on once the user confirmed their secret with the code of used_step
+func (q *Queries) EnableTOTP(ctx context.Context, arg EnableTOTPParams) (User, error) {
+	row := q.db.QueryRow(ctx, enableTOTP, arg.UsedStep, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -65,6 +172,203 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
+const requestPasswordReset = `-- name: RequestPasswordReset :one
+UPDATE users
+SET password_reset_requested_at = now()
+WHERE username = $1
+  AND (password_reset_requested_at IS NULL OR password_reset_requested_at <= $2)
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
+`
+
+type RequestPasswordResetParams struct {
+	Username        string    `json:"username"`
+	RequestedBefore time.Time `json:"requested_before"`
+}
+
+// RequestPasswordReset records that a password reset token is emailed to the user, unless one was emailed to them
+// after requested_before
+func (q *Queries) RequestPasswordReset(ctx context.Context, arg RequestPasswordResetParams) (User, error) {
+	row := q.db.QueryRow(ctx, requestPasswordReset, arg.Username, arg.RequestedBefore)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
+const requestVerifyEmail = `-- name: RequestVerifyEmail :one
+UPDATE users
+SET verify_email_requested_at = now()
+WHERE username = $1
+  AND is_email_verified = FALSE
+  AND COALESCE(verify_email_requested_at, created_at) <= $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
+`
+
+type RequestVerifyEmailParams struct {
+	Username        string             `json:"username"`
+	RequestedBefore pgtype.Timestamptz `json:"requested_before"`
+}
+
+// RequestVerifyEmail records that an unverified user asked for a new verification email, unless they signed up or
+// asked for one after requested_before
+func (q *Queries) RequestVerifyEmail(ctx context.Context, arg RequestVerifyEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, requestVerifyEmail, arg.Username, arg.RequestedBefore)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
+const resetTOTP = `-- name: ResetTOTP :one
+UPDATE users
+SET totp_secret = NULL,
+    totp_enabled_at = NULL,
+    totp_last_used_step = NULL,
+    totp_attempts = 0,
+    totp_locked_until = NULL
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
+`
+
+func (q *Queries) ResetTOTP(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, resetTOTP, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
+const setTOTPSecret = `-- name: SetTOTPSecret :one
+UPDATE users
+SET totp_secret = $1,
+    totp_last_used_step = NULL
+WHERE username = $2
+  AND totp_enabled_at IS NULL
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
+`
+
+type SetTOTPSecretParams struct {
+	TotpSecret string `json:"totp_secret"`
+	Username   string `json:"username"`
+}
+
+// SetTOTPSecret stores the secret of a TOTP enrolment, replacing an unconfirmed one. It returns no rows if two-factor
+// authentication is already on
+func (q *Queries) SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (User, error) {
+	row := q.db.QueryRow(ctx, setTOTPSecret, arg.TotpSecret, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
 	)
 	return i, err
 }
@@ -76,10 +380,12 @@ SET
   password_changed_at = COALESCE($2, password_changed_at),
   full_name = COALESCE($3, full_name),
   email = COALESCE($4, email),
-  is_email_verified = COALESCE($5, is_email_verified)
+  is_email_verified = COALESCE($5, is_email_verified),
+  phone_number = COALESCE($6, phone_number),
+  locale = COALESCE($7, locale)
 WHERE
-  username = $6
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
+  username = $8
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
 `
 
 type UpdateUserParams struct {
@@ -88,6 +394,8 @@ type UpdateUserParams struct {
 	FullName          pgtype.Text        `json:"full_name"`
 	Email             pgtype.Text        `json:"email"`
 	IsEmailVerified   pgtype.Bool        `json:"is_email_verified"`
+	PhoneNumber       pgtype.Text        `json:"phone_number"`
+	Locale            pgtype.Text        `json:"locale"`
 	Username          string             `json:"username"`
 }
 
@@ -98,6 +406,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.FullName,
 		arg.Email,
 		arg.IsEmailVerified,
+		arg.PhoneNumber,
+		arg.Locale,
 		arg.Username,
 	)
 	var i User
@@ -110,6 +420,93 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $1
+WHERE username = $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
+`
+
+type UpdateUserRoleParams struct {
+	Role     string `json:"role"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.Role, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
+	)
+	return i, err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :one
+UPDATE users
+SET totp_last_used_step = $1
+WHERE username = $2
+  AND totp_enabled_at IS NOT NULL
+  AND COALESCE(totp_last_used_step, -1) < $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, phone_number, locale, verify_email_requested_at, totp_secret, totp_enabled_at, totp_last_used_step, totp_attempts, totp_locked_until, password_reset_requested_at
+`
+
+type UseTOTPStepParams struct {
+	UsedStep int64  `json:"used_step"`
+	Username string `json:"username"`
+}
+
+// UseTOTPStep records that the code of used_step was accepted. It returns no rows if a code of that step or a later
+// one was already used, so that codes can't be replayed
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (User, error) {
+	row := q.db.QueryRow(ctx, useTOTPStep, arg.UsedStep, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PhoneNumber,
+		&i.Locale,
+		&i.VerifyEmailRequestedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastUsedStep,
+		&i.TotpAttempts,
+		&i.TotpLockedUntil,
+		&i.PasswordResetRequestedAt,
 	)
 	return i, err
 }
@@ -92,6 +92,25 @@ func TestUpdateUserOnlyEmail(t *testing.T) {
 	require.Equal(t, oldUser.HashedPassword, updatedUser.HashedPassword)
 }
 
+func TestUpdateUserOnlyPhoneNumber(t *testing.T) {
+	oldUser := createRandomUser(t)
+	require.Empty(t, oldUser.PhoneNumber)
+
+	updatedUser, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		PhoneNumber: pgtype.Text{
+			String: "+351912345678",
+			Valid:  true,
+		},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "+351912345678", updatedUser.PhoneNumber)
+	require.Equal(t, "en", updatedUser.Locale)
+	require.Equal(t, oldUser.Email, updatedUser.Email)
+	require.Equal(t, oldUser.FullName, updatedUser.FullName)
+}
+
 func TestUpdateUserOnlyPassword(t *testing.T) {
 	oldUser := createRandomUser(t)
 
@@ -147,3 +166,59 @@ func TestUpdateUserAllFields(t *testing.T) {
 	require.NotEqual(t, oldUser.FullName, updatedUser.FullName)
 	require.Equal(t, newFullName, updatedUser.FullName)
 }
+
+func TestUpdateUserTx(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username, "curl/8.0")
+
+	// other changes leave the sessions alone
+	_, err := testStore.UpdateUserTx(context.Background(), UpdateUserParams{
+		FullName: pgtype.Text{String: pkg.RandomOwner(), Valid: true},
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+
+	session, err = testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.False(t, session.IsBlocked)
+
+	// the sessions were opened with the old password
+	hashedPassword, err := pkg.HashPassword(pkg.RandomString(6))
+	require.NoError(t, err)
+	updated, err := testStore.UpdateUserTx(context.Background(), UpdateUserParams{
+		HashedPassword:    pgtype.Text{String: hashedPassword, Valid: true},
+		PasswordChangedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Username:          user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+
+	session, err = testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+}
+
+func TestChangeUserRoleTx(t *testing.T) {
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username, "curl/8.0")
+
+	changed, err := testStore.ChangeUserRoleTx(context.Background(), ChangeUserRoleTxParams{
+		Username: user.Username,
+		Role:     pkg.BankerRole,
+		Actor:    admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, pkg.BankerRole, changed.Role)
+
+	// the refresh tokens carry the old role
+	session, err = testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	auditLogs, err := testStore.ListAuditLogs(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 1)
+	require.Equal(t, pkg.AuditRoleChanged, auditLogs[0].Action)
+	require.JSONEq(t, `{"from": "`+user.Role+`", "to": "banker"}`, string(auditLogs[0].Details))
+}
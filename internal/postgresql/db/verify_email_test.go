@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateVerifyEmailTxExpiresEarlierCodes(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := CreateVerifyEmailParams{Username: user.Username, Email: user.Email, SecretCode: pkg.RandomString(32)}
+	first, err := testStore.CreateVerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	arg.SecretCode = pkg.RandomString(32)
+	second, err := testStore.CreateVerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	first, err = testStore.GetVerifyEmail(context.Background(), first.ID)
+	require.NoError(t, err)
+	require.False(t, first.IsUsed)
+	require.False(t, time.Now().Before(first.ExpiredAt))
+
+	// only the last code verifies the email
+	_, err = testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{EmailId: first.ID, SecretCode: first.SecretCode})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	result, err := testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{EmailId: second.ID, SecretCode: second.SecretCode})
+	require.NoError(t, err)
+	require.True(t, result.VerifyEmail.IsUsed)
+	require.True(t, result.User.IsEmailVerified)
+}
+
+func TestRequestVerifyEmailTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	// the user just signed up
+	_, err := testStore.RequestVerifyEmailTx(context.Background(), RequestVerifyEmailTxParams{
+		Username:        user.Username,
+		RequestedBefore: time.Now().Add(-time.Minute),
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	requested, err := testStore.RequestVerifyEmailTx(context.Background(), RequestVerifyEmailTxParams{
+		Username:        user.Username,
+		RequestedBefore: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.True(t, requested.VerifyEmailRequestedAt.Valid)
+
+	event := relayUntil(t, pkg.EventUserVerifyEmailRequested, user.Username)
+	var payload pkg.UserVerifyEmailRequestedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, user.Username, payload.Username)
+	require.Equal(t, user.Email, payload.Email)
+
+	// and just asked for an email
+	_, err = testStore.RequestVerifyEmailTx(context.Background(), RequestVerifyEmailTxParams{
+		Username:        user.Username,
+		RequestedBefore: requested.VerifyEmailRequestedAt.Time.Add(-time.Second),
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestRequestVerifyEmailTxVerifiedUser(t *testing.T) {
+	user := createRandomUser(t)
+
+	verifyEmail, err := testStore.CreateVerifyEmailTx(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: pkg.RandomString(32),
+	})
+	require.NoError(t, err)
+	_, err = testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{EmailId: verifyEmail.ID, SecretCode: verifyEmail.SecretCode})
+	require.NoError(t, err)
+
+	_, err = testStore.RequestVerifyEmailTx(context.Background(), RequestVerifyEmailTxParams{
+		Username:        user.Username,
+		RequestedBefore: time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestRequestEmailChangeTx(t *testing.T) {
+	user := createRandomUser(t)
+	newEmail := pkg.RandomEmail()
+
+	requested, err := testStore.RequestEmailChangeTx(context.Background(), RequestEmailChangeTxParams{
+		Username: user.Username,
+		NewEmail: newEmail,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Email, requested.Email)
+
+	event := relayUntil(t, pkg.EventUserEmailChangeRequested, user.Username)
+	var payload pkg.UserEmailChangeRequestedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, user.Email, payload.OldEmail)
+	require.Equal(t, newEmail, payload.NewEmail)
+
+	// the address switches once the code sent to it is verified
+	verifyEmail, err := testStore.CreateVerifyEmailTx(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      newEmail,
+		SecretCode: pkg.RandomString(32),
+	})
+	require.NoError(t, err)
+	result, err := testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{EmailId: verifyEmail.ID, SecretCode: verifyEmail.SecretCode})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, result.User.Email)
+	require.True(t, result.User.IsEmailVerified)
+}
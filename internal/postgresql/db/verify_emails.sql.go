@@ -40,6 +40,40 @@ func (q *Queries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailPa
 	return i, err
 }
 
+const expireVerifyEmails = `-- name: ExpireVerifyEmails :exec
+UPDATE verify_emails
+SET expired_at = now()
+WHERE username = $1
+  AND is_used = FALSE
+  AND expired_at > now()
+`
+
+// ExpireVerifyEmails makes the unused codes of a user expire, so that only the code sent last can be used
+func (q *Queries) ExpireVerifyEmails(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, expireVerifyEmails, username)
+	return err
+}
+
+const getVerifyEmail = `-- name: GetVerifyEmail :one
+SELECT id, username, email, secret_code, is_used, created_at, expired_at FROM verify_emails
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetVerifyEmail(ctx context.Context, id int64) (VerifyEmail, error) {
+	row := q.db.QueryRow(ctx, getVerifyEmail, id)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
 const updateVerifyEmail = `-- name: UpdateVerifyEmail :one
 UPDATE verify_emails
 SET
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhook(t *testing.T, owner string, eventTypes ...string) Webhook {
+	arg := CreateWebhookParams{
+		Owner:      owner,
+		Url:        "https://example.com/" + pkg.RandomString(8),
+		EventTypes: eventTypes,
+		Secret:     pkg.RandomString(32),
+	}
+
+	webhook, err := testStore.CreateWebhook(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, webhook.Owner)
+	require.Equal(t, arg.Url, webhook.Url)
+	require.Equal(t, arg.EventTypes, webhook.EventTypes)
+	require.Equal(t, arg.Secret, webhook.Secret)
+	require.NotZero(t, webhook.CreatedAt)
+
+	return webhook
+}
+
+func TestListWebhooksForEvent(t *testing.T) {
+	alice := createRandomUser(t)
+	bob := createRandomUser(t)
+	transfers := createRandomWebhook(t, alice.Username, pkg.EventTransferCompleted)
+	both := createRandomWebhook(t, bob.Username, pkg.EventAccountCredited, pkg.EventTransferCompleted)
+	createRandomWebhook(t, alice.Username, pkg.EventAccountCreated)
+
+	webhooks, err := testStore.ListWebhooksForEvent(context.Background(), ListWebhooksForEventParams{
+		Owners:    []string{alice.Username, bob.Username},
+		EventType: pkg.EventTransferCompleted,
+	})
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+	require.Equal(t, transfers.ID, webhooks[0].ID)
+	require.Equal(t, both.ID, webhooks[1].ID)
+
+	webhooks, err = testStore.ListWebhooksForEvent(context.Background(), ListWebhooksForEventParams{
+		Owners:    []string{alice.Username},
+		EventType: pkg.EventAccountCredited,
+	})
+	require.NoError(t, err)
+	require.Empty(t, webhooks)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	user := createRandomUser(t)
+	webhook := createRandomWebhook(t, user.Username, pkg.EventAccountCreated)
+	event, err := testStore.CreateOutboxEvent(context.Background(), CreateOutboxEventParams{
+		EventType:   pkg.EventAccountCreated,
+		AggregateID: "1",
+		Payload:     []byte(`{"account_id":1}`),
+	})
+	require.NoError(t, err)
+
+	arg := CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.EventType,
+		Payload:   []byte(`{"id":1}`),
+	}
+	delivery, err := testStore.CreateWebhookDelivery(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, pkg.WebhookDeliveryStatusPending, delivery.Status)
+	require.Zero(t, delivery.Attempts)
+	require.JSONEq(t, `{"id":1}`, string(delivery.Payload))
+
+	// creating the delivery of the same event again returns the first one
+	again, err := testStore.CreateWebhookDelivery(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, delivery.ID, again.ID)
+
+	failed, err := testStore.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         pkg.WebhookDeliveryStatusPending,
+		ResponseStatus: 503,
+		LastError:      "unexpected response status 503",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failed.Attempts)
+	require.Equal(t, int32(503), failed.ResponseStatus)
+	require.False(t, failed.DeliveredAt.Valid)
+
+	succeeded, err := testStore.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         pkg.WebhookDeliveryStatusSucceeded,
+		ResponseStatus: 200,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), succeeded.Attempts)
+	require.True(t, succeeded.DeliveredAt.Valid)
+	require.Empty(t, succeeded.LastError)
+
+	reset, err := testStore.ResetWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, pkg.WebhookDeliveryStatusPending, reset.Status)
+
+	deliveries, err := testStore.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	// deleting the webhook deletes its deliveries
+	require.NoError(t, testStore.DeleteWebhook(context.Background(), webhook.ID))
+	_, err = testStore.GetWebhookDelivery(context.Background(), delivery.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,103 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// LoanRepository represents the repository used for interacting with Loan records.
+type LoanRepository struct {
+	q db.Store
+}
+
+// NewLoanRepository instantiates the Loan repository.
+func NewLoanRepository(connPool *pgxpool.Pool) *LoanRepository {
+	return &LoanRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (loanRepo *LoanRepository) Create(ctx context.Context, arg db.CreateLoanParams) (db.Loan, error) {
+	loan, err := loanRepo.q.CreateLoan(ctx, arg)
+	if err != nil {
+		return db.Loan{}, internal.DBErrorToInternal(err)
+	}
+	return loan, nil
+}
+
+func (loanRepo *LoanRepository) Get(ctx context.Context, id int64) (db.Loan, error) {
+	loan, err := loanRepo.q.GetLoan(ctx, id)
+	if err != nil {
+		return db.Loan{}, internal.DBErrorToInternal(err)
+	}
+	return loan, nil
+}
+
+func (loanRepo *LoanRepository) ListByOwner(ctx context.Context, arg db.ListLoansByOwnerParams) ([]db.Loan, error) {
+	loans, err := loanRepo.q.ListLoansByOwner(ctx, arg)
+	if err != nil {
+		return []db.Loan{}, internal.DBErrorToInternal(err)
+	}
+	return loans, nil
+}
+
+func (loanRepo *LoanRepository) ListByStatus(ctx context.Context, arg db.ListLoansByStatusParams) ([]db.Loan, error) {
+	loans, err := loanRepo.q.ListLoansByStatus(ctx, arg)
+	if err != nil {
+		return []db.Loan{}, internal.DBErrorToInternal(err)
+	}
+	return loans, nil
+}
+
+func (loanRepo *LoanRepository) Reject(ctx context.Context, id int64, reviewedBy string) (db.Loan, error) {
+	loan, err := loanRepo.q.RejectLoan(ctx, db.RejectLoanParams{
+		ReviewedBy: pgtype.Text{String: reviewedBy, Valid: true},
+		ID:         id,
+	})
+	if err != nil {
+		return db.Loan{}, internal.DBErrorToInternal(err)
+	}
+	return loan, nil
+}
+
+func (loanRepo *LoanRepository) ListInstalments(ctx context.Context, loanID int64) ([]db.LoanInstalment, error) {
+	instalments, err := loanRepo.q.ListLoanInstalments(ctx, loanID)
+	if err != nil {
+		return []db.LoanInstalment{}, internal.DBErrorToInternal(err)
+	}
+	return instalments, nil
+}
+
+func (loanRepo *LoanRepository) ListDueInstalments(ctx context.Context, dueDate time.Time) ([]db.LoanInstalment, error) {
+	instalments, err := loanRepo.q.ListDueLoanInstalments(ctx, dueDate)
+	if err != nil {
+		return []db.LoanInstalment{}, internal.DBErrorToInternal(err)
+	}
+	return instalments, nil
+}
+
+func (loanRepo *LoanRepository) DisburseTx(ctx context.Context, arg db.DisburseLoanTxParams) (db.DisburseLoanTxResult, error) {
+	res, err := loanRepo.q.DisburseLoanTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrLoanNotPending) {
+			return db.DisburseLoanTxResult{}, fmt.Errorf("%w: %s", internal.ErrLoanNotPending, err.Error())
+		}
+		return db.DisburseLoanTxResult{}, internal.DBErrorToInternal(err)
+	}
+	return res, nil
+}
+
+func (loanRepo *LoanRepository) CollectInstalmentTx(ctx context.Context, arg db.CollectLoanInstalmentTxParams) (db.CollectLoanInstalmentTxResult, error) {
+	res, err := loanRepo.q.CollectLoanInstalmentTx(ctx, arg)
+	if err != nil {
+		return db.CollectLoanInstalmentTxResult{}, internal.DBErrorToInternal(err)
+	}
+	return res, nil
+}
@@ -0,0 +1,2 @@
+ALTER TABLE "entries" DROP COLUMN IF EXISTS "pocket_id";
+DROP TABLE IF EXISTS "pockets";
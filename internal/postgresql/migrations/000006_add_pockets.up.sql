@@ -0,0 +1,25 @@
+CREATE TABLE "pockets"
+(
+    "id"            bigserial PRIMARY KEY,
+    "account_id"    bigint      NOT NULL,
+    "name"          varchar     NOT NULL,
+    "balance"       bigint      NOT NULL DEFAULT 0,
+    "target_amount" bigint      NOT NULL,
+    "target_date"   timestamptz,
+    "created_at"    timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "pockets"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pockets" ADD CONSTRAINT "account_name_key" UNIQUE ("account_id", "name");
+
+ALTER TABLE "entries" ADD COLUMN "pocket_id" bigint;
+
+ALTER TABLE "entries"
+    ADD FOREIGN KEY ("pocket_id") REFERENCES "pockets" ("id");
+
+CREATE INDEX ON "entries" ("pocket_id");
+
+COMMENT ON COLUMN "entries"."pocket_id" IS 'pocket the entry is booked against, null for the account main balance';
+COMMENT ON COLUMN "pockets"."target_amount" IS 'must be positive';
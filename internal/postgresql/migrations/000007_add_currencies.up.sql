@@ -0,0 +1,175 @@
+CREATE TABLE "currencies"
+(
+    "code"         varchar PRIMARY KEY,
+    "numeric_code" varchar     NOT NULL UNIQUE,
+    "name"         varchar     NOT NULL,
+    "minor_units"  integer     NOT NULL,
+    "symbol"       varchar     NOT NULL,
+    "enabled"      boolean     NOT NULL DEFAULT false,
+    "updated_at"   timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 alphabetic code';
+COMMENT ON COLUMN "currencies"."numeric_code" IS 'ISO 4217 numeric code';
+COMMENT ON COLUMN "currencies"."minor_units" IS 'number of digits after the decimal separator';
+
+INSERT INTO "currencies" ("code", "numeric_code", "name", "minor_units", "symbol", "enabled")
+VALUES
+    ('AED', '784', 'UAE Dirham', 2, 'د.إ', false),
+    ('AFN', '971', 'Afghani', 2, '؋', false),
+    ('ALL', '008', 'Lek', 2, 'L', false),
+    ('AMD', '051', 'Armenian Dram', 2, '֏', false),
+    ('ANG', '532', 'Netherlands Antillean Guilder', 2, 'ƒ', false),
+    ('AOA', '973', 'Kwanza', 2, 'Kz', false),
+    ('ARS', '032', 'Argentine Peso', 2, '$', false),
+    ('AUD', '036', 'Australian Dollar', 2, 'A$', false),
+    ('AWG', '533', 'Aruban Florin', 2, 'ƒ', false),
+    ('AZN', '944', 'Azerbaijan Manat', 2, '₼', false),
+    ('BAM', '977', 'Convertible Mark', 2, 'KM', false),
+    ('BBD', '052', 'Barbados Dollar', 2, '$', false),
+    ('BDT', '050', 'Taka', 2, '৳', false),
+    ('BGN', '975', 'Bulgarian Lev', 2, 'лв', false),
+    ('BHD', '048', 'Bahraini Dinar', 3, '.د.ب', false),
+    ('BIF', '108', 'Burundi Franc', 0, 'FBu', false),
+    ('BMD', '060', 'Bermudian Dollar', 2, '$', false),
+    ('BND', '096', 'Brunei Dollar', 2, '$', false),
+    ('BOB', '068', 'Boliviano', 2, 'Bs.', false),
+    ('BRL', '986', 'Brazilian Real', 2, 'R$', false),
+    ('BSD', '044', 'Bahamian Dollar', 2, '$', false),
+    ('BTN', '064', 'Ngultrum', 2, 'Nu.', false),
+    ('BWP', '072', 'Pula', 2, 'P', false),
+    ('BYN', '933', 'Belarusian Ruble', 2, 'Br', false),
+    ('BZD', '084', 'Belize Dollar', 2, 'BZ$', false),
+    ('CAD', '124', 'Canadian Dollar', 2, 'CA$', true),
+    ('CDF', '976', 'Congolese Franc', 2, 'FC', false),
+    ('CHF', '756', 'Swiss Franc', 2, 'CHF', false),
+    ('CLP', '152', 'Chilean Peso', 0, '$', false),
+    ('CNY', '156', 'Yuan Renminbi', 2, '¥', false),
+    ('COP', '170', 'Colombian Peso', 2, '$', false),
+    ('CRC', '188', 'Costa Rican Colon', 2, '₡', false),
+    ('CUP', '192', 'Cuban Peso', 2, '$', false),
+    ('CVE', '132', 'Cabo Verde Escudo', 2, '$', false),
+    ('CZK', '203', 'Czech Koruna', 2, 'Kč', false),
+    ('DJF', '262', 'Djibouti Franc', 0, 'Fdj', false),
+    ('DKK', '208', 'Danish Krone', 2, 'kr', false),
+    ('DOP', '214', 'Dominican Peso', 2, 'RD$', false),
+    ('DZD', '012', 'Algerian Dinar', 2, 'د.ج', false),
+    ('EGP', '818', 'Egyptian Pound', 2, 'E£', false),
+    ('ERN', '232', 'Nakfa', 2, 'Nfk', false),
+    ('ETB', '230', 'Ethiopian Birr', 2, 'Br', false),
+    ('EUR', '978', 'Euro', 2, '€', true),
+    ('FJD', '242', 'Fiji Dollar', 2, '$', false),
+    ('FKP', '238', 'Falkland Islands Pound', 2, '£', false),
+    ('GBP', '826', 'Pound Sterling', 2, '£', false),
+    ('GEL', '981', 'Lari', 2, '₾', false),
+    ('GHS', '936', 'Ghana Cedi', 2, 'GH₵', false),
+    ('GIP', '292', 'Gibraltar Pound', 2, '£', false),
+    ('GMD', '270', 'Dalasi', 2, 'D', false),
+    ('GNF', '324', 'Guinean Franc', 0, 'FG', false),
+    ('GTQ', '320', 'Quetzal', 2, 'Q', false),
+    ('GYD', '328', 'Guyana Dollar', 2, '$', false),
+    ('HKD', '344', 'Hong Kong Dollar', 2, 'HK$', false),
+    ('HNL', '340', 'Lempira', 2, 'L', false),
+    ('HTG', '332', 'Gourde', 2, 'G', false),
+    ('HUF', '348', 'Forint', 2, 'Ft', false),
+    ('IDR', '360', 'Rupiah', 2, 'Rp', false),
+    ('ILS', '376', 'New Israeli Sheqel', 2, '₪', false),
+    ('INR', '356', 'Indian Rupee', 2, '₹', false),
+    ('IQD', '368', 'Iraqi Dinar', 3, 'ع.د', false),
+    ('IRR', '364', 'Iranian Rial', 2, '﷼', false),
+    ('ISK', '352', 'Iceland Krona', 0, 'kr', false),
+    ('JMD', '388', 'Jamaican Dollar', 2, 'J$', false),
+    ('JOD', '400', 'Jordanian Dinar', 3, 'د.ا', false),
+    ('JPY', '392', 'Yen', 0, '¥', false),
+    ('KES', '404', 'Kenyan Shilling', 2, 'KSh', false),
+    ('KGS', '417', 'Som', 2, 'с', false),
+    ('KHR', '116', 'Riel', 2, '៛', false),
+    ('KMF', '174', 'Comorian Franc', 0, 'CF', false),
+    ('KPW', '408', 'North Korean Won', 2, '₩', false),
+    ('KRW', '410', 'Won', 0, '₩', false),
+    ('KWD', '414', 'Kuwaiti Dinar', 3, 'د.ك', false),
+    ('KYD', '136', 'Cayman Islands Dollar', 2, '$', false),
+    ('KZT', '398', 'Tenge', 2, '₸', false),
+    ('LAK', '418', 'Lao Kip', 2, '₭', false),
+    ('LBP', '422', 'Lebanese Pound', 2, 'ل.ل', false),
+    ('LKR', '144', 'Sri Lanka Rupee', 2, 'Rs', false),
+    ('LRD', '430', 'Liberian Dollar', 2, '$', false),
+    ('LSL', '426', 'Loti', 2, 'L', false),
+    ('LYD', '434', 'Libyan Dinar', 3, 'ل.د', false),
+    ('MAD', '504', 'Moroccan Dirham', 2, 'د.م.', false),
+    ('MDL', '498', 'Moldovan Leu', 2, 'L', false),
+    ('MGA', '969', 'Malagasy Ariary', 2, 'Ar', false),
+    ('MKD', '807', 'Denar', 2, 'ден', false),
+    ('MMK', '104', 'Kyat', 2, 'K', false),
+    ('MNT', '496', 'Tugrik', 2, '₮', false),
+    ('MOP', '446', 'Pataca', 2, 'MOP$', false),
+    ('MRU', '929', 'Ouguiya', 2, 'UM', false),
+    ('MUR', '480', 'Mauritius Rupee', 2, '₨', false),
+    ('MVR', '462', 'Rufiyaa', 2, 'Rf', false),
+    ('MWK', '454', 'Malawi Kwacha', 2, 'MK', false),
+    ('MXN', '484', 'Mexican Peso', 2, '$', false),
+    ('MYR', '458', 'Malaysian Ringgit', 2, 'RM', false),
+    ('MZN', '943', 'Mozambique Metical', 2, 'MT', false),
+    ('NAD', '516', 'Namibia Dollar', 2, '$', false),
+    ('NGN', '566', 'Naira', 2, '₦', false),
+    ('NIO', '558', 'Cordoba Oro', 2, 'C$', false),
+    ('NOK', '578', 'Norwegian Krone', 2, 'kr', false),
+    ('NPR', '524', 'Nepalese Rupee', 2, '₨', false),
+    ('NZD', '554', 'New Zealand Dollar', 2, 'NZ$', false),
+    ('OMR', '512', 'Rial Omani', 3, 'ر.ع.', false),
+    ('PAB', '590', 'Balboa', 2, 'B/.', false),
+    ('PEN', '604', 'Sol', 2, 'S/', false),
+    ('PGK', '598', 'Kina', 2, 'K', false),
+    ('PHP', '608', 'Philippine Peso', 2, '₱', false),
+    ('PKR', '586', 'Pakistan Rupee', 2, '₨', false),
+    ('PLN', '985', 'Zloty', 2, 'zł', false),
+    ('PYG', '600', 'Guarani', 0, '₲', false),
+    ('QAR', '634', 'Qatari Rial', 2, 'ر.ق', false),
+    ('RON', '946', 'Romanian Leu', 2, 'lei', false),
+    ('RSD', '941', 'Serbian Dinar', 2, 'дин.', false),
+    ('RUB', '643', 'Russian Ruble', 2, '₽', false),
+    ('RWF', '646', 'Rwanda Franc', 0, 'FRw', false),
+    ('SAR', '682', 'Saudi Riyal', 2, 'ر.س', false),
+    ('SBD', '090', 'Solomon Islands Dollar', 2, '$', false),
+    ('SCR', '690', 'Seychelles Rupee', 2, '₨', false),
+    ('SDG', '938', 'Sudanese Pound', 2, 'ج.س.', false),
+    ('SEK', '752', 'Swedish Krona', 2, 'kr', false),
+    ('SGD', '702', 'Singapore Dollar', 2, 'S$', false),
+    ('SHP', '654', 'Saint Helena Pound', 2, '£', false),
+    ('SLE', '925', 'Leone', 2, 'Le', false),
+    ('SOS', '706', 'Somali Shilling', 2, 'Sh', false),
+    ('SRD', '968', 'Surinam Dollar', 2, '$', false),
+    ('SSP', '728', 'South Sudanese Pound', 2, '£', false),
+    ('STN', '930', 'Dobra', 2, 'Db', false),
+    ('SVC', '222', 'El Salvador Colon', 2, '₡', false),
+    ('SYP', '760', 'Syrian Pound', 2, '£', false),
+    ('SZL', '748', 'Lilangeni', 2, 'E', false),
+    ('THB', '764', 'Baht', 2, '฿', false),
+    ('TJS', '972', 'Somoni', 2, 'ЅМ', false),
+    ('TMT', '934', 'Turkmenistan New Manat', 2, 'm', false),
+    ('TND', '788', 'Tunisian Dinar', 3, 'د.ت', false),
+    ('TOP', '776', 'Pa’anga', 2, 'T$', false),
+    ('TRY', '949', 'Turkish Lira', 2, '₺', false),
+    ('TTD', '780', 'Trinidad and Tobago Dollar', 2, 'TT$', false),
+    ('TWD', '901', 'New Taiwan Dollar', 2, 'NT$', false),
+    ('TZS', '834', 'Tanzanian Shilling', 2, 'TSh', false),
+    ('UAH', '980', 'Hryvnia', 2, '₴', false),
+    ('UGX', '800', 'Uganda Shilling', 0, 'USh', false),
+    ('USD', '840', 'US Dollar', 2, '$', true),
+    ('UYU', '858', 'Peso Uruguayo', 2, '$U', false),
+    ('UZS', '860', 'Uzbekistan Sum', 2, 'сўм', false),
+    ('VES', '928', 'Bolívar Soberano', 2, 'Bs.', false),
+    ('VND', '704', 'Dong', 0, '₫', false),
+    ('VUV', '548', 'Vatu', 0, 'VT', false),
+    ('WST', '882', 'Tala', 2, 'WS$', false),
+    ('XAF', '950', 'CFA Franc BEAC', 0, 'FCFA', false),
+    ('XCD', '951', 'East Caribbean Dollar', 2, 'EC$', false),
+    ('XOF', '952', 'CFA Franc BCEAO', 0, 'CFA', false),
+    ('XPF', '953', 'CFP Franc', 0, '₣', false),
+    ('YER', '886', 'Yemeni Rial', 2, '﷼', false),
+    ('ZAR', '710', 'Rand', 2, 'R', false),
+    ('ZMW', '967', 'Zambian Kwacha', 2, 'ZK', false),
+    ('ZWL', '932', 'Zimbabwe Dollar', 2, 'Z$', false);
+
+ALTER TABLE "accounts"
+    ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
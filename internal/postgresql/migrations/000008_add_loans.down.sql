@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "loan_instalments";
+DROP TABLE IF EXISTS "loans";
@@ -0,0 +1,60 @@
+CREATE TABLE "loans"
+(
+    "id"                    bigserial PRIMARY KEY,
+    "account_id"            bigint      NOT NULL,
+    "principal"             bigint      NOT NULL,
+    "currency"              varchar     NOT NULL,
+    "term_months"           integer     NOT NULL,
+    "schedule_type"         varchar     NOT NULL,
+    "status"                varchar     NOT NULL DEFAULT 'pending',
+    "annual_rate_bps"       integer     NOT NULL DEFAULT 0,
+    "late_fee"              bigint      NOT NULL DEFAULT 0,
+    "outstanding_principal" bigint      NOT NULL DEFAULT 0,
+    "reviewed_by"           varchar,
+    "disbursement_entry_id" bigint,
+    "disbursed_at"          timestamptz,
+    "created_at"            timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "loan_instalments"
+(
+    "id"         bigserial PRIMARY KEY,
+    "loan_id"    bigint      NOT NULL,
+    "number"     integer     NOT NULL,
+    "due_date"   timestamptz NOT NULL,
+    "principal"  bigint      NOT NULL,
+    "interest"   bigint      NOT NULL,
+    "late_fee"   bigint      NOT NULL DEFAULT 0,
+    "status"     varchar     NOT NULL DEFAULT 'pending',
+    "entry_id"   bigint,
+    "paid_at"    timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "loans"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "loans"
+    ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+ALTER TABLE "loans"
+    ADD FOREIGN KEY ("reviewed_by") REFERENCES "users" ("username");
+ALTER TABLE "loans"
+    ADD FOREIGN KEY ("disbursement_entry_id") REFERENCES "entries" ("id");
+ALTER TABLE "loan_instalments"
+    ADD FOREIGN KEY ("loan_id") REFERENCES "loans" ("id");
+ALTER TABLE "loan_instalments"
+    ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "loan_instalments" ADD CONSTRAINT "loan_number_key" UNIQUE ("loan_id", "number");
+
+CREATE INDEX ON "loans" ("account_id");
+CREATE INDEX ON "loans" ("status");
+CREATE INDEX ON "loan_instalments" ("status", "due_date");
+
+COMMENT ON COLUMN "loans"."account_id" IS 'account the principal is disbursed to and instalments are collected from';
+COMMENT ON COLUMN "loans"."schedule_type" IS 'annuity or linear';
+COMMENT ON COLUMN "loans"."status" IS 'pending, rejected, active or paid_off';
+COMMENT ON COLUMN "loans"."annual_rate_bps" IS 'nominal annual interest rate in basis points';
+COMMENT ON COLUMN "loans"."late_fee" IS 'fee added to an instalment when it becomes overdue';
+COMMENT ON COLUMN "loans"."outstanding_principal" IS 'balance of the loan account, principal not repaid yet';
+COMMENT ON COLUMN "loan_instalments"."status" IS 'pending, overdue or paid';
+COMMENT ON COLUMN "loan_instalments"."entry_id" IS 'entry that debited the linked account';
@@ -0,0 +1,35 @@
+CREATE TABLE "payment_requests"
+(
+    "id"            bigserial PRIMARY KEY,
+    "requester"     varchar     NOT NULL,
+    "payer"         varchar     NOT NULL,
+    "to_account_id" bigint      NOT NULL,
+    "amount"        bigint      NOT NULL,
+    "currency"      varchar     NOT NULL,
+    "message"       varchar     NOT NULL DEFAULT '',
+    "status"        varchar     NOT NULL DEFAULT 'pending',
+    "transfer_id"   bigint,
+    "expires_at"    timestamptz NOT NULL,
+    "updated_at"    timestamptz NOT NULL DEFAULT (now()),
+    "created_at"    timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "payment_requests"
+    ADD FOREIGN KEY ("requester") REFERENCES "users" ("username");
+ALTER TABLE "payment_requests"
+    ADD FOREIGN KEY ("payer") REFERENCES "users" ("username");
+ALTER TABLE "payment_requests"
+    ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "payment_requests"
+    ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+ALTER TABLE "payment_requests"
+    ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "payment_requests" ("payer");
+CREATE INDEX ON "payment_requests" ("requester");
+CREATE INDEX ON "payment_requests" ("status", "expires_at");
+
+COMMENT ON COLUMN "payment_requests"."to_account_id" IS 'requester account that receives the money';
+COMMENT ON COLUMN "payment_requests"."amount" IS 'must be positive';
+COMMENT ON COLUMN "payment_requests"."status" IS 'pending, accepted, declined or expired';
+COMMENT ON COLUMN "payment_requests"."transfer_id" IS 'transfer that paid the request once accepted';
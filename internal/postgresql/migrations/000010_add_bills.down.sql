@@ -0,0 +1,3 @@
+ALTER TABLE "payment_requests" DROP COLUMN IF EXISTS "bill_id";
+
+DROP TABLE IF EXISTS "bills";
@@ -0,0 +1,34 @@
+CREATE TABLE "bills"
+(
+    "id"            bigserial PRIMARY KEY,
+    "organizer"     varchar     NOT NULL,
+    "to_account_id" bigint      NOT NULL,
+    "total"         bigint      NOT NULL,
+    "currency"      varchar     NOT NULL,
+    "description"   varchar     NOT NULL DEFAULT '',
+    "split_type"    varchar     NOT NULL,
+    "reminded_at"   timestamptz,
+    "created_at"    timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "bills"
+    ADD FOREIGN KEY ("organizer") REFERENCES "users" ("username");
+ALTER TABLE "bills"
+    ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "bills"
+    ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+CREATE INDEX ON "bills" ("organizer");
+
+ALTER TABLE "payment_requests"
+    ADD COLUMN "bill_id" bigint;
+ALTER TABLE "payment_requests"
+    ADD FOREIGN KEY ("bill_id") REFERENCES "bills" ("id");
+
+CREATE INDEX ON "payment_requests" ("bill_id");
+
+COMMENT ON COLUMN "bills"."to_account_id" IS 'organizer account that receives the shares';
+COMMENT ON COLUMN "bills"."total" IS 'must be positive, includes the organizer''s own share';
+COMMENT ON COLUMN "bills"."split_type" IS 'equal or custom';
+COMMENT ON COLUMN "bills"."reminded_at" IS 'last time the participants were reminded to pay';
+COMMENT ON COLUMN "payment_requests"."bill_id" IS 'bill this request is a share of';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "payment_link_redemptions";
+DROP TABLE IF EXISTS "payment_links";
@@ -0,0 +1,41 @@
+CREATE TABLE "payment_links"
+(
+    "id"            bigserial PRIMARY KEY,
+    "owner"         varchar     NOT NULL,
+    "to_account_id" bigint      NOT NULL,
+    "amount"        bigint,
+    "currency"      varchar     NOT NULL,
+    "reference"     varchar     NOT NULL DEFAULT '',
+    "single_use"    boolean     NOT NULL,
+    "expires_at"    timestamptz NOT NULL,
+    "created_at"    timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "payment_link_redemptions"
+(
+    "id"              bigserial PRIMARY KEY,
+    "payment_link_id" bigint      NOT NULL,
+    "payer"           varchar     NOT NULL,
+    "transfer_id"     bigint      NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "payment_links"
+    ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+ALTER TABLE "payment_links"
+    ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "payment_links"
+    ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+ALTER TABLE "payment_link_redemptions"
+    ADD FOREIGN KEY ("payment_link_id") REFERENCES "payment_links" ("id");
+ALTER TABLE "payment_link_redemptions"
+    ADD FOREIGN KEY ("payer") REFERENCES "users" ("username");
+ALTER TABLE "payment_link_redemptions"
+    ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "payment_links" ("owner");
+CREATE INDEX ON "payment_link_redemptions" ("payment_link_id");
+
+COMMENT ON COLUMN "payment_links"."to_account_id" IS 'owner account that receives the payments';
+COMMENT ON COLUMN "payment_links"."amount" IS 'fixed amount, the payer chooses it when null';
+COMMENT ON COLUMN "payment_links"."single_use" IS 'single-use links can only be redeemed once';
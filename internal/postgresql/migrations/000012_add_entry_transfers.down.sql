@@ -0,0 +1,4 @@
+ALTER TABLE "entries"
+    DROP COLUMN IF EXISTS "description";
+ALTER TABLE "entries"
+    DROP COLUMN IF EXISTS "transfer_id";
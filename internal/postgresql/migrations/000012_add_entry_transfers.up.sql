@@ -0,0 +1,31 @@
+ALTER TABLE "entries"
+    ADD COLUMN "transfer_id" bigint;
+ALTER TABLE "entries"
+    ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "entries"
+    ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+-- transfer entries were created in the same transaction as their transfer, so they share its created_at
+UPDATE "entries" e
+SET "transfer_id" = t."id",
+    "description" = 'Transfer to account #' || t."to_account_id"
+FROM "transfers" t
+WHERE e."created_at" = t."created_at"
+  AND e."account_id" = t."from_account_id"
+  AND e."amount" = -t."amount"
+  AND e."pocket_id" IS NULL;
+
+UPDATE "entries" e
+SET "transfer_id" = t."id",
+    "description" = 'Transfer from account #' || t."from_account_id"
+FROM "transfers" t
+WHERE e."created_at" = t."created_at"
+  AND e."account_id" = t."to_account_id"
+  AND e."amount" = t."amount"
+  AND e."pocket_id" IS NULL;
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that booked the entry, null for deposits, withdrawals, pocket moves and loans';
@@ -0,0 +1,24 @@
+CREATE TABLE "statements"
+(
+    "id"              bigserial PRIMARY KEY,
+    "account_id"      bigint      NOT NULL,
+    "period_start"    timestamptz NOT NULL,
+    "period_end"      timestamptz NOT NULL,
+    "opening_balance" bigint      NOT NULL,
+    "closing_balance" bigint      NOT NULL,
+    "currency"        varchar     NOT NULL,
+    "file"            bytea       NOT NULL,
+    "emailed_at"      timestamptz,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "statements"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "statements"
+    ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+CREATE UNIQUE INDEX ON "statements" ("account_id", "period_start");
+
+COMMENT ON COLUMN "statements"."period_end" IS 'exclusive, the start of the next period';
+COMMENT ON COLUMN "statements"."file" IS 'rendered PDF statement';
+COMMENT ON COLUMN "statements"."emailed_at" IS 'when the statement was emailed to the account owner, null until then';
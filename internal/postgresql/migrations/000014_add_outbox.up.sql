@@ -0,0 +1,17 @@
+CREATE TABLE "outbox"
+(
+    "id"           bigserial PRIMARY KEY,
+    "event_type"   varchar     NOT NULL,
+    "aggregate_id" varchar     NOT NULL,
+    "payload"      jsonb       NOT NULL,
+    "attempts"     int         NOT NULL DEFAULT 0,
+    "last_error"   varchar     NOT NULL DEFAULT '',
+    "published_at" timestamptz,
+    "created_at"   timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."aggregate_id" IS 'id of the user, account or transfer the event is about';
+COMMENT ON COLUMN "outbox"."attempts" IS 'failed attempts to publish the event';
+COMMENT ON COLUMN "outbox"."published_at" IS 'when the event was handed to the message broker, null until then';
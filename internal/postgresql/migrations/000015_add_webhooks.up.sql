@@ -0,0 +1,40 @@
+CREATE TABLE "webhooks"
+(
+    "id"          bigserial PRIMARY KEY,
+    "owner"       varchar     NOT NULL,
+    "url"         varchar     NOT NULL,
+    "event_types" varchar[]   NOT NULL,
+    "secret"      varchar     NOT NULL,
+    "created_at"  timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries"
+(
+    "id"              bigserial PRIMARY KEY,
+    "webhook_id"      bigint      NOT NULL,
+    "event_id"        bigint      NOT NULL,
+    "event_type"      varchar     NOT NULL,
+    "payload"         jsonb       NOT NULL,
+    "status"          varchar     NOT NULL DEFAULT 'pending',
+    "attempts"        int         NOT NULL DEFAULT 0,
+    "response_status" int         NOT NULL DEFAULT 0,
+    "last_error"      varchar     NOT NULL DEFAULT '',
+    "delivered_at"    timestamptz,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhooks"
+    ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+ALTER TABLE "webhook_deliveries"
+    ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON DELETE CASCADE;
+ALTER TABLE "webhook_deliveries"
+    ADD FOREIGN KEY ("event_id") REFERENCES "outbox" ("id");
+
+CREATE INDEX ON "webhooks" ("owner");
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("webhook_id", "event_id");
+
+COMMENT ON COLUMN "webhooks"."event_types" IS 'event types the webhook is subscribed to, e.g. transfer.completed';
+COMMENT ON COLUMN "webhooks"."secret" IS 'key of the HMAC-SHA256 signature of the deliveries';
+COMMENT ON COLUMN "webhook_deliveries"."payload" IS 'request body, the event envelope';
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or failed once the retries ran out';
+COMMENT ON COLUMN "webhook_deliveries"."response_status" IS 'HTTP status of the last attempt, 0 when no response was received';
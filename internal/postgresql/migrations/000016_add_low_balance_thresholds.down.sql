@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "low_balance_thresholds";
@@ -0,0 +1,12 @@
+CREATE TABLE "low_balance_thresholds"
+(
+    "account_id" bigint PRIMARY KEY,
+    "amount"     bigint      NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "low_balance_thresholds"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+COMMENT ON COLUMN "low_balance_thresholds"."amount" IS 'the owner is notified when a debit takes the balance from at least this amount to below it';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "sessions_username_user_agent_idx";
+DROP TABLE IF EXISTS "notification_preferences";
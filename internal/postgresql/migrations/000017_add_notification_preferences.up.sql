@@ -0,0 +1,22 @@
+CREATE TABLE "notification_preferences"
+(
+    "username"          varchar     NOT NULL,
+    "notification_type" varchar     NOT NULL,
+    "channels"          varchar[]   NOT NULL,
+    "quiet_hours_start" int,
+    "quiet_hours_end"   int,
+    "timezone"          varchar     NOT NULL DEFAULT 'UTC',
+    "created_at"        timestamptz NOT NULL DEFAULT (now()),
+    "updated_at"        timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("username", "notification_type")
+);
+
+ALTER TABLE "notification_preferences"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "sessions" ("username", "user_agent");
+
+COMMENT ON COLUMN "notification_preferences"."notification_type" IS 'e.g. transfer_received, low_balance or new_device_login';
+COMMENT ON COLUMN "notification_preferences"."channels" IS 'channels the notification is sent on, empty to turn it off';
+COMMENT ON COLUMN "notification_preferences"."quiet_hours_start" IS 'minutes after midnight in timezone, notifications are held until quiet_hours_end';
+COMMENT ON COLUMN "notification_preferences"."timezone" IS 'IANA time zone of the quiet hours, e.g. Europe/Lisbon';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "push_devices";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "phone_number";
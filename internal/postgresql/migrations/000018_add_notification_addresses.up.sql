@@ -0,0 +1,20 @@
+ALTER TABLE "users" ADD COLUMN "phone_number" varchar NOT NULL DEFAULT '';
+
+CREATE TABLE "push_devices"
+(
+    "id"         bigserial PRIMARY KEY,
+    "username"   varchar     NOT NULL,
+    "token"      varchar     NOT NULL,
+    "platform"   varchar     NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "push_devices"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "push_devices" ("username");
+CREATE UNIQUE INDEX ON "push_devices" ("token");
+
+COMMENT ON COLUMN "users"."phone_number" IS 'E.164 number SMS notifications are sent to, empty if unknown';
+COMMENT ON COLUMN "push_devices"."token" IS 'registration token the push provider issued to the app on the device';
+COMMENT ON COLUMN "push_devices"."platform" IS 'android, ios or web';
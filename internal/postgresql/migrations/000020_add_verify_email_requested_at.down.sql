@@ -0,0 +1 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "verify_email_requested_at";
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "verify_email_requested_at" timestamptz;
+
+COMMENT ON COLUMN "users"."verify_email_requested_at" IS 'when the user last asked for a new verification email, null if never';
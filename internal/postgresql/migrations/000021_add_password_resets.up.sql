@@ -0,0 +1,18 @@
+CREATE TABLE "password_resets"
+(
+    "id"         bigserial PRIMARY KEY,
+    "username"   varchar     NOT NULL,
+    "token_hash" varchar     NOT NULL,
+    "is_used"    boolean     NOT NULL DEFAULT false,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "password_resets"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "password_resets" ("token_hash");
+
+CREATE INDEX ON "password_resets" ("username");
+
+COMMENT ON COLUMN "password_resets"."token_hash" IS 'hex SHA-256 of the token emailed to the user, the token itself is never stored';
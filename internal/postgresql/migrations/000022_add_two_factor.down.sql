@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "audit_logs" CASCADE;
+DROP TABLE IF EXISTS "recovery_codes" CASCADE;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "totp_last_used_step";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "totp_enabled_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "totp_secret";
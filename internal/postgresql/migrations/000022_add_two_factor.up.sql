@@ -0,0 +1,41 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar;
+ALTER TABLE "users" ADD COLUMN "totp_enabled_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN "totp_last_used_step" bigint;
+
+COMMENT ON COLUMN "users"."totp_secret" IS 'base32 TOTP secret, set on enrolment and only checked at login once totp_enabled_at is set';
+COMMENT ON COLUMN "users"."totp_enabled_at" IS 'when the user confirmed their TOTP secret, null if two-factor authentication is off';
+COMMENT ON COLUMN "users"."totp_last_used_step" IS 'time step of the last TOTP code accepted, so that a code can not be used twice';
+
+CREATE TABLE "recovery_codes"
+(
+    "id"         bigserial PRIMARY KEY,
+    "username"   varchar     NOT NULL,
+    "code_hash"  varchar     NOT NULL,
+    "used_at"    timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "recovery_codes"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "code_hash");
+
+COMMENT ON COLUMN "recovery_codes"."code_hash" IS 'hex SHA-256 of the recovery code shown to the user, the code itself is never stored';
+
+CREATE TABLE "audit_logs"
+(
+    "id"         bigserial PRIMARY KEY,
+    "actor"      varchar     NOT NULL,
+    "action"     varchar     NOT NULL,
+    "target"     varchar     NOT NULL,
+    "details"    jsonb       NOT NULL DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "audit_logs"
+    ADD FOREIGN KEY ("actor") REFERENCES "users" ("username");
+
+CREATE INDEX ON "audit_logs" ("target");
+
+COMMENT ON COLUMN "audit_logs"."actor" IS 'user who performed the action';
+COMMENT ON COLUMN "audit_logs"."target" IS 'username the action was performed on';
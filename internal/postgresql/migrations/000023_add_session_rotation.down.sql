@@ -0,0 +1,3 @@
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "rotated_at";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "family_id";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "parent_id";
@@ -0,0 +1,16 @@
+ALTER TABLE "sessions" ADD COLUMN "parent_id" uuid;
+ALTER TABLE "sessions" ADD COLUMN "family_id" uuid;
+ALTER TABLE "sessions" ADD COLUMN "rotated_at" timestamptz;
+
+-- sessions opened before rotation start their own family
+UPDATE "sessions" SET "family_id" = "id";
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions"
+    ADD FOREIGN KEY ("parent_id") REFERENCES "sessions" ("id");
+
+CREATE INDEX ON "sessions" ("family_id");
+
+COMMENT ON COLUMN "sessions"."parent_id" IS 'session whose refresh token was rotated into this one, null for the session opened at login';
+COMMENT ON COLUMN "sessions"."family_id" IS 'session opened at login that this one descends from through rotations';
+COMMENT ON COLUMN "sessions"."rotated_at" IS 'when the refresh token was exchanged for a new one, presenting it again revokes the family';
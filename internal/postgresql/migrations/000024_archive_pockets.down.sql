@@ -0,0 +1,7 @@
+ALTER TABLE "pockets" DROP CONSTRAINT IF EXISTS "pockets_balance_check";
+ALTER TABLE "pockets" DROP CONSTRAINT IF EXISTS "pockets_target_amount_check";
+
+DROP INDEX IF EXISTS "account_name_key";
+ALTER TABLE "pockets" ADD CONSTRAINT "account_name_key" UNIQUE ("account_id", "name");
+
+ALTER TABLE "pockets" DROP COLUMN IF EXISTS "archived_at";
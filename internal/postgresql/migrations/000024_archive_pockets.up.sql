@@ -0,0 +1,11 @@
+ALTER TABLE "pockets" ADD COLUMN "archived_at" timestamptz;
+
+-- entries booked against a pocket keep pointing at it, so pockets are archived instead of deleted and the name of an
+-- archived pocket can be used again
+ALTER TABLE "pockets" DROP CONSTRAINT "account_name_key";
+CREATE UNIQUE INDEX "account_name_key" ON "pockets" ("account_id", "name") WHERE "archived_at" IS NULL;
+
+ALTER TABLE "pockets" ADD CONSTRAINT "pockets_target_amount_check" CHECK ("target_amount" > 0);
+ALTER TABLE "pockets" ADD CONSTRAINT "pockets_balance_check" CHECK ("balance" >= 0);
+
+COMMENT ON COLUMN "pockets"."archived_at" IS 'when the pocket was deleted, archived pockets are empty and are not listed';
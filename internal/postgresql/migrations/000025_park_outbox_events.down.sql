@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "outbox_id_idx";
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+ALTER TABLE "outbox"
+    DROP COLUMN IF EXISTS "parked_at";
@@ -0,0 +1,7 @@
+ALTER TABLE "outbox"
+    ADD COLUMN "parked_at" timestamptz;
+
+DROP INDEX IF EXISTS "outbox_id_idx";
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL AND "parked_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."parked_at" IS 'when the relay gave up on the event after too many failed attempts, clearing it publishes the event again';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "login_challenge_attempts" CASCADE;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "totp_locked_until";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "totp_attempts";
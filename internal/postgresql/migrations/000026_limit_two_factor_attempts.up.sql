@@ -0,0 +1,20 @@
+ALTER TABLE "users" ADD COLUMN "totp_attempts" int NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN "totp_locked_until" timestamptz;
+
+COMMENT ON COLUMN "users"."totp_attempts" IS 'second factor attempts at login since the last accepted code';
+COMMENT ON COLUMN "users"."totp_locked_until" IS 'second factor login is refused until then after too many attempts, null if it never was';
+
+CREATE TABLE "login_challenge_attempts"
+(
+    "challenge_id" uuid PRIMARY KEY,
+    "username"     varchar     NOT NULL,
+    "attempts"     int         NOT NULL DEFAULT 1,
+    "expires_at"   timestamptz NOT NULL
+);
+
+ALTER TABLE "login_challenge_attempts"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "login_challenge_attempts" ("expires_at");
+
+COMMENT ON COLUMN "login_challenge_attempts"."expires_at" IS 'when the challenge expires, the row is of no use afterwards';
@@ -0,0 +1,4 @@
+ALTER TABLE "outbox"
+    ADD COLUMN "next_attempt_at" timestamptz NOT NULL DEFAULT (now());
+
+COMMENT ON COLUMN "outbox"."next_attempt_at" IS 'the relay leaves the event alone until then, it backs off after each failed attempt';
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "password_reset_requested_at" timestamptz;
+
+COMMENT ON COLUMN "users"."password_reset_requested_at" IS 'when a password reset token was last emailed to the user, null if never';
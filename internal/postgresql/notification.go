@@ -0,0 +1,117 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// NotificationRepository represents the repository used for interacting with the notification settings of users and accounts.
+type NotificationRepository struct {
+	q db.Store
+}
+
+// NewNotificationRepository instantiates the Notification repository.
+func NewNotificationRepository(connPool *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (notificationRepo *NotificationRepository) GetLowBalanceThreshold(ctx context.Context, accountID int64) (db.LowBalanceThreshold, error) {
+	threshold, err := notificationRepo.q.GetLowBalanceThreshold(ctx, accountID)
+	if err != nil {
+		return db.LowBalanceThreshold{}, internal.DBErrorToInternal(err)
+	}
+	return threshold, nil
+}
+
+func (notificationRepo *NotificationRepository) UpsertLowBalanceThreshold(ctx context.Context, arg db.UpsertLowBalanceThresholdParams) (db.LowBalanceThreshold, error) {
+	threshold, err := notificationRepo.q.UpsertLowBalanceThreshold(ctx, arg)
+	if err != nil {
+		return db.LowBalanceThreshold{}, internal.DBErrorToInternal(err)
+	}
+	return threshold, nil
+}
+
+func (notificationRepo *NotificationRepository) DeleteLowBalanceThreshold(ctx context.Context, accountID int64) error {
+	err := notificationRepo.q.DeleteLowBalanceThreshold(ctx, accountID)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+	return nil
+}
+
+func (notificationRepo *NotificationRepository) GetPreference(ctx context.Context, arg db.GetNotificationPreferenceParams) (db.NotificationPreference, error) {
+	preference, err := notificationRepo.q.GetNotificationPreference(ctx, arg)
+	if err != nil {
+		return db.NotificationPreference{}, internal.DBErrorToInternal(err)
+	}
+	return preference, nil
+}
+
+func (notificationRepo *NotificationRepository) ListPreferences(ctx context.Context, username string) ([]db.NotificationPreference, error) {
+	preferences, err := notificationRepo.q.ListNotificationPreferences(ctx, username)
+	if err != nil {
+		return []db.NotificationPreference{}, internal.DBErrorToInternal(err)
+	}
+	return preferences, nil
+}
+
+func (notificationRepo *NotificationRepository) UpsertPreference(ctx context.Context, arg db.UpsertNotificationPreferenceParams) (db.NotificationPreference, error) {
+	preference, err := notificationRepo.q.UpsertNotificationPreference(ctx, arg)
+	if err != nil {
+		return db.NotificationPreference{}, internal.DBErrorToInternal(err)
+	}
+	return preference, nil
+}
+
+func (notificationRepo *NotificationRepository) DeletePreference(ctx context.Context, arg db.DeleteNotificationPreferenceParams) error {
+	err := notificationRepo.q.DeleteNotificationPreference(ctx, arg)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+	return nil
+}
+
+func (notificationRepo *NotificationRepository) CreatePushDevice(ctx context.Context, arg db.CreatePushDeviceParams) (db.PushDevice, error) {
+	device, err := notificationRepo.q.CreatePushDevice(ctx, arg)
+	if err != nil {
+		return db.PushDevice{}, internal.DBErrorToInternal(err)
+	}
+	return device, nil
+}
+
+func (notificationRepo *NotificationRepository) GetPushDevice(ctx context.Context, id int64) (db.PushDevice, error) {
+	device, err := notificationRepo.q.GetPushDevice(ctx, id)
+	if err != nil {
+		return db.PushDevice{}, internal.DBErrorToInternal(err)
+	}
+	return device, nil
+}
+
+func (notificationRepo *NotificationRepository) ListPushDevices(ctx context.Context, username string) ([]db.PushDevice, error) {
+	devices, err := notificationRepo.q.ListPushDevices(ctx, username)
+	if err != nil {
+		return []db.PushDevice{}, internal.DBErrorToInternal(err)
+	}
+	return devices, nil
+}
+
+func (notificationRepo *NotificationRepository) DeletePushDevice(ctx context.Context, id int64) error {
+	err := notificationRepo.q.DeletePushDevice(ctx, id)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+	return nil
+}
+
+func (notificationRepo *NotificationRepository) DeletePushDeviceByToken(ctx context.Context, token string) error {
+	err := notificationRepo.q.DeletePushDeviceByToken(ctx, token)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// OutboxRepository represents the repository used for interacting with Outbox records.
+type OutboxRepository struct {
+	q db.Store
+}
+
+// NewOutboxRepository instantiates the Outbox repository.
+func NewOutboxRepository(connPool *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (outboxRepo *OutboxRepository) Relay(ctx context.Context, arg db.RelayOutboxTxParams) (db.RelayOutboxTxResult, error) {
+	result, err := outboxRepo.q.RelayOutboxTx(ctx, arg)
+	if err != nil {
+		return result, internal.DBErrorToInternal(err)
+	}
+	return result, nil
+}
+
+func (outboxRepo *OutboxRepository) ListParked(ctx context.Context, arg db.ListParkedOutboxEventsParams) ([]db.Outbox, error) {
+	events, err := outboxRepo.q.ListParkedOutboxEvents(ctx, arg)
+	if err != nil {
+		return []db.Outbox{}, internal.DBErrorToInternal(err)
+	}
+	return events, nil
+}
+
+func (outboxRepo *OutboxRepository) Requeue(ctx context.Context, id int64) (db.Outbox, error) {
+	event, err := outboxRepo.q.RequeueOutboxEvent(ctx, id)
+	if err != nil {
+		return db.Outbox{}, internal.DBErrorToInternal(err)
+	}
+	return event, nil
+}
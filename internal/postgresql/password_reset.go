@@ -0,0 +1,43 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// PasswordResetRepository represents the repository used for interacting with PasswordReset records.
+type PasswordResetRepository struct {
+	q db.Store
+}
+
+// NewPasswordResetRepository instantiates the PasswordReset repository.
+func NewPasswordResetRepository(connPool *pgxpool.Pool) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+// Create creates a password reset token, the unused tokens sent to the user before expire. It returns
+// internal.ErrNoRows, leaving the earlier tokens alone, if a token was created for the user after RequestedBefore.
+func (passwordResetRepo *PasswordResetRepository) Create(ctx context.Context, arg db.CreatePasswordResetTxParams) (db.PasswordReset, error) {
+	passwordReset, err := passwordResetRepo.q.CreatePasswordResetTx(ctx, arg)
+	if err != nil {
+		return db.PasswordReset{}, internal.DBErrorToInternal(err)
+	}
+
+	return passwordReset, nil
+}
+
+// Reset uses the token with the given hash, sets the password of its user and blocks all their sessions. It returns
+// internal.ErrNoRows if the token doesn't exist, was used or expired.
+func (passwordResetRepo *PasswordResetRepository) Reset(ctx context.Context, arg db.ResetPasswordTxParams) (db.User, error) {
+	user, err := passwordResetRepo.q.ResetPasswordTx(ctx, arg)
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+
+	return user, nil
+}
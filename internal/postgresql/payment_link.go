@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// PaymentLinkRepository represents the repository used for interacting with PaymentLink records.
+type PaymentLinkRepository struct {
+	q db.Store
+}
+
+// NewPaymentLinkRepository instantiates the PaymentLink repository.
+func NewPaymentLinkRepository(connPool *pgxpool.Pool) *PaymentLinkRepository {
+	return &PaymentLinkRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (paymentLinkRepo *PaymentLinkRepository) Create(ctx context.Context, arg db.CreatePaymentLinkParams) (db.PaymentLink, error) {
+	paymentLink, err := paymentLinkRepo.q.CreatePaymentLink(ctx, arg)
+	if err != nil {
+		return db.PaymentLink{}, internal.DBErrorToInternal(err)
+	}
+	return paymentLink, nil
+}
+
+func (paymentLinkRepo *PaymentLinkRepository) Get(ctx context.Context, id int64) (db.PaymentLink, error) {
+	paymentLink, err := paymentLinkRepo.q.GetPaymentLink(ctx, id)
+	if err != nil {
+		return db.PaymentLink{}, internal.DBErrorToInternal(err)
+	}
+	return paymentLink, nil
+}
+
+func (paymentLinkRepo *PaymentLinkRepository) ListByOwner(ctx context.Context, arg db.ListPaymentLinksByOwnerParams) ([]db.PaymentLink, error) {
+	paymentLinks, err := paymentLinkRepo.q.ListPaymentLinksByOwner(ctx, arg)
+	if err != nil {
+		return []db.PaymentLink{}, internal.DBErrorToInternal(err)
+	}
+	return paymentLinks, nil
+}
+
+func (paymentLinkRepo *PaymentLinkRepository) ListRedemptions(ctx context.Context, paymentLinkID int64) ([]db.PaymentLinkRedemption, error) {
+	redemptions, err := paymentLinkRepo.q.ListPaymentLinkRedemptions(ctx, paymentLinkID)
+	if err != nil {
+		return []db.PaymentLinkRedemption{}, internal.DBErrorToInternal(err)
+	}
+	return redemptions, nil
+}
+
+func (paymentLinkRepo *PaymentLinkRepository) RedeemTx(ctx context.Context, arg db.RedeemPaymentLinkTxParams) (db.RedeemPaymentLinkTxResult, error) {
+	res, err := paymentLinkRepo.q.RedeemPaymentLinkTx(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrPaymentLinkExpired):
+			return db.RedeemPaymentLinkTxResult{}, fmt.Errorf("%w: %s", internal.ErrPaymentLinkExpired, err.Error())
+		case errors.Is(err, db.ErrPaymentLinkUsed):
+			return db.RedeemPaymentLinkTxResult{}, fmt.Errorf("%w: %s", internal.ErrPaymentLinkUsed, err.Error())
+		default:
+			return db.RedeemPaymentLinkTxResult{}, internal.DBErrorToInternal(err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// PaymentRequestRepository represents the repository used for interacting with PaymentRequest records.
+type PaymentRequestRepository struct {
+	q db.Store
+}
+
+// NewPaymentRequestRepository instantiates the PaymentRequest repository.
+func NewPaymentRequestRepository(connPool *pgxpool.Pool) *PaymentRequestRepository {
+	return &PaymentRequestRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (paymentRequestRepo *PaymentRequestRepository) CreateTx(ctx context.Context, arg db.CreatePaymentRequestParams) (db.PaymentRequest, error) {
+	res, err := paymentRequestRepo.q.CreatePaymentRequestTx(ctx, arg)
+	if err != nil {
+		return db.PaymentRequest{}, internal.DBErrorToInternal(err)
+	}
+	return res.PaymentRequest, nil
+}
+
+func (paymentRequestRepo *PaymentRequestRepository) Get(ctx context.Context, id int64) (db.PaymentRequest, error) {
+	paymentRequest, err := paymentRequestRepo.q.GetPaymentRequest(ctx, id)
+	if err != nil {
+		return db.PaymentRequest{}, internal.DBErrorToInternal(err)
+	}
+	return paymentRequest, nil
+}
+
+func (paymentRequestRepo *PaymentRequestRepository) ListIncoming(ctx context.Context, arg db.ListIncomingPaymentRequestsParams) ([]db.PaymentRequest, error) {
+	paymentRequests, err := paymentRequestRepo.q.ListIncomingPaymentRequests(ctx, arg)
+	if err != nil {
+		return []db.PaymentRequest{}, internal.DBErrorToInternal(err)
+	}
+	return paymentRequests, nil
+}
+
+func (paymentRequestRepo *PaymentRequestRepository) ListOutgoing(ctx context.Context, arg db.ListOutgoingPaymentRequestsParams) ([]db.PaymentRequest, error) {
+	paymentRequests, err := paymentRequestRepo.q.ListOutgoingPaymentRequests(ctx, arg)
+	if err != nil {
+		return []db.PaymentRequest{}, internal.DBErrorToInternal(err)
+	}
+	return paymentRequests, nil
+}
+
+func (paymentRequestRepo *PaymentRequestRepository) ListExpired(ctx context.Context, now time.Time) ([]db.PaymentRequest, error) {
+	paymentRequests, err := paymentRequestRepo.q.ListExpiredPaymentRequests(ctx, now)
+	if err != nil {
+		return []db.PaymentRequest{}, internal.DBErrorToInternal(err)
+	}
+	return paymentRequests, nil
+}
+
+func (paymentRequestRepo *PaymentRequestRepository) AcceptTx(ctx context.Context, arg db.AcceptPaymentRequestTxParams) (db.AcceptPaymentRequestTxResult, error) {
+	res, err := paymentRequestRepo.q.AcceptPaymentRequestTx(ctx, arg)
+	if err != nil {
+		return db.AcceptPaymentRequestTxResult{}, paymentRequestErrorToInternal(err)
+	}
+	return res, nil
+}
+
+func (paymentRequestRepo *PaymentRequestRepository) UpdateStatusTx(ctx context.Context, arg db.UpdatePaymentRequestStatusTxParams) (db.PaymentRequest, error) {
+	paymentRequest, err := paymentRequestRepo.q.UpdatePaymentRequestStatusTx(ctx, arg)
+	if err != nil {
+		return db.PaymentRequest{}, paymentRequestErrorToInternal(err)
+	}
+	return paymentRequest, nil
+}
+
+func paymentRequestErrorToInternal(err error) error {
+	switch {
+	case errors.Is(err, db.ErrPaymentRequestNotPending):
+		return fmt.Errorf("%w: %s", internal.ErrPaymentRequestNotPending, err.Error())
+	case errors.Is(err, db.ErrPaymentRequestExpired):
+		return fmt.Errorf("%w: %s", internal.ErrPaymentRequestExpired, err.Error())
+	default:
+		return internal.DBErrorToInternal(err)
+	}
+}
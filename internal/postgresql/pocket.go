@@ -0,0 +1,66 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// PocketRepository represents the repository used for interacting with Pocket records.
+type PocketRepository struct {
+	q db.Store
+}
+
+// NewPocketRepository instantiates the Pocket repository.
+func NewPocketRepository(connPool *pgxpool.Pool) *PocketRepository {
+	return &PocketRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (pocketRepo *PocketRepository) Create(ctx context.Context, arg db.CreatePocketParams) (db.Pocket, error) {
+	pocket, err := pocketRepo.q.CreatePocket(ctx, arg)
+	if err != nil {
+		return db.Pocket{}, internal.DBErrorToInternal(err)
+	}
+	return pocket, nil
+}
+
+func (pocketRepo *PocketRepository) Get(ctx context.Context, id int64) (db.Pocket, error) {
+	pocket, err := pocketRepo.q.GetPocket(ctx, id)
+	if err != nil {
+		return db.Pocket{}, internal.DBErrorToInternal(err)
+	}
+	return pocket, nil
+}
+
+func (pocketRepo *PocketRepository) List(ctx context.Context, accountID int64) ([]db.Pocket, error) {
+	pockets, err := pocketRepo.q.ListPockets(ctx, accountID)
+	if err != nil {
+		return []db.Pocket{}, internal.DBErrorToInternal(err)
+	}
+	return pockets, nil
+}
+
+func (pocketRepo *PocketRepository) Archive(ctx context.Context, id int64) (db.Pocket, error) {
+	pocket, err := pocketRepo.q.ArchivePocket(ctx, id)
+	if err != nil {
+		return db.Pocket{}, internal.DBErrorToInternal(err)
+	}
+	return pocket, nil
+}
+
+func (pocketRepo *PocketRepository) TransferTx(ctx context.Context, arg db.PocketTransferTxParams) (db.PocketTransferTxResult, error) {
+	res, err := pocketRepo.q.PocketTransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			return db.PocketTransferTxResult{}, fmt.Errorf("%w: %s", internal.ErrInsufficientFunds, err.Error())
+		}
+		return db.PocketTransferTxResult{}, internal.DBErrorToInternal(err)
+	}
+	return res, nil
+}
@@ -36,7 +36,11 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
--- name: DeleteAccount :exec
+-- name: DeleteAccount :one
+-- the account is only deleted if it and its pockets are still empty, a deposit made since the balances were read keeps it
 DELETE
 FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1
+  AND balance = 0
+  AND NOT EXISTS (SELECT 1 FROM pockets WHERE pockets.account_id = accounts.id AND pockets.balance <> 0)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_logs (actor,
+                        action,
+                        target,
+                        details)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_logs
+WHERE target = $1
+ORDER BY id;
@@ -0,0 +1,29 @@
+-- name: CreateBill :one
+INSERT INTO bills (organizer,
+                   to_account_id,
+                   total,
+                   currency,
+                   description,
+                   split_type)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetBill :one
+SELECT *
+FROM bills
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListBillsByOrganizer :many
+SELECT *
+FROM bills
+WHERE organizer = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: MarkBillReminded :one
+UPDATE bills
+SET reminded_at = now()
+WHERE id = sqlc.arg(id)
+  AND (reminded_at IS NULL OR reminded_at <= sqlc.arg(reminded_before))
+RETURNING *;
@@ -1,7 +1,10 @@
 -- name: CreateEntry :one
 INSERT INTO entries (account_id,
-                     amount)
-VALUES ($1, $2)
+                     amount,
+                     pocket_id,
+                     transfer_id,
+                     description)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetEntry :one
@@ -15,4 +18,4 @@ SELECT *
 FROM entries
 WHERE account_id = $1
 ORDER BY id
-LIMIT $2 OFFSET $3;
\ No newline at end of file
+LIMIT $2 OFFSET $3;
@@ -0,0 +1,67 @@
+-- name: CreateLoan :one
+INSERT INTO loans (account_id,
+                   principal,
+                   currency,
+                   term_months,
+                   schedule_type)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetLoan :one
+SELECT *
+FROM loans
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetLoanForUpdate :one
+SELECT *
+FROM loans
+WHERE id = $1
+LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListLoansByOwner :many
+SELECT loans.*
+FROM loans
+         JOIN accounts ON accounts.id = loans.account_id
+WHERE accounts.owner = $1
+ORDER BY loans.id
+LIMIT $2 OFFSET $3;
+
+-- name: ListLoansByStatus :many
+SELECT *
+FROM loans
+WHERE status = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: DisburseLoan :one
+UPDATE loans
+SET status                = 'active',
+    annual_rate_bps       = sqlc.arg(annual_rate_bps),
+    late_fee              = sqlc.arg(late_fee),
+    outstanding_principal = principal,
+    reviewed_by           = sqlc.arg(reviewed_by),
+    disbursement_entry_id = sqlc.arg(disbursement_entry_id),
+    disbursed_at          = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: RejectLoan :one
+UPDATE loans
+SET status      = 'rejected',
+    reviewed_by = sqlc.arg(reviewed_by)
+WHERE id = sqlc.arg(id)
+  AND status = 'pending'
+RETURNING *;
+
+-- name: AddLoanOutstandingPrincipal :one
+UPDATE loans
+SET outstanding_principal = outstanding_principal + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateLoanStatus :one
+UPDATE loans
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,48 @@
+-- name: CreateLoanInstalment :one
+INSERT INTO loan_instalments (loan_id,
+                              number,
+                              due_date,
+                              principal,
+                              interest)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetLoanInstalmentForUpdate :one
+SELECT *
+FROM loan_instalments
+WHERE id = $1
+LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListLoanInstalments :many
+SELECT *
+FROM loan_instalments
+WHERE loan_id = $1
+ORDER BY number;
+
+-- name: ListDueLoanInstalments :many
+SELECT *
+FROM loan_instalments
+WHERE status IN ('pending', 'overdue')
+  AND due_date <= $1
+ORDER BY due_date, id;
+
+-- name: CountUnpaidLoanInstalments :one
+SELECT count(*)
+FROM loan_instalments
+WHERE loan_id = $1
+  AND status <> 'paid';
+
+-- name: MarkLoanInstalmentPaid :one
+UPDATE loan_instalments
+SET status   = 'paid',
+    entry_id = sqlc.arg(entry_id),
+    paid_at  = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: MarkLoanInstalmentOverdue :one
+UPDATE loan_instalments
+SET status   = 'overdue',
+    late_fee = late_fee + sqlc.arg(late_fee)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,13 @@
+-- name: CountLoginChallengeAttempt :one
+-- CountLoginChallengeAttempt counts an attempt at a second factor with the login challenge and returns the attempts
+-- made with it so far, this one included
+INSERT INTO login_challenge_attempts (challenge_id,
+                                      username,
+                                      expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (challenge_id) DO UPDATE SET attempts = login_challenge_attempts.attempts + 1
+RETURNING attempts;
+
+-- name: DeleteExpiredLoginChallengeAttempts :exec
+DELETE FROM login_challenge_attempts
+WHERE expires_at < now();
@@ -0,0 +1,19 @@
+-- name: GetLowBalanceThreshold :one
+SELECT *
+FROM low_balance_thresholds
+WHERE account_id = $1
+LIMIT 1;
+
+-- name: UpsertLowBalanceThreshold :one
+INSERT INTO low_balance_thresholds (account_id,
+                                    amount)
+VALUES ($1, $2)
+ON CONFLICT (account_id) DO UPDATE
+    SET amount     = excluded.amount,
+        updated_at = now()
+RETURNING *;
+
+-- name: DeleteLowBalanceThreshold :exec
+DELETE
+FROM low_balance_thresholds
+WHERE account_id = $1;
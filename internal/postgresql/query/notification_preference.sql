@@ -0,0 +1,34 @@
+-- name: GetNotificationPreference :one
+SELECT *
+FROM notification_preferences
+WHERE username = $1
+  AND notification_type = $2
+LIMIT 1;
+
+-- name: ListNotificationPreferences :many
+SELECT *
+FROM notification_preferences
+WHERE username = $1
+ORDER BY notification_type;
+
+-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (username,
+                                      notification_type,
+                                      channels,
+                                      quiet_hours_start,
+                                      quiet_hours_end,
+                                      timezone)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (username, notification_type) DO UPDATE
+    SET channels          = excluded.channels,
+        quiet_hours_start = excluded.quiet_hours_start,
+        quiet_hours_end   = excluded.quiet_hours_end,
+        timezone          = excluded.timezone,
+        updated_at        = now()
+RETURNING *;
+
+-- name: DeleteNotificationPreference :exec
+DELETE
+FROM notification_preferences
+WHERE username = $1
+  AND notification_type = $2;
@@ -0,0 +1,56 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox (event_type,
+                    aggregate_id,
+                    payload)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetOutboxEvent :one
+SELECT *
+FROM outbox
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListPendingOutboxEvents :many
+SELECT *
+FROM outbox
+WHERE published_at IS NULL
+  AND parked_at IS NULL
+  AND next_attempt_at <= now()
+ORDER BY id
+LIMIT $1 FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :one
+-- MarkOutboxEventFailed records a failed attempt to publish the event, which is retried at next_attempt_at. The event
+-- is parked if park is set or it reached max_attempts
+UPDATE outbox
+SET attempts        = attempts + 1,
+    last_error      = sqlc.arg(last_error),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    parked_at       = CASE WHEN sqlc.arg(park)::bool OR attempts + 1 >= sqlc.arg(max_attempts)::int THEN now() END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListParkedOutboxEvents :many
+SELECT *
+FROM outbox
+WHERE published_at IS NULL
+  AND parked_at IS NOT NULL
+ORDER BY id
+LIMIT $1 OFFSET $2;
+
+-- name: RequeueOutboxEvent :one
+-- RequeueOutboxEvent hands a parked event back to the relay with a fresh count of attempts, it returns no rows if
+-- the event isn't parked
+UPDATE outbox
+SET parked_at       = NULL,
+    attempts        = 0,
+    next_attempt_at = now()
+WHERE id = $1
+  AND parked_at IS NOT NULL
+RETURNING *;
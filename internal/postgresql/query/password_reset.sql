@@ -0,0 +1,23 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (username,
+                             token_hash,
+                             expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ExpirePasswordResets :exec
+-- ExpirePasswordResets makes the unused tokens of a user expire, so that only the token sent last can be used
+UPDATE password_resets
+SET expires_at = now()
+WHERE username = $1
+  AND is_used = FALSE
+  AND expires_at > now();
+
+-- name: UsePasswordReset :one
+-- UsePasswordReset marks the token used, unless it was used before or expired
+UPDATE password_resets
+SET is_used = TRUE
+WHERE token_hash = $1
+  AND is_used = FALSE
+  AND expires_at > now()
+RETURNING *;
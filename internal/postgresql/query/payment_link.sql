@@ -0,0 +1,29 @@
+-- name: CreatePaymentLink :one
+INSERT INTO payment_links (owner,
+                           to_account_id,
+                           amount,
+                           currency,
+                           reference,
+                           single_use,
+                           expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetPaymentLink :one
+SELECT *
+FROM payment_links
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetPaymentLinkForUpdate :one
+SELECT *
+FROM payment_links
+WHERE id = $1
+LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListPaymentLinksByOwner :many
+SELECT *
+FROM payment_links
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
@@ -0,0 +1,17 @@
+-- name: CreatePaymentLinkRedemption :one
+INSERT INTO payment_link_redemptions (payment_link_id,
+                                      payer,
+                                      transfer_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CountPaymentLinkRedemptions :one
+SELECT count(*)
+FROM payment_link_redemptions
+WHERE payment_link_id = $1;
+
+-- name: ListPaymentLinkRedemptions :many
+SELECT *
+FROM payment_link_redemptions
+WHERE payment_link_id = $1
+ORDER BY id;
@@ -0,0 +1,60 @@
+-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (requester,
+                              payer,
+                              to_account_id,
+                              amount,
+                              currency,
+                              message,
+                              expires_at,
+                              bill_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetPaymentRequest :one
+SELECT *
+FROM payment_requests
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetPaymentRequestForUpdate :one
+SELECT *
+FROM payment_requests
+WHERE id = $1
+LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListIncomingPaymentRequests :many
+SELECT *
+FROM payment_requests
+WHERE payer = sqlc.arg(payer)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+ORDER BY id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListOutgoingPaymentRequests :many
+SELECT *
+FROM payment_requests
+WHERE requester = sqlc.arg(requester)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+ORDER BY id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListBillPaymentRequests :many
+SELECT *
+FROM payment_requests
+WHERE bill_id = $1
+ORDER BY id;
+
+-- name: ListExpiredPaymentRequests :many
+SELECT *
+FROM payment_requests
+WHERE status = 'pending'
+  AND expires_at <= $1
+ORDER BY id;
+
+-- name: UpdatePaymentRequestStatus :one
+UPDATE payment_requests
+SET status      = sqlc.arg(status),
+    transfer_id = sqlc.narg(transfer_id),
+    updated_at  = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
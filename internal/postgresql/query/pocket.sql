@@ -0,0 +1,43 @@
+-- name: CreatePocket :one
+INSERT INTO pockets (account_id,
+                     name,
+                     target_amount,
+                     target_date)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetPocket :one
+SELECT *
+FROM pockets
+WHERE id = $1
+  AND archived_at IS NULL
+LIMIT 1;
+
+-- name: GetPocketForUpdate :one
+SELECT *
+FROM pockets
+WHERE id = $1
+  AND archived_at IS NULL
+LIMIT 1 FOR NO KEY UPDATE;
+
+-- name: ListPockets :many
+SELECT *
+FROM pockets
+WHERE account_id = $1
+  AND archived_at IS NULL
+ORDER BY id;
+
+-- name: AddPocketBalance :one
+UPDATE pockets
+SET balance = balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ArchivePocket :one
+-- the pocket is only archived if it is still empty, a deposit made since its balance was read keeps it
+UPDATE pockets
+SET archived_at = now()
+WHERE id = $1
+  AND archived_at IS NULL
+  AND balance = 0
+RETURNING *;
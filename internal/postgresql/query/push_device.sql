@@ -0,0 +1,32 @@
+-- name: CreatePushDevice :one
+-- a token registered again, e.g. after another user logged in on the device, moves to the new user
+INSERT INTO push_devices (username,
+                          token,
+                          platform)
+VALUES ($1, $2, $3)
+ON CONFLICT (token) DO UPDATE
+    SET username = excluded.username,
+        platform = excluded.platform
+RETURNING *;
+
+-- name: GetPushDevice :one
+SELECT *
+FROM push_devices
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListPushDevices :many
+SELECT *
+FROM push_devices
+WHERE username = $1
+ORDER BY id;
+
+-- name: DeletePushDevice :exec
+DELETE
+FROM push_devices
+WHERE id = $1;
+
+-- name: DeletePushDeviceByToken :exec
+DELETE
+FROM push_devices
+WHERE token = $1;
@@ -0,0 +1,19 @@
+-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (username,
+                            code_hash)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = $1;
+
+-- name: UseRecoveryCode :one
+-- UseRecoveryCode marks the recovery code of the user used, it returns no rows if there is no such code or it was
+-- used before
+UPDATE recovery_codes
+SET used_at = now()
+WHERE username = sqlc.arg(username)
+  AND code_hash = sqlc.arg(code_hash)
+  AND used_at IS NULL
+RETURNING *;
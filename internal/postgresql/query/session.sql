@@ -6,11 +6,60 @@ INSERT INTO sessions (
     user_agent,
     client_ip,
     is_blocked,
-    expires_at
+    expires_at,
+    family_id,
+    parent_id
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7
+             $1, $2, $3, $4, $5, $6, $7, $8, $9
          ) RETURNING *;
 
 -- name: GetSession :one
 SELECT * FROM sessions
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: GetSessionDeviceCount :one
+-- counts the sessions of a user, and those opened with the given user agent
+SELECT count(*) AS sessions,
+       count(*) FILTER (WHERE user_agent = sqlc.arg(user_agent)) AS device_sessions
+FROM sessions
+WHERE username = sqlc.arg(username);
+
+-- name: BlockUserSessions :exec
+-- BlockUserSessions blocks every session of a user, their refresh tokens can't be used anymore
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE username = $1
+  AND is_blocked = FALSE;
+
+-- name: ListActiveSessions :many
+-- ListActiveSessions lists the sessions of a user whose refresh tokens can still be used, newest first
+SELECT * FROM sessions
+WHERE username = $1
+  AND is_blocked = FALSE
+  AND rotated_at IS NULL
+  AND expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: BlockSessionFamily :exec
+-- BlockSessionFamily blocks a session opened at login and every session rotated from it
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE family_id = $1
+  AND is_blocked = FALSE;
+
+-- name: BlockOtherUserSessions :exec
+-- BlockOtherUserSessions blocks every session of a user except the family of the one they keep
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE username = sqlc.arg(username)
+  AND family_id <> sqlc.arg(keep_family_id)
+  AND is_blocked = FALSE;
+
+-- name: RotateSession :one
+-- RotateSession marks the refresh token of a session exchanged, no rows are returned when it already was
+UPDATE sessions
+SET rotated_at = now()
+WHERE id = $1
+  AND rotated_at IS NULL
+  AND is_blocked = FALSE
+RETURNING *;
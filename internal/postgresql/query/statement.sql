@@ -0,0 +1,91 @@
+-- name: GetStatementSummary :one
+SELECT (a.balance - COALESCE(SUM(e.amount), 0))::bigint AS opening_balance,
+       (a.balance - COALESCE(SUM(e.amount) FILTER (WHERE e.created_at >= sqlc.arg(end_time)), 0))::bigint AS closing_balance,
+       COUNT(e.id) FILTER (WHERE e.amount > 0 AND e.created_at < sqlc.arg(end_time)) AS credit_count,
+       COALESCE(SUM(e.amount) FILTER (WHERE e.amount > 0 AND e.created_at < sqlc.arg(end_time)), 0)::bigint AS credit_total,
+       COUNT(e.id) FILTER (WHERE e.amount < 0 AND e.created_at < sqlc.arg(end_time)) AS debit_count,
+       COALESCE(-SUM(e.amount) FILTER (WHERE e.amount < 0 AND e.created_at < sqlc.arg(end_time)), 0)::bigint AS debit_total
+FROM accounts a
+         LEFT JOIN entries e ON e.account_id = a.id AND e.pocket_id IS NULL AND e.created_at >= sqlc.arg(start_time)
+WHERE a.id = sqlc.arg(account_id)
+GROUP BY a.id;
+
+-- name: ListStatementEntries :many
+SELECT e.id,
+       e.amount,
+       e.created_at,
+       e.description,
+       e.transfer_id,
+       c.id    AS counterparty_account_id,
+       c.owner AS counterparty_owner
+FROM entries e
+         LEFT JOIN transfers t ON t.id = e.transfer_id
+         LEFT JOIN accounts c ON c.id = CASE WHEN e.amount < 0 THEN t.to_account_id ELSE t.from_account_id END
+WHERE e.account_id = sqlc.arg(account_id)
+  AND e.pocket_id IS NULL
+  AND e.created_at >= sqlc.arg(start_time)
+  AND e.created_at < sqlc.arg(end_time)
+ORDER BY e.created_at, e.id;
+
+-- name: ListStatementAccounts :many
+SELECT a.*
+FROM accounts a
+WHERE a.id > sqlc.arg(after_id)
+  AND a.created_at < sqlc.arg(end_time)
+  AND (EXISTS (SELECT 1
+               FROM entries e
+               WHERE e.account_id = a.id
+                 AND e.pocket_id IS NULL
+                 AND e.created_at >= sqlc.arg(start_time)
+                 AND e.created_at < sqlc.arg(end_time))
+    OR a.balance - (SELECT COALESCE(SUM(e.amount), 0)
+                    FROM entries e
+                    WHERE e.account_id = a.id
+                      AND e.pocket_id IS NULL
+                      AND e.created_at >= sqlc.arg(end_time)) <> 0)
+ORDER BY a.id
+LIMIT sqlc.arg(page_size);
+
+-- name: CreateStatement :one
+INSERT INTO statements (account_id,
+                        period_start,
+                        period_end,
+                        opening_balance,
+                        closing_balance,
+                        currency,
+                        file)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetStatement :one
+SELECT *
+FROM statements
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetStatementByPeriod :one
+SELECT *
+FROM statements
+WHERE account_id = $1
+  AND period_start = $2
+LIMIT 1;
+
+-- name: ListStatements :many
+SELECT id,
+       account_id,
+       period_start,
+       period_end,
+       opening_balance,
+       closing_balance,
+       currency,
+       emailed_at,
+       created_at
+FROM statements
+WHERE account_id = $1
+ORDER BY period_start DESC
+LIMIT $2 OFFSET $3;
+
+-- name: MarkStatementEmailed :exec
+UPDATE statements
+SET emailed_at = now()
+WHERE id = $1;
@@ -17,7 +17,96 @@ SET
   password_changed_at = COALESCE(sqlc.narg(password_changed_at), password_changed_at),
   full_name = COALESCE(sqlc.narg(full_name), full_name),
   email = COALESCE(sqlc.narg(email), email),
-  is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified)
+  is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified),
+  phone_number = COALESCE(sqlc.narg(phone_number), phone_number),
+  locale = COALESCE(sqlc.narg(locale), locale)
 WHERE
   username = sqlc.arg(username)
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: RequestVerifyEmail :one
+-- RequestVerifyEmail records that an unverified user asked for a new verification email, unless they signed up or
+-- asked for one after requested_before
+UPDATE users
+SET verify_email_requested_at = now()
+WHERE username = sqlc.arg(username)
+  AND is_email_verified = FALSE
+  AND COALESCE(verify_email_requested_at, created_at) <= sqlc.arg(requested_before)
+RETURNING *;
+
+-- name: RequestPasswordReset :one
+-- RequestPasswordReset records that a password reset token is emailed to the user, unless one was emailed to them
+-- after requested_before
+UPDATE users
+SET password_reset_requested_at = now()
+WHERE username = sqlc.arg(username)
+  AND (password_reset_requested_at IS NULL OR password_reset_requested_at <= sqlc.arg(requested_before))
+RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: SetTOTPSecret :one
+-- SetTOTPSecret stores the secret of a TOTP enrolment, replacing an unconfirmed one. It returns no rows if two-factor
+-- authentication is already on
+UPDATE users
+SET totp_secret = sqlc.arg(totp_secret),
+    totp_last_used_step = NULL
+WHERE username = sqlc.arg(username)
+  AND totp_enabled_at IS NULL
+RETURNING *;
+
+-- name: EnableTOTP :one
+-- EnableTOTP turns two-factor authentication on once the user confirmed their secret with the code of used_step
+UPDATE users
+SET totp_enabled_at = now(),
+    totp_last_used_step = sqlc.arg(used_step)
+WHERE username = sqlc.arg(username)
+  AND totp_secret IS NOT NULL
+  AND totp_enabled_at IS NULL
+RETURNING *;
+
+-- name: UseTOTPStep :one
+-- UseTOTPStep records that the code of used_step was accepted. It returns no rows if a code of that step or a later
+-- one was already used, so that codes can't be replayed
+UPDATE users
+SET totp_last_used_step = sqlc.arg(used_step)
+WHERE username = sqlc.arg(username)
+  AND totp_enabled_at IS NOT NULL
+  AND COALESCE(totp_last_used_step, -1) < sqlc.arg(used_step)
+RETURNING *;
+
+-- name: CountTOTPAttempt :one
+-- CountTOTPAttempt counts a second factor attempt of the user, locking second factor login until locked_until once
+-- max_attempts are reached. It returns no rows while the user is locked
+UPDATE users
+SET totp_attempts     = totp_attempts + 1,
+    totp_locked_until = CASE
+                            WHEN totp_attempts + 1 >= sqlc.arg(max_attempts)::int THEN sqlc.arg(locked_until)::timestamptz
+                        END
+WHERE username = sqlc.arg(username)
+  AND (totp_locked_until IS NULL OR totp_locked_until <= now())
+RETURNING *;
+
+-- name: ClearTOTPAttempts :exec
+UPDATE users
+SET totp_attempts     = 0,
+    totp_locked_until = NULL
+WHERE username = $1;
+
+-- name: ResetTOTP :one
+UPDATE users
+SET totp_secret = NULL,
+    totp_enabled_at = NULL,
+    totp_last_used_step = NULL,
+    totp_attempts = 0,
+    totp_locked_until = NULL
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = sqlc.arg(role)
+WHERE username = sqlc.arg(username)
+RETURNING *;
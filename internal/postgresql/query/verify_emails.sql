@@ -16,4 +16,16 @@ WHERE
     AND secret_code = @secret_code
     AND is_used = FALSE
     AND expired_at > now()
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetVerifyEmail :one
+SELECT * FROM verify_emails
+WHERE id = $1 LIMIT 1;
+
+-- name: ExpireVerifyEmails :exec
+-- ExpireVerifyEmails makes the unused codes of a user expire, so that only the code sent last can be used
+UPDATE verify_emails
+SET expired_at = now()
+WHERE username = $1
+  AND is_used = FALSE
+  AND expired_at > now();
@@ -0,0 +1,71 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (owner,
+                      url,
+                      event_types,
+                      secret)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetWebhook :one
+SELECT *
+FROM webhooks
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListWebhooks :many
+SELECT *
+FROM webhooks
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: ListWebhooksForEvent :many
+SELECT *
+FROM webhooks
+WHERE owner = ANY (sqlc.arg(owners)::varchar[])
+  AND sqlc.arg(event_type)::varchar = ANY (event_types)
+ORDER BY id;
+
+-- name: DeleteWebhook :exec
+DELETE
+FROM webhooks
+WHERE id = $1;
+
+-- name: CreateWebhookDelivery :one
+-- an event handled again returns the delivery created the first time
+INSERT INTO webhook_deliveries (webhook_id,
+                                event_id,
+                                event_type,
+                                payload)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (webhook_id, event_id) DO UPDATE SET webhook_id = EXCLUDED.webhook_id
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT *
+FROM webhook_deliveries
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET status          = $2,
+    attempts        = attempts + 1,
+    response_status = $3,
+    last_error      = $4,
+    delivered_at    = CASE WHEN $2 = 'succeeded' THEN now() END
+WHERE id = $1
+RETURNING *;
+
+-- name: ResetWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending'
+WHERE id = $1
+RETURNING *;
@@ -22,7 +22,7 @@ func NewSessionRepository(connPool *pgxpool.Pool) *SessionRepository {
 }
 
 func (sessionRepo *SessionRepository) Create(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
-	session, err := sessionRepo.q.CreateSession(ctx, arg)
+	session, err := sessionRepo.q.CreateSessionTx(ctx, arg)
 	if err != nil {
 		return db.Session{}, internal.DBErrorToInternal(err)
 	}
@@ -38,3 +38,76 @@ func (sessionRepo *SessionRepository) Get(ctx context.Context, id uuid.UUID) (db
 
 	return session, nil
 }
+
+// BlockAll blocks every session of the user.
+func (sessionRepo *SessionRepository) BlockAll(ctx context.Context, username string) error {
+	err := sessionRepo.q.BlockUserSessions(ctx, username)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+
+	return nil
+}
+
+// ListActive lists the sessions of the user that weren't blocked and haven't expired, newest first.
+func (sessionRepo *SessionRepository) ListActive(ctx context.Context, username string) ([]db.Session, error) {
+	sessions, err := sessionRepo.q.ListActiveSessions(ctx, username)
+	if err != nil {
+		return nil, internal.DBErrorToInternal(err)
+	}
+
+	return sessions, nil
+}
+
+// BlockFamily blocks a session opened at login and every session rotated from it.
+func (sessionRepo *SessionRepository) BlockFamily(ctx context.Context, familyID uuid.UUID) error {
+	err := sessionRepo.q.BlockSessionFamily(ctx, familyID)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+
+	return nil
+}
+
+// BlockOthers blocks every session of the user except the family of the one to keep.
+func (sessionRepo *SessionRepository) BlockOthers(ctx context.Context, username string, keepFamilyID uuid.UUID) error {
+	err := sessionRepo.q.BlockOtherUserSessions(ctx, db.BlockOtherUserSessionsParams{
+		Username:     username,
+		KeepFamilyID: keepFamilyID,
+	})
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+
+	return nil
+}
+
+// Rotate creates a session in place of its parent, whose refresh token can't be exchanged again.
+func (sessionRepo *SessionRepository) Rotate(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	session, err := sessionRepo.q.RotateSessionTx(ctx, arg)
+	if err != nil {
+		return db.Session{}, internal.DBErrorToInternal(err)
+	}
+
+	return session, nil
+}
+
+// RevokeFamily blocks the family of a session whose rotated refresh token was reused, and tells the user.
+func (sessionRepo *SessionRepository) RevokeFamily(ctx context.Context, session db.Session) error {
+	err := sessionRepo.q.RevokeSessionFamilyTx(ctx, session)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+
+	return nil
+}
+
+// BlockAllByActor blocks every session of the user on behalf of a banker or admin, who is recorded in the audit log.
+func (sessionRepo *SessionRepository) BlockAllByActor(ctx context.Context, arg db.BlockUserSessionsTxParams) error {
+	_, err := sessionRepo.q.BlockUserSessionsTx(ctx, arg)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// StatementRepository represents the repository used for reading account statements.
+type StatementRepository struct {
+	q db.Store
+}
+
+// NewStatementRepository instantiates the Statement repository.
+func NewStatementRepository(connPool *pgxpool.Pool) *StatementRepository {
+	return &StatementRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (statementRepo *StatementRepository) StatementTx(
+	ctx context.Context,
+	arg db.StatementTxParams,
+	onSummary func(db.GetStatementSummaryRow) error,
+	onEntry func(db.ListStatementEntriesRow) error,
+) error {
+	err := statementRepo.q.StatementTx(ctx, arg, onSummary, onEntry)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+	return nil
+}
+
+func (statementRepo *StatementRepository) ListAccounts(ctx context.Context, arg db.ListStatementAccountsParams) ([]db.Account, error) {
+	accounts, err := statementRepo.q.ListStatementAccounts(ctx, arg)
+	if err != nil {
+		return []db.Account{}, internal.DBErrorToInternal(err)
+	}
+	return accounts, nil
+}
+
+func (statementRepo *StatementRepository) Create(ctx context.Context, arg db.CreateStatementParams) (db.Statement, error) {
+	statement, err := statementRepo.q.CreateStatement(ctx, arg)
+	if err != nil {
+		return db.Statement{}, internal.DBErrorToInternal(err)
+	}
+	return statement, nil
+}
+
+func (statementRepo *StatementRepository) Get(ctx context.Context, id int64) (db.Statement, error) {
+	statement, err := statementRepo.q.GetStatement(ctx, id)
+	if err != nil {
+		return db.Statement{}, internal.DBErrorToInternal(err)
+	}
+	return statement, nil
+}
+
+func (statementRepo *StatementRepository) GetByPeriod(ctx context.Context, arg db.GetStatementByPeriodParams) (db.Statement, error) {
+	statement, err := statementRepo.q.GetStatementByPeriod(ctx, arg)
+	if err != nil {
+		return db.Statement{}, internal.DBErrorToInternal(err)
+	}
+	return statement, nil
+}
+
+func (statementRepo *StatementRepository) List(ctx context.Context, arg db.ListStatementsParams) ([]db.ListStatementsRow, error) {
+	statements, err := statementRepo.q.ListStatements(ctx, arg)
+	if err != nil {
+		return []db.ListStatementsRow{}, internal.DBErrorToInternal(err)
+	}
+	return statements, nil
+}
+
+func (statementRepo *StatementRepository) MarkEmailed(ctx context.Context, id int64) error {
+	err := statementRepo.q.MarkStatementEmailed(ctx, id)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+	return nil
+}
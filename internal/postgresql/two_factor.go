@@ -0,0 +1,105 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// TwoFactorRepository represents the repository used for interacting with the TOTP secrets and recovery codes of
+// users.
+type TwoFactorRepository struct {
+	q db.Store
+}
+
+// NewTwoFactorRepository instantiates the TwoFactor repository.
+func NewTwoFactorRepository(connPool *pgxpool.Pool) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+// SetSecret stores the TOTP secret of an enrolment, it returns internal.ErrNoRows if two-factor authentication is
+// already on.
+func (twoFactorRepo *TwoFactorRepository) SetSecret(ctx context.Context, username string, secret string) (db.User, error) {
+	user, err := twoFactorRepo.q.SetTOTPSecret(ctx, db.SetTOTPSecretParams{
+		TotpSecret: secret,
+		Username:   username,
+	})
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+
+	return user, nil
+}
+
+// Enable turns two-factor authentication on and replaces the recovery codes of the user.
+func (twoFactorRepo *TwoFactorRepository) Enable(ctx context.Context, arg db.EnableTOTPTxParams) (db.User, error) {
+	user, err := twoFactorRepo.q.EnableTOTPTx(ctx, arg)
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+
+	return user, nil
+}
+
+// UseStep records that a TOTP code of the time step was accepted, it returns internal.ErrNoRows if one of that step
+// or a later one was used before.
+func (twoFactorRepo *TwoFactorRepository) UseStep(ctx context.Context, username string, step int64) (db.User, error) {
+	user, err := twoFactorRepo.q.UseTOTPStep(ctx, db.UseTOTPStepParams{
+		UsedStep: step,
+		Username: username,
+	})
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+
+	return user, nil
+}
+
+// UseRecoveryCode marks the recovery code with the given hash used, it returns internal.ErrNoRows if the user has
+// no such code or it was used before.
+func (twoFactorRepo *TwoFactorRepository) UseRecoveryCode(ctx context.Context, username string, codeHash string) (db.RecoveryCode, error) {
+	recoveryCode, err := twoFactorRepo.q.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		Username: username,
+		CodeHash: codeHash,
+	})
+	if err != nil {
+		return db.RecoveryCode{}, internal.DBErrorToInternal(err)
+	}
+
+	return recoveryCode, nil
+}
+
+// CountAttempt counts an attempt at a second factor for the login challenge and the user, it returns
+// internal.ErrNoRows while second factor login is locked for the user.
+func (twoFactorRepo *TwoFactorRepository) CountAttempt(ctx context.Context, arg db.CountSecondFactorAttemptTxParams) (db.CountSecondFactorAttemptTxResult, error) {
+	result, err := twoFactorRepo.q.CountSecondFactorAttemptTx(ctx, arg)
+	if err != nil {
+		return db.CountSecondFactorAttemptTxResult{}, internal.DBErrorToInternal(err)
+	}
+
+	return result, nil
+}
+
+// ClearAttempts forgets the second factor attempts of the user, once one was accepted.
+func (twoFactorRepo *TwoFactorRepository) ClearAttempts(ctx context.Context, username string) error {
+	err := twoFactorRepo.q.ClearTOTPAttempts(ctx, username)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+
+	return nil
+}
+
+// Reset turns two-factor authentication off for the user and records the admin who did it in the audit log.
+func (twoFactorRepo *TwoFactorRepository) Reset(ctx context.Context, arg db.ResetTOTPTxParams) (db.User, error) {
+	user, err := twoFactorRepo.q.ResetTOTPTx(ctx, arg)
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+
+	return user, nil
+}
@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/marco-almeida/mybank/internal"
@@ -36,6 +37,14 @@ func (userRepo *UserRepository) Get(ctx context.Context, username string) (db.Us
 	return user, nil
 }
 
+func (userRepo *UserRepository) GetByEmail(ctx context.Context, email string) (db.User, error) {
+	user, err := userRepo.q.GetUserByEmail(ctx, email)
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+	return user, nil
+}
+
 func (userRepo *UserRepository) CreateWithTx(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
 	res, err := userRepo.q.CreateUserTx(ctx, arg)
 	if err != nil {
@@ -44,8 +53,43 @@ func (userRepo *UserRepository) CreateWithTx(ctx context.Context, arg db.CreateU
 	return res, nil
 }
 
+// Update updates the user, a new password blocks all their sessions.
 func (userRepo *UserRepository) Update(ctx context.Context, arg db.UpdateUserParams) (db.User, error) {
-	user, err := userRepo.q.UpdateUser(ctx, arg)
+	user, err := userRepo.q.UpdateUserTx(ctx, arg)
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+	return user, nil
+}
+
+// RequestVerifyEmail records that the user asked for a new verification email, unless they are verified or signed up
+// or asked for one after requestedBefore.
+func (userRepo *UserRepository) RequestVerifyEmail(ctx context.Context, username string, requestedBefore time.Time) (db.User, error) {
+	user, err := userRepo.q.RequestVerifyEmailTx(ctx, db.RequestVerifyEmailTxParams{
+		Username:        username,
+		RequestedBefore: requestedBefore,
+	})
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+	return user, nil
+}
+
+// ChangeRole changes the role of the user and blocks their sessions, recording the admin who did it.
+func (userRepo *UserRepository) ChangeRole(ctx context.Context, arg db.ChangeUserRoleTxParams) (db.User, error) {
+	user, err := userRepo.q.ChangeUserRoleTx(ctx, arg)
+	if err != nil {
+		return db.User{}, internal.DBErrorToInternal(err)
+	}
+	return user, nil
+}
+
+// RequestEmailChange asks for the email of the user to change to newEmail, which only happens once it is verified.
+func (userRepo *UserRepository) RequestEmailChange(ctx context.Context, username string, newEmail string) (db.User, error) {
+	user, err := userRepo.q.RequestEmailChangeTx(ctx, db.RequestEmailChangeTxParams{
+		Username: username,
+		NewEmail: newEmail,
+	})
 	if err != nil {
 		return db.User{}, internal.DBErrorToInternal(err)
 	}
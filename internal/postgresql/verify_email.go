@@ -20,8 +20,18 @@ func NewVerifyEmailRepository(connPool *pgxpool.Pool) *VerifyEmailRepository {
 	}
 }
 
+// Create creates a verification code, the unused codes sent to the user before expire.
 func (verifyEmailRepo *VerifyEmailRepository) Create(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
-	ver, err := verifyEmailRepo.q.CreateVerifyEmail(ctx, arg)
+	ver, err := verifyEmailRepo.q.CreateVerifyEmailTx(ctx, arg)
+	if err != nil {
+		return db.VerifyEmail{}, internal.DBErrorToInternal(err)
+	}
+
+	return ver, nil
+}
+
+func (verifyEmailRepo *VerifyEmailRepository) Get(ctx context.Context, id int64) (db.VerifyEmail, error) {
+	ver, err := verifyEmailRepo.q.GetVerifyEmail(ctx, id)
 	if err != nil {
 		return db.VerifyEmail{}, internal.DBErrorToInternal(err)
 	}
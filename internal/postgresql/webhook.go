@@ -0,0 +1,101 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// WebhookRepository represents the repository used for interacting with Webhook records.
+type WebhookRepository struct {
+	q db.Store
+}
+
+// NewWebhookRepository instantiates the Webhook repository.
+func NewWebhookRepository(connPool *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{
+		q: db.NewStore(connPool),
+	}
+}
+
+func (webhookRepo *WebhookRepository) Create(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	webhook, err := webhookRepo.q.CreateWebhook(ctx, arg)
+	if err != nil {
+		return db.Webhook{}, internal.DBErrorToInternal(err)
+	}
+	return webhook, nil
+}
+
+func (webhookRepo *WebhookRepository) Get(ctx context.Context, id int64) (db.Webhook, error) {
+	webhook, err := webhookRepo.q.GetWebhook(ctx, id)
+	if err != nil {
+		return db.Webhook{}, internal.DBErrorToInternal(err)
+	}
+	return webhook, nil
+}
+
+func (webhookRepo *WebhookRepository) List(ctx context.Context, arg db.ListWebhooksParams) ([]db.Webhook, error) {
+	webhooks, err := webhookRepo.q.ListWebhooks(ctx, arg)
+	if err != nil {
+		return []db.Webhook{}, internal.DBErrorToInternal(err)
+	}
+	return webhooks, nil
+}
+
+func (webhookRepo *WebhookRepository) ListForEvent(ctx context.Context, arg db.ListWebhooksForEventParams) ([]db.Webhook, error) {
+	webhooks, err := webhookRepo.q.ListWebhooksForEvent(ctx, arg)
+	if err != nil {
+		return []db.Webhook{}, internal.DBErrorToInternal(err)
+	}
+	return webhooks, nil
+}
+
+func (webhookRepo *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	err := webhookRepo.q.DeleteWebhook(ctx, id)
+	if err != nil {
+		return internal.DBErrorToInternal(err)
+	}
+	return nil
+}
+
+func (webhookRepo *WebhookRepository) CreateDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	delivery, err := webhookRepo.q.CreateWebhookDelivery(ctx, arg)
+	if err != nil {
+		return db.WebhookDelivery{}, internal.DBErrorToInternal(err)
+	}
+	return delivery, nil
+}
+
+func (webhookRepo *WebhookRepository) GetDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	delivery, err := webhookRepo.q.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return db.WebhookDelivery{}, internal.DBErrorToInternal(err)
+	}
+	return delivery, nil
+}
+
+func (webhookRepo *WebhookRepository) ListDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	deliveries, err := webhookRepo.q.ListWebhookDeliveries(ctx, arg)
+	if err != nil {
+		return []db.WebhookDelivery{}, internal.DBErrorToInternal(err)
+	}
+	return deliveries, nil
+}
+
+func (webhookRepo *WebhookRepository) UpdateDeliveryAttempt(ctx context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+	delivery, err := webhookRepo.q.UpdateWebhookDeliveryAttempt(ctx, arg)
+	if err != nil {
+		return db.WebhookDelivery{}, internal.DBErrorToInternal(err)
+	}
+	return delivery, nil
+}
+
+func (webhookRepo *WebhookRepository) ResetDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	delivery, err := webhookRepo.q.ResetWebhookDelivery(ctx, id)
+	if err != nil {
+		return db.WebhookDelivery{}, internal.DBErrorToInternal(err)
+	}
+	return delivery, nil
+}
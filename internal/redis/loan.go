@@ -0,0 +1,12 @@
+package redis
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TaskCollectLoanInstalments = "task:collect_loan_instalments"
+
+// NewCollectLoanInstalmentsTask creates the periodic task that collects due loan instalments.
+func NewCollectLoanInstalmentsTask(opts ...asynq.Option) *asynq.Task {
+	return asynq.NewTask(TaskCollectLoanInstalments, nil, opts...)
+}
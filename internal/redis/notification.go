@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// NotificationMessageBrokerRepository represents the repository used for publishing customer notification tasks.
+type NotificationMessageBrokerRepository struct {
+	client *asynq.Client
+}
+
+// NewNotificationMessageBrokerRepository instantiates the NotificationMessageBrokerRepository repository.
+func NewNotificationMessageBrokerRepository(redisOpt asynq.RedisClientOpt) *NotificationMessageBrokerRepository {
+	return &NotificationMessageBrokerRepository{
+		client: asynq.NewClient(redisOpt),
+	}
+}
+
+const (
+	TaskSendTransferNotification       = "task:send_transfer_notification"
+	TaskSendLowBalanceNotification     = "task:send_low_balance_notification"
+	TaskSendNewDeviceLoginNotification = "task:send_new_device_login_notification"
+)
+
+// PayloadSendTransferNotification is the payload of TaskSendTransferNotification, it tells the recipient of a
+// transfer that the money arrived, or the sender that it left when Outgoing is set
+type PayloadSendTransferNotification struct {
+	TransferID int64 `json:"transfer_id"`
+	// AccountID is the account of the notified user
+	AccountID int64  `json:"account_id"`
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Outgoing  bool   `json:"outgoing,omitempty"`
+}
+
+// PayloadSendLowBalanceNotification is the payload of TaskSendLowBalanceNotification
+type PayloadSendLowBalanceNotification struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Balance   int64  `json:"balance"`
+	Threshold int64  `json:"threshold"`
+	Currency  string `json:"currency"`
+}
+
+// PayloadSendNewDeviceLoginNotification is the payload of TaskSendNewDeviceLoginNotification
+type PayloadSendNewDeviceLoginNotification struct {
+	Username   string    `json:"username"`
+	UserAgent  string    `json:"user_agent"`
+	ClientIP   string    `json:"client_ip"`
+	LoggedInAt time.Time `json:"logged_in_at"`
+}
+
+// CreateTransferNotificationTask publishes a task that emails the sender or the recipient of a transfer
+func (repo *NotificationMessageBrokerRepository) CreateTransferNotificationTask(ctx context.Context, payload PayloadSendTransferNotification, opts ...asynq.Option) error {
+	return repo.enqueue(ctx, TaskSendTransferNotification, payload, opts...)
+}
+
+// CreateNewDeviceLoginNotificationTask publishes a task that emails a user who logged in from a new device
+func (repo *NotificationMessageBrokerRepository) CreateNewDeviceLoginNotificationTask(ctx context.Context, payload PayloadSendNewDeviceLoginNotification, opts ...asynq.Option) error {
+	return repo.enqueue(ctx, TaskSendNewDeviceLoginNotification, payload, opts...)
+}
+
+// CreateLowBalanceNotificationTask publishes a task that emails the owner of an account whose balance went below
+// its threshold
+func (repo *NotificationMessageBrokerRepository) CreateLowBalanceNotificationTask(ctx context.Context, payload PayloadSendLowBalanceNotification, opts ...asynq.Option) error {
+	return repo.enqueue(ctx, TaskSendLowBalanceNotification, payload, opts...)
+}
+
+// enqueue publishes a notification task. A task whose id is already taken was enqueued before, e.g. by a retry
+// of the event that triggered it, so it isn't an error
+func (repo *NotificationMessageBrokerRepository) enqueue(ctx context.Context, typename string, payload any, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(typename, jsonPayload, opts...)
+	info, err := repo.client.EnqueueContext(ctx, task)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).Msg("notification task already enqueued")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+	return nil
+}
@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// Tasks published for the outbox events, the payload of each task is the event in a pkg.Event envelope
+const (
+	TaskUserCreated                 = "event:" + pkg.EventUserCreated
+	TaskUserNewDeviceLogin          = "event:" + pkg.EventUserNewDeviceLogin
+	TaskUserVerifyEmailRequested    = "event:" + pkg.EventUserVerifyEmailRequested
+	TaskUserEmailChangeRequested    = "event:" + pkg.EventUserEmailChangeRequested
+	TaskUserSessionReuseDetected    = "event:" + pkg.EventUserSessionReuseDetected
+	TaskAccountCreated              = "event:" + pkg.EventAccountCreated
+	TaskAccountCredited             = "event:" + pkg.EventAccountCredited
+	TaskAccountLowBalance           = "event:" + pkg.EventAccountLowBalance
+	TaskTransferCompleted           = "event:" + pkg.EventTransferCompleted
+	TaskPaymentRequestStatusChanged = "event:" + pkg.EventPaymentRequestStatusChanged
+)
+
+// outboxTaskRetention keeps processed event tasks around so that an event published twice is still deduplicated
+const outboxTaskRetention = 24 * time.Hour
+
+// OutboxMessageBrokerRepository represents the repository used for publishing outbox events.
+type OutboxMessageBrokerRepository struct {
+	client *asynq.Client
+}
+
+// NewOutboxMessageBrokerRepository instantiates the OutboxMessageBrokerRepository repository.
+func NewOutboxMessageBrokerRepository(redisOpt asynq.RedisClientOpt) *OutboxMessageBrokerRepository {
+	return &OutboxMessageBrokerRepository{
+		client: asynq.NewClient(redisOpt),
+	}
+}
+
+// Publish enqueues the task of an outbox event. The event id is the task id, so publishing an event again
+// after the relay failed to mark it published doesn't enqueue it twice. An event that can't be encoded fails with
+// db.ErrEventUnpublishable, and failing to reach redis fails with db.ErrBrokerUnavailable.
+func (repo *OutboxMessageBrokerRepository) Publish(ctx context.Context, event db.Outbox, opts ...asynq.Option) error {
+	opts = append([]asynq.Option{
+		asynq.TaskID(fmt.Sprintf("outbox:%d", event.ID)),
+		asynq.Retention(outboxTaskRetention),
+	}, opts...)
+
+	jsonPayload, err := json.Marshal(pkg.Event{
+		ID:        event.ID,
+		Type:      event.EventType,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to marshal task payload: %w", db.ErrEventUnpublishable, err)
+	}
+
+	task := asynq.NewTask("event:"+event.EventType, jsonPayload, opts...)
+	info, err := repo.client.EnqueueContext(ctx, task)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		log.Info().Str("type", task.Type()).Int64("event_id", event.ID).Msg("event task already enqueued")
+		return nil
+	}
+	if isConnectionError(err) {
+		return fmt.Errorf("%w: failed to enqueue task: %w", db.ErrBrokerUnavailable, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+	return nil
+}
+
+// isConnectionError reports whether err is redis being unreachable rather than the task being rejected
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, redis.ErrClosed) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// PaymentRequestMessageBrokerRepository represents the repository used for publishing PaymentRequest tasks.
+type PaymentRequestMessageBrokerRepository struct {
+	client *asynq.Client
+}
+
+// NewPaymentRequestMessageBrokerRepository instantiates the PaymentRequestMessageBrokerRepository repository.
+func NewPaymentRequestMessageBrokerRepository(redisOpt asynq.RedisClientOpt) *PaymentRequestMessageBrokerRepository {
+	return &PaymentRequestMessageBrokerRepository{
+		client: asynq.NewClient(redisOpt),
+	}
+}
+
+const (
+	TaskSendPaymentRequestUpdate = "task:send_payment_request_update"
+	TaskExpirePaymentRequests    = "task:expire_payment_requests"
+)
+
+// PayloadSendPaymentRequestUpdate is the payload of TaskSendPaymentRequestUpdate
+type PayloadSendPaymentRequestUpdate struct {
+	PaymentRequestID int64  `json:"payment_request_id"`
+	Status           string `json:"status"`
+	// Reminder is set when the payer is reminded of a request that is still pending
+	Reminder bool `json:"reminder,omitempty"`
+}
+
+// CreatePaymentRequestUpdateTask publishes a task that emails the other party about a payment request in the given status
+func (repo *PaymentRequestMessageBrokerRepository) CreatePaymentRequestUpdateTask(ctx context.Context, payload PayloadSendPaymentRequestUpdate, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendPaymentRequestUpdate, jsonPayload, opts...)
+	info, err := repo.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+	return nil
+}
+
+// NewExpirePaymentRequestsTask creates the periodic task that expires pending payment requests.
+func NewExpirePaymentRequestsTask(opts ...asynq.Option) *asynq.Task {
+	return asynq.NewTask(TaskExpirePaymentRequests, nil, opts...)
+}
@@ -0,0 +1,12 @@
+package redis
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TaskSendMonthlyStatements = "task:send_monthly_statements"
+
+// NewSendMonthlyStatementsTask creates the periodic task that archives and emails last month's statements.
+func NewSendMonthlyStatementsTask(opts ...asynq.Option) *asynq.Task {
+	return asynq.NewTask(TaskSendMonthlyStatements, nil, opts...)
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/token"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	revokedTokenKeyPrefix = "token:revoked:"
+	notBeforeKeyPrefix    = "token:not_before:"
+)
+
+// TokenRevocationRepository keeps the access tokens that were revoked before they expired: single tokens by id,
+// and every token of a user issued before their not before time.
+type TokenRevocationRepository struct {
+	client *redis.Client
+	// accessTokenDuration is how long the not before time of a user is kept, the tokens it rejects have expired by then
+	accessTokenDuration time.Duration
+}
+
+// NewTokenRevocationRepository instantiates the TokenRevocationRepository repository.
+func NewTokenRevocationRepository(redisOpt asynq.RedisClientOpt, accessTokenDuration time.Duration) *TokenRevocationRepository {
+	return &TokenRevocationRepository{
+		client: redis.NewClient(&redis.Options{
+			Addr:     redisOpt.Addr,
+			Username: redisOpt.Username,
+			Password: redisOpt.Password,
+			DB:       redisOpt.DB,
+		}),
+		accessTokenDuration: accessTokenDuration,
+	}
+}
+
+// Revoke rejects the token until it expires.
+func (repo *TokenRevocationRepository) Revoke(ctx context.Context, payload *token.Payload) error {
+	ttl := time.Until(payload.ExpiredAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := repo.client.Set(ctx, revokedTokenKeyPrefix+payload.ID.String(), 1, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// SetNotBefore rejects every token of the user issued before notBefore.
+func (repo *TokenRevocationRepository) SetNotBefore(ctx context.Context, username string, notBefore time.Time) error {
+	err := repo.client.Set(ctx, notBeforeKeyPrefix+username, notBefore.UnixNano(), repo.accessTokenDuration).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set not before: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked checks if the token was revoked, by id or by the not before time of its user, in a single round trip.
+func (repo *TokenRevocationRepository) IsRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	pipe := repo.client.Pipeline()
+	revoked := pipe.Exists(ctx, revokedTokenKeyPrefix+payload.ID.String())
+	notBefore := pipe.Get(ctx, notBeforeKeyPrefix+payload.Username)
+	_, err := pipe.Exec(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	if revoked.Val() > 0 {
+		return true, nil
+	}
+
+	if errors.Is(notBefore.Err(), redis.Nil) {
+		return false, nil
+	}
+	nanos, err := strconv.ParseInt(notBefore.Val(), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse not before: %w", err)
+	}
+	return payload.IssuedAt.Before(time.Unix(0, nanos)), nil
+}
@@ -3,13 +3,36 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/hibiken/asynq"
 	"github.com/rs/zerolog/log"
 )
 
-// UserMessageBrokerRepository represents the repository used for interacting with User records.
+// TaskSendVerifyEmail was enqueued for new users before they were announced through the outbox, its handler stays
+// registered so that tasks enqueued by older versions are still processed
+const TaskSendVerifyEmail = "task:send_verify_email"
+
+// TaskSendPasswordReset emails a password reset token to the user with the email address of its payload
+const TaskSendPasswordReset = "task:send_password_reset"
+
+// PayloadSendPasswordReset is the payload of TaskSendPasswordReset. Only the email address the user asked for is
+// known, the task does nothing if no user has it
+type PayloadSendPasswordReset struct {
+	Email string `json:"email"`
+}
+
+// TaskResendVerifyEmail sends a new verification email to the user of its payload
+const TaskResendVerifyEmail = "task:resend_verify_email"
+
+// PayloadResendVerifyEmail is the payload of TaskResendVerifyEmail. The user may not exist or be verified already, the
+// task does nothing then
+type PayloadResendVerifyEmail struct {
+	Username string `json:"username"`
+}
+
+// UserMessageBrokerRepository represents the repository used for publishing User tasks.
 type UserMessageBrokerRepository struct {
 	client *asynq.Client
 }
@@ -21,21 +44,35 @@ func NewUserMessageBrokerRepository(redisOpt asynq.RedisClientOpt) *UserMessageB
 	}
 }
 
-const TaskSendVerifyEmail = "task:send_verify_email"
+// CreatePasswordResetTask publishes a task that emails a password reset token. A task that is a duplicate of one
+// enqueued with asynq.Unique isn't an error, the user asked again before the first email was sent
+func (repo *UserMessageBrokerRepository) CreatePasswordResetTask(ctx context.Context, payload PayloadSendPasswordReset, opts ...asynq.Option) error {
+	return repo.enqueue(ctx, TaskSendPasswordReset, payload, opts...)
+}
 
-func (repo *UserMessageBrokerRepository) CreateVerifyEmailTask(ctx context.Context, username string, opts ...asynq.Option) error {
-	jsonPayload, err := json.Marshal(username)
+// CreateResendVerifyEmailTask publishes a task that sends a new verification email. Like CreatePasswordResetTask, a
+// duplicate of a task enqueued with asynq.Unique isn't an error
+func (repo *UserMessageBrokerRepository) CreateResendVerifyEmailTask(ctx context.Context, payload PayloadResendVerifyEmail, opts ...asynq.Option) error {
+	return repo.enqueue(ctx, TaskResendVerifyEmail, payload, opts...)
+}
+
+func (repo *UserMessageBrokerRepository) enqueue(ctx context.Context, typename string, payload any, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal task payload: %w", err)
 	}
 
-	task := asynq.NewTask(TaskSendVerifyEmail, jsonPayload, opts...)
+	task := asynq.NewTask(typename, jsonPayload, opts...)
 	info, err := repo.client.EnqueueContext(ctx, task)
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		log.Info().Str("type", task.Type()).Msg("task already enqueued")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to enqueue task: %w", err)
 	}
 
-	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
-		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+	// the payloads are what someone asked an email for, whoever they are, they aren't logged
+	log.Info().Str("type", task.Type()).Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
 	return nil
 }
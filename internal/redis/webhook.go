@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookMessageBrokerRepository represents the repository used for publishing webhook delivery tasks.
+type WebhookMessageBrokerRepository struct {
+	client *asynq.Client
+}
+
+// NewWebhookMessageBrokerRepository instantiates the WebhookMessageBrokerRepository repository.
+func NewWebhookMessageBrokerRepository(redisOpt asynq.RedisClientOpt) *WebhookMessageBrokerRepository {
+	return &WebhookMessageBrokerRepository{
+		client: asynq.NewClient(redisOpt),
+	}
+}
+
+const TaskDeliverWebhook = "task:deliver_webhook"
+
+// PayloadDeliverWebhook is the payload of TaskDeliverWebhook
+type PayloadDeliverWebhook struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// CreateDeliverWebhookTask publishes a task that sends a webhook delivery to its URL
+func (repo *WebhookMessageBrokerRepository) CreateDeliverWebhookTask(ctx context.Context, payload PayloadDeliverWebhook, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskDeliverWebhook, jsonPayload, opts...)
+	info, err := repo.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+	return nil
+}
@@ -50,6 +50,7 @@ func (s *AccountService) List(ctx context.Context, arg db.ListAccountsParams) ([
 	return s.repo.List(ctx, arg)
 }
 
+// Delete deletes an account whose main balance and pocket balances are all zero.
 func (s *AccountService) Delete(ctx context.Context, id int64) error {
 	acc, err := s.repo.Get(ctx, id)
 	if err != nil {
@@ -58,7 +59,16 @@ func (s *AccountService) Delete(ctx context.Context, id int64) error {
 	if acc.Balance != 0 {
 		return internal.ErrBalanceNotZero
 	}
-	return s.repo.Delete(ctx, id)
+
+	err = s.repo.Delete(ctx, id)
+	if errors.Is(err, internal.ErrNoRows) {
+		// one of its pockets holds money, or the account was deleted or received a deposit since it was read
+		if _, err := s.repo.Get(ctx, id); err != nil {
+			return err
+		}
+		return internal.ErrBalanceNotZero
+	}
+	return err
 }
 
 func (s *AccountService) AddBalance(ctx context.Context, owner string, overridePermission bool, arg db.AddAccountBalanceParams) (db.Account, error) {
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAccountRepository keeps accounts and the balances of their pockets in memory
+type fakeAccountRepository struct {
+	accounts       map[int64]db.Account
+	pocketBalances map[int64]int64
+}
+
+func newFakeAccountRepository(accounts ...db.Account) *fakeAccountRepository {
+	repo := &fakeAccountRepository{accounts: map[int64]db.Account{}, pocketBalances: map[int64]int64{}}
+	for _, account := range accounts {
+		repo.accounts[account.ID] = account
+	}
+	return repo
+}
+
+func (r *fakeAccountRepository) Create(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	account := db.Account{ID: int64(len(r.accounts) + 1), Owner: arg.Owner, Balance: arg.Balance, Currency: arg.Currency}
+	r.accounts[account.ID] = account
+	return account, nil
+}
+
+func (r *fakeAccountRepository) Get(ctx context.Context, id int64) (db.Account, error) {
+	account, ok := r.accounts[id]
+	if !ok {
+		return db.Account{}, internal.ErrNoRows
+	}
+	return account, nil
+}
+
+func (r *fakeAccountRepository) List(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+	return nil, nil
+}
+
+func (r *fakeAccountRepository) Delete(ctx context.Context, id int64) error {
+	account, ok := r.accounts[id]
+	if !ok || account.Balance != 0 || r.pocketBalances[id] != 0 {
+		return internal.ErrNoRows
+	}
+	delete(r.accounts, id)
+	return nil
+}
+
+func (r *fakeAccountRepository) AddBalance(ctx context.Context, arg db.AddAccountBalanceParams) (db.Account, error) {
+	account, err := r.Get(ctx, arg.ID)
+	if err != nil {
+		return db.Account{}, err
+	}
+	account.Balance += arg.Amount
+	r.accounts[arg.ID] = account
+	return account, nil
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeAccountRepository(db.Account{ID: 1, Owner: "alice", Currency: "EUR"})
+	svc := NewAccountService(repo)
+
+	require.NoError(t, svc.Delete(ctx, 1))
+	require.ErrorIs(t, svc.Delete(ctx, 1), internal.ErrNoRows)
+}
+
+func TestDeleteAccountWithBalance(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeAccountRepository(db.Account{ID: 1, Owner: "alice", Balance: 100, Currency: "EUR"})
+	svc := NewAccountService(repo)
+
+	require.ErrorIs(t, svc.Delete(ctx, 1), internal.ErrBalanceNotZero)
+
+	// the money moved into a pocket still belongs to the account
+	repo.pocketBalances[1] = 100
+	_, err := svc.AddBalance(ctx, "alice", false, db.AddAccountBalanceParams{ID: 1, Amount: -100})
+	require.NoError(t, err)
+	require.ErrorIs(t, svc.Delete(ctx, 1), internal.ErrBalanceNotZero)
+
+	_, err = svc.Get(ctx, 1)
+	require.NoError(t, err)
+}
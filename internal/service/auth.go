@@ -2,19 +2,25 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/marco-almeida/mybank/internal"
 	"github.com/marco-almeida/mybank/internal/pkg"
 	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
 	"github.com/marco-almeida/mybank/internal/token"
 )
 
+// VerifyEmailResendInterval is how long a user has to wait between two verification emails
+const VerifyEmailResendInterval = time.Minute
+
 // use a single instance of Validate, it caches struct info
 var validate = validator.New(validator.WithRequiredStructEnabled())
 
@@ -22,6 +28,19 @@ var validate = validator.New(validator.WithRequiredStructEnabled())
 type SessionRepository interface {
 	Create(ctx context.Context, arg db.CreateSessionParams) (db.Session, error)
 	Get(ctx context.Context, id uuid.UUID) (db.Session, error)
+	BlockAll(ctx context.Context, username string) error
+	ListActive(ctx context.Context, username string) ([]db.Session, error)
+	BlockFamily(ctx context.Context, familyID uuid.UUID) error
+	BlockOthers(ctx context.Context, username string, keepFamilyID uuid.UUID) error
+	Rotate(ctx context.Context, arg db.CreateSessionParams) (db.Session, error)
+	RevokeFamily(ctx context.Context, session db.Session) error
+	BlockAllByActor(ctx context.Context, arg db.BlockUserSessionsTxParams) error
+}
+
+// TokenRevocationRepository defines the methods that any TokenRevocation repository should implement.
+type TokenRevocationRepository interface {
+	Revoke(ctx context.Context, payload *token.Payload) error
+	SetNotBefore(ctx context.Context, username string, notBefore time.Time) error
 }
 
 // AuthServiceImpl defines the application service in charge of interacting with Auth.
@@ -29,13 +48,18 @@ type AuthServiceImpl struct {
 	sessionRepo          SessionRepository
 	userRepo             UserRepository
 	verifyEmailRepo      VerifyEmailRepository
+	passwordResetRepo    PasswordResetRepository
+	userBroker           UserMessageBroker
+	twoFactorRepo        TwoFactorRepository
+	tokenRevocationRepo  TokenRevocationRepository
 	tokenMaker           token.Maker
+	challengeSigner      *token.LoginChallengeSigner
 	accessTokenDuration  time.Duration
 	refreshTokenDuration time.Duration
 }
 
 // NewAuthService creates a new Auth service.
-func NewAuthService(userRepo UserRepository, sessionRepo SessionRepository, tokenMaker token.Maker, accessTokenDuration time.Duration, refreshTokenDuration time.Duration, verifyEmailRepo VerifyEmailRepository) *AuthServiceImpl {
+func NewAuthService(userRepo UserRepository, sessionRepo SessionRepository, tokenMaker token.Maker, accessTokenDuration time.Duration, refreshTokenDuration time.Duration, verifyEmailRepo VerifyEmailRepository, passwordResetRepo PasswordResetRepository, userBroker UserMessageBroker, twoFactorRepo TwoFactorRepository, challengeSigner *token.LoginChallengeSigner, tokenRevocationRepo TokenRevocationRepository) *AuthServiceImpl {
 	return &AuthServiceImpl{
 		userRepo:             userRepo,
 		sessionRepo:          sessionRepo,
@@ -43,6 +67,11 @@ func NewAuthService(userRepo UserRepository, sessionRepo SessionRepository, toke
 		accessTokenDuration:  accessTokenDuration,
 		refreshTokenDuration: refreshTokenDuration,
 		verifyEmailRepo:      verifyEmailRepo,
+		passwordResetRepo:    passwordResetRepo,
+		userBroker:           userBroker,
+		twoFactorRepo:        twoFactorRepo,
+		challengeSigner:      challengeSigner,
+		tokenRevocationRepo:  tokenRevocationRepo,
 	}
 }
 
@@ -58,7 +87,6 @@ type CreateUserTxParams struct {
 	PlaintextPassword string
 	FullName          string
 	Email             string
-	AfterCreate       func(user db.User) error
 }
 
 func (s *AuthServiceImpl) Create(ctx context.Context, req CreateUserTxParams) (db.CreateUserTxResult, error) {
@@ -81,7 +109,6 @@ func (s *AuthServiceImpl) Create(ctx context.Context, req CreateUserTxParams) (d
 			FullName:       req.FullName,
 			Email:          req.Email,
 		},
-		AfterCreate: req.AfterCreate,
 	})
 
 }
@@ -100,6 +127,8 @@ type LoginUserResponse struct {
 	RefreshToken          string       `json:"refresh_token"`
 	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
 	User                  userResponse `json:"user"`
+	// TwoFactor is set instead of the tokens when the user has to log in with their second factor
+	TwoFactor *TwoFactorChallenge `json:"-"`
 }
 
 type userResponse struct {
@@ -110,6 +139,8 @@ type userResponse struct {
 	CreatedAt         time.Time `json:"created_at"`
 }
 
+// Login checks the password of the user and opens a session. Users with two-factor authentication on get a
+// TwoFactorChallenge instead, which LoginTwoFactor exchanges for the session along with their code.
 func (s *AuthServiceImpl) Login(ctx context.Context, req LoginUserParams) (LoginUserResponse, error) {
 	user, err := s.userRepo.Get(ctx, req.Username)
 	if err != nil {
@@ -128,6 +159,25 @@ func (s *AuthServiceImpl) Login(ctx context.Context, req LoginUserParams) (Login
 		return LoginUserResponse{}, fmt.Errorf("%w; %w", internal.ErrInvalidCredentials, err)
 	}
 
+	if user.TotpEnabledAt.Valid {
+		challenge, payload, err := s.challengeSigner.Create(user.Username, loginChallengeDuration)
+		if err != nil {
+			return LoginUserResponse{}, fmt.Errorf("cannot create login challenge: %w", err)
+		}
+		return LoginUserResponse{
+			TwoFactor: &TwoFactorChallenge{
+				TwoFactorRequired:       true,
+				ChallengeToken:          challenge,
+				ChallengeTokenExpiresAt: payload.ExpiredAt,
+			},
+		}, nil
+	}
+
+	return s.createSession(ctx, user, req.UserAgent, req.ClientIP)
+}
+
+// createSession opens a session for the user who proved who they are, returning its tokens
+func (s *AuthServiceImpl) createSession(ctx context.Context, user db.User, userAgent string, clientIP string) (LoginUserResponse, error) {
 	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
 		user.Username,
 		user.Role,
@@ -150,10 +200,11 @@ func (s *AuthServiceImpl) Login(ctx context.Context, req LoginUserParams) (Login
 		ID:           refreshPayload.ID,
 		Username:     user.Username,
 		RefreshToken: refreshToken,
-		UserAgent:    req.UserAgent,
-		ClientIp:     req.ClientIP,
+		UserAgent:    userAgent,
+		ClientIp:     clientIP,
 		IsBlocked:    false,
 		ExpiresAt:    refreshPayload.ExpiredAt,
+		FamilyID:     refreshPayload.ID,
 	})
 	if err != nil {
 		return LoginUserResponse{}, fmt.Errorf("cannot create session: %w", err)
@@ -180,10 +231,17 @@ type RenewAccessTokenParams struct {
 }
 
 type RenewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// RenewAccessToken exchanges a refresh token for a new access token and a new refresh token, which replaces it. The
+// new session expires with the one it replaces, rotating doesn't make a session last longer. A refresh token that
+// was already exchanged is either stolen or was stolen from the user, so presenting it again blocks every session
+// rotated from the same login and tells the user.
 func (s *AuthServiceImpl) RenewAccessToken(ctx context.Context, req RenewAccessTokenParams) (RenewAccessTokenResponse, error) {
 	refreshPayload, err := s.tokenMaker.VerifyToken(req.RefreshToken)
 	if err != nil {
@@ -213,11 +271,28 @@ func (s *AuthServiceImpl) RenewAccessToken(ctx context.Context, req RenewAccessT
 		return RenewAccessTokenResponse{}, fmt.Errorf("%w; session token mismatch: %w", internal.ErrInvalidToken, err)
 	}
 
+	// the session is blocked when the password changes, this also holds if blocking it failed
+	user, err := s.userRepo.Get(ctx, session.Username)
+	if err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			return RenewAccessTokenResponse{}, fmt.Errorf("%w; user not found: %w", internal.ErrInvalidToken, err)
+		}
+		return RenewAccessTokenResponse{}, fmt.Errorf("internal server error: %w", err)
+	}
+	if refreshPayload.IssuedAt.Before(user.PasswordChangedAt) {
+		err := fmt.Errorf("token issued before password change")
+		return RenewAccessTokenResponse{}, fmt.Errorf("%w; password changed: %w", internal.ErrInvalidToken, err)
+	}
+
 	if time.Now().After(session.ExpiresAt) {
 		err := fmt.Errorf("expired session")
 		return RenewAccessTokenResponse{}, fmt.Errorf("%w; session expired: %w", internal.ErrInvalidToken, err)
 	}
 
+	if session.RotatedAt.Valid {
+		return RenewAccessTokenResponse{}, s.revokeReusedSession(ctx, session)
+	}
+
 	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
 		refreshPayload.Username,
 		refreshPayload.Role,
@@ -227,14 +302,86 @@ func (s *AuthServiceImpl) RenewAccessToken(ctx context.Context, req RenewAccessT
 		return RenewAccessTokenResponse{}, fmt.Errorf("cannot create access token: %w", err)
 	}
 
+	refreshToken, newRefreshPayload, err := s.tokenMaker.CreateToken(
+		refreshPayload.Username,
+		refreshPayload.Role,
+		time.Until(session.ExpiresAt),
+	)
+	if err != nil {
+		return RenewAccessTokenResponse{}, fmt.Errorf("cannot create refresh token: %w", err)
+	}
+
+	rotated, err := s.sessionRepo.Rotate(ctx, db.CreateSessionParams{
+		ID:           newRefreshPayload.ID,
+		Username:     session.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    session.UserAgent,
+		ClientIp:     session.ClientIp,
+		IsBlocked:    false,
+		ExpiresAt:    newRefreshPayload.ExpiredAt,
+		FamilyID:     session.FamilyID,
+		ParentID:     pgtype.UUID{Bytes: session.ID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			// another renewal exchanged the token first
+			return RenewAccessTokenResponse{}, s.revokeReusedSession(ctx, session)
+		}
+		return RenewAccessTokenResponse{}, fmt.Errorf("cannot rotate session: %w", err)
+	}
+
 	return RenewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		SessionID:             rotated.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}, nil
 }
 
+// revokeReusedSession blocks the family of a session whose refresh token was presented after it was exchanged,
+// returning the error the renewal fails with
+func (s *AuthServiceImpl) revokeReusedSession(ctx context.Context, session db.Session) error {
+	if err := s.sessionRepo.RevokeFamily(ctx, session); err != nil {
+		return fmt.Errorf("cannot revoke reused session: %w", err)
+	}
+
+	err := fmt.Errorf("refresh token reused")
+	return fmt.Errorf("%w; session family revoked: %w", internal.ErrInvalidToken, err)
+}
+
+// VerifyEmail marks the email of the user verified with the code sent to it. Codes that can't be used are told apart
+// with internal.ErrInvalidVerifyEmail, internal.ErrVerifyEmailExpired and internal.ErrVerifyEmailUsed.
 func (s *AuthServiceImpl) VerifyEmail(ctx context.Context, req db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
-	return s.verifyEmailRepo.Verify(ctx, req)
+	result, err := s.verifyEmailRepo.Verify(ctx, req)
+	if err == nil || !errors.Is(err, internal.ErrNoRows) {
+		return result, err
+	}
+
+	// the code didn't match a usable one, find out why
+	verifyEmail, getErr := s.verifyEmailRepo.Get(ctx, req.EmailId)
+	if getErr != nil && !errors.Is(getErr, internal.ErrNoRows) {
+		return db.VerifyEmailTxResult{}, getErr
+	}
+	// the state of a code is only told to whoever knows it
+	if getErr != nil || subtle.ConstantTimeCompare([]byte(verifyEmail.SecretCode), []byte(req.SecretCode)) != 1 {
+		return db.VerifyEmailTxResult{}, internal.ErrInvalidVerifyEmail
+	}
+	if verifyEmail.IsUsed {
+		return db.VerifyEmailTxResult{}, internal.ErrVerifyEmailUsed
+	}
+	if !time.Now().Before(verifyEmail.ExpiredAt) {
+		return db.VerifyEmailTxResult{}, internal.ErrVerifyEmailExpired
+	}
+	return db.VerifyEmailTxResult{}, err
+}
+
+// ResendVerifyEmail sends a new verification email to an unverified user, the codes sent before stop working. A user
+// can ask for one once per VerifyEmailResendInterval, counting from when they signed up. Like ForgotPassword, it
+// behaves the same whether the user exists, is verified or asked too recently: all of it is checked by the task.
+func (s *AuthServiceImpl) ResendVerifyEmail(ctx context.Context, username string) error {
+	return s.userBroker.CreateResendVerifyEmailTask(ctx, redisRepo.PayloadResendVerifyEmail{Username: username},
+		asynq.Unique(VerifyEmailResendInterval))
 }
 
 type UpdateUserFullnameParams struct {
@@ -249,6 +396,9 @@ type UpdateUserPasswordParams struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+// Update changes the details of the user. A new email is only written once the code sent to it is verified, the
+// current address gets a notice of the change meanwhile. A new password logs the user out everywhere: their sessions
+// are blocked together with the change and their access tokens rejected
 func (s *AuthServiceImpl) Update(ctx context.Context, arg UpdateUserParams) (db.User, error) {
 	// if there is email and it is not valid, return error
 	// if there is password and password is less than 6, return error
@@ -268,12 +418,26 @@ func (s *AuthServiceImpl) Update(ctx context.Context, arg UpdateUserParams) (db.
 			return db.User{}, err
 		}
 
-		args.Email = pgtype.Text{
-			String: arg.Email,
-			Valid:  true,
+		// the new address is only written once it is verified, but taken addresses are refused right away
+		other, err := s.userRepo.GetByEmail(ctx, arg.Email)
+		switch {
+		case err == nil && other.Username != arg.Username:
+			return db.User{}, fmt.Errorf("%w: email already in use", internal.ErrUniqueConstraintViolation)
+		case err != nil && !errors.Is(err, internal.ErrNoRows):
+			return db.User{}, err
 		}
 	}
 
+	args.PhoneNumber = pgtype.Text{
+		String: arg.PhoneNumber,
+		Valid:  arg.PhoneNumber != "",
+	}
+
+	args.Locale = pgtype.Text{
+		String: arg.Locale,
+		Valid:  arg.Locale != "",
+	}
+
 	if arg.PlaintextPassword != "" {
 		err := validate.Struct(UpdateUserPasswordParams{arg.PlaintextPassword})
 		if err != nil {
@@ -298,5 +462,54 @@ func (s *AuthServiceImpl) Update(ctx context.Context, arg UpdateUserParams) (db.
 		return db.User{}, err
 	}
 
+	// access tokens issued with the old password are rejected from now on, the sessions were blocked by the update
+	if args.PasswordChangedAt.Valid {
+		err = s.tokenRevocationRepo.SetNotBefore(ctx, user.Username, args.PasswordChangedAt.Time)
+		if err != nil {
+			return db.User{}, fmt.Errorf("cannot revoke access tokens: %w", err)
+		}
+	}
+
+	if arg.Email != "" && arg.Email != user.Email {
+		user, err = s.userRepo.RequestEmailChange(ctx, user.Username, arg.Email)
+		if err != nil {
+			return db.User{}, err
+		}
+	}
+
+	return user, nil
+}
+
+type ChangeRoleParams struct {
+	Username string `json:"username" validate:"required,alphanum"`
+	Role     string `json:"role" validate:"required,oneof=depositor banker admin"`
+}
+
+// ChangeRole changes the role of a user on behalf of an admin, who can't change their own. The user is logged out
+// everywhere, so that no token carries the old role: their sessions are blocked and their access tokens rejected.
+func (s *AuthServiceImpl) ChangeRole(ctx context.Context, actor string, req ChangeRoleParams) (db.User, error) {
+	err := validate.Struct(req)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	if actor == req.Username {
+		return db.User{}, fmt.Errorf("%w; admins can't change their own role", internal.ErrForbidden)
+	}
+
+	user, err := s.userRepo.ChangeRole(ctx, db.ChangeUserRoleTxParams{
+		Username: req.Username,
+		Role:     req.Role,
+		Actor:    actor,
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+
+	err = s.tokenRevocationRepo.SetNotBefore(ctx, user.Username, time.Now())
+	if err != nil {
+		return db.User{}, fmt.Errorf("cannot revoke access tokens: %w", err)
+	}
+
 	return user, nil
 }
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+	"github.com/marco-almeida/mybank/internal/token"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUserRepository struct {
+	users map[string]db.User
+	// requestedAt is when each user last asked for a verification email
+	requestedAt map[string]time.Time
+	// emailChanges is the last address each user asked to change to
+	emailChanges map[string]string
+	// sessions is blocked by a password change, as if in the same transaction
+	sessions *fakeSessionRepository
+}
+
+func newFakeUserRepository(users ...db.User) *fakeUserRepository {
+	r := &fakeUserRepository{users: map[string]db.User{}, requestedAt: map[string]time.Time{}, emailChanges: map[string]string{}}
+	for _, user := range users {
+		r.users[user.Username] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepository) Get(ctx context.Context, username string) (db.User, error) {
+	user, ok := r.users[username]
+	if !ok {
+		return db.User{}, internal.ErrNoRows
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (db.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return db.User{}, internal.ErrNoRows
+}
+
+func (r *fakeUserRepository) CreateWithTx(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	user := db.User{Username: arg.Username, Email: arg.Email, CreatedAt: time.Now()}
+	r.users[user.Username] = user
+	return db.CreateUserTxResult{User: user}, nil
+}
+
+func (r *fakeUserRepository) Update(ctx context.Context, arg db.UpdateUserParams) (db.User, error) {
+	user, err := r.Get(ctx, arg.Username)
+	if err != nil {
+		return db.User{}, err
+	}
+	if arg.HashedPassword.Valid {
+		user.HashedPassword = arg.HashedPassword.String
+		user.PasswordChangedAt = arg.PasswordChangedAt.Time
+		if r.sessions != nil {
+			if err := r.sessions.BlockAll(ctx, user.Username); err != nil {
+				return db.User{}, err
+			}
+		}
+	}
+	if arg.Email.Valid {
+		user.Email = arg.Email.String
+	}
+	if arg.FullName.Valid {
+		user.FullName = arg.FullName.String
+	}
+	r.users[user.Username] = user
+	return user, nil
+}
+
+func (r *fakeUserRepository) RequestVerifyEmail(ctx context.Context, username string, requestedBefore time.Time) (db.User, error) {
+	user, ok := r.users[username]
+	if !ok || user.IsEmailVerified {
+		return db.User{}, internal.ErrNoRows
+	}
+	last, ok := r.requestedAt[username]
+	if !ok {
+		last = user.CreatedAt
+	}
+	if last.After(requestedBefore) {
+		return db.User{}, internal.ErrNoRows
+	}
+	r.requestedAt[username] = time.Now()
+	return user, nil
+}
+
+func (r *fakeUserRepository) RequestEmailChange(ctx context.Context, username string, newEmail string) (db.User, error) {
+	user, err := r.Get(ctx, username)
+	if err != nil {
+		return db.User{}, err
+	}
+	r.emailChanges[username] = newEmail
+	return user, nil
+}
+
+func (r *fakeUserRepository) ChangeRole(ctx context.Context, arg db.ChangeUserRoleTxParams) (db.User, error) {
+	user, err := r.Get(ctx, arg.Username)
+	if err != nil {
+		return db.User{}, err
+	}
+	user.Role = arg.Role
+	r.users[arg.Username] = user
+	return user, nil
+}
+
+type fakeVerifyEmailRepository struct {
+	verifyEmails map[int64]db.VerifyEmail
+}
+
+func (r *fakeVerifyEmailRepository) Create(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+	return db.VerifyEmail{}, nil
+}
+
+func (r *fakeVerifyEmailRepository) Get(ctx context.Context, id int64) (db.VerifyEmail, error) {
+	verifyEmail, ok := r.verifyEmails[id]
+	if !ok {
+		return db.VerifyEmail{}, internal.ErrNoRows
+	}
+	return verifyEmail, nil
+}
+
+func (r *fakeVerifyEmailRepository) Verify(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+	verifyEmail, ok := r.verifyEmails[arg.EmailId]
+	if !ok || verifyEmail.SecretCode != arg.SecretCode || verifyEmail.IsUsed || !time.Now().Before(verifyEmail.ExpiredAt) {
+		return db.VerifyEmailTxResult{}, internal.ErrNoRows
+	}
+	verifyEmail.IsUsed = true
+	r.verifyEmails[arg.EmailId] = verifyEmail
+	return db.VerifyEmailTxResult{VerifyEmail: verifyEmail, User: db.User{Username: verifyEmail.Username, IsEmailVerified: true}}, nil
+}
+
+func TestVerifyEmailErrors(t *testing.T) {
+	now := time.Now()
+	verifyEmailRepo := &fakeVerifyEmailRepository{verifyEmails: map[int64]db.VerifyEmail{
+		1: {ID: 1, Username: "alice", SecretCode: "valid", ExpiredAt: now.Add(time.Minute)},
+		2: {ID: 2, Username: "alice", SecretCode: "expired", ExpiredAt: now.Add(-time.Minute)},
+		3: {ID: 3, Username: "alice", SecretCode: "used", IsUsed: true, ExpiredAt: now.Add(time.Minute)},
+	}}
+	authSvc := NewAuthService(newFakeUserRepository(), nil, nil, time.Minute, time.Hour, verifyEmailRepo, nil, nil, nil, nil, nil)
+
+	testCases := []struct {
+		name       string
+		emailID    int64
+		secretCode string
+		wantErr    error
+	}{
+		{name: "Expired", emailID: 2, secretCode: "expired", wantErr: internal.ErrVerifyEmailExpired},
+		{name: "Used", emailID: 3, secretCode: "used", wantErr: internal.ErrVerifyEmailUsed},
+		{name: "WrongCode", emailID: 3, secretCode: "guess", wantErr: internal.ErrInvalidVerifyEmail},
+		{name: "UnknownID", emailID: 4, secretCode: "valid", wantErr: internal.ErrInvalidVerifyEmail},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := authSvc.VerifyEmail(context.Background(), db.VerifyEmailTxParams{EmailId: tc.emailID, SecretCode: tc.secretCode})
+			require.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+
+	result, err := authSvc.VerifyEmail(context.Background(), db.VerifyEmailTxParams{EmailId: 1, SecretCode: "valid"})
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+
+	// the code can't be used twice
+	_, err = authSvc.VerifyEmail(context.Background(), db.VerifyEmailTxParams{EmailId: 1, SecretCode: "valid"})
+	require.ErrorIs(t, err, internal.ErrVerifyEmailUsed)
+}
+
+func TestResendVerifyEmail(t *testing.T) {
+	broker := &fakeUserBroker{}
+	authSvc := NewAuthService(newFakeUserRepository(), nil, nil, time.Minute, time.Hour, nil, nil, broker, nil, nil, nil)
+
+	// whether the user exists, is verified or asked too recently is only checked by the task
+	require.NoError(t, authSvc.ResendVerifyEmail(context.Background(), "alice"))
+	require.NoError(t, authSvc.ResendVerifyEmail(context.Background(), "nobody"))
+	require.Equal(t, []redisRepo.PayloadResendVerifyEmail{{Username: "alice"}, {Username: "nobody"}}, broker.verifyEmails)
+	// a user gets one task per VerifyEmailResendInterval
+	require.Contains(t, broker.verifyEmailOpts, asynq.Unique(VerifyEmailResendInterval))
+}
+
+type fakeSessionRepository struct {
+	sessions map[uuid.UUID]db.Session
+	blocked  []string
+	// reused are the sessions whose family was revoked because their refresh token was reused
+	reused []db.Session
+}
+
+func (r *fakeSessionRepository) Create(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	session := db.Session{
+		ID:           arg.ID,
+		Username:     arg.Username,
+		RefreshToken: arg.RefreshToken,
+		ExpiresAt:    arg.ExpiresAt,
+		FamilyID:     arg.FamilyID,
+		ParentID:     arg.ParentID,
+	}
+	if r.sessions != nil {
+		r.sessions[arg.ID] = session
+	}
+	return session, nil
+}
+
+func (r *fakeSessionRepository) Get(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return db.Session{}, internal.ErrNoRows
+	}
+	return session, nil
+}
+
+func (r *fakeSessionRepository) BlockAll(ctx context.Context, username string) error {
+	r.blocked = append(r.blocked, username)
+	return nil
+}
+
+func (r *fakeSessionRepository) ListActive(ctx context.Context, username string) ([]db.Session, error) {
+	var sessions []db.Session
+	for _, session := range r.sessions {
+		if session.Username == username && !session.IsBlocked && !session.RotatedAt.Valid && time.Now().Before(session.ExpiresAt) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepository) BlockFamily(ctx context.Context, familyID uuid.UUID) error {
+	for id, session := range r.sessions {
+		if session.FamilyID == familyID {
+			session.IsBlocked = true
+			r.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (r *fakeSessionRepository) BlockOthers(ctx context.Context, username string, keepFamilyID uuid.UUID) error {
+	for id, session := range r.sessions {
+		if session.Username == username && session.FamilyID != keepFamilyID {
+			session.IsBlocked = true
+			r.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (r *fakeSessionRepository) Rotate(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	parent, ok := r.sessions[uuid.UUID(arg.ParentID.Bytes)]
+	if !ok || parent.RotatedAt.Valid || parent.IsBlocked {
+		return db.Session{}, internal.ErrNoRows
+	}
+	parent.RotatedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	r.sessions[parent.ID] = parent
+	return r.Create(ctx, arg)
+}
+
+func (r *fakeSessionRepository) RevokeFamily(ctx context.Context, session db.Session) error {
+	r.reused = append(r.reused, session)
+	return r.BlockFamily(ctx, session.FamilyID)
+}
+
+func (r *fakeSessionRepository) BlockAllByActor(ctx context.Context, arg db.BlockUserSessionsTxParams) error {
+	return r.BlockAll(ctx, arg.Username)
+}
+
+type fakeTokenRevocationRepository struct {
+	revoked   []uuid.UUID
+	notBefore map[string]time.Time
+}
+
+func newFakeTokenRevocationRepository() *fakeTokenRevocationRepository {
+	return &fakeTokenRevocationRepository{notBefore: map[string]time.Time{}}
+}
+
+func (r *fakeTokenRevocationRepository) Revoke(ctx context.Context, payload *token.Payload) error {
+	r.revoked = append(r.revoked, payload.ID)
+	return nil
+}
+
+func (r *fakeTokenRevocationRepository) SetNotBefore(ctx context.Context, username string, notBefore time.Time) error {
+	r.notBefore[username] = notBefore
+	return nil
+}
+
+type fakePasswordResetRepository struct {
+	passwordResets map[string]db.PasswordReset
+	// users and sessions are the repositories a reset writes to, as if in the same transaction
+	users    *fakeUserRepository
+	sessions *fakeSessionRepository
+}
+
+func (r *fakePasswordResetRepository) Create(ctx context.Context, arg db.CreatePasswordResetTxParams) (db.PasswordReset, error) {
+	passwordReset := db.PasswordReset{Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}
+	r.passwordResets[arg.TokenHash] = passwordReset
+	return passwordReset, nil
+}
+
+func (r *fakePasswordResetRepository) Reset(ctx context.Context, arg db.ResetPasswordTxParams) (db.User, error) {
+	passwordReset, ok := r.passwordResets[arg.TokenHash]
+	if !ok || passwordReset.IsUsed || !time.Now().Before(passwordReset.ExpiresAt) {
+		return db.User{}, internal.ErrNoRows
+	}
+	user, ok := r.users.users[passwordReset.Username]
+	if !ok {
+		return db.User{}, internal.ErrNoRows
+	}
+
+	passwordReset.IsUsed = true
+	r.passwordResets[arg.TokenHash] = passwordReset
+	user.HashedPassword = arg.HashedPassword
+	user.PasswordChangedAt = arg.PasswordChangedAt
+	r.users.users[user.Username] = user
+	return user, r.sessions.BlockAll(ctx, user.Username)
+}
+
+type fakeUserBroker struct {
+	passwordResets  []redisRepo.PayloadSendPasswordReset
+	verifyEmails    []redisRepo.PayloadResendVerifyEmail
+	verifyEmailOpts []asynq.Option
+}
+
+func (b *fakeUserBroker) CreatePasswordResetTask(ctx context.Context, payload redisRepo.PayloadSendPasswordReset, opts ...asynq.Option) error {
+	b.passwordResets = append(b.passwordResets, payload)
+	return nil
+}
+
+func (b *fakeUserBroker) CreateResendVerifyEmailTask(ctx context.Context, payload redisRepo.PayloadResendVerifyEmail, opts ...asynq.Option) error {
+	b.verifyEmails = append(b.verifyEmails, payload)
+	b.verifyEmailOpts = append(b.verifyEmailOpts, opts...)
+	return nil
+}
+
+func TestForgotPassword(t *testing.T) {
+	broker := &fakeUserBroker{}
+	authSvc := NewAuthService(newFakeUserRepository(), nil, nil, time.Minute, time.Hour, nil, nil, broker, nil, nil, nil)
+
+	// whether anyone has the address is only checked by the task
+	require.NoError(t, authSvc.ForgotPassword(context.Background(), "alice@example.com"))
+	require.NoError(t, authSvc.ForgotPassword(context.Background(), "nobody@example.com"))
+	require.Equal(t, []redisRepo.PayloadSendPasswordReset{{Email: "alice@example.com"}, {Email: "nobody@example.com"}}, broker.passwordResets)
+}
+
+func TestResetPassword(t *testing.T) {
+	oldPassword, err := pkg.HashPassword("old-secret")
+	require.NoError(t, err)
+	userRepo := newFakeUserRepository(db.User{Username: "alice", HashedPassword: oldPassword})
+	sessionRepo := &fakeSessionRepository{}
+	passwordResetRepo := &fakePasswordResetRepository{passwordResets: map[string]db.PasswordReset{}, users: userRepo, sessions: sessionRepo}
+	tokenRevocationRepo := newFakeTokenRevocationRepository()
+	authSvc := NewAuthService(userRepo, sessionRepo, nil, time.Minute, time.Hour, nil, passwordResetRepo, nil, nil, nil, tokenRevocationRepo)
+
+	token, tokenHash, err := NewPasswordResetToken()
+	require.NoError(t, err)
+	require.NotEqual(t, token, tokenHash)
+	_, err = passwordResetRepo.Create(context.Background(), db.CreatePasswordResetTxParams{
+		Username:  "alice",
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(PasswordResetTokenDuration),
+	})
+	require.NoError(t, err)
+
+	// an invalid password doesn't use up the token
+	_, err = authSvc.ResetPassword(context.Background(), ResetPasswordParams{Token: token, PlaintextPassword: "short"})
+	require.Error(t, err)
+	require.Empty(t, sessionRepo.blocked)
+
+	user, err := authSvc.ResetPassword(context.Background(), ResetPasswordParams{Token: token, PlaintextPassword: "new-secret"})
+	require.NoError(t, err)
+	require.NoError(t, pkg.CheckPassword("new-secret", user.HashedPassword))
+	require.WithinDuration(t, time.Now(), user.PasswordChangedAt, time.Second)
+	require.Equal(t, []string{"alice"}, sessionRepo.blocked)
+	// access tokens issued before the reset are rejected
+	require.Equal(t, user.PasswordChangedAt, tokenRevocationRepo.notBefore["alice"])
+
+	_, err = authSvc.ResetPassword(context.Background(), ResetPasswordParams{Token: token, PlaintextPassword: "other-secret"})
+	require.ErrorIs(t, err, internal.ErrInvalidPasswordReset)
+
+	_, err = authSvc.ResetPassword(context.Background(), ResetPasswordParams{Token: "guess", PlaintextPassword: "other-secret"})
+	require.ErrorIs(t, err, internal.ErrInvalidPasswordReset)
+}
+
+func TestUpdateEmailRequiresVerification(t *testing.T) {
+	alice := db.User{Username: "alice", Email: "alice@example.com", IsEmailVerified: true}
+	bob := db.User{Username: "bob", Email: "bob@example.com", IsEmailVerified: true}
+	userRepo := newFakeUserRepository(alice, bob)
+	s := NewAuthService(userRepo, nil, nil, time.Minute, time.Hour, nil, nil, nil, nil, nil, nil)
+
+	user, err := s.Update(context.Background(), UpdateUserParams{Username: "alice", FullName: "Alice", Email: "new@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, "alice@example.com", user.Email)
+	require.True(t, user.IsEmailVerified)
+	require.Equal(t, "Alice", user.FullName)
+	require.Equal(t, "new@example.com", userRepo.emailChanges["alice"])
+
+	// an address in use by another user is refused before anything is sent
+	_, err = s.Update(context.Background(), UpdateUserParams{Username: "alice", Email: "bob@example.com"})
+	require.ErrorIs(t, err, internal.ErrUniqueConstraintViolation)
+	require.Equal(t, "new@example.com", userRepo.emailChanges["alice"])
+
+	// the current address is no change
+	delete(userRepo.emailChanges, "alice")
+	_, err = s.Update(context.Background(), UpdateUserParams{Username: "alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	require.NotContains(t, userRepo.emailChanges, "alice")
+}
+
+func TestChangeRole(t *testing.T) {
+	userRepo := newFakeUserRepository(db.User{Username: "admin", Role: pkg.AdminRole}, db.User{Username: "alice", Role: pkg.DepositorRole})
+	tokenRevocationRepo := newFakeTokenRevocationRepository()
+	s := NewAuthService(userRepo, nil, nil, time.Minute, time.Hour, nil, nil, nil, nil, nil, tokenRevocationRepo)
+	ctx := context.Background()
+
+	_, err := s.ChangeRole(ctx, "admin", ChangeRoleParams{Username: "alice", Role: "owner"})
+	require.Error(t, err)
+	_, err = s.ChangeRole(ctx, "admin", ChangeRoleParams{Username: "admin", Role: pkg.DepositorRole})
+	require.ErrorIs(t, err, internal.ErrForbidden)
+	_, err = s.ChangeRole(ctx, "admin", ChangeRoleParams{Username: "nobody", Role: pkg.BankerRole})
+	require.ErrorIs(t, err, internal.ErrNoRows)
+	require.Empty(t, tokenRevocationRepo.notBefore)
+
+	user, err := s.ChangeRole(ctx, "admin", ChangeRoleParams{Username: "alice", Role: pkg.BankerRole})
+	require.NoError(t, err)
+	require.Equal(t, pkg.BankerRole, user.Role)
+	// access tokens carrying the old role are rejected
+	require.WithinDuration(t, time.Now(), tokenRevocationRepo.notBefore["alice"], time.Second)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+)
+
+// billReminderInterval is how long the organizer has to wait between two reminders of the same bill
+const billReminderInterval = 24 * time.Hour
+
+// BillRepository defines the methods that any Bill repository should implement.
+type BillRepository interface {
+	CreateTx(ctx context.Context, arg db.CreateBillTxParams) (db.CreateBillTxResult, error)
+	Get(ctx context.Context, id int64) (db.Bill, error)
+	ListByOrganizer(ctx context.Context, arg db.ListBillsByOrganizerParams) ([]db.Bill, error)
+	ListPaymentRequests(ctx context.Context, billID int64) ([]db.PaymentRequest, error)
+	MarkReminded(ctx context.Context, id int64, remindedBefore time.Time) (db.Bill, error)
+}
+
+// BillService defines the application service in charge of interacting with Bills. The payment requests of a bill
+// are sent by the events the repository writes to the outbox, the broker only sends reminders.
+type BillService struct {
+	repo   BillRepository
+	broker PaymentRequestMessageBrokerRepository
+}
+
+// NewBillService creates a new Bill service.
+func NewBillService(repo BillRepository, broker PaymentRequestMessageBrokerRepository) *BillService {
+	return &BillService{
+		repo:   repo,
+		broker: broker,
+	}
+}
+
+// BillParticipant is a user splitting a bill. Weight is only used by custom splits.
+type BillParticipant struct {
+	Username string `json:"username"`
+	Weight   int64  `json:"weight"`
+}
+
+// Create splits the bill between its participants and sends each of them a payment request for their share.
+// The organizer may take part in the split, their own share is not requested.
+func (s *BillService) Create(ctx context.Context, arg db.CreateBillParams, participants []BillParticipant, expiresAt time.Time) (db.CreateBillTxResult, error) {
+	shares, err := splitBill(pkg.NewMoney(arg.Total, arg.Currency), arg.Organizer, arg.SplitType, participants)
+	if err != nil {
+		return db.CreateBillTxResult{}, fmt.Errorf("%w; %w", internal.ErrInvalidParams, err)
+	}
+
+	return s.repo.CreateTx(ctx, db.CreateBillTxParams{
+		CreateBillParams: arg,
+		Shares:           shares,
+		ExpiresAt:        expiresAt,
+	})
+}
+
+func (s *BillService) ListByOrganizer(ctx context.Context, arg db.ListBillsByOrganizerParams) ([]db.Bill, error) {
+	return s.repo.ListByOrganizer(ctx, arg)
+}
+
+// BillSummary tells the organizer who has paid their share of a bill.
+type BillSummary struct {
+	db.Bill
+	// OrganizerShare is the part of the total that was not requested from anyone
+	OrganizerShare int64 `json:"organizer_share"`
+	Paid           int64 `json:"paid"`
+	Pending        int64 `json:"pending"`
+	// Unpaid adds up the shares that were declined or expired
+	Unpaid       int64               `json:"unpaid"`
+	Participants []db.PaymentRequest `json:"participants"`
+}
+
+// Summary returns the state of every share of the bill, only the organizer can see it.
+func (s *BillService) Summary(ctx context.Context, organizer string, id int64) (BillSummary, error) {
+	bill, err := s.getOrganized(ctx, organizer, id)
+	if err != nil {
+		return BillSummary{}, err
+	}
+
+	paymentRequests, err := s.repo.ListPaymentRequests(ctx, bill.ID)
+	if err != nil {
+		return BillSummary{}, err
+	}
+
+	return newBillSummary(bill, paymentRequests), nil
+}
+
+// Remind emails the participants that have not answered yet and returns how many were reminded.
+// A bill can be reminded once per billReminderInterval.
+func (s *BillService) Remind(ctx context.Context, organizer string, id int64) (int, error) {
+	bill, err := s.getOrganized(ctx, organizer, id)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	if _, err := s.repo.MarkReminded(ctx, bill.ID, now.Add(-billReminderInterval)); err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			return 0, internal.ErrBillReminderTooSoon
+		}
+		return 0, err
+	}
+
+	paymentRequests, err := s.repo.ListPaymentRequests(ctx, bill.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	reminded := 0
+	for _, paymentRequest := range paymentRequests {
+		if paymentRequest.Status != pkg.PaymentRequestStatusPending || !now.Before(paymentRequest.ExpiresAt) {
+			continue
+		}
+
+		err := s.broker.CreatePaymentRequestUpdateTask(ctx, redisRepo.PayloadSendPaymentRequestUpdate{
+			PaymentRequestID: paymentRequest.ID,
+			Status:           paymentRequest.Status,
+			Reminder:         true,
+		})
+		if err != nil {
+			return reminded, err
+		}
+		reminded++
+	}
+
+	return reminded, nil
+}
+
+// getOrganized returns the bill if the user is its organizer
+func (s *BillService) getOrganized(ctx context.Context, organizer string, id int64) (db.Bill, error) {
+	bill, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return db.Bill{}, err
+	}
+	if bill.Organizer != organizer {
+		return db.Bill{}, internal.ErrNoRows
+	}
+	return bill, nil
+}
+
+func newBillSummary(bill db.Bill, paymentRequests []db.PaymentRequest) BillSummary {
+	summary := BillSummary{
+		Bill:           bill,
+		OrganizerShare: bill.Total,
+		Participants:   paymentRequests,
+	}
+	for _, paymentRequest := range paymentRequests {
+		summary.OrganizerShare -= paymentRequest.Amount
+		switch paymentRequest.Status {
+		case pkg.PaymentRequestStatusAccepted:
+			summary.Paid += paymentRequest.Amount
+		case pkg.PaymentRequestStatusPending:
+			summary.Pending += paymentRequest.Amount
+		default:
+			summary.Unpaid += paymentRequest.Amount
+		}
+	}
+	return summary
+}
+
+// splitBill divides total between the participants, equally or in proportion to their weights.
+// Remainder minor units go to the first participants, so the shares always add up to the total.
+// The organizer's share and empty shares are left out since nobody has to pay them.
+func splitBill(total pkg.Money, organizer string, splitType string, participants []BillParticipant) ([]db.BillShare, error) {
+	if total.Amount <= 0 {
+		return nil, fmt.Errorf("total must be positive")
+	}
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("a bill needs at least one participant")
+	}
+
+	seen := make(map[string]bool, len(participants))
+	weights := make([]int64, len(participants))
+	for i, participant := range participants {
+		if seen[participant.Username] {
+			return nil, fmt.Errorf("participant %s is listed more than once", participant.Username)
+		}
+		seen[participant.Username] = true
+
+		switch splitType {
+		case pkg.BillSplitEqual:
+			weights[i] = 1
+		case pkg.BillSplitCustom:
+			if participant.Weight <= 0 {
+				return nil, fmt.Errorf("participant %s must have a positive weight", participant.Username)
+			}
+			weights[i] = participant.Weight
+		default:
+			return nil, fmt.Errorf("unknown split type %q", splitType)
+		}
+	}
+
+	amounts, err := total.Allocate(weights...)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]db.BillShare, 0, len(participants))
+	for i, participant := range participants {
+		if participant.Username == organizer || amounts[i].Amount == 0 {
+			continue
+		}
+		shares = append(shares, db.BillShare{
+			Payer:  participant.Username,
+			Amount: amounts[i].Amount,
+		})
+	}
+
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("nobody other than the organizer has to pay")
+	}
+
+	return shares, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitBillEqually(t *testing.T) {
+	// 100.00 between three people is 33.34, 33.33 and 33.33, the organizer keeps the first share
+	participants := []BillParticipant{{Username: "alice"}, {Username: "bob"}, {Username: "carol"}}
+	shares, err := splitBill(pkg.NewMoney(10000, pkg.EUR), "alice", pkg.BillSplitEqual, participants)
+	require.NoError(t, err)
+	require.Equal(t, []db.BillShare{
+		{Payer: "bob", Amount: 3333},
+		{Payer: "carol", Amount: 3333},
+	}, shares)
+
+	// the remainder goes to the first participants when the organizer is not part of the split
+	shares, err = splitBill(pkg.NewMoney(10000, pkg.EUR), "dave", pkg.BillSplitEqual, participants)
+	require.NoError(t, err)
+	require.Equal(t, []db.BillShare{
+		{Payer: "alice", Amount: 3334},
+		{Payer: "bob", Amount: 3333},
+		{Payer: "carol", Amount: 3333},
+	}, shares)
+}
+
+func TestSplitBillCustom(t *testing.T) {
+	participants := []BillParticipant{
+		{Username: "alice", Weight: 1},
+		{Username: "bob", Weight: 2},
+		{Username: "carol", Weight: 3},
+	}
+
+	shares, err := splitBill(pkg.NewMoney(1001, pkg.EUR), "dave", pkg.BillSplitCustom, participants)
+	require.NoError(t, err)
+
+	var total int64
+	for _, share := range shares {
+		total += share.Amount
+	}
+	require.Equal(t, int64(1001), total)
+
+	// 1001 * 1/6, 2/6 and 3/6 are truncated to 166, 333 and 500, the two cents left go to the first participants
+	require.Equal(t, []db.BillShare{
+		{Payer: "alice", Amount: 167},
+		{Payer: "bob", Amount: 334},
+		{Payer: "carol", Amount: 500},
+	}, shares)
+}
+
+func TestSplitBillInvalid(t *testing.T) {
+	testCases := []struct {
+		name         string
+		total        int64
+		splitType    string
+		participants []BillParticipant
+	}{
+		{
+			name:         "NoParticipants",
+			total:        1000,
+			splitType:    pkg.BillSplitEqual,
+			participants: nil,
+		},
+		{
+			name:         "NonPositiveTotal",
+			total:        0,
+			splitType:    pkg.BillSplitEqual,
+			participants: []BillParticipant{{Username: "bob"}},
+		},
+		{
+			name:         "DuplicateParticipant",
+			total:        1000,
+			splitType:    pkg.BillSplitEqual,
+			participants: []BillParticipant{{Username: "bob"}, {Username: "bob"}},
+		},
+		{
+			name:         "MissingWeight",
+			total:        1000,
+			splitType:    pkg.BillSplitCustom,
+			participants: []BillParticipant{{Username: "bob", Weight: 1}, {Username: "carol"}},
+		},
+		{
+			name:         "UnknownSplitType",
+			total:        1000,
+			splitType:    "random",
+			participants: []BillParticipant{{Username: "bob"}},
+		},
+		{
+			name:         "OnlyOrganizer",
+			total:        1000,
+			splitType:    pkg.BillSplitEqual,
+			participants: []BillParticipant{{Username: "alice"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := splitBill(pkg.NewMoney(tc.total, pkg.EUR), "alice", tc.splitType, tc.participants)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestBillSummary(t *testing.T) {
+	bill := db.Bill{ID: 1, Organizer: "alice", Total: 10000, Currency: pkg.EUR}
+	paymentRequests := []db.PaymentRequest{
+		{Payer: "bob", Amount: 3333, Status: pkg.PaymentRequestStatusAccepted},
+		{Payer: "carol", Amount: 3333, Status: pkg.PaymentRequestStatusPending},
+		{Payer: "dave", Amount: 1000, Status: pkg.PaymentRequestStatusDeclined},
+	}
+
+	summary := newBillSummary(bill, paymentRequests)
+	require.Equal(t, int64(2334), summary.OrganizerShare)
+	require.Equal(t, int64(3333), summary.Paid)
+	require.Equal(t, int64(3333), summary.Pending)
+	require.Equal(t, int64(1000), summary.Unpaid)
+	require.Len(t, summary.Participants, 3)
+}
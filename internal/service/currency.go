@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// CurrencyRepository defines the methods that any Currency repository should implement.
+type CurrencyRepository interface {
+	List(ctx context.Context) ([]db.Currency, error)
+	UpdateEnabled(ctx context.Context, arg db.UpdateCurrencyEnabledParams) (db.Currency, error)
+}
+
+// CurrencyService defines the application service in charge of interacting with Currencies.
+// It keeps the in-memory currency registry used for validation and formatting in sync with the database.
+type CurrencyService struct {
+	repo     CurrencyRepository
+	registry *pkg.CurrencyRegistry
+}
+
+// NewCurrencyService creates a new Currency service.
+func NewCurrencyService(repo CurrencyRepository, registry *pkg.CurrencyRegistry) *CurrencyService {
+	return &CurrencyService{
+		repo:     repo,
+		registry: registry,
+	}
+}
+
+// Load replaces the contents of the registry with the currencies stored in the database.
+func (s *CurrencyService) Load(ctx context.Context) error {
+	currencies, err := s.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list currencies: %w", err)
+	}
+
+	registryCurrencies := make([]pkg.Currency, 0, len(currencies))
+	for _, c := range currencies {
+		registryCurrencies = append(registryCurrencies, newRegistryCurrency(c))
+	}
+	s.registry.Load(registryCurrencies)
+
+	return nil
+}
+
+// List returns the currencies in the registry, optionally only the enabled ones.
+func (s *CurrencyService) List(ctx context.Context, enabledOnly bool) []pkg.Currency {
+	currencies := s.registry.List()
+	if !enabledOnly {
+		return currencies
+	}
+
+	enabled := make([]pkg.Currency, 0, len(currencies))
+	for _, c := range currencies {
+		if c.Enabled {
+			enabled = append(enabled, c)
+		}
+	}
+	return enabled
+}
+
+// SetEnabled enables or disables a currency for new accounts and transfers.
+func (s *CurrencyService) SetEnabled(ctx context.Context, code string, enabled bool) (pkg.Currency, error) {
+	currency, err := s.repo.UpdateEnabled(ctx, db.UpdateCurrencyEnabledParams{
+		Code:    code,
+		Enabled: enabled,
+	})
+	if err != nil {
+		return pkg.Currency{}, err
+	}
+
+	registryCurrency := newRegistryCurrency(currency)
+	s.registry.Set(registryCurrency)
+
+	return registryCurrency, nil
+}
+
+func newRegistryCurrency(c db.Currency) pkg.Currency {
+	return pkg.Currency{
+		Code:        c.Code,
+		NumericCode: c.NumericCode,
+		Name:        c.Name,
+		MinorUnits:  int(c.MinorUnits),
+		Symbol:      c.Symbol,
+		Enabled:     c.Enabled,
+	}
+}
@@ -2,20 +2,49 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
+	"net/mail"
 	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jordan-wright/email"
 	"github.com/marco-almeida/mybank/internal/postgresql/db"
 )
 
 const (
-	smtpAuthAddress   = "smtp.gmail.com"
-	smtpServerAddress = "smtp.gmail.com:587"
+	smtpGmailHost = "smtp.gmail.com"
+	smtpGmailPort = 587
+	// smtpTimeout bounds connecting to the server and every command after it
+	smtpTimeout = 30 * time.Second
+)
+
+// SMTP TLS modes
+const (
+	// SMTPTLSModeStartTLS connects in plain text and upgrades the connection with STARTTLS, failing if the server
+	// doesn't offer it. Usually on port 587
+	SMTPTLSModeStartTLS = "starttls"
+	// SMTPTLSModeTLS connects with TLS from the start, usually on port 465
+	SMTPTLSModeTLS = "tls"
+	// SMTPTLSModeNone never encrypts the connection, only for relays on a trusted network and local mail catchers
+	SMTPTLSModeNone = "none"
+)
+
+// SMTP auth mechanisms
+const (
+	SMTPAuthPlain   = "plain"
+	SMTPAuthLogin   = "login"
+	SMTPAuthCRAMMD5 = "cram-md5"
+	SMTPAuthNone    = "none"
 )
 
 type VerifyEmailRepository interface {
 	Create(ctx context.Context, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error)
+	Get(ctx context.Context, id int64) (db.VerifyEmail, error)
 	Verify(ctx context.Context, arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error)
 }
 
@@ -23,6 +52,7 @@ type EmailService interface {
 	SendEmail(
 		subject string,
 		content string,
+		textContent string,
 		to []string,
 		cc []string,
 		bcc []string,
@@ -30,23 +60,65 @@ type EmailService interface {
 	) error
 }
 
-type GmailSender struct {
-	name              string
-	fromEmailAddress  string
-	fromEmailPassword string
+// SMTPConfig is how an SMTPSender reaches and authenticates with the server.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	TLSMode  string
+	Auth     string
+	Username string
+	Password string
 }
 
-func NewGmailSender(name string, fromEmailAddress string, fromEmailPassword string) EmailService {
-	return &GmailSender{
-		name:              name,
-		fromEmailAddress:  fromEmailAddress,
-		fromEmailPassword: fromEmailPassword,
+// SMTPSender sends emails through any SMTP server.
+type SMTPSender struct {
+	name             string
+	fromEmailAddress string
+	config           SMTPConfig
+	tlsConfig        *tls.Config
+}
+
+// NewSMTPSender creates a sender of emails from fromEmailAddress, shown as name, through the server in config.
+func NewSMTPSender(name string, fromEmailAddress string, config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" || config.Port <= 0 {
+		return nil, fmt.Errorf("invalid smtp address %s:%d", config.Host, config.Port)
+	}
+	switch config.TLSMode {
+	case SMTPTLSModeStartTLS, SMTPTLSModeTLS, SMTPTLSModeNone:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", config.TLSMode)
 	}
+	switch config.Auth {
+	case SMTPAuthPlain, SMTPAuthLogin, SMTPAuthCRAMMD5, SMTPAuthNone:
+	default:
+		return nil, fmt.Errorf("unknown smtp auth mechanism %q", config.Auth)
+	}
+
+	return &SMTPSender{
+		name:             name,
+		fromEmailAddress: fromEmailAddress,
+		config:           config,
+		tlsConfig:        &tls.Config{ServerName: config.Host, MinVersion: tls.VersionTLS12},
+	}, nil
 }
 
-func (sender *GmailSender) SendEmail(
+// NewGmailSender creates a sender of emails through Gmail, authenticating as fromEmailAddress with an app password.
+func NewGmailSender(name string, fromEmailAddress string, fromEmailPassword string) EmailService {
+	sender, _ := NewSMTPSender(name, fromEmailAddress, SMTPConfig{
+		Host:     smtpGmailHost,
+		Port:     smtpGmailPort,
+		TLSMode:  SMTPTLSModeStartTLS,
+		Auth:     SMTPAuthPlain,
+		Username: fromEmailAddress,
+		Password: fromEmailPassword,
+	})
+	return sender
+}
+
+func (sender *SMTPSender) SendEmail(
 	subject string,
 	content string,
+	textContent string,
 	to []string,
 	cc []string,
 	bcc []string,
@@ -56,6 +128,7 @@ func (sender *GmailSender) SendEmail(
 	e.From = fmt.Sprintf("%s <%s>", sender.name, sender.fromEmailAddress)
 	e.Subject = subject
 	e.HTML = []byte(content)
+	e.Text = []byte(textContent)
 	e.To = to
 	e.Cc = cc
 	e.Bcc = bcc
@@ -67,6 +140,138 @@ func (sender *GmailSender) SendEmail(
 		}
 	}
 
-	smtpAuth := smtp.PlainAuth("", sender.fromEmailAddress, sender.fromEmailPassword, smtpAuthAddress)
-	return e.Send(smtpServerAddress, smtpAuth)
+	// bcc recipients are only in the envelope, the message leaves them out
+	var addresses []string
+	addresses = append(append(append(addresses, to...), cc...), bcc...)
+	recipients := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", address, err)
+		}
+		recipients = append(recipients, parsed.Address)
+	}
+	if len(recipients) == 0 {
+		return errors.New("email has no recipients")
+	}
+
+	msg, err := e.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	return sender.send(recipients, msg)
+}
+
+// send delivers msg to the recipients in one SMTP transaction
+func (sender *SMTPSender) send(recipients []string, msg []byte) error {
+	addr := net.JoinHostPort(sender.config.Host, strconv.Itoa(sender.config.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+
+	var conn net.Conn
+	var err error
+	if sender.config.TLSMode == SMTPTLSModeTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, sender.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, sender.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if sender.config.TLSMode == SMTPTLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server doesn't support STARTTLS")
+		}
+		if err := client.StartTLS(sender.tlsConfig); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if auth := sender.auth(); auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server doesn't support AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate with smtp server: %w", err)
+		}
+	}
+
+	if err := client.Mail(sender.fromEmailAddress); err != nil {
+		return fmt.Errorf("smtp server refused sender: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("smtp server refused recipient %s: %w", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// auth returns the configured auth mechanism, nil if the server is used without authentication
+func (sender *SMTPSender) auth() smtp.Auth {
+	switch sender.config.Auth {
+	case SMTPAuthPlain:
+		return smtp.PlainAuth("", sender.config.Username, sender.config.Password, sender.config.Host)
+	case SMTPAuthLogin:
+		return &loginAuth{host: sender.config.Host, username: sender.config.Username, password: sender.config.Password}
+	case SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(sender.config.Username, sender.config.Password)
+	}
+	return nil
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp leaves out but Microsoft servers among others require
+type loginAuth struct {
+	host     string
+	username string
+	password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// like smtp.PlainAuth, never send the password in the clear to a remote server
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected smtp LOGIN challenge %q", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
 }
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CapturedEmail is an email a CaptureSender kept instead of sending.
+type CapturedEmail struct {
+	ID          int64                `json:"id"`
+	Subject     string               `json:"subject"`
+	HTML        string               `json:"html"`
+	Text        string               `json:"text"`
+	To          []string             `json:"to"`
+	Cc          []string             `json:"cc"`
+	Bcc         []string             `json:"bcc"`
+	Attachments []CapturedAttachment `json:"attachments"`
+	SentAt      time.Time            `json:"sent_at"`
+}
+
+// CapturedAttachment describes a file attached to a captured email, its content is left out.
+type CapturedAttachment struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+// CaptureSender keeps the emails in memory instead of sending them, so tests and local runs can look at what would
+// have been sent. Only the latest emails are kept.
+type CaptureSender struct {
+	mu     sync.Mutex
+	limit  int
+	nextID int64
+	emails []CapturedEmail
+}
+
+// NewCaptureSender creates a sender that keeps the last limit emails.
+func NewCaptureSender(limit int) *CaptureSender {
+	return &CaptureSender{
+		limit:  max(limit, 1),
+		nextID: 1,
+	}
+}
+
+func (sender *CaptureSender) SendEmail(
+	subject string,
+	content string,
+	textContent string,
+	to []string,
+	cc []string,
+	bcc []string,
+	attachFiles []string,
+) error {
+	captured := CapturedEmail{
+		Subject:     subject,
+		HTML:        content,
+		Text:        textContent,
+		To:          slices.Clone(to),
+		Cc:          slices.Clone(cc),
+		Bcc:         slices.Clone(bcc),
+		Attachments: make([]CapturedAttachment, 0, len(attachFiles)),
+		SentAt:      time.Now(),
+	}
+	// the files are usually temporary, so they are read now like a real sender would
+	for _, f := range attachFiles {
+		info, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		captured.Attachments = append(captured.Attachments, CapturedAttachment{Filename: filepath.Base(f), Size: info.Size()})
+	}
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	captured.ID = sender.nextID
+	sender.nextID++
+	sender.emails = append(sender.emails, captured)
+	if len(sender.emails) > sender.limit {
+		sender.emails = slices.Delete(sender.emails, 0, len(sender.emails)-sender.limit)
+	}
+	return nil
+}
+
+// List returns the captured emails, newest first, optionally only those sent to recipient.
+func (sender *CaptureSender) List(recipient string) []CapturedEmail {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	emails := make([]CapturedEmail, 0, len(sender.emails))
+	for i := len(sender.emails) - 1; i >= 0; i-- {
+		email := sender.emails[i]
+		if recipient != "" && !slices.Contains(email.To, recipient) && !slices.Contains(email.Cc, recipient) && !slices.Contains(email.Bcc, recipient) {
+			continue
+		}
+		emails = append(emails, email)
+	}
+	return emails
+}
+
+// Clear deletes the captured emails.
+func (sender *CaptureSender) Clear() {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	sender.emails = nil
+}
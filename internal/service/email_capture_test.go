@@ -0,0 +1,48 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureSender(t *testing.T) {
+	sender := NewCaptureSender(2)
+
+	attachment := filepath.Join(t.TempDir(), "statement.pdf")
+	require.NoError(t, os.WriteFile(attachment, []byte("%PDF-1.4"), 0o600))
+
+	err := sender.SendEmail("First", "<p>1</p>", "1", []string{"alice@example.com"}, nil, nil, nil)
+	require.NoError(t, err)
+	err = sender.SendEmail("Second", "<p>2</p>", "2", []string{"bob@example.com"}, nil, []string{"alice@example.com"}, []string{attachment})
+	require.NoError(t, err)
+	err = sender.SendEmail("Third", "<p>3</p>", "3", []string{"bob@example.com"}, nil, nil, nil)
+	require.NoError(t, err)
+
+	// only the last two are kept, newest first
+	emails := sender.List("")
+	require.Len(t, emails, 2)
+	require.Equal(t, "Third", emails[0].Subject)
+	require.Equal(t, int64(3), emails[0].ID)
+	require.Equal(t, "Second", emails[1].Subject)
+	require.Equal(t, []CapturedAttachment{{Filename: "statement.pdf", Size: 8}}, emails[1].Attachments)
+
+	emails = sender.List("alice@example.com")
+	require.Len(t, emails, 1)
+	require.Equal(t, "Second", emails[0].Subject)
+
+	require.Empty(t, sender.List("carol@example.com"))
+
+	sender.Clear()
+	require.Empty(t, sender.List(""))
+}
+
+func TestCaptureSenderMissingAttachment(t *testing.T) {
+	sender := NewCaptureSender(10)
+
+	err := sender.SendEmail("Hello", "<p>hi</p>", "hi", []string{"alice@example.com"}, nil, nil, []string{filepath.Join(t.TempDir(), "missing.pdf")})
+	require.Error(t, err)
+	require.Empty(t, sender.List(""))
+}
@@ -1,8 +1,21 @@
 package service
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
 	"fmt"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/marco-almeida/mybank/internal/config"
 	"github.com/stretchr/testify/require"
@@ -19,7 +32,7 @@ func TestSendEmailWithGmail(t *testing.T) {
 	fmt.Println(config)
 
 	sender := NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
-	fmt.Println(sender.(*GmailSender))
+	fmt.Println(sender.(*SMTPSender))
 
 	subject := "A test email"
 	content := `
@@ -27,6 +40,253 @@ func TestSendEmailWithGmail(t *testing.T) {
 	`
 	to := []string{"marco.aa.almeida02@gmail.com"}
 
-	err = sender.SendEmail(subject, content, to, nil, nil, nil)
+	err = sender.SendEmail(subject, content, "Hello world", to, nil, nil, nil)
 	require.NoError(t, err)
 }
+
+// fakeSMTPServer accepts one session at a time and keeps what it received
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	// implicitTLS makes clients start with TLS, otherwise STARTTLS is offered unless noStartTLS is set
+	implicitTLS bool
+	noStartTLS  bool
+
+	mu       sync.Mutex
+	auth     []string
+	from     string
+	rcpt     []string
+	data     string
+	usedTLS  bool
+	sessions int
+}
+
+func newFakeSMTPServer(t *testing.T, implicitTLS bool) (*fakeSMTPServer, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	server := &fakeSMTPServer{
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		implicitTLS: implicitTLS,
+	}
+	if implicitTLS {
+		server.listener, err = tls.Listen("tcp", "127.0.0.1:0", server.tlsConfig)
+	} else {
+		server.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	require.NoError(t, err)
+	t.Cleanup(func() { server.listener.Close() })
+
+	go func() {
+		for {
+			conn, err := server.listener.Accept()
+			if err != nil {
+				return
+			}
+			server.serve(conn)
+		}
+	}()
+
+	return server, pool
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions++
+	_, s.usedTLS = conn.(*tls.Conn)
+
+	r := textproto.NewConn(conn)
+	r.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			_, encrypted := conn.(*tls.Conn)
+			r.PrintfLine("250-fake")
+			if !encrypted && !s.noStartTLS {
+				r.PrintfLine("250-STARTTLS")
+			}
+			r.PrintfLine("250 AUTH PLAIN LOGIN CRAM-MD5")
+		case "STARTTLS":
+			r.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			s.usedTLS = true
+			r = textproto.NewConn(conn)
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			s.auth = append(s.auth, mechanism)
+			switch mechanism {
+			case "PLAIN":
+				decoded, _ := base64.StdEncoding.DecodeString(initial)
+				s.auth = append(s.auth, strings.Split(string(decoded), "\x00")[1:]...)
+			case "LOGIN":
+				for _, prompt := range []string{"Username:", "Password:"} {
+					r.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+					answer, _ := r.ReadLine()
+					decoded, _ := base64.StdEncoding.DecodeString(answer)
+					s.auth = append(s.auth, string(decoded))
+				}
+			}
+			r.PrintfLine("235 authenticated")
+		case "MAIL":
+			s.from = arg
+			r.PrintfLine("250 ok")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, arg)
+			r.PrintfLine("250 ok")
+		case "DATA":
+			r.PrintfLine("354 go ahead")
+			data, err := r.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			r.PrintfLine("250 queued")
+		case "QUIT":
+			r.PrintfLine("221 bye")
+			return
+		default:
+			r.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPSenderTLSModes(t *testing.T) {
+	testCases := []struct {
+		name        string
+		tlsMode     string
+		auth        string
+		implicitTLS bool
+		wantAuth    []string
+		wantTLS     bool
+	}{
+		{
+			name:     "NoTLSLoginAuth",
+			tlsMode:  SMTPTLSModeNone,
+			auth:     SMTPAuthLogin,
+			wantAuth: []string{"LOGIN", "user", "secret"},
+			wantTLS:  false,
+		},
+		{
+			name:     "StartTLSPlainAuth",
+			tlsMode:  SMTPTLSModeStartTLS,
+			auth:     SMTPAuthPlain,
+			wantAuth: []string{"PLAIN", "user", "secret"},
+			wantTLS:  true,
+		},
+		{
+			name:        "ImplicitTLSNoAuth",
+			tlsMode:     SMTPTLSModeTLS,
+			auth:        SMTPAuthNone,
+			implicitTLS: true,
+			wantAuth:    nil,
+			wantTLS:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, pool := newFakeSMTPServer(t, tc.implicitTLS)
+
+			sender, err := NewSMTPSender("MyBank", "bank@example.com", SMTPConfig{
+				Host:     "127.0.0.1",
+				Port:     server.port(),
+				TLSMode:  tc.tlsMode,
+				Auth:     tc.auth,
+				Username: "user",
+				Password: "secret",
+			})
+			require.NoError(t, err)
+			sender.tlsConfig.RootCAs = pool
+
+			err = sender.SendEmail("Hello", "<h1>Hello</h1>", "Hello", []string{"Alice <alice@example.com>"}, nil, []string{"bob@example.com"}, nil)
+			require.NoError(t, err)
+
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			require.Equal(t, 1, server.sessions)
+			require.Equal(t, tc.wantTLS, server.usedTLS)
+			require.Equal(t, tc.wantAuth, server.auth)
+			require.Equal(t, "FROM:<bank@example.com>", server.from)
+			require.Equal(t, []string{"TO:<alice@example.com>", "TO:<bob@example.com>"}, server.rcpt)
+			require.Contains(t, server.data, "Subject: Hello")
+			require.Contains(t, server.data, "<h1>Hello</h1>")
+			require.NotContains(t, server.data, "bob@example.com")
+		})
+	}
+}
+
+func TestSMTPSenderRequiresStartTLS(t *testing.T) {
+	server, pool := newFakeSMTPServer(t, false)
+	server.mu.Lock()
+	server.noStartTLS = true
+	server.mu.Unlock()
+
+	sender, err := NewSMTPSender("MyBank", "bank@example.com", SMTPConfig{
+		Host:    "127.0.0.1",
+		Port:    server.port(),
+		TLSMode: SMTPTLSModeStartTLS,
+		Auth:    SMTPAuthNone,
+	})
+	require.NoError(t, err)
+	sender.tlsConfig.RootCAs = pool
+
+	err = sender.SendEmail("Hello", "<h1>Hello</h1>", "Hello", []string{"alice@example.com"}, nil, nil, nil)
+	require.ErrorContains(t, err, "STARTTLS")
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Empty(t, server.rcpt)
+}
+
+func TestNewSMTPSenderInvalidConfig(t *testing.T) {
+	valid := SMTPConfig{Host: "localhost", Port: 25, TLSMode: SMTPTLSModeNone, Auth: SMTPAuthNone}
+
+	_, err := NewSMTPSender("MyBank", "bank@example.com", valid)
+	require.NoError(t, err)
+
+	invalid := valid
+	invalid.TLSMode = "ssl"
+	_, err = NewSMTPSender("MyBank", "bank@example.com", invalid)
+	require.Error(t, err)
+
+	invalid = valid
+	invalid.Auth = "xoauth2"
+	_, err = NewSMTPSender("MyBank", "bank@example.com", invalid)
+	require.Error(t, err)
+
+	invalid = valid
+	invalid.Port = 0
+	_, err = NewSMTPSender("MyBank", "bank@example.com", invalid)
+	require.Error(t, err)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// LoanRepository defines the methods that any Loan repository should implement.
+type LoanRepository interface {
+	Create(ctx context.Context, arg db.CreateLoanParams) (db.Loan, error)
+	Get(ctx context.Context, id int64) (db.Loan, error)
+	ListByOwner(ctx context.Context, arg db.ListLoansByOwnerParams) ([]db.Loan, error)
+	ListByStatus(ctx context.Context, arg db.ListLoansByStatusParams) ([]db.Loan, error)
+	Reject(ctx context.Context, id int64, reviewedBy string) (db.Loan, error)
+	ListInstalments(ctx context.Context, loanID int64) ([]db.LoanInstalment, error)
+	ListDueInstalments(ctx context.Context, dueDate time.Time) ([]db.LoanInstalment, error)
+	DisburseTx(ctx context.Context, arg db.DisburseLoanTxParams) (db.DisburseLoanTxResult, error)
+	CollectInstalmentTx(ctx context.Context, arg db.CollectLoanInstalmentTxParams) (db.CollectLoanInstalmentTxResult, error)
+}
+
+// LoanService defines the application service in charge of interacting with Loans.
+type LoanService struct {
+	repo LoanRepository
+}
+
+// NewLoanService creates a new Loan service.
+func NewLoanService(repo LoanRepository) *LoanService {
+	return &LoanService{
+		repo: repo,
+	}
+}
+
+// Apply creates a loan application, it has no effect on the account until a banker approves it.
+func (s *LoanService) Apply(ctx context.Context, arg db.CreateLoanParams) (db.Loan, error) {
+	return s.repo.Create(ctx, arg)
+}
+
+func (s *LoanService) Get(ctx context.Context, id int64) (db.Loan, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *LoanService) ListByOwner(ctx context.Context, arg db.ListLoansByOwnerParams) ([]db.Loan, error) {
+	return s.repo.ListByOwner(ctx, arg)
+}
+
+// ListPending returns the applications waiting for a banker's review.
+func (s *LoanService) ListPending(ctx context.Context, limit int32, offset int32) ([]db.Loan, error) {
+	return s.repo.ListByStatus(ctx, db.ListLoansByStatusParams{
+		Status: pkg.LoanStatusPending,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (s *LoanService) ListInstalments(ctx context.Context, loanID int64) ([]db.LoanInstalment, error) {
+	return s.repo.ListInstalments(ctx, loanID)
+}
+
+// Approve disburses a pending loan into its account and creates its amortization schedule,
+// with the first instalment due one month from today.
+func (s *LoanService) Approve(ctx context.Context, id int64, reviewedBy string, annualRateBps int32, lateFee int64) (db.DisburseLoanTxResult, error) {
+	loan, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return db.DisburseLoanTxResult{}, err
+	}
+
+	if loan.Status != pkg.LoanStatusPending {
+		return db.DisburseLoanTxResult{}, internal.ErrLoanNotPending
+	}
+
+	instalments, err := newLoanSchedule(pkg.NewMoney(loan.Principal, loan.Currency), annualRateBps, loan.TermMonths, loan.ScheduleType, time.Now())
+	if err != nil {
+		return db.DisburseLoanTxResult{}, fmt.Errorf("%w; %w", internal.ErrInvalidParams, err)
+	}
+
+	return s.repo.DisburseTx(ctx, db.DisburseLoanTxParams{
+		LoanID:        loan.ID,
+		AnnualRateBps: annualRateBps,
+		LateFee:       lateFee,
+		ReviewedBy:    reviewedBy,
+		Instalments:   instalments,
+	})
+}
+
+func (s *LoanService) Reject(ctx context.Context, id int64, reviewedBy string) (db.Loan, error) {
+	loan, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return db.Loan{}, err
+	}
+
+	if loan.Status != pkg.LoanStatusPending {
+		return db.Loan{}, internal.ErrLoanNotPending
+	}
+
+	loan, err = s.repo.Reject(ctx, id, reviewedBy)
+	if errors.Is(err, internal.ErrNoRows) {
+		// reviewed by someone else in the meantime
+		return db.Loan{}, internal.ErrLoanNotPending
+	}
+	return loan, err
+}
+
+// LoanCollectionResult summarizes a run of CollectDueInstalments.
+type LoanCollectionResult struct {
+	Collected int `json:"collected"`
+	Unpaid    int `json:"unpaid"`
+}
+
+// CollectDueInstalments tries to debit every unpaid instalment due by now from its loan's account.
+// Instalments that are still unpaid once their due day is over become overdue and carry the loan's late fee.
+func (s *LoanService) CollectDueInstalments(ctx context.Context, now time.Time) (LoanCollectionResult, error) {
+	var result LoanCollectionResult
+
+	instalments, err := s.repo.ListDueInstalments(ctx, now)
+	if err != nil {
+		return result, err
+	}
+
+	var errs []error
+	for _, instalment := range instalments {
+		res, err := s.repo.CollectInstalmentTx(ctx, db.CollectLoanInstalmentTxParams{
+			InstalmentID: instalment.ID,
+			MarkOverdue:  !now.Before(instalment.DueDate.AddDate(0, 0, 1)),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("instalment %d: %w", instalment.ID, err))
+			continue
+		}
+
+		if res.Collected {
+			result.Collected++
+		} else {
+			result.Unpaid++
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// monthlyRateDenominator turns an annual rate in basis points into a monthly rate: bps / (12 * 10000)
+const monthlyRateDenominator = 12 * 10000
+
+// newLoanSchedule computes the amortization schedule of a loan disbursed at start.
+// Interest accrues monthly on the outstanding principal, and the last instalment repays
+// whatever principal is left so that rounding never leaves a balance behind.
+func newLoanSchedule(principal pkg.Money, annualRateBps int32, termMonths int32, scheduleType string, start time.Time) ([]db.CreateLoanInstalmentParams, error) {
+	if principal.Amount <= 0 {
+		return nil, fmt.Errorf("principal must be positive")
+	}
+	if termMonths <= 0 {
+		return nil, fmt.Errorf("term must be at least one month")
+	}
+	if annualRateBps < 0 {
+		return nil, fmt.Errorf("rate must not be negative")
+	}
+
+	balance := principal
+
+	// principal part of each instalment for linear schedules, total instalment for annuities
+	var linearShares []pkg.Money
+	var annuityPayment int64
+
+	switch {
+	case scheduleType == pkg.LoanScheduleLinear || (scheduleType == pkg.LoanScheduleAnnuity && annualRateBps == 0):
+		var err error
+		linearShares, err = balance.Split(int(termMonths))
+		if err != nil {
+			return nil, err
+		}
+	case scheduleType == pkg.LoanScheduleAnnuity:
+		annuityPayment = annuityInstalment(principal.Amount, annualRateBps, termMonths)
+	default:
+		return nil, fmt.Errorf("unknown schedule type %q", scheduleType)
+	}
+
+	dueDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	instalments := make([]db.CreateLoanInstalmentParams, 0, termMonths)
+	for i := int32(1); i <= termMonths; i++ {
+		interest, err := balance.MulRatio(int64(annualRateBps), monthlyRateDenominator, pkg.RoundHalfEven)
+		if err != nil {
+			return nil, err
+		}
+
+		var principalPart int64
+		switch {
+		case i == termMonths:
+			principalPart = balance.Amount
+		case linearShares != nil:
+			principalPart = linearShares[i-1].Amount
+		default:
+			principalPart = min(annuityPayment-interest.Amount, balance.Amount)
+		}
+
+		balance, err = balance.Sub(pkg.NewMoney(principalPart, balance.Currency))
+		if err != nil {
+			return nil, err
+		}
+
+		instalments = append(instalments, db.CreateLoanInstalmentParams{
+			Number:    i,
+			DueDate:   addMonths(dueDay, int(i)),
+			Principal: principalPart,
+			Interest:  interest.Amount,
+		})
+	}
+
+	return instalments, nil
+}
+
+// annuityInstalment returns the constant monthly payment P * r / (1 - (1 + r)^-n), rounded half up
+func annuityInstalment(principal int64, annualRateBps int32, termMonths int32) int64 {
+	rate := big.NewRat(int64(annualRateBps), monthlyRateDenominator)
+
+	// growth = (1 + r)^n
+	growth := big.NewRat(1, 1)
+	onePlusRate := new(big.Rat).Add(big.NewRat(1, 1), rate)
+	for i := int32(0); i < termMonths; i++ {
+		growth.Mul(growth, onePlusRate)
+	}
+
+	// P * r * growth / (growth - 1)
+	payment := new(big.Rat).SetInt64(principal)
+	payment.Mul(payment, rate)
+	payment.Mul(payment, growth)
+	payment.Quo(payment, new(big.Rat).Sub(growth, big.NewRat(1, 1)))
+
+	// round half up: floor((2 * num + den) / (2 * den))
+	num := new(big.Int).Lsh(payment.Num(), 1)
+	num.Add(num, payment.Denom())
+	den := new(big.Int).Lsh(payment.Denom(), 1)
+	return num.Quo(num, den).Int64()
+}
+
+// addMonths adds months to t, clamping the day to the end of shorter months (Jan 31 + 1 month is Feb 28/29)
+func addMonths(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	target := firstOfMonth.AddDate(0, months, 0)
+	lastDay := target.AddDate(0, 1, -1).Day()
+	return target.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
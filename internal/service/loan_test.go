@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnuityLoanSchedule(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 15, 4, 5, 0, time.UTC)
+
+	// 10000.00 at 12% a year over 12 months is 888.49 a month
+	instalments, err := newLoanSchedule(pkg.NewMoney(1_000_000, pkg.EUR), 1200, 12, pkg.LoanScheduleAnnuity, start)
+	require.NoError(t, err)
+	require.Len(t, instalments, 12)
+
+	var totalPrincipal int64
+	for i, instalment := range instalments {
+		require.Equal(t, int32(i+1), instalment.Number)
+		totalPrincipal += instalment.Principal
+		if i < len(instalments)-1 {
+			require.Equal(t, int64(88849), instalment.Principal+instalment.Interest)
+		}
+	}
+	require.Equal(t, int64(1_000_000), totalPrincipal)
+
+	// the first month accrues 1% of the whole principal
+	require.Equal(t, int64(10000), instalments[0].Interest)
+	require.Equal(t, int64(78849), instalments[0].Principal)
+
+	// the last instalment absorbs the rounding differences
+	last := instalments[11]
+	require.InDelta(t, 88849, last.Principal+last.Interest, 5)
+
+	// due dates are clamped to the end of shorter months
+	require.Equal(t, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), instalments[0].DueDate)
+	require.Equal(t, time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), instalments[1].DueDate)
+	require.Equal(t, time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC), instalments[11].DueDate)
+}
+
+func TestLinearLoanSchedule(t *testing.T) {
+	start := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	instalments, err := newLoanSchedule(pkg.NewMoney(1000, pkg.EUR), 1200, 3, pkg.LoanScheduleLinear, start)
+	require.NoError(t, err)
+	require.Len(t, instalments, 3)
+
+	// 1000 splits into 334, 333, 333 and interest is 1% of what is left
+	require.Equal(t, int64(334), instalments[0].Principal)
+	require.Equal(t, int64(10), instalments[0].Interest)
+	require.Equal(t, int64(333), instalments[1].Principal)
+	require.Equal(t, int64(7), instalments[1].Interest)
+	require.Equal(t, int64(333), instalments[2].Principal)
+	require.Equal(t, int64(3), instalments[2].Interest)
+}
+
+func TestZeroRateAnnuityLoanSchedule(t *testing.T) {
+	instalments, err := newLoanSchedule(pkg.NewMoney(1000, pkg.EUR), 0, 3, pkg.LoanScheduleAnnuity, time.Now())
+	require.NoError(t, err)
+
+	for _, instalment := range instalments {
+		require.Zero(t, instalment.Interest)
+	}
+	require.Equal(t, int64(334), instalments[0].Principal)
+	require.Equal(t, int64(333), instalments[2].Principal)
+}
+
+func TestInvalidLoanSchedule(t *testing.T) {
+	_, err := newLoanSchedule(pkg.NewMoney(0, pkg.EUR), 1200, 12, pkg.LoanScheduleAnnuity, time.Now())
+	require.Error(t, err)
+
+	_, err = newLoanSchedule(pkg.NewMoney(1000, pkg.EUR), 1200, 0, pkg.LoanScheduleAnnuity, time.Now())
+	require.Error(t, err)
+
+	_, err = newLoanSchedule(pkg.NewMoney(1000, pkg.EUR), 1200, 12, "balloon", time.Now())
+	require.Error(t, err)
+}
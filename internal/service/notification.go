@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// minutesPerDay bounds the quiet hours, which are stored as minutes after midnight
+const minutesPerDay = 24 * 60
+
+// NotificationRepository defines the methods that any Notification repository should implement.
+type NotificationRepository interface {
+	GetLowBalanceThreshold(ctx context.Context, accountID int64) (db.LowBalanceThreshold, error)
+	UpsertLowBalanceThreshold(ctx context.Context, arg db.UpsertLowBalanceThresholdParams) (db.LowBalanceThreshold, error)
+	DeleteLowBalanceThreshold(ctx context.Context, accountID int64) error
+	GetPreference(ctx context.Context, arg db.GetNotificationPreferenceParams) (db.NotificationPreference, error)
+	ListPreferences(ctx context.Context, username string) ([]db.NotificationPreference, error)
+	UpsertPreference(ctx context.Context, arg db.UpsertNotificationPreferenceParams) (db.NotificationPreference, error)
+	DeletePreference(ctx context.Context, arg db.DeleteNotificationPreferenceParams) error
+	CreatePushDevice(ctx context.Context, arg db.CreatePushDeviceParams) (db.PushDevice, error)
+	GetPushDevice(ctx context.Context, id int64) (db.PushDevice, error)
+	ListPushDevices(ctx context.Context, username string) ([]db.PushDevice, error)
+	DeletePushDevice(ctx context.Context, id int64) error
+	DeletePushDeviceByToken(ctx context.Context, token string) error
+}
+
+// NotificationService defines the application service in charge of the notification settings of customers.
+type NotificationService struct {
+	repo NotificationRepository
+}
+
+// NewNotificationService creates a new Notification service.
+func NewNotificationService(repo NotificationRepository) *NotificationService {
+	return &NotificationService{
+		repo: repo,
+	}
+}
+
+// GetLowBalanceThreshold returns the balance under which the owner of the account is notified.
+func (s *NotificationService) GetLowBalanceThreshold(ctx context.Context, accountID int64) (db.LowBalanceThreshold, error) {
+	return s.repo.GetLowBalanceThreshold(ctx, accountID)
+}
+
+// SetLowBalanceThreshold creates or replaces the low balance threshold of an account. The owner is notified the
+// next time a debit takes the balance from at least amount to below it.
+func (s *NotificationService) SetLowBalanceThreshold(ctx context.Context, accountID int64, amount int64) (db.LowBalanceThreshold, error) {
+	return s.repo.UpsertLowBalanceThreshold(ctx, db.UpsertLowBalanceThresholdParams{
+		AccountID: accountID,
+		Amount:    amount,
+	})
+}
+
+// DeleteLowBalanceThreshold stops the low balance notifications of an account.
+func (s *NotificationService) DeleteLowBalanceThreshold(ctx context.Context, accountID int64) error {
+	return s.repo.DeleteLowBalanceThreshold(ctx, accountID)
+}
+
+// QuietHours is a daily period, in minutes after midnight in Timezone, during which notifications are held.
+// The period crosses midnight when End is before Start.
+type QuietHours struct {
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Timezone string `json:"timezone"`
+}
+
+// NotificationPreference is how a user wants to be notified of a notification type.
+type NotificationPreference struct {
+	NotificationType string      `json:"notification_type"`
+	Channels         []string    `json:"channels"`
+	QuietHours       *QuietHours `json:"quiet_hours"`
+	// Default is set when the user didn't set a preference for the type
+	Default bool `json:"default"`
+}
+
+// QuietHoursError is returned for notifications that must wait until the quiet hours of the user end.
+type QuietHoursError struct {
+	Until time.Time
+}
+
+func (e *QuietHoursError) Error() string {
+	return fmt.Sprintf("notification held by quiet hours until %s", e.Until.Format(time.RFC3339))
+}
+
+// PostponedUntil reports when a task that failed with err may run again, if all of its errors, joined or wrapped,
+// are quiet hours. It is the earliest end of those quiet hours.
+func PostponedUntil(err error) (time.Time, bool) {
+	switch e := err.(type) {
+	case *QuietHoursError:
+		return e.Until, true
+	case interface{ Unwrap() []error }:
+		var until time.Time
+		for _, err := range e.Unwrap() {
+			u, ok := PostponedUntil(err)
+			if !ok {
+				return time.Time{}, false
+			}
+			if until.IsZero() || u.Before(until) {
+				until = u
+			}
+		}
+		return until, !until.IsZero()
+	case interface{ Unwrap() error }:
+		return PostponedUntil(e.Unwrap())
+	}
+	return time.Time{}, false
+}
+
+// ListPreferences returns the preferences of a user for every notification type, the default ones included.
+func (s *NotificationService) ListPreferences(ctx context.Context, username string) ([]NotificationPreference, error) {
+	stored, err := s.repo.ListPreferences(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[string]db.NotificationPreference, len(stored))
+	for _, preference := range stored {
+		byType[preference.NotificationType] = preference
+	}
+
+	preferences := make([]NotificationPreference, 0, len(pkg.NotificationTypes))
+	for _, notificationType := range pkg.NotificationTypes {
+		preference, ok := byType[notificationType]
+		if !ok {
+			preferences = append(preferences, defaultNotificationPreference(notificationType))
+			continue
+		}
+		preferences = append(preferences, newNotificationPreference(preference))
+	}
+	return preferences, nil
+}
+
+// GetPreference returns the preference of a user for a notification type, or the default one if they didn't set it.
+func (s *NotificationService) GetPreference(ctx context.Context, username string, notificationType string) (NotificationPreference, error) {
+	if !pkg.IsNotificationType(notificationType) {
+		return NotificationPreference{}, fmt.Errorf("%w; unknown notification type %q", internal.ErrInvalidParams, notificationType)
+	}
+
+	preference, err := s.repo.GetPreference(ctx, db.GetNotificationPreferenceParams{
+		Username:         username,
+		NotificationType: notificationType,
+	})
+	if err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			return defaultNotificationPreference(notificationType), nil
+		}
+		return NotificationPreference{}, err
+	}
+	return newNotificationPreference(preference), nil
+}
+
+// SetNotificationPreferenceParams contains the input parameters of SetPreference.
+type SetNotificationPreferenceParams struct {
+	Username         string
+	NotificationType string
+	// Channels turns the notification off when empty
+	Channels   []string
+	QuietHours *QuietHours
+}
+
+// SetPreference creates or replaces the preference of a user for a notification type.
+func (s *NotificationService) SetPreference(ctx context.Context, arg SetNotificationPreferenceParams) (NotificationPreference, error) {
+	if !pkg.IsNotificationType(arg.NotificationType) {
+		return NotificationPreference{}, fmt.Errorf("%w; unknown notification type %q", internal.ErrInvalidParams, arg.NotificationType)
+	}
+	for _, channel := range arg.Channels {
+		if !pkg.IsNotificationChannel(channel) {
+			return NotificationPreference{}, fmt.Errorf("%w; unknown notification channel %q", internal.ErrInvalidParams, channel)
+		}
+	}
+
+	upsert := db.UpsertNotificationPreferenceParams{
+		Username:         arg.Username,
+		NotificationType: arg.NotificationType,
+		Channels:         arg.Channels,
+		Timezone:         "UTC",
+	}
+	if upsert.Channels == nil {
+		upsert.Channels = []string{}
+	}
+	if arg.QuietHours != nil {
+		if err := validateQuietHours(*arg.QuietHours); err != nil {
+			return NotificationPreference{}, err
+		}
+		upsert.QuietHoursStart = pgtype.Int4{Int32: int32(arg.QuietHours.Start), Valid: true}
+		upsert.QuietHoursEnd = pgtype.Int4{Int32: int32(arg.QuietHours.End), Valid: true}
+		upsert.Timezone = arg.QuietHours.Timezone
+	}
+
+	preference, err := s.repo.UpsertPreference(ctx, upsert)
+	if err != nil {
+		return NotificationPreference{}, err
+	}
+	return newNotificationPreference(preference), nil
+}
+
+// DeletePreference reverts the preference of a user for a notification type to the default one.
+func (s *NotificationService) DeletePreference(ctx context.Context, username string, notificationType string) error {
+	if !pkg.IsNotificationType(notificationType) {
+		return fmt.Errorf("%w; unknown notification type %q", internal.ErrInvalidParams, notificationType)
+	}
+
+	return s.repo.DeletePreference(ctx, db.DeleteNotificationPreferenceParams{
+		Username:         username,
+		NotificationType: notificationType,
+	})
+}
+
+// Channels returns the channels a notification may be sent to the user on at now, none if they turned it off.
+// It returns a *QuietHoursError if the notification must wait for the quiet hours of the user to end.
+func (s *NotificationService) Channels(ctx context.Context, username string, notificationType string, now time.Time) ([]string, error) {
+	if notificationType == pkg.NotificationEmailVerification || notificationType == pkg.NotificationSecurityAlert {
+		return []string{pkg.NotificationChannelEmail}, nil
+	}
+
+	preference, err := s.GetPreference(ctx, username, notificationType)
+	if err != nil {
+		return nil, err
+	}
+	if len(preference.Channels) == 0 || preference.QuietHours == nil {
+		return preference.Channels, nil
+	}
+
+	if until, quiet := preference.QuietHours.until(now); quiet {
+		return nil, &QuietHoursError{Until: until}
+	}
+	return preference.Channels, nil
+}
+
+// until reports if now is within the quiet hours, and when they end
+func (q QuietHours) until(now time.Time) (time.Time, bool) {
+	location, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		// the time zone was valid when the preference was set, fall back to UTC rather than holding it forever
+		location = time.UTC
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	end := time.Date(local.Year(), local.Month(), local.Day(), q.End/60, q.End%60, 0, 0, location)
+
+	if q.Start < q.End {
+		return end, minute >= q.Start && minute < q.End
+	}
+	// the quiet hours cross midnight
+	if minute >= q.Start {
+		return end.AddDate(0, 0, 1), true
+	}
+	return end, minute < q.End
+}
+
+func validateQuietHours(q QuietHours) error {
+	if q.Start < 0 || q.Start >= minutesPerDay || q.End < 0 || q.End >= minutesPerDay {
+		return fmt.Errorf("%w; quiet hours must be within a day", internal.ErrInvalidParams)
+	}
+	if q.Start == q.End {
+		return fmt.Errorf("%w; quiet hours must start and end at different times", internal.ErrInvalidParams)
+	}
+	if _, err := time.LoadLocation(q.Timezone); err != nil || q.Timezone == "" || q.Timezone == "Local" {
+		return fmt.Errorf("%w; unknown time zone %q", internal.ErrInvalidParams, q.Timezone)
+	}
+	return nil
+}
+
+func newNotificationPreference(preference db.NotificationPreference) NotificationPreference {
+	result := NotificationPreference{
+		NotificationType: preference.NotificationType,
+		Channels:         preference.Channels,
+	}
+	if preference.QuietHoursStart.Valid && preference.QuietHoursEnd.Valid {
+		result.QuietHours = &QuietHours{
+			Start:    int(preference.QuietHoursStart.Int32),
+			End:      int(preference.QuietHoursEnd.Int32),
+			Timezone: preference.Timezone,
+		}
+	}
+	return result
+}
+
+func defaultNotificationPreference(notificationType string) NotificationPreference {
+	return NotificationPreference{
+		NotificationType: notificationType,
+		Channels:         pkg.DefaultNotificationChannels(notificationType),
+		Default:          true,
+	}
+}
+
+// RegisterPushDevice registers the device of a user for push notifications. A token that was registered before,
+// by the same or another user, is moved to the user.
+func (s *NotificationService) RegisterPushDevice(ctx context.Context, username string, token string, platform string) (db.PushDevice, error) {
+	if !pkg.IsPushPlatform(platform) {
+		return db.PushDevice{}, fmt.Errorf("%w; unknown push platform %q", internal.ErrInvalidParams, platform)
+	}
+
+	return s.repo.CreatePushDevice(ctx, db.CreatePushDeviceParams{
+		Username: username,
+		Token:    token,
+		Platform: platform,
+	})
+}
+
+// GetPushDevice returns a device registered for push notifications.
+func (s *NotificationService) GetPushDevice(ctx context.Context, id int64) (db.PushDevice, error) {
+	return s.repo.GetPushDevice(ctx, id)
+}
+
+// ListPushDevices returns the devices a user registered for push notifications.
+func (s *NotificationService) ListPushDevices(ctx context.Context, username string) ([]db.PushDevice, error) {
+	return s.repo.ListPushDevices(ctx, username)
+}
+
+// DeletePushDevice stops the push notifications to a device.
+func (s *NotificationService) DeletePushDevice(ctx context.Context, id int64) error {
+	return s.repo.DeletePushDevice(ctx, id)
+}
+
+// Recipient returns the addresses a user is notified at on every channel.
+func (s *NotificationService) Recipient(ctx context.Context, user db.User) (Recipient, error) {
+	devices, err := s.repo.ListPushDevices(ctx, user.Username)
+	if err != nil {
+		return Recipient{}, err
+	}
+
+	recipient := Recipient{
+		Username:    user.Username,
+		Name:        user.FullName,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		PushTokens:  make([]string, 0, len(devices)),
+	}
+	for _, device := range devices {
+		recipient.PushTokens = append(recipient.PushTokens, device.Token)
+	}
+	return recipient, nil
+}
+
+// ForgetPushTokens deletes the devices of tokens the push provider no longer accepts, e.g. after the app was
+// uninstalled.
+func (s *NotificationService) ForgetPushTokens(ctx context.Context, tokens []string) error {
+	var errs []error
+	for _, token := range tokens {
+		if err := s.repo.DeletePushDeviceByToken(ctx, token); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
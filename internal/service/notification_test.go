@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotificationRepository keeps preferences in memory, only what the tests below use is implemented
+type fakeNotificationRepository struct {
+	NotificationRepository
+	preferences map[string]db.NotificationPreference
+}
+
+func newFakeNotificationRepository() *fakeNotificationRepository {
+	return &fakeNotificationRepository{preferences: map[string]db.NotificationPreference{}}
+}
+
+func (r *fakeNotificationRepository) GetPreference(ctx context.Context, arg db.GetNotificationPreferenceParams) (db.NotificationPreference, error) {
+	preference, ok := r.preferences[arg.Username+"/"+arg.NotificationType]
+	if !ok {
+		return db.NotificationPreference{}, internal.ErrNoRows
+	}
+	return preference, nil
+}
+
+func (r *fakeNotificationRepository) ListPreferences(ctx context.Context, username string) ([]db.NotificationPreference, error) {
+	var preferences []db.NotificationPreference
+	for _, preference := range r.preferences {
+		if preference.Username == username {
+			preferences = append(preferences, preference)
+		}
+	}
+	return preferences, nil
+}
+
+func (r *fakeNotificationRepository) UpsertPreference(ctx context.Context, arg db.UpsertNotificationPreferenceParams) (db.NotificationPreference, error) {
+	preference := db.NotificationPreference{
+		Username:         arg.Username,
+		NotificationType: arg.NotificationType,
+		Channels:         arg.Channels,
+		QuietHoursStart:  arg.QuietHoursStart,
+		QuietHoursEnd:    arg.QuietHoursEnd,
+		Timezone:         arg.Timezone,
+	}
+	r.preferences[arg.Username+"/"+arg.NotificationType] = preference
+	return preference, nil
+}
+
+func TestNotificationChannelsDefaults(t *testing.T) {
+	svc := NewNotificationService(newFakeNotificationRepository())
+	now := time.Now()
+
+	channels, err := svc.Channels(context.Background(), "alice", pkg.NotificationTransferReceived, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{pkg.NotificationChannelEmail}, channels)
+
+	channels, err = svc.Channels(context.Background(), "alice", pkg.NotificationTransferSent, now)
+	require.NoError(t, err)
+	require.Empty(t, channels)
+
+	preferences, err := svc.ListPreferences(context.Background(), "alice")
+	require.NoError(t, err)
+	require.Len(t, preferences, len(pkg.NotificationTypes))
+	for _, preference := range preferences {
+		require.True(t, preference.Default)
+	}
+}
+
+func TestNotificationChannelsTurnedOff(t *testing.T) {
+	svc := NewNotificationService(newFakeNotificationRepository())
+
+	_, err := svc.SetPreference(context.Background(), SetNotificationPreferenceParams{
+		Username:         "alice",
+		NotificationType: pkg.NotificationLowBalance,
+		Channels:         []string{},
+	})
+	require.NoError(t, err)
+
+	channels, err := svc.Channels(context.Background(), "alice", pkg.NotificationLowBalance, time.Now())
+	require.NoError(t, err)
+	require.Empty(t, channels)
+
+	// the email verification can't be turned off
+	channels, err = svc.Channels(context.Background(), "alice", pkg.NotificationEmailVerification, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, []string{pkg.NotificationChannelEmail}, channels)
+}
+
+func TestNotificationChannelsQuietHours(t *testing.T) {
+	lisbon, err := time.LoadLocation("Europe/Lisbon")
+	require.NoError(t, err)
+
+	svc := NewNotificationService(newFakeNotificationRepository())
+	_, err = svc.SetPreference(context.Background(), SetNotificationPreferenceParams{
+		Username:         "alice",
+		NotificationType: pkg.NotificationTransferReceived,
+		Channels:         []string{pkg.NotificationChannelEmail},
+		QuietHours:       &QuietHours{Start: 22 * 60, End: 7 * 60, Timezone: "Europe/Lisbon"},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		now   time.Time
+		until time.Time
+	}{
+		{
+			name:  "before midnight",
+			now:   time.Date(2024, 7, 1, 23, 30, 0, 0, lisbon),
+			until: time.Date(2024, 7, 2, 7, 0, 0, 0, lisbon),
+		},
+		{
+			name:  "after midnight",
+			now:   time.Date(2024, 7, 2, 6, 59, 0, 0, lisbon),
+			until: time.Date(2024, 7, 2, 7, 0, 0, 0, lisbon),
+		},
+		{
+			name: "daytime",
+			now:  time.Date(2024, 7, 2, 7, 0, 0, 0, lisbon),
+		},
+		{
+			// 21:30 UTC is 22:30 in Lisbon summer time
+			name:  "other time zone",
+			now:   time.Date(2024, 7, 1, 21, 30, 0, 0, time.UTC),
+			until: time.Date(2024, 7, 2, 7, 0, 0, 0, lisbon),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			channels, err := svc.Channels(context.Background(), "alice", pkg.NotificationTransferReceived, tc.now)
+			if tc.until.IsZero() {
+				require.NoError(t, err)
+				require.Equal(t, []string{pkg.NotificationChannelEmail}, channels)
+				return
+			}
+
+			var quiet *QuietHoursError
+			require.ErrorAs(t, err, &quiet)
+			require.True(t, tc.until.Equal(quiet.Until), "until %s, want %s", quiet.Until, tc.until)
+		})
+	}
+}
+
+func TestSetNotificationPreferenceInvalid(t *testing.T) {
+	svc := NewNotificationService(newFakeNotificationRepository())
+
+	testCases := []SetNotificationPreferenceParams{
+		{Username: "alice", NotificationType: "unknown", Channels: []string{pkg.NotificationChannelEmail}},
+		{Username: "alice", NotificationType: pkg.NotificationStatement, Channels: []string{"pigeon"}},
+		{Username: "alice", NotificationType: pkg.NotificationStatement, QuietHours: &QuietHours{Start: 60, End: 60, Timezone: "UTC"}},
+		{Username: "alice", NotificationType: pkg.NotificationStatement, QuietHours: &QuietHours{Start: 60, End: 24 * 60, Timezone: "UTC"}},
+		{Username: "alice", NotificationType: pkg.NotificationStatement, QuietHours: &QuietHours{Start: 60, End: 120, Timezone: "Mars/Olympus"}},
+	}
+
+	for _, arg := range testCases {
+		_, err := svc.SetPreference(context.Background(), arg)
+		require.ErrorIs(t, err, internal.ErrInvalidParams)
+	}
+}
+
+func TestPostponedUntil(t *testing.T) {
+	early := time.Date(2024, 7, 2, 7, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+
+	until, postponed := PostponedUntil(fmt.Errorf("account 1: %w", &QuietHoursError{Until: late}))
+	require.True(t, postponed)
+	require.Equal(t, late, until)
+
+	until, postponed = PostponedUntil(errors.Join(&QuietHoursError{Until: late}, fmt.Errorf("account 2: %w", &QuietHoursError{Until: early})))
+	require.True(t, postponed)
+	require.Equal(t, early, until)
+
+	_, postponed = PostponedUntil(errors.Join(&QuietHoursError{Until: late}, errors.New("smtp down")))
+	require.False(t, postponed)
+
+	_, postponed = PostponedUntil(errors.New("smtp down"))
+	require.False(t, postponed)
+}
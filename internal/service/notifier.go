@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal/config"
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// Notification providers selected through config for each channel
+const (
+	NotificationProviderSMTP   = "smtp"
+	NotificationProviderGmail  = "gmail"
+	NotificationProviderTwilio = "twilio"
+	NotificationProviderFCM    = "fcm"
+	// NotificationProviderFile writes the rendered messages to NOTIFICATION_FILE_DIR, or stdout if it is empty
+	NotificationProviderFile = "file"
+	// NotificationProviderCapture keeps the emails in memory, they are listed by the admin emails endpoint
+	NotificationProviderCapture = "capture"
+	NotificationProviderNone    = "none"
+)
+
+// ErrNoAddress is returned by notifiers when the recipient has no address on their channel, e.g. no phone number.
+var ErrNoAddress = errors.New("recipient has no address on the channel")
+
+// Notification is a rendered message, every channel sends the parts it supports.
+type Notification struct {
+	Subject string
+	// HTML and Text are the parts of the email body
+	HTML string
+	Text string
+	// Summary is the one line sent by SMS and push, Text if empty
+	Summary string
+	// Attachments are paths of files attached to emails
+	Attachments []string
+}
+
+// summary returns what channels that can't show the whole notification send
+func (n Notification) summary() string {
+	if n.Summary != "" {
+		return n.Summary
+	}
+	return n.Text
+}
+
+// Recipient is who a notification is sent to, with their address on every channel.
+type Recipient struct {
+	Username    string
+	Name        string
+	Email       string
+	PhoneNumber string
+	PushTokens  []string
+}
+
+// Notifier sends notifications on one channel.
+type Notifier interface {
+	Notify(ctx context.Context, to Recipient, n Notification) error
+}
+
+// NewNotifiers returns the notifier of every channel with a provider configured, channels set to none are left out.
+// Emails go to capture with the capture provider.
+func NewNotifiers(config config.Config, capture *CaptureSender) (map[string]Notifier, error) {
+	notifiers := map[string]Notifier{}
+
+	switch config.NotificationEmailProvider {
+	case NotificationProviderSMTP, "":
+		smtpConfig := SMTPConfig{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			TLSMode:  config.SMTPTLSMode,
+			Auth:     config.SMTPAuth,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+		}
+		if smtpConfig.Username == "" {
+			smtpConfig.Username = config.EmailSenderAddress
+		}
+		if smtpConfig.Password == "" {
+			smtpConfig.Password = config.EmailSenderPassword
+		}
+		sender, err := NewSMTPSender(config.EmailSenderName, config.EmailSenderAddress, smtpConfig)
+		if err != nil {
+			return nil, err
+		}
+		notifiers[pkg.NotificationChannelEmail] = NewEmailNotifier(sender)
+	case NotificationProviderGmail:
+		notifiers[pkg.NotificationChannelEmail] = NewEmailNotifier(NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword))
+	case NotificationProviderCapture:
+		if capture == nil {
+			return nil, errors.New("capture email provider without a capture sender")
+		}
+		notifiers[pkg.NotificationChannelEmail] = NewEmailNotifier(capture)
+	case NotificationProviderFile:
+		notifiers[pkg.NotificationChannelEmail] = NewFileNotifier(pkg.NotificationChannelEmail, config.NotificationFileDir)
+	case NotificationProviderNone:
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", config.NotificationEmailProvider)
+	}
+
+	switch config.NotificationSMSProvider {
+	case NotificationProviderTwilio:
+		notifiers[pkg.NotificationChannelSMS] = NewTwilioSender(config.TwilioAccountSID, config.TwilioAuthToken, config.TwilioFromNumber)
+	case NotificationProviderFile:
+		notifiers[pkg.NotificationChannelSMS] = NewFileNotifier(pkg.NotificationChannelSMS, config.NotificationFileDir)
+	case NotificationProviderNone, "":
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", config.NotificationSMSProvider)
+	}
+
+	switch config.NotificationPushProvider {
+	case NotificationProviderFCM:
+		sender, err := NewFCMSender(config.FCMProjectID, config.FCMCredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		notifiers[pkg.NotificationChannelPush] = sender
+	case NotificationProviderFile:
+		notifiers[pkg.NotificationChannelPush] = NewFileNotifier(pkg.NotificationChannelPush, config.NotificationFileDir)
+	case NotificationProviderNone, "":
+	default:
+		return nil, fmt.Errorf("unknown push provider %q", config.NotificationPushProvider)
+	}
+
+	return notifiers, nil
+}
+
+// EmailNotifier sends notifications by email.
+type EmailNotifier struct {
+	sender EmailService
+}
+
+// NewEmailNotifier creates a notifier that sends emails with sender.
+func NewEmailNotifier(sender EmailService) *EmailNotifier {
+	return &EmailNotifier{
+		sender: sender,
+	}
+}
+
+func (notifier *EmailNotifier) Notify(ctx context.Context, to Recipient, n Notification) error {
+	if to.Email == "" {
+		return ErrNoAddress
+	}
+
+	content := n.HTML
+	if content == "" {
+		content = strings.ReplaceAll(html.EscapeString(n.Text), "\n", "<br/>\n")
+	}
+	return notifier.sender.SendEmail(n.Subject, content, n.Text, []string{to.Email}, nil, nil, n.Attachments)
+}
+
+// FileNotifier writes notifications to files instead of sending them, for development and tests.
+type FileNotifier struct {
+	channel string
+	dir     string
+	out     io.Writer
+	mu      sync.Mutex
+}
+
+// NewFileNotifier creates a notifier that writes the notifications of channel to a file each in dir, or to stdout if
+// dir is empty.
+func NewFileNotifier(channel string, dir string) *FileNotifier {
+	return &FileNotifier{
+		channel: channel,
+		dir:     dir,
+		out:     os.Stdout,
+	}
+}
+
+// unsafeFileChars are replaced in the names of the files notifications are written to
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+func (notifier *FileNotifier) Notify(ctx context.Context, to Recipient, n Notification) error {
+	var address string
+	switch notifier.channel {
+	case pkg.NotificationChannelEmail:
+		address = to.Email
+	case pkg.NotificationChannelSMS:
+		address = to.PhoneNumber
+	case pkg.NotificationChannelPush:
+		address = strings.Join(to.PushTokens, ", ")
+	}
+	if address == "" {
+		return ErrNoAddress
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Channel: %s\n", notifier.channel)
+	fmt.Fprintf(&b, "To: %s <%s>\n", to.Name, address)
+	fmt.Fprintf(&b, "Subject: %s\n", n.Subject)
+	for _, attachment := range n.Attachments {
+		fmt.Fprintf(&b, "Attachment: %s\n", filepath.Base(attachment))
+	}
+	if notifier.channel == pkg.NotificationChannelEmail {
+		fmt.Fprintf(&b, "\n%s\n", n.Text)
+		if n.HTML != "" {
+			fmt.Fprintf(&b, "\n%s\n", n.HTML)
+		}
+	} else {
+		fmt.Fprintf(&b, "\n%s\n", n.summary())
+	}
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+
+	if notifier.dir == "" {
+		_, err := fmt.Fprintf(notifier.out, "%s\n", b.String())
+		return err
+	}
+
+	if err := os.MkdirAll(notifier.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create notification directory: %w", err)
+	}
+	name := fmt.Sprintf("%d-%s-%s.txt", time.Now().UnixNano(), notifier.channel, unsafeFileChars.ReplaceAllString(to.Username, "_"))
+	if err := os.WriteFile(filepath.Join(notifier.dir, name), []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	return nil
+}
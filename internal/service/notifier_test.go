@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecipient = Recipient{
+	Username:    "alice",
+	Name:        "Alice",
+	Email:       "alice@example.com",
+	PhoneNumber: "+351912345678",
+	PushTokens:  []string{"token-a", "token-b"},
+}
+
+var testNotification = Notification{
+	Subject: "You received 10.00 EUR",
+	HTML:    "Hello Alice,<br/>\nBob sent 10.00 EUR to your account #1.<br/>",
+	Text:    "Bob sent 10.00 EUR to your account #1.",
+}
+
+func TestFileNotifierDir(t *testing.T) {
+	dir := t.TempDir()
+	notifier := NewFileNotifier(pkg.NotificationChannelEmail, dir)
+
+	err := notifier.Notify(context.Background(), testRecipient, testNotification)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.True(t, strings.HasSuffix(files[0].Name(), "-email-alice.txt"))
+
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(content), "To: Alice <alice@example.com>")
+	require.Contains(t, string(content), "Subject: You received 10.00 EUR")
+	require.Contains(t, string(content), testNotification.Text)
+	require.Contains(t, string(content), testNotification.HTML)
+}
+
+func TestFileNotifierStdout(t *testing.T) {
+	var out bytes.Buffer
+	notifier := NewFileNotifier(pkg.NotificationChannelSMS, "")
+	notifier.out = &out
+
+	err := notifier.Notify(context.Background(), testRecipient, testNotification)
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "To: Alice <+351912345678>")
+	// only emails carry the html body
+	require.NotContains(t, out.String(), "<br/>")
+
+	err = notifier.Notify(context.Background(), Recipient{Username: "bob"}, testNotification)
+	require.ErrorIs(t, err, ErrNoAddress)
+}
+
+func TestTwilioSender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		user, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "AC123", user)
+		require.Equal(t, "secret", password)
+		require.NoError(t, r.ParseForm())
+
+		if r.PostForm.Get("To") == "+15005550001" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 21211, "message": "The 'To' number is not a valid phone number."}`))
+			return
+		}
+		require.Equal(t, "+351912345678", r.PostForm.Get("To"))
+		require.Equal(t, "+15005550006", r.PostForm.Get("From"))
+		require.Equal(t, testNotification.Subject+"\n"+testNotification.Text, r.PostForm.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	sender := NewTwilioSender("AC123", "secret", "+15005550006")
+	sender.baseURL = server.URL
+
+	err := sender.Notify(context.Background(), testRecipient, testNotification)
+	require.NoError(t, err)
+
+	err = sender.Notify(context.Background(), Recipient{PhoneNumber: "+15005550001"}, testNotification)
+	require.ErrorContains(t, err, "21211")
+
+	err = sender.Notify(context.Background(), Recipient{Email: "bob@example.com"}, testNotification)
+	require.ErrorIs(t, err, ErrNoAddress)
+}
+
+func TestFCMSender(t *testing.T) {
+	tokenRequests := 0
+	var sent []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+		require.NotEmpty(t, r.PostForm.Get("assertion"))
+		w.Write([]byte(`{"access_token": "access", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("/v1/projects/mybank/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer access", r.Header.Get("Authorization"))
+		var body struct {
+			Message struct {
+				Token        string            `json:"token"`
+				Notification map[string]string `json:"notification"`
+			} `json:"message"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, testNotification.Subject, body.Message.Notification["title"])
+
+		if body.Message.Token == "token-b" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"status": "NOT_FOUND", "details": [{"errorCode": "UNREGISTERED"}]}}`))
+			return
+		}
+		sent = append(sent, body.Message.Token)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	credentials, err := json.Marshal(fcmCredentials{
+		ProjectID:   "mybank",
+		ClientEmail: "push@mybank.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		TokenURI:    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	credentialsFile := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(credentialsFile, credentials, 0600))
+
+	sender, err := NewFCMSender("", credentialsFile)
+	require.NoError(t, err)
+	sender.baseURL = server.URL
+
+	err = sender.Notify(context.Background(), testRecipient, testNotification)
+	var unregistered *UnregisteredTokensError
+	require.True(t, errors.As(err, &unregistered))
+	require.Equal(t, []string{"token-b"}, unregistered.Tokens)
+	require.Equal(t, []string{"token-a"}, sent)
+
+	// the access token is reused until it expires
+	err = sender.Notify(context.Background(), Recipient{PushTokens: []string{"token-a"}}, testNotification)
+	require.NoError(t, err)
+	require.Equal(t, 1, tokenRequests)
+
+	err = sender.Notify(context.Background(), Recipient{Email: "bob@example.com"}, testNotification)
+	require.ErrorIs(t, err, ErrNoAddress)
+}
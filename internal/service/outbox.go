@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// outboxBatchSize is how many events are published by one relay transaction
+	outboxBatchSize = 100
+	// outboxMaxAttempts is how many relays can fail to publish an event before it is parked, an outage of the
+	// broker doesn't count
+	outboxMaxAttempts = 10
+	// outboxBaseBackoff is how long the relay leaves an event alone after it failed once, it doubles with every
+	// further failure up to outboxMaxBackoff
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = time.Hour
+)
+
+// OutboxRepository defines the methods that any Outbox repository should implement.
+type OutboxRepository interface {
+	Relay(ctx context.Context, arg db.RelayOutboxTxParams) (db.RelayOutboxTxResult, error)
+	ListParked(ctx context.Context, arg db.ListParkedOutboxEventsParams) ([]db.Outbox, error)
+	Requeue(ctx context.Context, id int64) (db.Outbox, error)
+}
+
+// OutboxMessageBrokerRepository defines the methods that any OutboxMessageBrokerRepository should implement.
+type OutboxMessageBrokerRepository interface {
+	// Publish publishes the task of an event to the queue
+	Publish(ctx context.Context, event db.Outbox, opts ...asynq.Option) error
+}
+
+// OutboxService defines the application service in charge of relaying the outbox events to the message broker.
+type OutboxService struct {
+	repo   OutboxRepository
+	broker OutboxMessageBrokerRepository
+}
+
+// NewOutboxService creates a new Outbox service. The broker is only needed to relay the outbox.
+func NewOutboxService(repo OutboxRepository, broker OutboxMessageBrokerRepository) *OutboxService {
+	return &OutboxService{
+		repo:   repo,
+		broker: broker,
+	}
+}
+
+// Relay publishes the pending events in batches until none are left, and returns how many were published. Events
+// that keep failing are parked and logged.
+func (s *OutboxService) Relay(ctx context.Context) (int, error) {
+	var published int
+	for {
+		result, err := s.repo.Relay(ctx, db.RelayOutboxTxParams{
+			Limit:       outboxBatchSize,
+			MaxAttempts: outboxMaxAttempts,
+			Backoff:     outboxBackoff,
+			Publish: func(event db.Outbox) error {
+				return s.broker.Publish(ctx, event)
+			},
+		})
+		published += result.Published
+		if result.Parked != nil {
+			log.Error().Int64("event_id", result.Parked.ID).Str("type", result.Parked.EventType).
+				Int32("attempts", result.Parked.Attempts).Str("last_error", result.Parked.LastError).
+				Msg("parked outbox event")
+		}
+		if err != nil || !result.Pending {
+			return published, err
+		}
+	}
+}
+
+// outboxBackoff is how long the relay waits before publishing an event again after it failed attempts times
+func outboxBackoff(attempts int32) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := int32(1); i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, outboxMaxBackoff)
+}
+
+// ListParked returns the events the relay gave up on.
+func (s *OutboxService) ListParked(ctx context.Context, arg db.ListParkedOutboxEventsParams) ([]db.Outbox, error) {
+	return s.repo.ListParked(ctx, arg)
+}
+
+// Requeue hands a parked event back to the relay, which publishes it on its next run. It fails with
+// internal.ErrNoRows if the event isn't parked.
+func (s *OutboxService) Requeue(ctx context.Context, id int64) (db.Outbox, error) {
+	event, err := s.repo.Requeue(ctx, id)
+	if err != nil {
+		return db.Outbox{}, err
+	}
+	log.Info().Int64("event_id", event.ID).Str("type", event.EventType).Msg("requeued outbox event")
+	return event, nil
+}
+
+// Run relays the outbox every interval until ctx is done. Failures are logged and retried on the next tick.
+func (s *OutboxService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := s.Relay(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Int("published", published).Msg("failed to relay outbox")
+		} else if published > 0 {
+			log.Info().Int("published", published).Msg("relayed outbox")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutboxRepository hands out its events in batches like RelayOutboxTx, unpublished events stay pending until
+// they failed MaxAttempts times or can't be published. It doesn't back off.
+type fakeOutboxRepository struct {
+	events []db.Outbox
+	parked []db.Outbox
+}
+
+func (r *fakeOutboxRepository) Relay(ctx context.Context, arg db.RelayOutboxTxParams) (db.RelayOutboxTxResult, error) {
+	var result db.RelayOutboxTxResult
+	batch := r.events
+	if len(batch) > int(arg.Limit) {
+		batch = batch[:arg.Limit]
+	}
+	result.Pending = len(batch) == int(arg.Limit)
+
+	for _, event := range batch {
+		if err := arg.Publish(event); err != nil {
+			if errors.Is(err, db.ErrBrokerUnavailable) {
+				return result, nil
+			}
+			r.events[0].Attempts++
+			r.events[0].LastError = err.Error()
+			if r.events[0].Attempts >= arg.MaxAttempts || errors.Is(err, db.ErrEventUnpublishable) {
+				r.parked = append(r.parked, r.events[0])
+				result.Parked = &r.parked[len(r.parked)-1]
+				r.events = r.events[1:]
+			}
+			return result, err
+		}
+		r.events = r.events[1:]
+		result.Published++
+	}
+	return result, nil
+}
+
+func (r *fakeOutboxRepository) ListParked(ctx context.Context, arg db.ListParkedOutboxEventsParams) ([]db.Outbox, error) {
+	return r.parked, nil
+}
+
+func (r *fakeOutboxRepository) Requeue(ctx context.Context, id int64) (db.Outbox, error) {
+	for i, event := range r.parked {
+		if event.ID == id {
+			r.parked = append(r.parked[:i], r.parked[i+1:]...)
+			event.Attempts = 0
+			r.events = append(r.events, event)
+			return event, nil
+		}
+	}
+	return db.Outbox{}, internal.ErrNoRows
+}
+
+type fakeOutboxBroker struct {
+	published []int64
+	failOn    int64
+	failWith  error
+}
+
+func (b *fakeOutboxBroker) Publish(ctx context.Context, event db.Outbox, opts ...asynq.Option) error {
+	if event.ID == b.failOn {
+		if b.failWith != nil {
+			return b.failWith
+		}
+		return errors.New("task rejected")
+	}
+	b.published = append(b.published, event.ID)
+	return nil
+}
+
+func TestOutboxRelay(t *testing.T) {
+	repo := &fakeOutboxRepository{}
+	for id := int64(1); id <= 2*outboxBatchSize+1; id++ {
+		repo.events = append(repo.events, db.Outbox{ID: id})
+	}
+	broker := &fakeOutboxBroker{}
+
+	published, err := NewOutboxService(repo, broker).Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2*outboxBatchSize+1, published)
+	require.Len(t, broker.published, 2*outboxBatchSize+1)
+	require.Equal(t, int64(1), broker.published[0])
+	require.Empty(t, repo.events)
+}
+
+func TestOutboxRelayStopsOnFailure(t *testing.T) {
+	repo := &fakeOutboxRepository{}
+	for id := int64(1); id <= 5; id++ {
+		repo.events = append(repo.events, db.Outbox{ID: id})
+	}
+	broker := &fakeOutboxBroker{failOn: 3}
+
+	published, err := NewOutboxService(repo, broker).Relay(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 2, published)
+	require.Equal(t, []int64{1, 2}, broker.published)
+	require.Len(t, repo.events, 3)
+}
+
+func TestOutboxRelayParksFailingEvent(t *testing.T) {
+	repo := &fakeOutboxRepository{}
+	for id := int64(1); id <= 5; id++ {
+		repo.events = append(repo.events, db.Outbox{ID: id})
+	}
+	broker := &fakeOutboxBroker{failOn: 3}
+	svc := NewOutboxService(repo, broker)
+
+	for i := 1; i < outboxMaxAttempts; i++ {
+		_, err := svc.Relay(context.Background())
+		require.Error(t, err)
+		require.Empty(t, repo.parked)
+	}
+
+	// the event that keeps failing no longer holds up the ones after it
+	_, err := svc.Relay(context.Background())
+	require.Error(t, err)
+	require.Len(t, repo.parked, 1)
+	require.Equal(t, int64(3), repo.parked[0].ID)
+
+	published, err := svc.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, published)
+	require.Equal(t, []int64{1, 2, 4, 5}, broker.published)
+	require.Empty(t, repo.events)
+
+	// a parked event is published again once requeued
+	parked, err := svc.ListParked(context.Background(), db.ListParkedOutboxEventsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, parked, 1)
+
+	broker.failOn = 0
+	_, err = svc.Requeue(context.Background(), parked[0].ID)
+	require.NoError(t, err)
+	_, err = svc.Requeue(context.Background(), parked[0].ID)
+	require.ErrorIs(t, err, internal.ErrNoRows)
+
+	published, err = svc.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, published)
+	require.Equal(t, []int64{1, 2, 4, 5, 3}, broker.published)
+}
+
+func TestOutboxRelayBrokerUnavailable(t *testing.T) {
+	repo := &fakeOutboxRepository{}
+	for id := int64(1); id <= 5; id++ {
+		repo.events = append(repo.events, db.Outbox{ID: id})
+	}
+	broker := &fakeOutboxBroker{failOn: 3, failWith: fmt.Errorf("%w: connection refused", db.ErrBrokerUnavailable)}
+	svc := NewOutboxService(repo, broker)
+
+	// an outage outlasting every attempt doesn't park the event
+	for i := 0; i < 2*outboxMaxAttempts; i++ {
+		_, err := svc.Relay(context.Background())
+		require.NoError(t, err)
+	}
+	require.Empty(t, repo.parked)
+	require.Zero(t, repo.events[0].Attempts)
+
+	broker.failOn = 0
+	published, err := svc.Relay(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, published)
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, broker.published)
+}
+
+func TestOutboxRelayParksUnpublishableEvent(t *testing.T) {
+	repo := &fakeOutboxRepository{}
+	for id := int64(1); id <= 5; id++ {
+		repo.events = append(repo.events, db.Outbox{ID: id})
+	}
+	broker := &fakeOutboxBroker{failOn: 3, failWith: fmt.Errorf("%w: bad payload", db.ErrEventUnpublishable)}
+
+	_, err := NewOutboxService(repo, broker).Relay(context.Background())
+	require.Error(t, err)
+	require.Len(t, repo.parked, 1)
+	require.Equal(t, int32(1), repo.parked[0].Attempts)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	require.Equal(t, outboxBaseBackoff, outboxBackoff(1))
+	require.Equal(t, 2*outboxBaseBackoff, outboxBackoff(2))
+	require.Equal(t, 8*outboxBaseBackoff, outboxBackoff(4))
+	require.Equal(t, 512*outboxBaseBackoff, outboxBackoff(outboxMaxAttempts))
+	require.Equal(t, outboxMaxBackoff, outboxBackoff(1000))
+}
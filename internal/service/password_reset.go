@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+)
+
+const (
+	// PasswordResetTokenDuration is how long a password reset token can be used for
+	PasswordResetTokenDuration = 15 * time.Minute
+	// PasswordResetInterval is how long a user has to wait between two password reset emails
+	PasswordResetInterval = time.Minute
+)
+
+// PasswordResetRepository defines the methods that any PasswordReset repository should implement.
+type PasswordResetRepository interface {
+	Create(ctx context.Context, arg db.CreatePasswordResetTxParams) (db.PasswordReset, error)
+	Reset(ctx context.Context, arg db.ResetPasswordTxParams) (db.User, error)
+}
+
+// UserMessageBroker defines the user tasks the auth service enqueues.
+type UserMessageBroker interface {
+	CreatePasswordResetTask(ctx context.Context, payload redisRepo.PayloadSendPasswordReset, opts ...asynq.Option) error
+	CreateResendVerifyEmailTask(ctx context.Context, payload redisRepo.PayloadResendVerifyEmail, opts ...asynq.Option) error
+}
+
+// NewPasswordResetToken returns a random password reset token and the hash it is stored as.
+func NewPasswordResetToken() (token string, tokenHash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashPasswordResetToken(token), nil
+}
+
+// HashPasswordResetToken returns the hash a password reset token is stored as, so that the tokens can't be used by
+// whoever reads the database.
+func HashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ForgotPassword emails a password reset token to the user with the email address. A user gets one email per
+// PasswordResetInterval, a request within it is dropped and the token sent before keeps working. It behaves the same
+// whether anyone has the address or asked too recently, so that it can't tell who has an account: all of it is
+// checked by the task that sends the email.
+func (s *AuthServiceImpl) ForgotPassword(ctx context.Context, email string) error {
+	// requests for an address already waiting in the queue are dropped here already
+	return s.userBroker.CreatePasswordResetTask(ctx, redisRepo.PayloadSendPasswordReset{Email: email},
+		asynq.Unique(PasswordResetInterval))
+}
+
+type ResetPasswordParams struct {
+	Token             string
+	PlaintextPassword string
+}
+
+// ResetPassword sets the password of the user the token was emailed to and blocks all their sessions, which were
+// opened with the old password. A token works once, internal.ErrInvalidPasswordReset is returned for tokens that
+// don't exist, were used or expired.
+func (s *AuthServiceImpl) ResetPassword(ctx context.Context, arg ResetPasswordParams) (db.User, error) {
+	// the token is only used up once the new password is known to be valid
+	err := validate.Struct(UpdateUserPasswordParams{arg.PlaintextPassword})
+	if err != nil {
+		return db.User{}, err
+	}
+
+	hashedPassword, err := pkg.HashPassword(arg.PlaintextPassword)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	user, err := s.passwordResetRepo.Reset(ctx, db.ResetPasswordTxParams{
+		TokenHash:         HashPasswordResetToken(arg.Token),
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			return db.User{}, fmt.Errorf("%w: %w", internal.ErrInvalidPasswordReset, err)
+		}
+		return db.User{}, err
+	}
+
+	// access tokens issued with the old password are rejected from now on
+	err = s.tokenRevocationRepo.SetNotBefore(ctx, user.Username, user.PasswordChangedAt)
+	if err != nil {
+		return db.User{}, fmt.Errorf("cannot revoke access tokens: %w", err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/token"
+	"github.com/skip2/go-qrcode"
+)
+
+// PaymentLinkRepository defines the methods that any PaymentLink repository should implement.
+type PaymentLinkRepository interface {
+	Create(ctx context.Context, arg db.CreatePaymentLinkParams) (db.PaymentLink, error)
+	Get(ctx context.Context, id int64) (db.PaymentLink, error)
+	ListByOwner(ctx context.Context, arg db.ListPaymentLinksByOwnerParams) ([]db.PaymentLink, error)
+	ListRedemptions(ctx context.Context, paymentLinkID int64) ([]db.PaymentLinkRedemption, error)
+	RedeemTx(ctx context.Context, arg db.RedeemPaymentLinkTxParams) (db.RedeemPaymentLinkTxResult, error)
+}
+
+// PaymentLinkSigner defines the methods used to sign and verify payment links.
+type PaymentLinkSigner interface {
+	Sign(payload token.PaymentLinkPayload) (string, error)
+	Verify(link string) (*token.PaymentLinkPayload, error)
+}
+
+// PaymentLinkService defines the application service in charge of interacting with PaymentLinks.
+type PaymentLinkService struct {
+	repo          PaymentLinkRepository
+	signer        PaymentLinkSigner
+	publicBaseURL string
+}
+
+// NewPaymentLinkService creates a new PaymentLink service.
+func NewPaymentLinkService(repo PaymentLinkRepository, signer PaymentLinkSigner, publicBaseURL string) *PaymentLinkService {
+	return &PaymentLinkService{
+		repo:          repo,
+		signer:        signer,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+	}
+}
+
+// SignedPaymentLink is a payment link along with the signed token that encodes it and the URL to share.
+type SignedPaymentLink struct {
+	db.PaymentLink
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// PaymentLinkDetails is a signed payment link and every payment made through it.
+type PaymentLinkDetails struct {
+	SignedPaymentLink
+	Redemptions []db.PaymentLinkRedemption `json:"redemptions"`
+}
+
+func (s *PaymentLinkService) Create(ctx context.Context, arg db.CreatePaymentLinkParams) (SignedPaymentLink, error) {
+	paymentLink, err := s.repo.Create(ctx, arg)
+	if err != nil {
+		return SignedPaymentLink{}, err
+	}
+	return s.sign(paymentLink)
+}
+
+func (s *PaymentLinkService) ListByOwner(ctx context.Context, arg db.ListPaymentLinksByOwnerParams) ([]SignedPaymentLink, error) {
+	paymentLinks, err := s.repo.ListByOwner(ctx, arg)
+	if err != nil {
+		return []SignedPaymentLink{}, err
+	}
+
+	signedLinks := make([]SignedPaymentLink, 0, len(paymentLinks))
+	for _, paymentLink := range paymentLinks {
+		signedLink, err := s.sign(paymentLink)
+		if err != nil {
+			return []SignedPaymentLink{}, err
+		}
+		signedLinks = append(signedLinks, signedLink)
+	}
+	return signedLinks, nil
+}
+
+// Get returns the payment link with its redemptions, only the owner of the link can see it.
+func (s *PaymentLinkService) Get(ctx context.Context, owner string, id int64) (PaymentLinkDetails, error) {
+	signedLink, err := s.getOwned(ctx, owner, id)
+	if err != nil {
+		return PaymentLinkDetails{}, err
+	}
+
+	redemptions, err := s.repo.ListRedemptions(ctx, id)
+	if err != nil {
+		return PaymentLinkDetails{}, err
+	}
+
+	return PaymentLinkDetails{
+		SignedPaymentLink: signedLink,
+		Redemptions:       redemptions,
+	}, nil
+}
+
+// QRCode renders the URL of the payment link as a PNG image of size x size pixels.
+func (s *PaymentLinkService) QRCode(ctx context.Context, owner string, id int64, size int) ([]byte, error) {
+	signedLink, err := s.getOwned(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+	return qrcode.Encode(signedLink.URL, qrcode.Medium, size)
+}
+
+// Redeem pays a signed payment link from the payer's account. Links with a fixed amount ignore amount
+// unless it's different from the fixed one, links without one need the payer to choose a positive amount.
+func (s *PaymentLinkService) Redeem(ctx context.Context, payer string, link string, fromAccountID int64, amount int64) (db.RedeemPaymentLinkTxResult, error) {
+	payload, err := s.Verify(link)
+	if err != nil {
+		return db.RedeemPaymentLinkTxResult{}, err
+	}
+
+	if payload.Amount != 0 {
+		if amount != 0 && amount != payload.Amount {
+			return db.RedeemPaymentLinkTxResult{}, fmt.Errorf("%w; the payment link is for %d", internal.ErrInvalidAmount, payload.Amount)
+		}
+		amount = payload.Amount
+	}
+	if amount <= 0 {
+		return db.RedeemPaymentLinkTxResult{}, fmt.Errorf("%w; amount must be positive", internal.ErrInvalidAmount)
+	}
+
+	return s.repo.RedeemTx(ctx, db.RedeemPaymentLinkTxParams{
+		PaymentLinkID: payload.LinkID,
+		Payer:         payer,
+		FromAccountID: fromAccountID,
+		Amount:        amount,
+	})
+}
+
+// Verify checks the signature and expiry of a signed payment link and returns its content.
+func (s *PaymentLinkService) Verify(link string) (*token.PaymentLinkPayload, error) {
+	payload, err := s.signer.Verify(link)
+	if err != nil {
+		if errors.Is(err, token.ErrExpiredToken) {
+			return nil, fmt.Errorf("%w: %w", internal.ErrPaymentLinkExpired, err)
+		}
+		return nil, fmt.Errorf("%w: %w", internal.ErrInvalidPaymentLink, err)
+	}
+	return payload, nil
+}
+
+// getOwned returns the signed payment link if the user owns it
+func (s *PaymentLinkService) getOwned(ctx context.Context, owner string, id int64) (SignedPaymentLink, error) {
+	paymentLink, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return SignedPaymentLink{}, err
+	}
+	if paymentLink.Owner != owner {
+		return SignedPaymentLink{}, internal.ErrNoRows
+	}
+	return s.sign(paymentLink)
+}
+
+// sign encodes the payment link in a signed token, signing is deterministic so a link always has the same token
+func (s *PaymentLinkService) sign(paymentLink db.PaymentLink) (SignedPaymentLink, error) {
+	signedToken, err := s.signer.Sign(token.PaymentLinkPayload{
+		LinkID:      paymentLink.ID,
+		ToAccountID: paymentLink.ToAccountID,
+		Amount:      paymentLink.Amount.Int64,
+		Currency:    paymentLink.Currency,
+		Reference:   paymentLink.Reference,
+		SingleUse:   paymentLink.SingleUse,
+		ExpiredAt:   paymentLink.ExpiresAt,
+	})
+	if err != nil {
+		return SignedPaymentLink{}, err
+	}
+
+	return SignedPaymentLink{
+		PaymentLink: paymentLink,
+		Token:       signedToken,
+		URL:         s.publicBaseURL + "/pay?token=" + url.QueryEscape(signedToken),
+	}, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+)
+
+// PaymentRequestRepository defines the methods that any PaymentRequest repository should implement.
+type PaymentRequestRepository interface {
+	CreateTx(ctx context.Context, arg db.CreatePaymentRequestParams) (db.PaymentRequest, error)
+	Get(ctx context.Context, id int64) (db.PaymentRequest, error)
+	ListIncoming(ctx context.Context, arg db.ListIncomingPaymentRequestsParams) ([]db.PaymentRequest, error)
+	ListOutgoing(ctx context.Context, arg db.ListOutgoingPaymentRequestsParams) ([]db.PaymentRequest, error)
+	ListExpired(ctx context.Context, now time.Time) ([]db.PaymentRequest, error)
+	AcceptTx(ctx context.Context, arg db.AcceptPaymentRequestTxParams) (db.AcceptPaymentRequestTxResult, error)
+	UpdateStatusTx(ctx context.Context, arg db.UpdatePaymentRequestStatusTxParams) (db.PaymentRequest, error)
+}
+
+// PaymentRequestMessageBrokerRepository defines the methods that any PaymentRequestMessageBrokerRepository should implement.
+type PaymentRequestMessageBrokerRepository interface {
+	// CreatePaymentRequestUpdateTask publishes task to queue
+	CreatePaymentRequestUpdateTask(ctx context.Context, payload redisRepo.PayloadSendPaymentRequestUpdate, opts ...asynq.Option) error
+}
+
+// PaymentRequestService defines the application service in charge of interacting with PaymentRequests.
+// The other party is told about every change by the event the repository writes to the outbox.
+type PaymentRequestService struct {
+	repo PaymentRequestRepository
+}
+
+// NewPaymentRequestService creates a new PaymentRequest service.
+func NewPaymentRequestService(repo PaymentRequestRepository) *PaymentRequestService {
+	return &PaymentRequestService{
+		repo: repo,
+	}
+}
+
+// Create asks the payer for money and lets them know by email.
+func (s *PaymentRequestService) Create(ctx context.Context, arg db.CreatePaymentRequestParams) (db.PaymentRequest, error) {
+	if arg.Payer == arg.Requester {
+		return db.PaymentRequest{}, fmt.Errorf("%w; cannot request money from yourself", internal.ErrInvalidParams)
+	}
+
+	return s.repo.CreateTx(ctx, arg)
+}
+
+// Get returns the payment request with the given id, as long as the user is its requester or payer.
+func (s *PaymentRequestService) Get(ctx context.Context, username string, id int64) (db.PaymentRequest, error) {
+	paymentRequest, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return db.PaymentRequest{}, err
+	}
+	if paymentRequest.Requester != username && paymentRequest.Payer != username {
+		return db.PaymentRequest{}, internal.ErrNoRows
+	}
+	return paymentRequest, nil
+}
+
+func (s *PaymentRequestService) ListIncoming(ctx context.Context, arg db.ListIncomingPaymentRequestsParams) ([]db.PaymentRequest, error) {
+	return s.repo.ListIncoming(ctx, arg)
+}
+
+func (s *PaymentRequestService) ListOutgoing(ctx context.Context, arg db.ListOutgoingPaymentRequestsParams) ([]db.PaymentRequest, error) {
+	return s.repo.ListOutgoing(ctx, arg)
+}
+
+// Accept pays the request from one of the payer's accounts.
+func (s *PaymentRequestService) Accept(ctx context.Context, payer string, id int64, fromAccountID int64) (db.AcceptPaymentRequestTxResult, error) {
+	if _, err := s.getIncoming(ctx, payer, id); err != nil {
+		return db.AcceptPaymentRequestTxResult{}, err
+	}
+
+	return s.repo.AcceptTx(ctx, db.AcceptPaymentRequestTxParams{
+		ID:            id,
+		FromAccountID: fromAccountID,
+	})
+}
+
+func (s *PaymentRequestService) Decline(ctx context.Context, payer string, id int64) (db.PaymentRequest, error) {
+	if _, err := s.getIncoming(ctx, payer, id); err != nil {
+		return db.PaymentRequest{}, err
+	}
+
+	return s.repo.UpdateStatusTx(ctx, db.UpdatePaymentRequestStatusTxParams{
+		ID:     id,
+		Status: pkg.PaymentRequestStatusDeclined,
+	})
+}
+
+// ExpireDue expires every pending request whose expiry is past and returns how many were expired.
+func (s *PaymentRequestService) ExpireDue(ctx context.Context, now time.Time) (int, error) {
+	paymentRequests, err := s.repo.ListExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	var errs []error
+	for _, paymentRequest := range paymentRequests {
+		_, err := s.repo.UpdateStatusTx(ctx, db.UpdatePaymentRequestStatusTxParams{
+			ID:     paymentRequest.ID,
+			Status: pkg.PaymentRequestStatusExpired,
+		})
+		if err != nil {
+			// answered in the meantime
+			if errors.Is(err, internal.ErrPaymentRequestNotPending) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("payment request %d: %w", paymentRequest.ID, err))
+			continue
+		}
+		expired++
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// getIncoming returns the payment request if the user is the one asked to pay it
+func (s *PaymentRequestService) getIncoming(ctx context.Context, payer string, id int64) (db.PaymentRequest, error) {
+	paymentRequest, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return db.PaymentRequest{}, err
+	}
+	if paymentRequest.Payer != payer {
+		return db.PaymentRequest{}, internal.ErrNoRows
+	}
+	return paymentRequest, nil
+}
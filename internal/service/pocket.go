@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+// PocketRepository defines the methods that any Pocket repository should implement.
+type PocketRepository interface {
+	Create(ctx context.Context, arg db.CreatePocketParams) (db.Pocket, error)
+	Get(ctx context.Context, id int64) (db.Pocket, error)
+	List(ctx context.Context, accountID int64) ([]db.Pocket, error)
+	Archive(ctx context.Context, id int64) (db.Pocket, error)
+	TransferTx(ctx context.Context, arg db.PocketTransferTxParams) (db.PocketTransferTxResult, error)
+}
+
+// PocketService defines the application service in charge of interacting with Pockets.
+type PocketService struct {
+	repo PocketRepository
+}
+
+// NewPocketService creates a new Pocket service.
+func NewPocketService(repo PocketRepository) *PocketService {
+	return &PocketService{
+		repo: repo,
+	}
+}
+
+func (s *PocketService) Create(ctx context.Context, arg db.CreatePocketParams) (db.Pocket, error) {
+	return s.repo.Create(ctx, arg)
+}
+
+// Get returns the pocket with the given id, as long as it belongs to the given account.
+func (s *PocketService) Get(ctx context.Context, accountID int64, id int64) (db.Pocket, error) {
+	pocket, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return db.Pocket{}, err
+	}
+	if pocket.AccountID != accountID {
+		return db.Pocket{}, internal.ErrNoRows
+	}
+	return pocket, nil
+}
+
+func (s *PocketService) List(ctx context.Context, accountID int64) ([]db.Pocket, error) {
+	return s.repo.List(ctx, accountID)
+}
+
+// Delete archives an empty pocket, it is no longer listed but the entries booked against it keep pointing at it.
+func (s *PocketService) Delete(ctx context.Context, accountID int64, id int64) error {
+	pocket, err := s.Get(ctx, accountID, id)
+	if err != nil {
+		return err
+	}
+	if pocket.Balance != 0 {
+		return internal.ErrBalanceNotZero
+	}
+
+	_, err = s.repo.Archive(ctx, id)
+	if errors.Is(err, internal.ErrNoRows) {
+		// the pocket was archived or received a deposit since it was read
+		if _, err := s.Get(ctx, accountID, id); err != nil {
+			return err
+		}
+		return internal.ErrBalanceNotZero
+	}
+	return err
+}
+
+// Deposit moves money from the account main balance into one of its pockets.
+func (s *PocketService) Deposit(ctx context.Context, accountID int64, id int64, amount int64) (db.PocketTransferTxResult, error) {
+	return s.repo.TransferTx(ctx, db.PocketTransferTxParams{
+		AccountID: accountID,
+		PocketID:  id,
+		Amount:    amount,
+	})
+}
+
+// Withdraw moves money from a pocket back into the account main balance.
+func (s *PocketService) Withdraw(ctx context.Context, accountID int64, id int64, amount int64) (db.PocketTransferTxResult, error) {
+	return s.repo.TransferTx(ctx, db.PocketTransferTxParams{
+		AccountID: accountID,
+		PocketID:  id,
+		Amount:    -amount,
+	})
+}
+
+// PocketProgress reports how far a pocket is from reaching its target amount.
+type PocketProgress struct {
+	PocketID        int64      `json:"pocket_id"`
+	Name            string     `json:"name"`
+	Balance         int64      `json:"balance"`
+	TargetAmount    int64      `json:"target_amount"`
+	RemainingAmount int64      `json:"remaining_amount"`
+	ProgressPercent float64    `json:"progress_percent"`
+	IsCompleted     bool       `json:"is_completed"`
+	TargetDate      *time.Time `json:"target_date,omitempty"`
+	DaysRemaining   *int64     `json:"days_remaining,omitempty"`
+}
+
+func (s *PocketService) Progress(ctx context.Context, accountID int64, id int64) (PocketProgress, error) {
+	pocket, err := s.Get(ctx, accountID, id)
+	if err != nil {
+		return PocketProgress{}, err
+	}
+	return newPocketProgress(pocket, time.Now()), nil
+}
+
+func newPocketProgress(pocket db.Pocket, now time.Time) PocketProgress {
+	progress := PocketProgress{
+		PocketID:        pocket.ID,
+		Name:            pocket.Name,
+		Balance:         pocket.Balance,
+		TargetAmount:    pocket.TargetAmount,
+		RemainingAmount: max(pocket.TargetAmount-pocket.Balance, 0),
+		IsCompleted:     pocket.Balance >= pocket.TargetAmount,
+	}
+
+	if pocket.TargetAmount > 0 {
+		percent := float64(pocket.Balance) / float64(pocket.TargetAmount) * 100
+		progress.ProgressPercent = math.Min(math.Round(percent*100)/100, 100)
+	}
+
+	if pocket.TargetDate.Valid {
+		targetDate := pocket.TargetDate.Time
+		daysRemaining := max(int64(math.Ceil(targetDate.Sub(now).Hours()/24)), 0)
+		progress.TargetDate = &targetDate
+		progress.DaysRemaining = &daysRemaining
+	}
+
+	return progress
+}
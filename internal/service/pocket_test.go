@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePocketRepository keeps pockets and the entries booked against them in memory
+type fakePocketRepository struct {
+	pockets map[int64]db.Pocket
+	entries []db.Entry
+}
+
+func newFakePocketRepository() *fakePocketRepository {
+	return &fakePocketRepository{pockets: map[int64]db.Pocket{}}
+}
+
+func (r *fakePocketRepository) Create(ctx context.Context, arg db.CreatePocketParams) (db.Pocket, error) {
+	pocket := db.Pocket{ID: int64(len(r.pockets) + 1), AccountID: arg.AccountID, Name: arg.Name, TargetAmount: arg.TargetAmount, TargetDate: arg.TargetDate}
+	r.pockets[pocket.ID] = pocket
+	return pocket, nil
+}
+
+func (r *fakePocketRepository) Get(ctx context.Context, id int64) (db.Pocket, error) {
+	pocket, ok := r.pockets[id]
+	if !ok || pocket.ArchivedAt.Valid {
+		return db.Pocket{}, internal.ErrNoRows
+	}
+	return pocket, nil
+}
+
+func (r *fakePocketRepository) List(ctx context.Context, accountID int64) ([]db.Pocket, error) {
+	pockets := []db.Pocket{}
+	for id := int64(1); id <= int64(len(r.pockets)); id++ {
+		if pocket := r.pockets[id]; pocket.AccountID == accountID && !pocket.ArchivedAt.Valid {
+			pockets = append(pockets, pocket)
+		}
+	}
+	return pockets, nil
+}
+
+func (r *fakePocketRepository) Archive(ctx context.Context, id int64) (db.Pocket, error) {
+	pocket, ok := r.pockets[id]
+	if !ok || pocket.ArchivedAt.Valid || pocket.Balance != 0 {
+		return db.Pocket{}, internal.ErrNoRows
+	}
+	pocket.ArchivedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	r.pockets[id] = pocket
+	return pocket, nil
+}
+
+func (r *fakePocketRepository) TransferTx(ctx context.Context, arg db.PocketTransferTxParams) (db.PocketTransferTxResult, error) {
+	pocket, err := r.Get(ctx, arg.PocketID)
+	if err != nil || pocket.AccountID != arg.AccountID {
+		return db.PocketTransferTxResult{}, internal.ErrNoRows
+	}
+	if pocket.Balance < -arg.Amount {
+		return db.PocketTransferTxResult{}, internal.ErrInsufficientFunds
+	}
+
+	pocket.Balance += arg.Amount
+	r.pockets[pocket.ID] = pocket
+	entry := db.Entry{ID: int64(len(r.entries) + 1), AccountID: arg.AccountID, Amount: arg.Amount, PocketID: pgtype.Int8{Int64: pocket.ID, Valid: true}}
+	r.entries = append(r.entries, entry)
+
+	return db.PocketTransferTxResult{Pocket: pocket, PocketEntry: entry}, nil
+}
+
+func TestDeletePocketAfterUse(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakePocketRepository()
+	svc := NewPocketService(repo)
+
+	pocket, err := svc.Create(ctx, db.CreatePocketParams{AccountID: 1, Name: "holidays", TargetAmount: 1000})
+	require.NoError(t, err)
+
+	_, err = svc.Deposit(ctx, 1, pocket.ID, 300)
+	require.NoError(t, err)
+
+	// a pocket with money in it cannot be deleted
+	require.ErrorIs(t, svc.Delete(ctx, 1, pocket.ID), internal.ErrBalanceNotZero)
+
+	_, err = svc.Withdraw(ctx, 1, pocket.ID, 300)
+	require.NoError(t, err)
+
+	// once empty it is archived, the entries booked against it are kept
+	require.NoError(t, svc.Delete(ctx, 1, pocket.ID))
+	require.Len(t, repo.entries, 2)
+	for _, entry := range repo.entries {
+		require.Equal(t, pocket.ID, entry.PocketID.Int64)
+	}
+
+	_, err = svc.Get(ctx, 1, pocket.ID)
+	require.ErrorIs(t, err, internal.ErrNoRows)
+
+	pockets, err := svc.List(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, pockets)
+
+	_, err = svc.Deposit(ctx, 1, pocket.ID, 100)
+	require.ErrorIs(t, err, internal.ErrNoRows)
+
+	require.ErrorIs(t, svc.Delete(ctx, 1, pocket.ID), internal.ErrNoRows)
+}
+
+func TestPocketProgress(t *testing.T) {
+	now := time.Now()
+
+	progress := newPocketProgress(db.Pocket{
+		ID:           1,
+		Name:         "holidays",
+		Balance:      250,
+		TargetAmount: 1000,
+		TargetDate:   pgtype.Timestamptz{Time: now.Add(72 * time.Hour), Valid: true},
+	}, now)
+
+	require.Equal(t, int64(750), progress.RemainingAmount)
+	require.Equal(t, 25.0, progress.ProgressPercent)
+	require.False(t, progress.IsCompleted)
+	require.NotNil(t, progress.DaysRemaining)
+	require.Equal(t, int64(3), *progress.DaysRemaining)
+}
+
+func TestPocketProgressCompleted(t *testing.T) {
+	progress := newPocketProgress(db.Pocket{
+		ID:           1,
+		Name:         "car",
+		Balance:      1200,
+		TargetAmount: 1000,
+	}, time.Now())
+
+	require.Zero(t, progress.RemainingAmount)
+	require.Equal(t, 100.0, progress.ProgressPercent)
+	require.True(t, progress.IsCompleted)
+	require.Nil(t, progress.TargetDate)
+	require.Nil(t, progress.DaysRemaining)
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	fcmBaseURL = "https://fcm.googleapis.com"
+	fcmScope   = "https://www.googleapis.com/auth/firebase.messaging"
+	// fcmTokenLifetime is how long the access tokens requested from Google are valid for, the most it allows
+	fcmTokenLifetime = time.Hour
+)
+
+// UnregisteredTokensError is returned for push tokens the provider no longer accepts, their devices should be
+// forgotten.
+type UnregisteredTokensError struct {
+	Tokens []string
+}
+
+func (e *UnregisteredTokensError) Error() string {
+	return fmt.Sprintf("%d push tokens are no longer registered", len(e.Tokens))
+}
+
+// fcmCredentials are the fields of a Google service account key file the sender uses
+type fcmCredentials struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMSender sends push notifications through the Firebase Cloud Messaging HTTP v1 API.
+type FCMSender struct {
+	client      *http.Client
+	baseURL     string
+	projectID   string
+	clientEmail string
+	privateKey  *rsa.PrivateKey
+	tokenURI    string
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMSender creates a notifier that sends push notifications as the service account of credentialsFile. The
+// project of the service account is used if projectID is empty.
+func NewFCMSender(projectID string, credentialsFile string) (*FCMSender, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fcm credentials: %w", err)
+	}
+
+	var credentials fcmCredentials
+	if err := json.Unmarshal(raw, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse fcm credentials: %w", err)
+	}
+
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(credentials.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fcm private key: %w", err)
+	}
+
+	if projectID == "" {
+		projectID = credentials.ProjectID
+	}
+	if projectID == "" || credentials.ClientEmail == "" || credentials.TokenURI == "" {
+		return nil, errors.New("fcm credentials are missing the project, client email or token uri")
+	}
+
+	return &FCMSender{
+		client:      &http.Client{Timeout: 10 * time.Second},
+		baseURL:     fcmBaseURL,
+		projectID:   projectID,
+		clientEmail: credentials.ClientEmail,
+		privateKey:  privateKey,
+		tokenURI:    credentials.TokenURI,
+	}, nil
+}
+
+// token returns an access token for the FCM API, exchanging a JWT signed by the service account for a new one
+// shortly before the current one expires
+func (sender *FCMSender) token(ctx context.Context) (string, error) {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	now := time.Now()
+	if sender.accessToken != "" && now.Add(time.Minute).Before(sender.expiresAt) {
+		return sender.accessToken, nil
+	}
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   sender.clientEmail,
+		"scope": fcmScope,
+		"aud":   sender.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(fcmTokenLifetime).Unix(),
+	}).SignedString(sender.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign fcm assertion: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sender.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create fcm token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get fcm access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fcm token endpoint responded %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode fcm access token: %w", err)
+	}
+
+	sender.accessToken = token.AccessToken
+	sender.expiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return sender.accessToken, nil
+}
+
+// Notify sends the notification to every device of the recipient. Tokens FCM no longer knows are returned in an
+// *UnregisteredTokensError, joined with the errors of the other devices.
+func (sender *FCMSender) Notify(ctx context.Context, to Recipient, n Notification) error {
+	if len(to.PushTokens) == 0 {
+		return ErrNoAddress
+	}
+
+	accessToken, err := sender.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	var unregistered []string
+	for _, pushToken := range to.PushTokens {
+		err := sender.send(ctx, accessToken, pushToken, n)
+		switch {
+		case errors.Is(err, errUnregisteredToken):
+			unregistered = append(unregistered, pushToken)
+		case err != nil:
+			errs = append(errs, err)
+		}
+	}
+
+	if len(unregistered) > 0 {
+		errs = append(errs, &UnregisteredTokensError{Tokens: unregistered})
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// errUnregisteredToken is returned by send when FCM doesn't know the token
+var errUnregisteredToken = errors.New("push token is not registered")
+
+func (sender *FCMSender) send(ctx context.Context, accessToken string, pushToken string, n Notification) error {
+	message := map[string]any{
+		"message": map[string]any{
+			"token": pushToken,
+			"notification": map[string]string{
+				"title": n.Subject,
+				"body":  n.summary(),
+			},
+		},
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal push message: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", sender.baseURL, url.PathEscape(sender.projectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusNotFound || bytes.Contains(raw, []byte("UNREGISTERED")) {
+		return errUnregisteredToken
+	}
+	return fmt.Errorf("fcm responded %d", resp.StatusCode)
+}
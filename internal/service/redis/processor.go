@@ -2,8 +2,12 @@ package redis
 
 import (
 	"context"
+	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/mail"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
 	redisRepo "github.com/marco-almeida/mybank/internal/redis"
 	"github.com/marco-almeida/mybank/internal/service"
 	"github.com/redis/go-redis/v9"
@@ -19,16 +23,86 @@ type TaskProcessor interface {
 	Start() error
 	Shutdown()
 	ProcessTaskSendVerifyEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendPasswordReset(ctx context.Context, task *asynq.Task) error
+	ProcessTaskResendVerifyEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskCollectLoanInstalments(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendPaymentRequestUpdate(ctx context.Context, task *asynq.Task) error
+	ProcessTaskExpirePaymentRequests(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendMonthlyStatements(ctx context.Context, task *asynq.Task) error
+	ProcessTaskUserCreated(ctx context.Context, task *asynq.Task) error
+	ProcessTaskUserNewDeviceLogin(ctx context.Context, task *asynq.Task) error
+	ProcessTaskUserVerifyEmailRequested(ctx context.Context, task *asynq.Task) error
+	ProcessTaskUserEmailChangeRequested(ctx context.Context, task *asynq.Task) error
+	ProcessTaskUserSessionReuseDetected(ctx context.Context, task *asynq.Task) error
+	ProcessTaskAccountCreated(ctx context.Context, task *asynq.Task) error
+	ProcessTaskAccountCredited(ctx context.Context, task *asynq.Task) error
+	ProcessTaskAccountLowBalance(ctx context.Context, task *asynq.Task) error
+	ProcessTaskTransferCompleted(ctx context.Context, task *asynq.Task) error
+	ProcessTaskPaymentRequestStatusChanged(ctx context.Context, task *asynq.Task) error
+	ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendTransferNotification(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendLowBalanceNotification(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendNewDeviceLoginNotification(ctx context.Context, task *asynq.Task) error
+}
+
+// LoanService defines the loan methods the task processor will use
+type LoanService interface {
+	CollectDueInstalments(ctx context.Context, now time.Time) (service.LoanCollectionResult, error)
+}
+
+// PaymentRequestService defines the payment request methods the task processor will use
+type PaymentRequestService interface {
+	ExpireDue(ctx context.Context, now time.Time) (int, error)
+}
+
+// StatementService defines the statement methods the task processor will use
+type StatementService interface {
+	SendMonthly(ctx context.Context, now time.Time, send func(account db.Account, archived db.Statement) error) (service.MonthlyStatementsResult, error)
+}
+
+// WebhookService defines the webhook methods the task processor will use
+type WebhookService interface {
+	Dispatch(ctx context.Context, event pkg.Event, owners ...string) (int, error)
+	Deliver(ctx context.Context, deliveryID int64, lastAttempt bool) error
+}
+
+// NotificationMessageBroker defines the customer notification tasks the task processor will enqueue
+type NotificationMessageBroker interface {
+	CreateTransferNotificationTask(ctx context.Context, payload redisRepo.PayloadSendTransferNotification, opts ...asynq.Option) error
+	CreateLowBalanceNotificationTask(ctx context.Context, payload redisRepo.PayloadSendLowBalanceNotification, opts ...asynq.Option) error
+	CreateNewDeviceLoginNotificationTask(ctx context.Context, payload redisRepo.PayloadSendNewDeviceLoginNotification, opts ...asynq.Option) error
+}
+
+// EmailRenderer renders the messages the task processor sends from their templates
+type EmailRenderer interface {
+	Render(name string, locale string, recipientName string, data any) (mail.Message, error)
+}
+
+// NotificationService defines the notification preference methods the task processor will use
+type NotificationService interface {
+	Channels(ctx context.Context, username string, notificationType string, now time.Time) ([]string, error)
+	Recipient(ctx context.Context, user db.User) (service.Recipient, error)
+	ForgetPushTokens(ctx context.Context, tokens []string) error
 }
 
 type RedisTaskProcessor struct {
-	server          *asynq.Server
-	emailService    service.EmailService
-	userRepo        service.UserRepository
-	verifyEmailRepo service.VerifyEmailRepository
+	server            *asynq.Server
+	notifiers         map[string]service.Notifier
+	renderer          EmailRenderer
+	userRepo          service.UserRepository
+	verifyEmailRepo   service.VerifyEmailRepository
+	passwordResetRepo service.PasswordResetRepository
+	loanService       LoanService
+
+	paymentRequestRepo    service.PaymentRequestRepository
+	paymentRequestService PaymentRequestService
+	statementService      StatementService
+	webhookService        WebhookService
+	notificationBroker    NotificationMessageBroker
+	notificationService   NotificationService
 }
 
-func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, emailService service.EmailService, userRepo service.UserRepository, verifyEmailRepo service.VerifyEmailRepository) TaskProcessor {
+func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, notifiers map[string]service.Notifier, renderer EmailRenderer, userRepo service.UserRepository, verifyEmailRepo service.VerifyEmailRepository, passwordResetRepo service.PasswordResetRepository, loanService LoanService, paymentRequestRepo service.PaymentRequestRepository, paymentRequestService PaymentRequestService, statementService StatementService, webhookService WebhookService, notificationBroker NotificationMessageBroker, notificationService NotificationService) TaskProcessor {
 	logger := NewLogger()
 	redis.SetLogger(logger)
 
@@ -40,18 +114,48 @@ func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, emailService service.E
 				QueueDefault:  5,
 			},
 			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
+				if until, postponed := service.PostponedUntil(err); postponed {
+					log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+						Time("until", until).Msg("task postponed by quiet hours")
+					return
+				}
 				log.Error().Err(err).Str("type", task.Type()).
 					Bytes("payload", task.Payload()).Msg("process task failed")
 			}),
+			// notifications held by quiet hours run again once they end, without using up a retry
+			IsFailure: func(err error) bool {
+				_, postponed := service.PostponedUntil(err)
+				return !postponed
+			},
+			// webhook deliveries back off exponentially, receivers may be down for hours
+			RetryDelayFunc: func(n int, err error, task *asynq.Task) time.Duration {
+				if until, postponed := service.PostponedUntil(err); postponed {
+					return max(time.Until(until), time.Second)
+				}
+				if task.Type() == redisRepo.TaskDeliverWebhook {
+					return service.WebhookRetryDelay(n)
+				}
+				return asynq.DefaultRetryDelayFunc(n, err, task)
+			},
 			Logger: logger,
 		},
 	)
 
 	return &RedisTaskProcessor{
-		server:          server,
-		emailService:    emailService,
-		userRepo:        userRepo,
-		verifyEmailRepo: verifyEmailRepo,
+		server:            server,
+		notifiers:         notifiers,
+		renderer:          renderer,
+		userRepo:          userRepo,
+		verifyEmailRepo:   verifyEmailRepo,
+		passwordResetRepo: passwordResetRepo,
+		loanService:       loanService,
+
+		paymentRequestRepo:    paymentRequestRepo,
+		paymentRequestService: paymentRequestService,
+		statementService:      statementService,
+		webhookService:        webhookService,
+		notificationBroker:    notificationBroker,
+		notificationService:   notificationService,
 	}
 }
 
@@ -60,6 +164,28 @@ func (processor *RedisTaskProcessor) Start() error {
 
 	// register tasks handlers
 	mux.HandleFunc(redisRepo.TaskSendVerifyEmail, processor.ProcessTaskSendVerifyEmail)
+	mux.HandleFunc(redisRepo.TaskSendPasswordReset, processor.ProcessTaskSendPasswordReset)
+	mux.HandleFunc(redisRepo.TaskResendVerifyEmail, processor.ProcessTaskResendVerifyEmail)
+	mux.HandleFunc(redisRepo.TaskCollectLoanInstalments, processor.ProcessTaskCollectLoanInstalments)
+	mux.HandleFunc(redisRepo.TaskSendPaymentRequestUpdate, processor.ProcessTaskSendPaymentRequestUpdate)
+	mux.HandleFunc(redisRepo.TaskExpirePaymentRequests, processor.ProcessTaskExpirePaymentRequests)
+	mux.HandleFunc(redisRepo.TaskSendMonthlyStatements, processor.ProcessTaskSendMonthlyStatements)
+	mux.HandleFunc(redisRepo.TaskSendTransferNotification, processor.ProcessTaskSendTransferNotification)
+	mux.HandleFunc(redisRepo.TaskSendLowBalanceNotification, processor.ProcessTaskSendLowBalanceNotification)
+	mux.HandleFunc(redisRepo.TaskSendNewDeviceLoginNotification, processor.ProcessTaskSendNewDeviceLoginNotification)
+
+	// register outbox event handlers
+	mux.HandleFunc(redisRepo.TaskUserCreated, processor.ProcessTaskUserCreated)
+	mux.HandleFunc(redisRepo.TaskUserNewDeviceLogin, processor.ProcessTaskUserNewDeviceLogin)
+	mux.HandleFunc(redisRepo.TaskUserVerifyEmailRequested, processor.ProcessTaskUserVerifyEmailRequested)
+	mux.HandleFunc(redisRepo.TaskUserEmailChangeRequested, processor.ProcessTaskUserEmailChangeRequested)
+	mux.HandleFunc(redisRepo.TaskUserSessionReuseDetected, processor.ProcessTaskUserSessionReuseDetected)
+	mux.HandleFunc(redisRepo.TaskAccountCreated, processor.ProcessTaskAccountCreated)
+	mux.HandleFunc(redisRepo.TaskAccountCredited, processor.ProcessTaskAccountCredited)
+	mux.HandleFunc(redisRepo.TaskAccountLowBalance, processor.ProcessTaskAccountLowBalance)
+	mux.HandleFunc(redisRepo.TaskTransferCompleted, processor.ProcessTaskTransferCompleted)
+	mux.HandleFunc(redisRepo.TaskPaymentRequestStatusChanged, processor.ProcessTaskPaymentRequestStatusChanged)
+	mux.HandleFunc(redisRepo.TaskDeliverWebhook, processor.ProcessTaskDeliverWebhook)
 
 	return processor.server.Start(mux)
 }
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/hibiken/asynq"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+)
+
+// CollectLoanInstalmentsSchedule is how often due loan instalments are collected
+const CollectLoanInstalmentsSchedule = "@hourly"
+
+// ExpirePaymentRequestsSchedule is how often pending payment requests past their expiry are expired
+const ExpirePaymentRequestsSchedule = "@hourly"
+
+// SendMonthlyStatementsSchedule sends last month's statements on the first day of each month, a couple of hours
+// after midnight so that late entries of the previous day are committed
+const SendMonthlyStatementsSchedule = "0 2 1 * *"
+
+type TaskScheduler interface {
+	Start() error
+	Shutdown()
+}
+
+// RedisTaskScheduler enqueues the periodic tasks
+type RedisTaskScheduler struct {
+	scheduler *asynq.Scheduler
+}
+
+func NewRedisTaskScheduler(redisOpt asynq.RedisClientOpt) TaskScheduler {
+	scheduler := asynq.NewScheduler(redisOpt, &asynq.SchedulerOpts{
+		Logger: NewLogger(),
+	})
+
+	return &RedisTaskScheduler{
+		scheduler: scheduler,
+	}
+}
+
+func (s *RedisTaskScheduler) Start() error {
+	// register periodic tasks
+	_, err := s.scheduler.Register(CollectLoanInstalmentsSchedule, redisRepo.NewCollectLoanInstalmentsTask(asynq.Queue(QueueCritical)))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.scheduler.Register(ExpirePaymentRequestsSchedule, redisRepo.NewExpirePaymentRequestsTask(asynq.Queue(QueueDefault)))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.scheduler.Register(SendMonthlyStatementsSchedule, redisRepo.NewSendMonthlyStatementsTask(asynq.Queue(QueueDefault), asynq.Timeout(2*time.Hour)))
+	if err != nil {
+		return err
+	}
+
+	return s.scheduler.Start()
+}
+
+func (s *RedisTaskScheduler) Shutdown() {
+	s.scheduler.Shutdown()
+}
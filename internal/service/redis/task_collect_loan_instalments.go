@@ -0,0 +1,21 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+func (processor *RedisTaskProcessor) ProcessTaskCollectLoanInstalments(ctx context.Context, task *asynq.Task) error {
+	result, err := processor.loanService.CollectDueInstalments(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to collect loan instalments: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Int("collected", result.Collected).
+		Int("unpaid", result.Unpaid).Msg("processed task")
+	return nil
+}
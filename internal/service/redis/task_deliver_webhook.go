@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+	"github.com/rs/zerolog/log"
+)
+
+func (processor *RedisTaskProcessor) ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error {
+	var payload redisRepo.PayloadDeliverWebhook
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	// the delivery fails for good once asynq has no retries left for it
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+
+	err := processor.webhookService.Deliver(ctx, payload.DeliveryID, retried >= maxRetry)
+	if errors.Is(err, internal.ErrNoRows) {
+		// the webhook was deleted along with its deliveries
+		return fmt.Errorf("failed to get webhook delivery: %w: %w", err, asynq.SkipRetry)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).Msg("processed task")
+	return nil
+}
@@ -0,0 +1,20 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+func (processor *RedisTaskProcessor) ProcessTaskExpirePaymentRequests(ctx context.Context, task *asynq.Task) error {
+	expired, err := processor.paymentRequestService.ExpireDue(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to expire payment requests: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Int("expired", expired).Msg("processed task")
+	return nil
+}
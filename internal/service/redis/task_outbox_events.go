@@ -0,0 +1,277 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/mail"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+	"github.com/rs/zerolog/log"
+)
+
+// notificationTaskRetention keeps sent notification tasks around so that an event processed twice doesn't notify twice
+const notificationTaskRetention = 24 * time.Hour
+
+// unmarshalEvent decodes the event envelope of an outbox task and its data into v
+func unmarshalEvent(task *asynq.Task, v any) (pkg.Event, error) {
+	var event pkg.Event
+	if err := json.Unmarshal(task.Payload(), &event); err != nil {
+		return pkg.Event{}, fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+	if err := json.Unmarshal(event.Data, v); err != nil {
+		return pkg.Event{}, fmt.Errorf("failed to unmarshal event data: %w", asynq.SkipRetry)
+	}
+	return event, nil
+}
+
+// ProcessTaskUserCreated sends the verification email to a new user
+func (processor *RedisTaskProcessor) ProcessTaskUserCreated(ctx context.Context, task *asynq.Task) error {
+	var data pkg.UserCreatedEvent
+	if _, err := unmarshalEvent(task, &data); err != nil {
+		return err
+	}
+
+	email, err := processor.sendVerifyEmail(ctx, data.Username)
+	if err != nil || email == "" {
+		return err
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", email).Msg("processed task")
+	return nil
+}
+
+// ProcessTaskUserVerifyEmailRequested sends a new verification email to a user who asked for it
+func (processor *RedisTaskProcessor) ProcessTaskUserVerifyEmailRequested(ctx context.Context, task *asynq.Task) error {
+	var data pkg.UserVerifyEmailRequestedEvent
+	if _, err := unmarshalEvent(task, &data); err != nil {
+		return err
+	}
+
+	email, err := processor.sendVerifyEmail(ctx, data.Username)
+	if err != nil || email == "" {
+		return err
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", email).Msg("processed task")
+	return nil
+}
+
+// ProcessTaskUserEmailChangeRequested tells the current address of the user that a change was requested and sends a
+// verification code to the new one, which becomes their address once it is verified
+func (processor *RedisTaskProcessor) ProcessTaskUserEmailChangeRequested(ctx context.Context, task *asynq.Task) error {
+	var data pkg.UserEmailChangeRequestedEvent
+	if _, err := unmarshalEvent(task, &data); err != nil {
+		return err
+	}
+
+	channels, err := processor.notificationChannels(ctx, data.Username, pkg.NotificationEmailVerification)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+
+	user, err := processor.userRepo.Get(ctx, data.Username)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// the code of a later request may have been verified while the task waited
+	if user.Email == data.NewEmail {
+		log.Info().Str("username", data.Username).Msg("email already changed")
+		return nil
+	}
+
+	// the notice goes to the address the change was requested from, even if it changed since
+	notice := user
+	notice.Email = data.OldEmail
+	n, err := processor.render(notice, mail.TemplateEmailChangeRequested, mail.EmailChangeData{NewEmail: data.NewEmail})
+	if err != nil {
+		return err
+	}
+	err = processor.notify(ctx, notice, channels, n)
+	if err != nil {
+		return fmt.Errorf("failed to send email change notice: %w", err)
+	}
+
+	err = processor.sendVerificationCode(ctx, user, data.NewEmail, mail.TemplateVerifyEmailChange, channels)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", data.NewEmail).Msg("processed task")
+	return nil
+}
+
+// ProcessTaskUserNewDeviceLogin notifies the user of a login from a device they never used before
+func (processor *RedisTaskProcessor) ProcessTaskUserNewDeviceLogin(ctx context.Context, task *asynq.Task) error {
+	var data pkg.UserNewDeviceLoginEvent
+	event, err := unmarshalEvent(task, &data)
+	if err != nil {
+		return err
+	}
+
+	err = processor.notificationBroker.CreateNewDeviceLoginNotificationTask(ctx, redisRepo.PayloadSendNewDeviceLoginNotification{
+		Username:   data.Username,
+		UserAgent:  data.UserAgent,
+		ClientIP:   data.ClientIP,
+		LoggedInAt: data.CreatedAt,
+	}, asynq.Queue(QueueCritical), asynq.TaskID(fmt.Sprintf("new_device_login_notification:%d", event.ID)), asynq.Retention(notificationTaskRetention))
+	if err != nil {
+		return fmt.Errorf("failed to create new device login notification task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).Msg("processed task")
+	return nil
+}
+
+// ProcessTaskUserSessionReuseDetected tells the user that a device was logged out because a refresh token of theirs
+// was used after it was exchanged, whatever their notification preferences
+func (processor *RedisTaskProcessor) ProcessTaskUserSessionReuseDetected(ctx context.Context, task *asynq.Task) error {
+	var data pkg.UserSessionReuseDetectedEvent
+	event, err := unmarshalEvent(task, &data)
+	if err != nil {
+		return err
+	}
+
+	channels, err := processor.notificationChannels(ctx, data.Username, pkg.NotificationSecurityAlert)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+
+	user, err := processor.userRepo.Get(ctx, data.Username)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	n, err := processor.render(user, mail.TemplateSessionReuseDetected, mail.SessionReuseData{
+		DetectedAt: event.CreatedAt,
+		UserAgent:  data.UserAgent,
+		ClientIP:   data.ClientIP,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = processor.notify(ctx, user, channels, n)
+	if err != nil {
+		return fmt.Errorf("failed to send session reuse notification: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Strs("channels", channels).Msg("processed task")
+	return nil
+}
+
+// ProcessTaskAccountCreated sends the event to the webhooks of the account owner
+func (processor *RedisTaskProcessor) ProcessTaskAccountCreated(ctx context.Context, task *asynq.Task) error {
+	var data pkg.AccountCreatedEvent
+	event, err := unmarshalEvent(task, &data)
+	if err != nil {
+		return err
+	}
+
+	return processor.dispatchWebhooks(ctx, task, event, data.Owner)
+}
+
+// ProcessTaskAccountCredited sends the event to the webhooks of the account owner
+func (processor *RedisTaskProcessor) ProcessTaskAccountCredited(ctx context.Context, task *asynq.Task) error {
+	var data pkg.AccountCreditedEvent
+	event, err := unmarshalEvent(task, &data)
+	if err != nil {
+		return err
+	}
+
+	return processor.dispatchWebhooks(ctx, task, event, data.Owner)
+}
+
+// ProcessTaskAccountLowBalance notifies the account owner and sends the event to their webhooks
+func (processor *RedisTaskProcessor) ProcessTaskAccountLowBalance(ctx context.Context, task *asynq.Task) error {
+	var data pkg.AccountLowBalanceEvent
+	event, err := unmarshalEvent(task, &data)
+	if err != nil {
+		return err
+	}
+
+	err = processor.notificationBroker.CreateLowBalanceNotificationTask(ctx, redisRepo.PayloadSendLowBalanceNotification{
+		AccountID: data.AccountID,
+		Owner:     data.Owner,
+		Balance:   data.Balance,
+		Threshold: data.Threshold,
+		Currency:  data.Currency,
+	}, asynq.Queue(QueueCritical), asynq.TaskID(fmt.Sprintf("low_balance_notification:%d", event.ID)), asynq.Retention(notificationTaskRetention))
+	if err != nil {
+		return fmt.Errorf("failed to create low balance notification task: %w", err)
+	}
+
+	return processor.dispatchWebhooks(ctx, task, event, data.Owner)
+}
+
+// ProcessTaskTransferCompleted notifies both parties of the transfer and sends the event to the webhooks of both
+// account owners
+func (processor *RedisTaskProcessor) ProcessTaskTransferCompleted(ctx context.Context, task *asynq.Task) error {
+	var data pkg.TransferCompletedEvent
+	event, err := unmarshalEvent(task, &data)
+	if err != nil {
+		return err
+	}
+
+	// moving money between your own accounts isn't worth an email
+	if data.FromOwner != data.ToOwner {
+		payload := redisRepo.PayloadSendTransferNotification{
+			TransferID: data.TransferID,
+			AccountID:  data.ToAccountID,
+			Sender:     data.FromOwner,
+			Recipient:  data.ToOwner,
+			Amount:     data.Amount,
+			Currency:   data.Currency,
+		}
+		err = processor.notificationBroker.CreateTransferNotificationTask(ctx, payload,
+			asynq.Queue(QueueDefault), asynq.TaskID(fmt.Sprintf("transfer_notification:%d", data.TransferID)), asynq.Retention(notificationTaskRetention))
+		if err != nil {
+			return fmt.Errorf("failed to create transfer notification task: %w", err)
+		}
+
+		// the sender is only emailed if they asked for it, which the task checks
+		payload.AccountID = data.FromAccountID
+		payload.Outgoing = true
+		err = processor.notificationBroker.CreateTransferNotificationTask(ctx, payload,
+			asynq.Queue(QueueDefault), asynq.TaskID(fmt.Sprintf("transfer_sent_notification:%d", data.TransferID)), asynq.Retention(notificationTaskRetention))
+		if err != nil {
+			return fmt.Errorf("failed to create transfer notification task: %w", err)
+		}
+	}
+
+	return processor.dispatchWebhooks(ctx, task, event, data.FromOwner, data.ToOwner)
+}
+
+// ProcessTaskPaymentRequestStatusChanged tells the payer about a new payment request and the requester about the
+// answer to theirs
+func (processor *RedisTaskProcessor) ProcessTaskPaymentRequestStatusChanged(ctx context.Context, task *asynq.Task) error {
+	var data pkg.PaymentRequestStatusChangedEvent
+	if _, err := unmarshalEvent(task, &data); err != nil {
+		return err
+	}
+
+	return processor.sendPaymentRequestUpdate(ctx, task, redisRepo.PayloadSendPaymentRequestUpdate{
+		PaymentRequestID: data.PaymentRequestID,
+		Status:           data.Status,
+	})
+}
+
+// dispatchWebhooks creates and enqueues the webhook deliveries of an event
+func (processor *RedisTaskProcessor) dispatchWebhooks(ctx context.Context, task *asynq.Task, event pkg.Event, owners ...string) error {
+	dispatched, err := processor.webhookService.Dispatch(ctx, event, owners...)
+	if err != nil {
+		return fmt.Errorf("failed to dispatch webhooks: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Int("webhooks", dispatched).Msg("processed task")
+	return nil
+}
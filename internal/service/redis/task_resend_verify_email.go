@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+	"github.com/marco-almeida/mybank/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// ProcessTaskResendVerifyEmail records that the user of the payload asked for a new verification email, which is
+// sent once the request is relayed from the outbox. Nothing is sent if the user doesn't exist, is verified or asked
+// within service.VerifyEmailResendInterval, whoever asked isn't told either way
+func (processor *RedisTaskProcessor) ProcessTaskResendVerifyEmail(ctx context.Context, task *asynq.Task) error {
+	var payload redisRepo.PayloadResendVerifyEmail
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	_, err := processor.userRepo.RequestVerifyEmail(ctx, payload.Username, time.Now().Add(-service.VerifyEmailResendInterval))
+	if errors.Is(err, internal.ErrNoRows) {
+		log.Info().Str("type", task.Type()).Str("username", payload.Username).
+			Msg("user doesn't exist, is verified or asked too recently, verification email not sent")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to request verification email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Str("username", payload.Username).Msg("processed task")
+	return nil
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/mail"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/rs/zerolog/log"
+)
+
+func (processor *RedisTaskProcessor) ProcessTaskSendMonthlyStatements(ctx context.Context, task *asynq.Task) error {
+	result, err := processor.statementService.SendMonthly(ctx, time.Now(), func(account db.Account, archived db.Statement) error {
+		return processor.sendStatementEmail(ctx, account, archived)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send monthly statements: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Int("generated", result.Generated).
+		Int("sent", result.Sent).Msg("processed task")
+	return nil
+}
+
+// sendStatementEmail notifies the account owner of the archived statement, the PDF is attached to emails from a
+// temporary file. Owners who turned statement notifications off still find the statement in the archive
+func (processor *RedisTaskProcessor) sendStatementEmail(ctx context.Context, account db.Account, archived db.Statement) error {
+	channels, err := processor.notificationChannels(ctx, account.Owner, pkg.NotificationStatement)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+
+	user, err := processor.userRepo.Get(ctx, account.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "mybank-statement-")
+	if err != nil {
+		return fmt.Errorf("failed to create statement directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	period := archived.PeriodStart.Format("January 2006")
+	file := filepath.Join(dir, fmt.Sprintf("statement-%d-%s.pdf", account.ID, archived.PeriodStart.Format("2006-01")))
+	if err := os.WriteFile(file, archived.File, 0600); err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+
+	n, err := processor.render(user, mail.TemplateStatement, mail.StatementData{
+		AccountID:      account.ID,
+		PeriodStart:    archived.PeriodStart,
+		OpeningBalance: pkg.FormatAmount(archived.OpeningBalance, archived.Currency),
+		ClosingBalance: pkg.FormatAmount(archived.ClosingBalance, archived.Currency),
+	})
+	if err != nil {
+		return err
+	}
+	n.Attachments = []string{file}
+
+	err = processor.notify(ctx, user, channels, n)
+	if err != nil {
+		return fmt.Errorf("failed to send statement: %w", err)
+	}
+
+	log.Info().Int64("account_id", account.ID).Strs("channels", channels).Str("period", period).Msg("sent statement")
+	return nil
+}
@@ -0,0 +1,219 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/mail"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+	"github.com/marco-almeida/mybank/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// notificationChannels checks the notification preferences of a user before notifying them. It returns no channels if
+// they turned the notification off, and a *service.QuietHoursError if it must wait for their quiet hours to end,
+// which makes asynq run the task again then
+func (processor *RedisTaskProcessor) notificationChannels(ctx context.Context, username string, notificationType string) ([]string, error) {
+	channels, err := processor.notificationService.Channels(ctx, username, notificationType, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	if len(channels) == 0 {
+		log.Info().Str("username", username).Str("notification_type", notificationType).Msg("notification turned off")
+	}
+	return channels, nil
+}
+
+// notify sends the notification to the user on each of the channels that has a notifier configured, skipping the
+// ones they have no address on. It only fails if nothing was sent, a retry would repeat it on the channels that
+// succeeded otherwise
+func (processor *RedisTaskProcessor) notify(ctx context.Context, user db.User, channels []string, n service.Notification) error {
+	to, err := processor.notificationService.Recipient(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to get recipient: %w", err)
+	}
+
+	var errs []error
+	sent := 0
+	for _, channel := range channels {
+		notifier, ok := processor.notifiers[channel]
+		if !ok {
+			log.Debug().Str("channel", channel).Msg("notification channel not configured")
+			continue
+		}
+
+		err := notifier.Notify(ctx, to, n)
+
+		var unregistered *service.UnregisteredTokensError
+		if errors.As(err, &unregistered) {
+			if err := processor.notificationService.ForgetPushTokens(ctx, unregistered.Tokens); err != nil {
+				log.Error().Err(err).Str("username", user.Username).Msg("failed to forget push tokens")
+			}
+			if err == error(unregistered) {
+				err = nil
+			}
+		}
+
+		switch {
+		case errors.Is(err, service.ErrNoAddress):
+			log.Debug().Str("channel", channel).Str("username", user.Username).Msg("no address on notification channel")
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to send %s notification: %w", channel, err))
+		default:
+			sent++
+		}
+	}
+
+	if sent == 0 {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Error().Err(err).Str("username", user.Username).Msg("notification partially sent")
+	}
+	return nil
+}
+
+// render renders the message name to the user in their locale
+func (processor *RedisTaskProcessor) render(user db.User, name string, data any) (service.Notification, error) {
+	msg, err := processor.renderer.Render(name, user.Locale, user.FullName, data)
+	if err != nil {
+		return service.Notification{}, fmt.Errorf("failed to render %s: %w", name, err)
+	}
+
+	return service.Notification{
+		Subject: msg.Subject,
+		HTML:    msg.HTML,
+		Text:    msg.Text,
+		Summary: msg.Summary,
+	}, nil
+}
+
+// ProcessTaskSendTransferNotification notifies the recipient of a transfer that the money arrived, or the sender that
+// it left their account
+func (processor *RedisTaskProcessor) ProcessTaskSendTransferNotification(ctx context.Context, task *asynq.Task) error {
+	var payload redisRepo.PayloadSendTransferNotification
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	username, counterparty, notificationType := payload.Recipient, payload.Sender, pkg.NotificationTransferReceived
+	if payload.Outgoing {
+		username, counterparty, notificationType = payload.Sender, payload.Recipient, pkg.NotificationTransferSent
+	}
+
+	channels, err := processor.notificationChannels(ctx, username, notificationType)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+
+	other, err := processor.userRepo.Get(ctx, counterparty)
+	if err != nil {
+		return fmt.Errorf("failed to get counterparty: %w", err)
+	}
+
+	user, err := processor.userRepo.Get(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	template := mail.TemplateTransferReceived
+	if payload.Outgoing {
+		template = mail.TemplateTransferSent
+	}
+	n, err := processor.render(user, template, mail.TransferData{
+		AccountID:    payload.AccountID,
+		Amount:       pkg.FormatAmount(payload.Amount, payload.Currency),
+		Counterparty: other.FullName,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = processor.notify(ctx, user, channels, n)
+	if err != nil {
+		return fmt.Errorf("failed to send transfer notification: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Strs("channels", channels).Msg("processed task")
+	return nil
+}
+
+// ProcessTaskSendLowBalanceNotification notifies the owner of an account whose balance went below its threshold
+func (processor *RedisTaskProcessor) ProcessTaskSendLowBalanceNotification(ctx context.Context, task *asynq.Task) error {
+	var payload redisRepo.PayloadSendLowBalanceNotification
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	channels, err := processor.notificationChannels(ctx, payload.Owner, pkg.NotificationLowBalance)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+
+	user, err := processor.userRepo.Get(ctx, payload.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	n, err := processor.render(user, mail.TemplateLowBalance, mail.LowBalanceData{
+		AccountID: payload.AccountID,
+		Balance:   pkg.FormatAmount(payload.Balance, payload.Currency),
+		Threshold: pkg.FormatAmount(payload.Threshold, payload.Currency),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = processor.notify(ctx, user, channels, n)
+	if err != nil {
+		return fmt.Errorf("failed to send low balance notification: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Strs("channels", channels).Msg("processed task")
+	return nil
+}
+
+// ProcessTaskSendNewDeviceLoginNotification notifies a user who logged in from a device they never used before
+func (processor *RedisTaskProcessor) ProcessTaskSendNewDeviceLoginNotification(ctx context.Context, task *asynq.Task) error {
+	var payload redisRepo.PayloadSendNewDeviceLoginNotification
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	channels, err := processor.notificationChannels(ctx, payload.Username, pkg.NotificationNewDeviceLogin)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+
+	user, err := processor.userRepo.Get(ctx, payload.Username)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	n, err := processor.render(user, mail.TemplateNewDeviceLogin, mail.NewDeviceLoginData{
+		LoggedInAt: payload.LoggedInAt,
+		UserAgent:  payload.UserAgent,
+		ClientIP:   payload.ClientIP,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = processor.notify(ctx, user, channels, n)
+	if err != nil {
+		return fmt.Errorf("failed to send new device login notification: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Strs("channels", channels).Msg("processed task")
+	return nil
+}
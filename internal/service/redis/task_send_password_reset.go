@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/mail"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+	"github.com/marco-almeida/mybank/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// ProcessTaskSendPasswordReset emails a password reset token to the user with the email address of the payload, if
+// there is one and no token was emailed to them within service.PasswordResetInterval. The tokens emailed to them
+// before stop working
+func (processor *RedisTaskProcessor) ProcessTaskSendPasswordReset(ctx context.Context, task *asynq.Task) error {
+	var payload redisRepo.PayloadSendPasswordReset
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	user, err := processor.userRepo.GetByEmail(ctx, payload.Email)
+	if errors.Is(err, internal.ErrNoRows) {
+		log.Info().Str("type", task.Type()).Msg("no user with the email address, password reset not sent")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, tokenHash, err := service.NewPasswordResetToken()
+	if err != nil {
+		return err
+	}
+
+	passwordReset, err := processor.passwordResetRepo.Create(ctx, db.CreatePasswordResetTxParams{
+		Username:        user.Username,
+		TokenHash:       tokenHash,
+		ExpiresAt:       time.Now().Add(service.PasswordResetTokenDuration),
+		RequestedBefore: time.Now().Add(-service.PasswordResetInterval),
+	})
+	if errors.Is(err, internal.ErrNoRows) {
+		log.Info().Str("type", task.Type()).Str("username", user.Username).
+			Msg("user asked too recently, password reset not sent")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	n, err := processor.render(user, mail.TemplatePasswordReset, mail.PasswordResetData{
+		Token:     token,
+		ExpiresAt: passwordReset.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	// like the verification email, the token is only ever sent to the email address and regardless of preferences
+	err = processor.notify(ctx, user, []string{pkg.NotificationChannelEmail}, n)
+	if err != nil {
+		return fmt.Errorf("failed to send password reset: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Str("username", user.Username).Msg("processed task")
+	return nil
+}
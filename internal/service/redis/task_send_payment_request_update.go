@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/mail"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+	"github.com/rs/zerolog/log"
+)
+
+func (processor *RedisTaskProcessor) ProcessTaskSendPaymentRequestUpdate(ctx context.Context, task *asynq.Task) error {
+	var payload redisRepo.PayloadSendPaymentRequestUpdate
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	return processor.sendPaymentRequestUpdate(ctx, task, payload)
+}
+
+// sendPaymentRequestUpdate notifies the other party about a payment request in the status of the payload
+func (processor *RedisTaskProcessor) sendPaymentRequestUpdate(ctx context.Context, task *asynq.Task, payload redisRepo.PayloadSendPaymentRequestUpdate) error {
+	paymentRequest, err := processor.paymentRequestRepo.Get(ctx, payload.PaymentRequestID)
+	if err != nil {
+		return fmt.Errorf("failed to get payment request: %w", err)
+	}
+
+	data := mail.PaymentRequestData{
+		Requester: paymentRequest.Requester,
+		Payer:     paymentRequest.Payer,
+		Amount:    pkg.FormatAmount(paymentRequest.Amount, paymentRequest.Currency),
+		Message:   paymentRequest.Message,
+		ExpiresAt: paymentRequest.ExpiresAt,
+	}
+
+	// a new request is sent to the payer, the answer to it goes back to the requester
+	var username, template string
+	switch payload.Status {
+	case pkg.PaymentRequestStatusPending:
+		username = paymentRequest.Payer
+		template = mail.TemplatePaymentRequestNew
+		if payload.Reminder {
+			template = mail.TemplatePaymentRequestReminder
+		}
+	case pkg.PaymentRequestStatusAccepted, pkg.PaymentRequestStatusDeclined, pkg.PaymentRequestStatusExpired:
+		username = paymentRequest.Requester
+		template = mail.TemplatePaymentRequestAnswered
+		data.Status = payload.Status
+	default:
+		return fmt.Errorf("unknown payment request status %q: %w", payload.Status, asynq.SkipRetry)
+	}
+
+	channels, err := processor.notificationChannels(ctx, username, pkg.NotificationPaymentRequest)
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+
+	user, err := processor.userRepo.Get(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	n, err := processor.render(user, template, data)
+	if err != nil {
+		return err
+	}
+
+	err = processor.notify(ctx, user, channels, n)
+	if err != nil {
+		return fmt.Errorf("failed to send payment request notification: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Strs("channels", channels).Msg("processed task")
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal/mail"
 	"github.com/marco-almeida/mybank/internal/pkg"
 	"github.com/marco-almeida/mybank/internal/postgresql/db"
 	"github.com/rs/zerolog/log"
@@ -21,35 +22,69 @@ func (processor *RedisTaskProcessor) ProcessTaskSendVerifyEmail(ctx context.Cont
 		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
 	}
 
+	email, err := processor.sendVerifyEmail(ctx, username)
+	if err != nil || email == "" {
+		return err
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", email).Msg("processed task")
+	return nil
+}
+
+// sendVerifyEmail emails the user a link to verify their email address and returns the address, which is empty if
+// nothing was sent. The links sent to the user before stop working
+func (processor *RedisTaskProcessor) sendVerifyEmail(ctx context.Context, username string) (string, error) {
+	channels, err := processor.notificationChannels(ctx, username, pkg.NotificationEmailVerification)
+	if err != nil || len(channels) == 0 {
+		return "", err
+	}
+
 	user, err := processor.userRepo.Get(ctx, username)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// the user may have used an earlier link while the task waited
+	if user.IsEmailVerified {
+		log.Info().Str("username", username).Msg("email already verified")
+		return "", nil
 	}
 
+	err = processor.sendVerificationCode(ctx, user, user.Email, mail.TemplateVerifyEmail, channels)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Email, nil
+}
+
+// sendVerificationCode emails a link that verifies email as the address of the user, rendered with template. The
+// links sent to the user before stop working
+func (processor *RedisTaskProcessor) sendVerificationCode(ctx context.Context, user db.User, email string, template string, channels []string) error {
 	verifyEmail, err := processor.verifyEmailRepo.Create(ctx, db.CreateVerifyEmailParams{
 		Username:   user.Username,
-		Email:      user.Email,
+		Email:      email,
 		SecretCode: pkg.RandomString(32),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create verify email: %w", err)
 	}
 
-	subject := "Welcome to mybank"
-	verifyUrl := fmt.Sprintf("http://localhost:3000/api/v1/users/verify_email?email_id=%d&secret_code=%s",
-		verifyEmail.ID, verifyEmail.SecretCode)
-	content := fmt.Sprintf(`Hello %s,<br/>
-	Thank you for registering with us!<br/>
-	Please <a href="%s">click here</a> to verify your email address.<br/>
-	`, user.FullName, verifyUrl)
-	to := []string{user.Email}
+	n, err := processor.render(user, template, mail.VerifyEmailData{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	if err != nil {
+		return err
+	}
 
-	err = processor.emailService.SendEmail(subject, content, to, nil, nil, nil)
+	// the link proves the user owns the email address, so it is only ever sent there
+	user.Email = email
+	err = processor.notify(ctx, user, channels, n)
 	if err != nil {
 		return fmt.Errorf("failed to send verify email: %w", err)
 	}
 
-	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
-		Str("email", user.Email).Msg("processed task")
 	return nil
 }
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/token"
+)
+
+// ListSessions lists the sessions of the user whose refresh tokens can still be used, newest first.
+func (s *AuthServiceImpl) ListSessions(ctx context.Context, username string) ([]db.Session, error) {
+	return s.sessionRepo.ListActive(ctx, username)
+}
+
+// RevokeSession blocks one of the sessions of the user along with the ones rotated from the same login, so its
+// refresh token can't renew access tokens anymore.
+func (s *AuthServiceImpl) RevokeSession(ctx context.Context, username string, id uuid.UUID) error {
+	session, err := s.sessionRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if session.Username != username {
+		return fmt.Errorf("%w; session doesn't belong to the user", internal.ErrNoRows)
+	}
+
+	return s.sessionRepo.BlockFamily(ctx, session.FamilyID)
+}
+
+// RevokeOtherSessions blocks every session of the user but the one they are using, which must be theirs.
+func (s *AuthServiceImpl) RevokeOtherSessions(ctx context.Context, username string, currentID uuid.UUID) error {
+	session, err := s.sessionRepo.Get(ctx, currentID)
+	if err != nil {
+		return err
+	}
+
+	if session.Username != username {
+		return fmt.Errorf("%w; session doesn't belong to the user", internal.ErrNoRows)
+	}
+
+	return s.sessionRepo.BlockOthers(ctx, username, session.FamilyID)
+}
+
+type LogoutParams struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+	// AccessTokenPayload is the access token the user logs out with, it is revoked along with the session
+	AccessTokenPayload *token.Payload `json:"-"`
+}
+
+// Logout blocks the session of the refresh token along with the ones rotated from the same login, and revokes the
+// access token of the request. Both tokens must belong to the same user.
+func (s *AuthServiceImpl) Logout(ctx context.Context, req LogoutParams) error {
+	refreshPayload, err := s.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("%w; %w", internal.ErrInvalidToken, err)
+	}
+
+	if refreshPayload.Username != req.AccessTokenPayload.Username {
+		err := fmt.Errorf("incorrect refresh token user")
+		return fmt.Errorf("%w; refresh token user mismatch: %w", internal.ErrInvalidToken, err)
+	}
+
+	session, err := s.sessionRepo.Get(ctx, refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			return fmt.Errorf("%w; session not found: %w", internal.ErrInvalidToken, err)
+		}
+		return err
+	}
+
+	if session.Username != refreshPayload.Username || session.RefreshToken != req.RefreshToken {
+		err := fmt.Errorf("mismatched session token")
+		return fmt.Errorf("%w; session token mismatch: %w", internal.ErrInvalidToken, err)
+	}
+
+	err = s.sessionRepo.BlockFamily(ctx, session.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	err = s.tokenRevocationRepo.Revoke(ctx, req.AccessTokenPayload)
+	if err != nil {
+		return fmt.Errorf("cannot revoke access token: %w", err)
+	}
+	return nil
+}
+
+// BlockUserSessions blocks every session of a user and rejects the access tokens issued to them so far, recording
+// the banker or admin who did it in the audit log.
+func (s *AuthServiceImpl) BlockUserSessions(ctx context.Context, actor string, username string) error {
+	err := s.sessionRepo.BlockAllByActor(ctx, db.BlockUserSessionsTxParams{
+		Username: username,
+		Actor:    actor,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.tokenRevocationRepo.SetNotBefore(ctx, username, time.Now())
+	if err != nil {
+		return fmt.Errorf("cannot revoke access tokens: %w", err)
+	}
+	return nil
+}
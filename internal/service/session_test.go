@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeSessions(t *testing.T) {
+	tokenMaker, err := token.NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	sessionRepo := &fakeSessionRepository{sessions: map[uuid.UUID]db.Session{}}
+	alice := db.User{Username: "alice", Role: pkg.DepositorRole}
+	userRepo := newFakeUserRepository(alice, db.User{Username: "bob", Role: pkg.DepositorRole})
+	s := NewAuthService(userRepo, sessionRepo, tokenMaker, time.Minute, time.Hour, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	current, err := s.createSession(ctx, alice, "curl/8.0", "127.0.0.1")
+	require.NoError(t, err)
+	phone, err := s.createSession(ctx, alice, "Android", "127.0.0.1")
+	require.NoError(t, err)
+	laptop, err := s.createSession(ctx, alice, "Firefox/128.0", "127.0.0.1")
+	require.NoError(t, err)
+	bob, err := s.createSession(ctx, db.User{Username: "bob", Role: pkg.DepositorRole}, "curl/8.0", "127.0.0.1")
+	require.NoError(t, err)
+
+	sessions, err := s.ListSessions(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+
+	// sessions of someone else aren't found
+	require.ErrorIs(t, s.RevokeSession(ctx, "alice", bob.SessionID), internal.ErrNoRows)
+	require.ErrorIs(t, s.RevokeOtherSessions(ctx, "alice", bob.SessionID), internal.ErrNoRows)
+
+	require.NoError(t, s.RevokeSession(ctx, "alice", phone.SessionID))
+	_, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: phone.RefreshToken})
+	require.ErrorIs(t, err, internal.ErrInvalidToken)
+
+	require.NoError(t, s.RevokeOtherSessions(ctx, "alice", current.SessionID))
+	_, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: laptop.RefreshToken})
+	require.ErrorIs(t, err, internal.ErrInvalidToken)
+
+	sessions, err = s.ListSessions(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, current.SessionID, sessions[0].ID)
+
+	// bob is left alone
+	_, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: bob.RefreshToken})
+	require.NoError(t, err)
+}
+
+func TestLogout(t *testing.T) {
+	tokenMaker, err := token.NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	sessionRepo := &fakeSessionRepository{sessions: map[uuid.UUID]db.Session{}}
+	tokenRevocationRepo := newFakeTokenRevocationRepository()
+	s := NewAuthService(newFakeUserRepository(), sessionRepo, tokenMaker, time.Minute, time.Hour, nil, nil, nil, nil, nil, tokenRevocationRepo)
+	ctx := context.Background()
+
+	login, err := s.createSession(ctx, db.User{Username: "alice", Role: pkg.DepositorRole}, "curl/8.0", "127.0.0.1")
+	require.NoError(t, err)
+	accessPayload, err := tokenMaker.VerifyToken(login.AccessToken)
+	require.NoError(t, err)
+	bob, err := s.createSession(ctx, db.User{Username: "bob", Role: pkg.DepositorRole}, "curl/8.0", "127.0.0.1")
+	require.NoError(t, err)
+
+	// access tokens aren't refresh tokens
+	require.ErrorIs(t, s.Logout(ctx, LogoutParams{RefreshToken: login.AccessToken, AccessTokenPayload: accessPayload}), internal.ErrInvalidToken)
+	require.ErrorIs(t, s.Logout(ctx, LogoutParams{RefreshToken: "not a token", AccessTokenPayload: accessPayload}), internal.ErrInvalidToken)
+	// nor can someone else's session be logged out
+	require.ErrorIs(t, s.Logout(ctx, LogoutParams{RefreshToken: bob.RefreshToken, AccessTokenPayload: accessPayload}), internal.ErrInvalidToken)
+	require.Empty(t, tokenRevocationRepo.revoked)
+
+	require.NoError(t, s.Logout(ctx, LogoutParams{RefreshToken: login.RefreshToken, AccessTokenPayload: accessPayload}))
+	_, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: login.RefreshToken})
+	require.ErrorIs(t, err, internal.ErrInvalidToken)
+	require.Equal(t, []uuid.UUID{accessPayload.ID}, tokenRevocationRepo.revoked)
+}
+
+func TestRenewAccessTokenAfterPasswordChange(t *testing.T) {
+	tokenMaker, err := token.NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	sessionRepo := &fakeSessionRepository{sessions: map[uuid.UUID]db.Session{}}
+	userRepo := newFakeUserRepository(db.User{Username: "alice", Role: pkg.DepositorRole})
+	userRepo.sessions = sessionRepo
+	tokenRevocationRepo := newFakeTokenRevocationRepository()
+	s := NewAuthService(userRepo, sessionRepo, tokenMaker, time.Minute, time.Hour, nil, nil, nil, nil, nil, tokenRevocationRepo)
+	ctx := context.Background()
+
+	login, err := s.createSession(ctx, db.User{Username: "alice", Role: pkg.DepositorRole}, "curl/8.0", "127.0.0.1")
+	require.NoError(t, err)
+
+	// other changes don't log the user out
+	_, err = s.Update(ctx, UpdateUserParams{Username: "alice", FullName: "Alice"})
+	require.NoError(t, err)
+	require.Empty(t, sessionRepo.blocked)
+
+	user, err := s.Update(ctx, UpdateUserParams{Username: "alice", PlaintextPassword: "new secret"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice"}, sessionRepo.blocked)
+	require.Equal(t, user.PasswordChangedAt, tokenRevocationRepo.notBefore["alice"])
+
+	// the refresh token was issued with the old password, even if its session is still open
+	_, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: login.RefreshToken})
+	require.ErrorIs(t, err, internal.ErrInvalidToken)
+
+	// logging in with the new password works again
+	relogin, err := s.createSession(ctx, user, "curl/8.0", "127.0.0.1")
+	require.NoError(t, err)
+	_, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: relogin.RefreshToken})
+	require.NoError(t, err)
+}
+
+func TestRenewAccessTokenRotation(t *testing.T) {
+	tokenMaker, err := token.NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	sessionRepo := &fakeSessionRepository{sessions: map[uuid.UUID]db.Session{}}
+	userRepo := newFakeUserRepository(db.User{Username: "alice", Role: pkg.DepositorRole})
+	s := NewAuthService(userRepo, sessionRepo, tokenMaker, time.Minute, time.Hour, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	login, err := s.createSession(ctx, db.User{Username: "alice", Role: pkg.DepositorRole}, "curl/8.0", "127.0.0.1")
+	require.NoError(t, err)
+
+	renewed, err := s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	require.NotEqual(t, login.SessionID, renewed.SessionID)
+	require.NotEqual(t, login.RefreshToken, renewed.RefreshToken)
+	// rotating doesn't make the session last longer
+	require.WithinDuration(t, login.RefreshTokenExpiresAt, renewed.RefreshTokenExpiresAt, time.Second)
+
+	rotated, err := sessionRepo.Get(ctx, renewed.SessionID)
+	require.NoError(t, err)
+	require.Equal(t, login.SessionID, rotated.FamilyID)
+	require.Equal(t, login.SessionID, uuid.UUID(rotated.ParentID.Bytes))
+
+	// only the latest session of a login is listed
+	sessions, err := s.ListSessions(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, renewed.SessionID, sessions[0].ID)
+
+	renewed, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: renewed.RefreshToken})
+	require.NoError(t, err)
+
+	// the first refresh token was exchanged already, so the whole family goes
+	_, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: login.RefreshToken})
+	require.ErrorIs(t, err, internal.ErrInvalidToken)
+	require.Len(t, sessionRepo.reused, 1)
+	require.Equal(t, login.SessionID, sessionRepo.reused[0].ID)
+
+	_, err = s.RenewAccessToken(ctx, RenewAccessTokenParams{RefreshToken: renewed.RefreshToken})
+	require.ErrorIs(t, err, internal.ErrInvalidToken)
+	sessions, err = s.ListSessions(ctx, "alice")
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	twilioBaseURL = "https://api.twilio.com"
+	// twilioMaxBodyLength is the longest message Twilio accepts, in characters
+	twilioMaxBodyLength = 1600
+)
+
+// TwilioSender sends notifications by SMS through the Twilio messaging API.
+type TwilioSender struct {
+	client     *http.Client
+	baseURL    string
+	accountSID string
+	authToken  string
+	fromNumber string
+}
+
+// NewTwilioSender creates a notifier that sends SMS from fromNumber with a Twilio account.
+func NewTwilioSender(accountSID string, authToken string, fromNumber string) *TwilioSender {
+	return &TwilioSender{
+		client:     &http.Client{Timeout: 10 * time.Second},
+		baseURL:    twilioBaseURL,
+		accountSID: accountSID,
+		authToken:  authToken,
+		fromNumber: fromNumber,
+	}
+}
+
+func (sender *TwilioSender) Notify(ctx context.Context, to Recipient, n Notification) error {
+	if to.PhoneNumber == "" {
+		return ErrNoAddress
+	}
+
+	body := n.Subject
+	if summary := n.summary(); summary != "" {
+		body += "\n" + summary
+	}
+	if runes := []rune(body); len(runes) > twilioMaxBodyLength {
+		body = string(runes[:twilioMaxBodyLength])
+	}
+
+	form := url.Values{}
+	form.Set("To", to.PhoneNumber)
+	form.Set("From", sender.fromNumber)
+	form.Set("Body", body)
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", sender.baseURL, url.PathEscape(sender.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create sms request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(sender.accountSID, sender.authToken)
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var twilioErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(raw, &twilioErr) == nil && twilioErr.Message != "" {
+			return fmt.Errorf("twilio responded %d: %s (code %d)", resp.StatusCode, twilioErr.Message, twilioErr.Code)
+		}
+		return fmt.Errorf("twilio responded %d", resp.StatusCode)
+	}
+	return nil
+}
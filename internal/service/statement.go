@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/statement"
+)
+
+// monthlyStatementsPageSize is how many accounts are read at a time when generating the monthly statements
+const monthlyStatementsPageSize = 100
+
+// StatementRepository defines the methods that any Statement repository should implement.
+type StatementRepository interface {
+	StatementTx(ctx context.Context, arg db.StatementTxParams, onSummary func(db.GetStatementSummaryRow) error, onEntry func(db.ListStatementEntriesRow) error) error
+	ListAccounts(ctx context.Context, arg db.ListStatementAccountsParams) ([]db.Account, error)
+	Create(ctx context.Context, arg db.CreateStatementParams) (db.Statement, error)
+	Get(ctx context.Context, id int64) (db.Statement, error)
+	GetByPeriod(ctx context.Context, arg db.GetStatementByPeriodParams) (db.Statement, error)
+	List(ctx context.Context, arg db.ListStatementsParams) ([]db.ListStatementsRow, error)
+	MarkEmailed(ctx context.Context, id int64) error
+}
+
+// StatementService defines the application service in charge of exporting and archiving account statements.
+type StatementService struct {
+	repo     StatementRepository
+	bankName string
+}
+
+// NewStatementService creates a new Statement service.
+func NewStatementService(repo StatementRepository, bankName string) *StatementService {
+	return &StatementService{
+		repo:     repo,
+		bankName: bankName,
+	}
+}
+
+// MonthlyStatementsResult summarizes a run of SendMonthly.
+type MonthlyStatementsResult struct {
+	Generated int `json:"generated"`
+	Sent      int `json:"sent"`
+}
+
+// Export writes the statement of the account main balance from the first to the last given day, both included,
+// with enc. Entries are handed to the encoder while they are read from the database.
+func (s *StatementService) Export(ctx context.Context, account db.Account, from time.Time, to time.Time, enc statement.Encoder) error {
+	_, err := s.export(ctx, account, from, to, enc)
+	return err
+}
+
+// List returns the archived statements of an account, without their files.
+func (s *StatementService) List(ctx context.Context, arg db.ListStatementsParams) ([]db.ListStatementsRow, error) {
+	return s.repo.List(ctx, arg)
+}
+
+// Get returns an archived statement, as long as it belongs to the given account.
+func (s *StatementService) Get(ctx context.Context, accountID int64, id int64) (db.Statement, error) {
+	archived, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return db.Statement{}, err
+	}
+	if archived.AccountID != accountID {
+		return db.Statement{}, internal.ErrNoRows
+	}
+	return archived, nil
+}
+
+// SendMonthly archives the PDF statement of last month for every account that had entries or a balance in it,
+// and calls send for each statement that wasn't sent yet. Statements are archived and marked as sent one
+// at a time, so running it again after a failure only retries what is missing.
+func (s *StatementService) SendMonthly(ctx context.Context, now time.Time, send func(account db.Account, archived db.Statement) error) (MonthlyStatementsResult, error) {
+	var result MonthlyStatementsResult
+
+	now = now.UTC()
+	periodEnd := truncateToDay(now).AddDate(0, 0, 1-now.Day())
+	periodStart := periodEnd.AddDate(0, -1, 0)
+
+	var errs []error
+	var afterID int64
+	for {
+		accounts, err := s.repo.ListAccounts(ctx, db.ListStatementAccountsParams{
+			AfterID:   afterID,
+			EndTime:   periodEnd,
+			StartTime: periodStart,
+			PageSize:  monthlyStatementsPageSize,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		for _, account := range accounts {
+			afterID = account.ID
+
+			archived, generated, err := s.archive(ctx, account, periodStart, periodEnd)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("account %d: %w", account.ID, err))
+				continue
+			}
+			if generated {
+				result.Generated++
+			}
+			if archived.EmailedAt.Valid {
+				continue
+			}
+
+			if err := send(account, archived); err != nil {
+				errs = append(errs, fmt.Errorf("account %d: %w", account.ID, err))
+				continue
+			}
+			if err := s.repo.MarkEmailed(ctx, archived.ID); err != nil {
+				errs = append(errs, fmt.Errorf("account %d: %w", account.ID, err))
+				continue
+			}
+			result.Sent++
+		}
+
+		if len(accounts) < monthlyStatementsPageSize {
+			return result, errors.Join(errs...)
+		}
+	}
+}
+
+// archive returns the archived statement of the account for the period, rendering and storing it if it doesn't exist yet
+func (s *StatementService) archive(ctx context.Context, account db.Account, periodStart time.Time, periodEnd time.Time) (db.Statement, bool, error) {
+	byPeriod := db.GetStatementByPeriodParams{
+		AccountID:   account.ID,
+		PeriodStart: periodStart,
+	}
+
+	archived, err := s.repo.GetByPeriod(ctx, byPeriod)
+	if err == nil || !errors.Is(err, internal.ErrNoRows) {
+		return archived, false, err
+	}
+
+	var file bytes.Buffer
+	header, err := s.export(ctx, account, periodStart, periodEnd.AddDate(0, 0, -1), statement.NewPDFEncoder(&file, s.bankName))
+	if err != nil {
+		return db.Statement{}, false, err
+	}
+
+	archived, err = s.repo.Create(ctx, db.CreateStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd,
+		OpeningBalance: header.OpeningBalance,
+		ClosingBalance: header.ClosingBalance,
+		Currency:       account.Currency,
+		File:           file.Bytes(),
+	})
+	if errors.Is(err, internal.ErrUniqueConstraintViolation) {
+		// archived by a concurrent run in the meantime
+		archived, err = s.repo.GetByPeriod(ctx, byPeriod)
+		return archived, false, err
+	}
+	return archived, err == nil, err
+}
+
+// export writes the statement with enc and returns what was written before the entries
+func (s *StatementService) export(ctx context.Context, account db.Account, from time.Time, to time.Time, enc statement.Encoder) (statement.Statement, error) {
+	from = truncateToDay(from)
+	to = truncateToDay(to)
+	createdAt := time.Now().UTC()
+
+	var header statement.Statement
+	err := s.repo.StatementTx(ctx, db.StatementTxParams{
+		AccountID: account.ID,
+		StartTime: from,
+		EndTime:   to.AddDate(0, 0, 1),
+	}, func(summary db.GetStatementSummaryRow) error {
+		header = statement.Statement{
+			ID:             fmt.Sprintf("%d-%s-%s", account.ID, from.Format("20060102"), to.Format("20060102")),
+			AccountID:      account.ID,
+			Owner:          account.Owner,
+			Currency:       account.Currency,
+			From:           from,
+			To:             to,
+			CreatedAt:      createdAt,
+			OpeningBalance: summary.OpeningBalance,
+			ClosingBalance: summary.ClosingBalance,
+			CreditCount:    summary.CreditCount,
+			CreditTotal:    summary.CreditTotal,
+			DebitCount:     summary.DebitCount,
+			DebitTotal:     summary.DebitTotal,
+		}
+		return enc.Begin(header)
+	}, func(row db.ListStatementEntriesRow) error {
+		return enc.Entry(statement.Entry{
+			ID:                    row.ID,
+			Amount:                row.Amount,
+			BookedAt:              row.CreatedAt,
+			Description:           row.Description,
+			TransferID:            row.TransferID.Int64,
+			CounterpartyAccountID: row.CounterpartyAccountID.Int64,
+			CounterpartyOwner:     row.CounterpartyOwner.String,
+		})
+	})
+	if err != nil {
+		return header, err
+	}
+
+	return header, enc.End()
+}
+
+// truncateToDay returns midnight UTC of the day of t
+func truncateToDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+)
+
+const (
+	// loginChallengeDuration is how long a user has to enter their second factor after their password
+	loginChallengeDuration = 5 * time.Minute
+	// RecoveryCodeCount is how many recovery codes a user gets when they turn two-factor authentication on
+	RecoveryCodeCount = 10
+	// totpIssuer is the name authenticator apps show next to the account
+	totpIssuer = "mybank"
+	// maxLoginChallengeAttempts is how many codes can be tried with a login challenge, it is void afterwards
+	maxLoginChallengeAttempts = 5
+	// maxTwoFactorAttempts is how many codes a user can try, with any challenge, before second factor login is locked
+	maxTwoFactorAttempts = 10
+	// twoFactorLockDuration is how long second factor login is locked, a wrong code afterwards locks it again until
+	// one is accepted
+	twoFactorLockDuration = 15 * time.Minute
+)
+
+// recoveryCodeEncoding spells recovery codes in lowercase base32, which is easy to read and type
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// TwoFactorRepository defines the methods that any TwoFactor repository should implement.
+type TwoFactorRepository interface {
+	SetSecret(ctx context.Context, username string, secret string) (db.User, error)
+	Enable(ctx context.Context, arg db.EnableTOTPTxParams) (db.User, error)
+	UseStep(ctx context.Context, username string, step int64) (db.User, error)
+	UseRecoveryCode(ctx context.Context, username string, codeHash string) (db.RecoveryCode, error)
+	CountAttempt(ctx context.Context, arg db.CountSecondFactorAttemptTxParams) (db.CountSecondFactorAttemptTxResult, error)
+	ClearAttempts(ctx context.Context, username string) error
+	Reset(ctx context.Context, arg db.ResetTOTPTxParams) (db.User, error)
+}
+
+// TwoFactorChallenge is returned by Login for users with two-factor authentication on, the challenge token proves
+// the password was correct.
+type TwoFactorChallenge struct {
+	TwoFactorRequired       bool      `json:"two_factor_required"`
+	ChallengeToken          string    `json:"challenge_token"`
+	ChallengeTokenExpiresAt time.Time `json:"challenge_token_expires_at"`
+}
+
+// TwoFactorEnrollment is the TOTP secret of an enrolment, OtpauthURI holds it in the form authenticator apps read.
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// NewRecoveryCode returns a random recovery code, formatted as two groups of five characters, and the hash it is
+// stored as.
+func NewRecoveryCode() (code string, codeHash string, err error) {
+	// 50 random bits, spelled in the first ten characters
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	encoded := recoveryCodeEncoding.EncodeToString(raw)[:10]
+	code = encoded[:5] + "-" + encoded[5:]
+	return code, HashRecoveryCode(code), nil
+}
+
+// HashRecoveryCode returns the hash a recovery code is stored as. The code is read the way users may type it, in
+// any case and with or without the dash.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnrollTwoFactor generates a TOTP secret for the user, which is only checked at login once ConfirmTwoFactor
+// confirms it. Enrolling again before confirming replaces the secret.
+func (s *AuthServiceImpl) EnrollTwoFactor(ctx context.Context, username string) (TwoFactorEnrollment, error) {
+	secret, err := pkg.NewTOTPSecret()
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+
+	user, err := s.twoFactorRepo.SetSecret(ctx, username, secret)
+	if err != nil {
+		if !errors.Is(err, internal.ErrNoRows) {
+			return TwoFactorEnrollment{}, err
+		}
+		// either the user doesn't exist or two-factor authentication is on
+		if _, err := s.userRepo.Get(ctx, username); err != nil {
+			return TwoFactorEnrollment{}, err
+		}
+		return TwoFactorEnrollment{}, internal.ErrTwoFactorAlreadyEnabled
+	}
+
+	return TwoFactorEnrollment{
+		Secret:     secret,
+		OtpauthURI: pkg.TOTPURI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor turns two-factor authentication on once the user proves their authenticator app has the secret
+// with a code of it. It returns RecoveryCodeCount recovery codes, each of which can replace a code once. They are
+// only stored hashed, so this is the only time they can be shown.
+func (s *AuthServiceImpl) ConfirmTwoFactor(ctx context.Context, username string, code string) ([]string, error) {
+	user, err := s.userRepo.Get(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TotpEnabledAt.Valid {
+		return nil, internal.ErrTwoFactorAlreadyEnabled
+	}
+	if !user.TotpSecret.Valid {
+		return nil, internal.ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := pkg.ValidateTOTP(user.TotpSecret.String, code, time.Now())
+	if !ok {
+		return nil, internal.ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, codeHash, err := NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, codeHash)
+	}
+
+	_, err = s.twoFactorRepo.Enable(ctx, db.EnableTOTPTxParams{
+		Username:           username,
+		UsedStep:           step,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", internal.ErrTwoFactorAlreadyEnabled, err)
+		}
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+type LoginTwoFactorParams struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is a TOTP code or one of the recovery codes of the user
+	Code      string `json:"code" validate:"required"`
+	UserAgent string `json:"user_agent" validate:"required"`
+	ClientIP  string `json:"client_ip" validate:"required"`
+}
+
+// LoginTwoFactor opens a session for the user of the challenge token Login returned, once they enter a TOTP code or
+// one of their recovery codes. Codes can't be used twice. Every attempt counts against maxLoginChallengeAttempts
+// for the challenge and maxTwoFactorAttempts for the user, internal.ErrTwoFactorLocked is returned while the user
+// is locked.
+func (s *AuthServiceImpl) LoginTwoFactor(ctx context.Context, req LoginTwoFactorParams) (LoginUserResponse, error) {
+	payload, err := s.challengeSigner.Verify(req.ChallengeToken)
+	if err != nil {
+		return LoginUserResponse{}, fmt.Errorf("%w; %w", internal.ErrInvalidToken, err)
+	}
+
+	user, err := s.userRepo.Get(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			return LoginUserResponse{}, fmt.Errorf("%w; user not found: %w", internal.ErrInvalidToken, err)
+		}
+		return LoginUserResponse{}, err
+	}
+
+	// the challenge is void once the password changed or two-factor authentication was reset after it was issued,
+	// challenges issued before they had an id can't have their attempts counted
+	if payload.ID == uuid.Nil || !user.TotpEnabledAt.Valid || user.TotpEnabledAt.Time.After(payload.IssuedAt) || user.PasswordChangedAt.After(payload.IssuedAt) {
+		return LoginUserResponse{}, fmt.Errorf("%w; login challenge outdated", internal.ErrInvalidToken)
+	}
+
+	result, err := s.twoFactorRepo.CountAttempt(ctx, db.CountSecondFactorAttemptTxParams{
+		Username:             user.Username,
+		ChallengeID:          payload.ID,
+		ChallengeExpiresAt:   payload.ExpiredAt,
+		MaxChallengeAttempts: maxLoginChallengeAttempts,
+		MaxUserAttempts:      maxTwoFactorAttempts,
+		LockedUntil:          time.Now().Add(twoFactorLockDuration),
+	})
+	if err != nil {
+		if errors.Is(err, internal.ErrNoRows) {
+			return LoginUserResponse{}, fmt.Errorf("%w: %w", internal.ErrTwoFactorLocked, err)
+		}
+		return LoginUserResponse{}, err
+	}
+	if result.ChallengeAttempts > maxLoginChallengeAttempts {
+		return LoginUserResponse{}, fmt.Errorf("%w; login challenge used up", internal.ErrInvalidToken)
+	}
+
+	if err := s.checkSecondFactor(ctx, user, req.Code); err != nil {
+		return LoginUserResponse{}, err
+	}
+
+	err = s.twoFactorRepo.ClearAttempts(ctx, user.Username)
+	if err != nil {
+		return LoginUserResponse{}, err
+	}
+
+	return s.createSession(ctx, user, req.UserAgent, req.ClientIP)
+}
+
+// checkSecondFactor uses up the TOTP code or recovery code of the user
+func (s *AuthServiceImpl) checkSecondFactor(ctx context.Context, user db.User, code string) error {
+	if step, ok := pkg.ValidateTOTP(user.TotpSecret.String, code, time.Now()); ok {
+		_, err := s.twoFactorRepo.UseStep(ctx, user.Username, step)
+		if errors.Is(err, internal.ErrNoRows) {
+			return fmt.Errorf("%w: code already used", internal.ErrInvalidTwoFactorCode)
+		}
+		return err
+	}
+
+	_, err := s.twoFactorRepo.UseRecoveryCode(ctx, user.Username, HashRecoveryCode(code))
+	if errors.Is(err, internal.ErrNoRows) {
+		return fmt.Errorf("%w: %w", internal.ErrInvalidTwoFactorCode, err)
+	}
+	return err
+}
+
+// ResetTwoFactor turns two-factor authentication off for a user who lost their authenticator app and recovery
+// codes, recording the admin who did it in the audit log. The user can enrol again afterwards.
+func (s *AuthServiceImpl) ResetTwoFactor(ctx context.Context, actor string, username string) error {
+	_, err := s.twoFactorRepo.Reset(ctx, db.ResetTOTPTxParams{
+		Username: username,
+		Actor:    actor,
+	})
+	return err
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	"github.com/marco-almeida/mybank/internal/token"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTwoFactorRepository keeps the TOTP state on the users of a fakeUserRepository
+type fakeTwoFactorRepository struct {
+	users *fakeUserRepository
+	// recoveryCodes maps the hashes of the recovery codes of each user to whether they were used
+	recoveryCodes map[string]map[string]bool
+	resets        []db.ResetTOTPTxParams
+	// challengeAttempts counts the attempts made with each login challenge
+	challengeAttempts map[uuid.UUID]int32
+}
+
+func (r *fakeTwoFactorRepository) SetSecret(ctx context.Context, username string, secret string) (db.User, error) {
+	user, ok := r.users.users[username]
+	if !ok || user.TotpEnabledAt.Valid {
+		return db.User{}, internal.ErrNoRows
+	}
+	user.TotpSecret = pgtype.Text{String: secret, Valid: true}
+	r.users.users[username] = user
+	return user, nil
+}
+
+func (r *fakeTwoFactorRepository) Enable(ctx context.Context, arg db.EnableTOTPTxParams) (db.User, error) {
+	user, ok := r.users.users[arg.Username]
+	if !ok || !user.TotpSecret.Valid || user.TotpEnabledAt.Valid {
+		return db.User{}, internal.ErrNoRows
+	}
+	user.TotpEnabledAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	user.TotpLastUsedStep = pgtype.Int8{Int64: arg.UsedStep, Valid: true}
+	r.users.users[arg.Username] = user
+	r.recoveryCodes[arg.Username] = map[string]bool{}
+	for _, codeHash := range arg.RecoveryCodeHashes {
+		r.recoveryCodes[arg.Username][codeHash] = false
+	}
+	return user, nil
+}
+
+func (r *fakeTwoFactorRepository) UseStep(ctx context.Context, username string, step int64) (db.User, error) {
+	user, ok := r.users.users[username]
+	if !ok || !user.TotpEnabledAt.Valid || (user.TotpLastUsedStep.Valid && user.TotpLastUsedStep.Int64 >= step) {
+		return db.User{}, internal.ErrNoRows
+	}
+	user.TotpLastUsedStep = pgtype.Int8{Int64: step, Valid: true}
+	r.users.users[username] = user
+	return user, nil
+}
+
+func (r *fakeTwoFactorRepository) UseRecoveryCode(ctx context.Context, username string, codeHash string) (db.RecoveryCode, error) {
+	used, ok := r.recoveryCodes[username][codeHash]
+	if !ok || used {
+		return db.RecoveryCode{}, internal.ErrNoRows
+	}
+	r.recoveryCodes[username][codeHash] = true
+	return db.RecoveryCode{Username: username, CodeHash: codeHash}, nil
+}
+
+func (r *fakeTwoFactorRepository) CountAttempt(ctx context.Context, arg db.CountSecondFactorAttemptTxParams) (db.CountSecondFactorAttemptTxResult, error) {
+	r.challengeAttempts[arg.ChallengeID]++
+	result := db.CountSecondFactorAttemptTxResult{ChallengeAttempts: r.challengeAttempts[arg.ChallengeID]}
+	if result.ChallengeAttempts > arg.MaxChallengeAttempts {
+		return result, nil
+	}
+
+	user, ok := r.users.users[arg.Username]
+	if !ok || (user.TotpLockedUntil.Valid && user.TotpLockedUntil.Time.After(time.Now())) {
+		// the transaction is rolled back
+		r.challengeAttempts[arg.ChallengeID]--
+		return db.CountSecondFactorAttemptTxResult{}, internal.ErrNoRows
+	}
+	user.TotpAttempts++
+	user.TotpLockedUntil = pgtype.Timestamptz{}
+	if user.TotpAttempts >= arg.MaxUserAttempts {
+		user.TotpLockedUntil = pgtype.Timestamptz{Time: arg.LockedUntil, Valid: true}
+	}
+	r.users.users[arg.Username] = user
+	result.User = user
+	return result, nil
+}
+
+func (r *fakeTwoFactorRepository) ClearAttempts(ctx context.Context, username string) error {
+	user, ok := r.users.users[username]
+	if !ok {
+		return internal.ErrNoRows
+	}
+	user.TotpAttempts, user.TotpLockedUntil = 0, pgtype.Timestamptz{}
+	r.users.users[username] = user
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) Reset(ctx context.Context, arg db.ResetTOTPTxParams) (db.User, error) {
+	user, ok := r.users.users[arg.Username]
+	if !ok {
+		return db.User{}, internal.ErrNoRows
+	}
+	user.TotpSecret, user.TotpEnabledAt, user.TotpLastUsedStep = pgtype.Text{}, pgtype.Timestamptz{}, pgtype.Int8{}
+	user.TotpAttempts, user.TotpLockedUntil = 0, pgtype.Timestamptz{}
+	r.users.users[arg.Username] = user
+	delete(r.recoveryCodes, arg.Username)
+	r.resets = append(r.resets, arg)
+	return user, nil
+}
+
+func newTwoFactorAuthService(t *testing.T, users ...db.User) (*AuthServiceImpl, *fakeTwoFactorRepository) {
+	tokenMaker, err := token.NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	challengeSigner, err := token.NewLoginChallengeSigner(pkg.RandomString(32))
+	require.NoError(t, err)
+
+	userRepo := newFakeUserRepository(users...)
+	twoFactorRepo := &fakeTwoFactorRepository{
+		users:             userRepo,
+		recoveryCodes:     map[string]map[string]bool{},
+		challengeAttempts: map[uuid.UUID]int32{},
+	}
+	s := NewAuthService(userRepo, &fakeSessionRepository{}, tokenMaker, time.Minute, time.Hour, nil, nil, nil, twoFactorRepo, challengeSigner, nil)
+	return s, twoFactorRepo
+}
+
+func newTwoFactorUser(t *testing.T, username string, password string) db.User {
+	hashedPassword, err := pkg.HashPassword(password)
+	require.NoError(t, err)
+	return db.User{
+		Username:          username,
+		HashedPassword:    hashedPassword,
+		IsEmailVerified:   true,
+		PasswordChangedAt: time.Now().Add(-time.Hour),
+	}
+}
+
+func TestTwoFactorEnrolment(t *testing.T) {
+	s, _ := newTwoFactorAuthService(t, newTwoFactorUser(t, "alice", "secret1"))
+	ctx := context.Background()
+
+	_, err := s.ConfirmTwoFactor(ctx, "alice", "123456")
+	require.ErrorIs(t, err, internal.ErrTwoFactorNotEnrolled)
+
+	enrollment, err := s.EnrollTwoFactor(ctx, "alice")
+	require.NoError(t, err)
+	require.NotEmpty(t, enrollment.Secret)
+	require.Contains(t, enrollment.OtpauthURI, "secret="+enrollment.Secret)
+
+	code, err := pkg.TOTPCode(enrollment.Secret, pkg.TOTPStep(time.Now().Add(-time.Hour)))
+	require.NoError(t, err)
+	_, err = s.ConfirmTwoFactor(ctx, "alice", code)
+	require.ErrorIs(t, err, internal.ErrInvalidTwoFactorCode)
+
+	code, err = pkg.TOTPCode(enrollment.Secret, pkg.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	recoveryCodes, err := s.ConfirmTwoFactor(ctx, "alice", code)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, RecoveryCodeCount)
+
+	_, err = s.EnrollTwoFactor(ctx, "alice")
+	require.ErrorIs(t, err, internal.ErrTwoFactorAlreadyEnabled)
+	_, err = s.ConfirmTwoFactor(ctx, "alice", code)
+	require.ErrorIs(t, err, internal.ErrTwoFactorAlreadyEnabled)
+	_, err = s.EnrollTwoFactor(ctx, "bob")
+	require.ErrorIs(t, err, internal.ErrNoRows)
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	s, twoFactorRepo := newTwoFactorAuthService(t, newTwoFactorUser(t, "alice", "secret1"))
+	ctx := context.Background()
+
+	enrollment, err := s.EnrollTwoFactor(ctx, "alice")
+	require.NoError(t, err)
+	code, err := pkg.TOTPCode(enrollment.Secret, pkg.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	recoveryCodes, err := s.ConfirmTwoFactor(ctx, "alice", code)
+	require.NoError(t, err)
+
+	// the password alone only gets a challenge
+	rsp, err := s.Login(ctx, LoginUserParams{Username: "alice", Password: "secret1", UserAgent: "test", ClientIP: "127.0.0.1"})
+	require.NoError(t, err)
+	require.Empty(t, rsp.AccessToken)
+	require.NotNil(t, rsp.TwoFactor)
+	require.True(t, rsp.TwoFactor.TwoFactorRequired)
+
+	login := func(code string) (LoginUserResponse, error) {
+		return s.LoginTwoFactor(ctx, LoginTwoFactorParams{
+			ChallengeToken: rsp.TwoFactor.ChallengeToken,
+			Code:           code,
+			UserAgent:      "test",
+			ClientIP:       "127.0.0.1",
+		})
+	}
+
+	// the code that confirmed the enrolment can't be used again
+	_, err = login(code)
+	require.ErrorIs(t, err, internal.ErrInvalidTwoFactorCode)
+	_, err = login("000000")
+	require.ErrorIs(t, err, internal.ErrInvalidTwoFactorCode)
+
+	// the next code works once
+	next, err := pkg.TOTPCode(enrollment.Secret, pkg.TOTPStep(time.Now())+1)
+	require.NoError(t, err)
+	session, err := login(next)
+	require.NoError(t, err)
+	require.NotEmpty(t, session.AccessToken)
+	require.NotEmpty(t, session.RefreshToken)
+	_, err = login(next)
+	require.ErrorIs(t, err, internal.ErrInvalidTwoFactorCode)
+
+	// a challenge only takes maxLoginChallengeAttempts codes
+	rsp, err = s.Login(ctx, LoginUserParams{Username: "alice", Password: "secret1", UserAgent: "test", ClientIP: "127.0.0.1"})
+	require.NoError(t, err)
+
+	// recovery codes work once, however they are typed
+	session, err = login(" " + strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", "")))
+	require.NoError(t, err)
+	require.NotEmpty(t, session.AccessToken)
+	_, err = login(recoveryCodes[0])
+	require.ErrorIs(t, err, internal.ErrInvalidTwoFactorCode)
+
+	_, err = s.LoginTwoFactor(ctx, LoginTwoFactorParams{ChallengeToken: "bogus", Code: recoveryCodes[1]})
+	require.ErrorIs(t, err, internal.ErrInvalidToken)
+
+	// a reset voids the challenges handed out before it
+	require.NoError(t, s.ResetTwoFactor(ctx, "admin", "alice"))
+	require.Equal(t, []db.ResetTOTPTxParams{{Username: "alice", Actor: "admin"}}, twoFactorRepo.resets)
+	_, err = login(recoveryCodes[1])
+	require.ErrorIs(t, err, internal.ErrInvalidToken)
+
+	rsp, err = s.Login(ctx, LoginUserParams{Username: "alice", Password: "secret1", UserAgent: "test", ClientIP: "127.0.0.1"})
+	require.NoError(t, err)
+	require.Nil(t, rsp.TwoFactor)
+	require.NotEmpty(t, rsp.AccessToken)
+}
+
+func TestLoginTwoFactorAttempts(t *testing.T) {
+	s, twoFactorRepo := newTwoFactorAuthService(t, newTwoFactorUser(t, "alice", "secret1"))
+	ctx := context.Background()
+
+	enrollment, err := s.EnrollTwoFactor(ctx, "alice")
+	require.NoError(t, err)
+	code, err := pkg.TOTPCode(enrollment.Secret, pkg.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	_, err = s.ConfirmTwoFactor(ctx, "alice", code)
+	require.NoError(t, err)
+	next, err := pkg.TOTPCode(enrollment.Secret, pkg.TOTPStep(time.Now())+1)
+	require.NoError(t, err)
+	wrong := "000000"
+	if next == wrong {
+		wrong = "000001"
+	}
+
+	challenge := func() string {
+		rsp, err := s.Login(ctx, LoginUserParams{Username: "alice", Password: "secret1", UserAgent: "test", ClientIP: "127.0.0.1"})
+		require.NoError(t, err)
+		return rsp.TwoFactor.ChallengeToken
+	}
+	login := func(challengeToken string, code string) error {
+		_, err := s.LoginTwoFactor(ctx, LoginTwoFactorParams{
+			ChallengeToken: challengeToken,
+			Code:           code,
+			UserAgent:      "test",
+			ClientIP:       "127.0.0.1",
+		})
+		return err
+	}
+
+	// the challenge is void after maxLoginChallengeAttempts misses, even for the right code
+	first := challenge()
+	for i := 0; i < maxLoginChallengeAttempts; i++ {
+		require.ErrorIs(t, login(first, wrong), internal.ErrInvalidTwoFactorCode)
+	}
+	require.ErrorIs(t, login(first, next), internal.ErrInvalidToken)
+	require.Equal(t, int32(maxLoginChallengeAttempts), twoFactorRepo.users.users["alice"].TotpAttempts)
+
+	// the misses of every challenge count for the user, who is locked at maxTwoFactorAttempts
+	second := challenge()
+	for i := maxLoginChallengeAttempts; i < maxTwoFactorAttempts; i++ {
+		require.ErrorIs(t, login(second, wrong), internal.ErrInvalidTwoFactorCode)
+	}
+	require.ErrorIs(t, login(challenge(), next), internal.ErrTwoFactorLocked)
+
+	// the code is accepted once the lock runs out, which forgets the misses
+	user := twoFactorRepo.users.users["alice"]
+	user.TotpLockedUntil.Time = time.Now().Add(-time.Second)
+	twoFactorRepo.users.users["alice"] = user
+	require.NoError(t, login(challenge(), next))
+	require.Zero(t, twoFactorRepo.users.users["alice"].TotpAttempts)
+	require.False(t, twoFactorRepo.users.users["alice"].TotpLockedUntil.Valid)
+}
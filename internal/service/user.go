@@ -2,16 +2,21 @@ package service
 
 import (
 	"context"
+	"time"
 
-	"github.com/hibiken/asynq"
+	"github.com/google/uuid"
 	"github.com/marco-almeida/mybank/internal/postgresql/db"
 )
 
 // UserRepository defines the methods that any User repository should implement.
 type UserRepository interface {
 	Get(ctx context.Context, username string) (db.User, error)
+	GetByEmail(ctx context.Context, email string) (db.User, error)
 	CreateWithTx(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error)
 	Update(ctx context.Context, arg db.UpdateUserParams) (db.User, error)
+	RequestVerifyEmail(ctx context.Context, username string, requestedBefore time.Time) (db.User, error)
+	RequestEmailChange(ctx context.Context, username string, newEmail string) (db.User, error)
+	ChangeRole(ctx context.Context, arg db.ChangeUserRoleTxParams) (db.User, error)
 }
 
 // AuthService defines the application service in charge of interacting with Users.
@@ -20,28 +25,33 @@ type AuthService interface {
 	Login(ctx context.Context, req LoginUserParams) (LoginUserResponse, error)
 	RenewAccessToken(ctx context.Context, req RenewAccessTokenParams) (RenewAccessTokenResponse, error)
 	VerifyEmail(ctx context.Context, req db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error)
+	ResendVerifyEmail(ctx context.Context, username string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, arg ResetPasswordParams) (db.User, error)
 	Update(ctx context.Context, arg UpdateUserParams) (db.User, error)
-}
-
-// UserMessageBrokerRepository defines the methods that any UserMessageBrokerRepository should implement.
-type UserMessageBrokerRepository interface {
-	// CreateVerifyEmailTask publishes task to queue
-	CreateVerifyEmailTask(ctx context.Context, username string, opts ...asynq.Option) error
+	LoginTwoFactor(ctx context.Context, req LoginTwoFactorParams) (LoginUserResponse, error)
+	EnrollTwoFactor(ctx context.Context, username string) (TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, username string, code string) ([]string, error)
+	ResetTwoFactor(ctx context.Context, actor string, username string) error
+	ListSessions(ctx context.Context, username string) ([]db.Session, error)
+	RevokeSession(ctx context.Context, username string, id uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, username string, currentID uuid.UUID) error
+	Logout(ctx context.Context, req LogoutParams) error
+	BlockUserSessions(ctx context.Context, actor string, username string) error
+	ChangeRole(ctx context.Context, actor string, req ChangeRoleParams) (db.User, error)
 }
 
 // UserService defines the application service in charge of interacting with Users.
 type UserService struct {
-	repo                        UserRepository
-	authSvc                     AuthService
-	UserMessageBrokerRepository UserMessageBrokerRepository
+	repo    UserRepository
+	authSvc AuthService
 }
 
 // NewUserService creates a new User service.
-func NewUserService(repo UserRepository, authSvc AuthService, UserMessageBrokerRepository UserMessageBrokerRepository) *UserService {
+func NewUserService(repo UserRepository, authSvc AuthService) *UserService {
 	return &UserService{
-		repo:                        repo,
-		authSvc:                     authSvc,
-		UserMessageBrokerRepository: UserMessageBrokerRepository,
+		repo:    repo,
+		authSvc: authSvc,
 	}
 }
 
@@ -51,10 +61,8 @@ func (s *UserService) Create(ctx context.Context, req CreateUserParams) (db.User
 		PlaintextPassword: req.PlaintextPassword,
 		FullName:          req.FullName,
 		Email:             req.Email,
-		AfterCreate: func(user db.User) error {
-			return s.UserMessageBrokerRepository.CreateVerifyEmailTask(ctx, user.Username) // publishes task to queue
-		},
 	}
+	// the verification email is sent once the user created event written with the user is relayed
 	txResult, err := s.authSvc.Create(ctx, txParams)
 
 	if err != nil {
@@ -84,11 +92,65 @@ func (s *UserService) VerifyEmail(ctx context.Context, req db.VerifyEmailTxParam
 	return s.authSvc.VerifyEmail(ctx, req)
 }
 
+func (s *UserService) ResendVerifyEmail(ctx context.Context, username string) error {
+	return s.authSvc.ResendVerifyEmail(ctx, username)
+}
+
+func (s *UserService) ForgotPassword(ctx context.Context, email string) error {
+	return s.authSvc.ForgotPassword(ctx, email)
+}
+
+func (s *UserService) ResetPassword(ctx context.Context, arg ResetPasswordParams) (db.User, error) {
+	return s.authSvc.ResetPassword(ctx, arg)
+}
+
+func (s *UserService) LoginTwoFactor(ctx context.Context, req LoginTwoFactorParams) (LoginUserResponse, error) {
+	return s.authSvc.LoginTwoFactor(ctx, req)
+}
+
+func (s *UserService) EnrollTwoFactor(ctx context.Context, username string) (TwoFactorEnrollment, error) {
+	return s.authSvc.EnrollTwoFactor(ctx, username)
+}
+
+func (s *UserService) ConfirmTwoFactor(ctx context.Context, username string, code string) ([]string, error) {
+	return s.authSvc.ConfirmTwoFactor(ctx, username, code)
+}
+
+func (s *UserService) ResetTwoFactor(ctx context.Context, actor string, username string) error {
+	return s.authSvc.ResetTwoFactor(ctx, actor, username)
+}
+
+func (s *UserService) ListSessions(ctx context.Context, username string) ([]db.Session, error) {
+	return s.authSvc.ListSessions(ctx, username)
+}
+
+func (s *UserService) RevokeSession(ctx context.Context, username string, id uuid.UUID) error {
+	return s.authSvc.RevokeSession(ctx, username, id)
+}
+
+func (s *UserService) RevokeOtherSessions(ctx context.Context, username string, currentID uuid.UUID) error {
+	return s.authSvc.RevokeOtherSessions(ctx, username, currentID)
+}
+
+func (s *UserService) Logout(ctx context.Context, req LogoutParams) error {
+	return s.authSvc.Logout(ctx, req)
+}
+
+func (s *UserService) BlockUserSessions(ctx context.Context, actor string, username string) error {
+	return s.authSvc.BlockUserSessions(ctx, actor, username)
+}
+
+func (s *UserService) ChangeRole(ctx context.Context, actor string, req ChangeRoleParams) (db.User, error) {
+	return s.authSvc.ChangeRole(ctx, actor, req)
+}
+
 type UpdateUserParams struct {
 	Username          string
 	PlaintextPassword string
 	FullName          string
 	Email             string
+	PhoneNumber       string
+	Locale            string
 }
 
 func (s *UserService) Update(ctx context.Context, arg UpdateUserParams) (db.User, error) {
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+)
+
+const (
+	// webhookMaxRetry is how many times a failed delivery is retried, with WebhookRetryDelay that spans about 8 hours
+	webhookMaxRetry = 10
+	// webhookTimeout is how long a receiver has to answer a delivery
+	webhookTimeout = 10 * time.Second
+	// webhookMaxRetryDelay caps the delay between two attempts of a delivery
+	webhookMaxRetryDelay = 4 * time.Hour
+)
+
+// WebhookRetryDelay is the exponential backoff between the attempts of a delivery: 30s, 1m, 2m, 4m and so on
+func WebhookRetryDelay(retried int) time.Duration {
+	if retried > 16 {
+		return webhookMaxRetryDelay
+	}
+	return min(30*time.Second<<retried, webhookMaxRetryDelay)
+}
+
+// WebhookRepository defines the methods that any Webhook repository should implement.
+type WebhookRepository interface {
+	Create(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error)
+	Get(ctx context.Context, id int64) (db.Webhook, error)
+	List(ctx context.Context, arg db.ListWebhooksParams) ([]db.Webhook, error)
+	ListForEvent(ctx context.Context, arg db.ListWebhooksForEventParams) ([]db.Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	CreateDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error)
+	UpdateDeliveryAttempt(ctx context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error)
+	ResetDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error)
+}
+
+// WebhookMessageBrokerRepository defines the methods that any WebhookMessageBrokerRepository should implement.
+type WebhookMessageBrokerRepository interface {
+	// CreateDeliverWebhookTask publishes task to queue
+	CreateDeliverWebhookTask(ctx context.Context, payload redisRepo.PayloadDeliverWebhook, opts ...asynq.Option) error
+}
+
+// WebhookService defines the application service in charge of interacting with Webhooks.
+type WebhookService struct {
+	repo   WebhookRepository
+	broker WebhookMessageBrokerRepository
+	client *http.Client
+	// requireHTTPS rejects webhooks with http urls
+	requireHTTPS bool
+	// lookupIP resolves the host of a webhook url when it is created
+	lookupIP func(ctx context.Context, host string) ([]netip.Addr, error)
+	// isAllowedIP tells whether deliveries can be sent to an address, it is checked when a webhook is created and
+	// again on every connection, so that a host that resolved to a public address cannot later point somewhere else
+	isAllowedIP func(addr netip.Addr) bool
+}
+
+// NewWebhookService creates a new Webhook service. Deliveries are only sent to public addresses and redirects are not
+// followed, so that webhooks cannot be used to reach the internal network. Only https urls are accepted if
+// requireHTTPS is true.
+func NewWebhookService(repo WebhookRepository, broker WebhookMessageBrokerRepository, requireHTTPS bool) *WebhookService {
+	s := &WebhookService{
+		repo:         repo,
+		broker:       broker,
+		requireHTTPS: requireHTTPS,
+		lookupIP: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+		isAllowedIP: isPublicIP,
+	}
+
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		// the address is checked after it was resolved, right before connecting to it
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !s.isAllowedIP(addrPort.Addr().Unmap()) {
+				return fmt.Errorf("webhook address %s is not public", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	s.client = &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			// no proxy, the connection must be made to the checked address
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConnsPerHost: 2,
+		},
+		// a redirect could point at an internal address, the 3xx response fails the attempt instead
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return s
+}
+
+// nonPublicPrefixes are the special purpose ranges netip has no predicate for
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT shared address space
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved, including the limited broadcast address
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which reaches any IPv4 address through the gateway
+	netip.MustParsePrefix("2002::/16"),     // 6to4, which embeds an IPv4 address
+}
+
+// isPublicIP tells whether addr is not a loopback, link-local, private, multicast, unspecified or other special
+// purpose address
+func isPublicIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsPrivate() ||
+		addr.IsUnspecified() {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+type CreateWebhookParams struct {
+	Owner      string
+	URL        string
+	EventTypes []string
+}
+
+// Create subscribes the URL to the event types, deliveries are signed with a new random secret.
+func (s *WebhookService) Create(ctx context.Context, arg CreateWebhookParams) (db.Webhook, error) {
+	u, err := s.checkURL(ctx, arg.URL)
+	if err != nil {
+		return db.Webhook{}, err
+	}
+
+	for _, eventType := range arg.EventTypes {
+		if !pkg.IsWebhookEventType(eventType) {
+			return db.Webhook{}, fmt.Errorf("%w; unknown event type %q", internal.ErrInvalidParams, eventType)
+		}
+	}
+
+	// the event types are kept in the order of pkg.WebhookEventTypes, without duplicates
+	eventTypes := make([]string, 0, len(arg.EventTypes))
+	for _, eventType := range pkg.WebhookEventTypes {
+		if slices.Contains(arg.EventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return db.Webhook{}, err
+	}
+
+	return s.repo.Create(ctx, db.CreateWebhookParams{
+		Owner:      arg.Owner,
+		Url:        u.String(),
+		EventTypes: eventTypes,
+		Secret:     secret,
+	})
+}
+
+// checkURL parses a webhook url and checks that the addresses its host resolves to are public
+func (s *WebhookService) checkURL(ctx context.Context, rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, fmt.Errorf("%w; the url must be an absolute http or https url", internal.ErrInvalidParams)
+	}
+	if s.requireHTTPS && u.Scheme != "https" {
+		return nil, fmt.Errorf("%w; the url must be an https url", internal.ErrInvalidParams)
+	}
+
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		addrs, err = s.lookupIP(ctx, u.Hostname())
+		if err != nil || len(addrs) == 0 {
+			return nil, fmt.Errorf("%w; the url host cannot be resolved", internal.ErrInvalidParams)
+		}
+	}
+
+	for _, addr := range addrs {
+		if !s.isAllowedIP(addr) {
+			return nil, fmt.Errorf("%w; the url must point to a public address", internal.ErrInvalidParams)
+		}
+	}
+
+	return u, nil
+}
+
+func (s *WebhookService) List(ctx context.Context, arg db.ListWebhooksParams) ([]db.Webhook, error) {
+	return s.repo.List(ctx, arg)
+}
+
+// Get returns the webhook, only its owner can see it.
+func (s *WebhookService) Get(ctx context.Context, owner string, id int64) (db.Webhook, error) {
+	webhook, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return db.Webhook{}, err
+	}
+	if webhook.Owner != owner {
+		return db.Webhook{}, internal.ErrNoRows
+	}
+	return webhook, nil
+}
+
+// Delete removes the webhook along with its deliveries, deliveries that are still retried are dropped.
+func (s *WebhookService) Delete(ctx context.Context, owner string, id int64) error {
+	if _, err := s.Get(ctx, owner, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// ListDeliveries returns the delivery log of the webhook, newest first.
+func (s *WebhookService) ListDeliveries(ctx context.Context, owner string, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, owner, arg.WebhookID); err != nil {
+		return []db.WebhookDelivery{}, err
+	}
+	return s.repo.ListDeliveries(ctx, arg)
+}
+
+// Redeliver sends a delivery of the webhook again, whatever happened to it before.
+func (s *WebhookService) Redeliver(ctx context.Context, owner string, webhookID int64, deliveryID int64) (db.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, owner, webhookID); err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	if delivery.WebhookID != webhookID {
+		return db.WebhookDelivery{}, internal.ErrNoRows
+	}
+
+	delivery, err = s.repo.ResetDelivery(ctx, deliveryID)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	err = s.broker.CreateDeliverWebhookTask(ctx, redisRepo.PayloadDeliverWebhook{DeliveryID: delivery.ID}, asynq.MaxRetry(webhookMaxRetry))
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+// Dispatch creates a delivery of the event for every webhook of the owners subscribed to its type and
+// enqueues them. Dispatching an event again only enqueues the deliveries that were never attempted.
+func (s *WebhookService) Dispatch(ctx context.Context, event pkg.Event, owners ...string) (int, error) {
+	webhooks, err := s.repo.ListForEvent(ctx, db.ListWebhooksForEventParams{
+		Owners:    owners,
+		EventType: event.Type,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(webhooks) == 0 {
+		return 0, nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var dispatched int
+	var errs []error
+	for _, webhook := range webhooks {
+		delivery, err := s.repo.CreateDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   payload,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", webhook.ID, err))
+			continue
+		}
+		if delivery.Status != pkg.WebhookDeliveryStatusPending || delivery.Attempts > 0 {
+			continue
+		}
+
+		// the task id deduplicates the task of a delivery enqueued by a previous attempt at dispatching
+		err = s.broker.CreateDeliverWebhookTask(ctx, redisRepo.PayloadDeliverWebhook{DeliveryID: delivery.ID},
+			asynq.MaxRetry(webhookMaxRetry), asynq.TaskID("webhook_delivery:"+strconv.FormatInt(delivery.ID, 10)))
+		if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", webhook.ID, err))
+			continue
+		}
+		dispatched++
+	}
+
+	return dispatched, errors.Join(errs...)
+}
+
+// Deliver posts a delivery to the URL of its webhook and records the attempt. An error means the attempt failed
+// and should be retried, unless it was the last one which marks the delivery as failed.
+func (s *WebhookService) Deliver(ctx context.Context, deliveryID int64, lastAttempt bool) error {
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery.Status == pkg.WebhookDeliveryStatusSucceeded {
+		return nil
+	}
+
+	webhook, err := s.repo.Get(ctx, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	responseStatus, sendErr := s.send(ctx, webhook, delivery)
+
+	attempt := db.UpdateWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         pkg.WebhookDeliveryStatusSucceeded,
+		ResponseStatus: int32(responseStatus),
+	}
+	if sendErr != nil {
+		attempt.Status = pkg.WebhookDeliveryStatusPending
+		if lastAttempt {
+			attempt.Status = pkg.WebhookDeliveryStatusFailed
+		}
+		attempt.LastError = sendErr.Error()
+	}
+
+	if _, err := s.repo.UpdateDeliveryAttempt(ctx, attempt); err != nil {
+		return errors.Join(sendErr, err)
+	}
+	return sendErr
+}
+
+// send posts the delivery payload signed with the webhook secret and returns the response status
+func (s *WebhookService) send(ctx context.Context, webhook db.Webhook, delivery db.WebhookDelivery) (int, error) {
+	if s.requireHTTPS && !strings.HasPrefix(webhook.Url, "https://") {
+		return 0, fmt.Errorf("webhook url %s is not an https url", webhook.Url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mybank-webhooks/1.0")
+	req.Header.Set("Mybank-Event", delivery.EventType)
+	req.Header.Set("Mybank-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(pkg.WebhookSignatureHeader, pkg.SignWebhookPayload(webhook.Secret, time.Now(), delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// the body is drained, up to a limit, so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newWebhookSecret returns a random secret to sign deliveries with
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
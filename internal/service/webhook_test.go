@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/marco-almeida/mybank/internal"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/marco-almeida/mybank/internal/postgresql/db"
+	redisRepo "github.com/marco-almeida/mybank/internal/redis"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhookRepository keeps webhooks and deliveries in memory, only what the tests below use is implemented
+type fakeWebhookRepository struct {
+	WebhookRepository
+	webhooks   map[int64]db.Webhook
+	deliveries map[int64]db.WebhookDelivery
+}
+
+func newFakeWebhookRepository(webhooks ...db.Webhook) *fakeWebhookRepository {
+	repo := &fakeWebhookRepository{
+		webhooks:   map[int64]db.Webhook{},
+		deliveries: map[int64]db.WebhookDelivery{},
+	}
+	for _, webhook := range webhooks {
+		repo.webhooks[webhook.ID] = webhook
+	}
+	return repo
+}
+
+func (r *fakeWebhookRepository) Create(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	webhook := db.Webhook{ID: int64(len(r.webhooks) + 1), Owner: arg.Owner, Url: arg.Url, EventTypes: arg.EventTypes, Secret: arg.Secret}
+	r.webhooks[webhook.ID] = webhook
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepository) Get(ctx context.Context, id int64) (db.Webhook, error) {
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return db.Webhook{}, internal.ErrNoRows
+	}
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepository) ListForEvent(ctx context.Context, arg db.ListWebhooksForEventParams) ([]db.Webhook, error) {
+	var webhooks []db.Webhook
+	for id := int64(1); id <= int64(len(r.webhooks)); id++ {
+		webhook := r.webhooks[id]
+		for _, owner := range arg.Owners {
+			if webhook.Owner == owner && slices.Contains(webhook.EventTypes, arg.EventType) {
+				webhooks = append(webhooks, webhook)
+				break
+			}
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *fakeWebhookRepository) CreateDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == arg.WebhookID && delivery.EventID == arg.EventID {
+			return delivery, nil
+		}
+	}
+	delivery := db.WebhookDelivery{
+		ID:        int64(len(r.deliveries) + 1),
+		WebhookID: arg.WebhookID,
+		EventID:   arg.EventID,
+		EventType: arg.EventType,
+		Payload:   arg.Payload,
+		Status:    pkg.WebhookDeliveryStatusPending,
+	}
+	r.deliveries[delivery.ID] = delivery
+	return delivery, nil
+}
+
+func (r *fakeWebhookRepository) GetDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return db.WebhookDelivery{}, internal.ErrNoRows
+	}
+	return delivery, nil
+}
+
+func (r *fakeWebhookRepository) UpdateDeliveryAttempt(ctx context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+	delivery := r.deliveries[arg.ID]
+	delivery.Status = arg.Status
+	delivery.Attempts++
+	delivery.ResponseStatus = arg.ResponseStatus
+	delivery.LastError = arg.LastError
+	r.deliveries[arg.ID] = delivery
+	return delivery, nil
+}
+
+type fakeWebhookBroker struct {
+	enqueued []int64
+}
+
+func (b *fakeWebhookBroker) CreateDeliverWebhookTask(ctx context.Context, payload redisRepo.PayloadDeliverWebhook, opts ...asynq.Option) error {
+	b.enqueued = append(b.enqueued, payload.DeliveryID)
+	return nil
+}
+
+// newTestWebhookService creates a webhook service that resolves host names with hosts instead of DNS
+func newTestWebhookService(repo WebhookRepository, broker WebhookMessageBrokerRepository, requireHTTPS bool, hosts map[string]string) *WebhookService {
+	svc := NewWebhookService(repo, broker, requireHTTPS)
+	svc.lookupIP = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		addr, ok := hosts[host]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		return []netip.Addr{netip.MustParseAddr(addr)}, nil
+	}
+	return svc
+}
+
+func TestCreateWebhook(t *testing.T) {
+	svc := newTestWebhookService(newFakeWebhookRepository(), &fakeWebhookBroker{}, true, map[string]string{
+		"example.com":          "93.184.216.34",
+		"internal.example.com": "10.0.0.5",
+		"rebind.example.com":   "::ffff:127.0.0.1",
+	})
+
+	webhook, err := svc.Create(context.Background(), CreateWebhookParams{
+		Owner:      "alice",
+		URL:        "https://example.com/hooks",
+		EventTypes: []string{pkg.EventTransferCompleted, pkg.EventAccountCredited, pkg.EventTransferCompleted},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{pkg.EventAccountCredited, pkg.EventTransferCompleted}, webhook.EventTypes)
+	require.Regexp(t, `^whsec_[A-Za-z0-9_-]{43}$`, webhook.Secret)
+
+	_, err = svc.Create(context.Background(), CreateWebhookParams{Owner: "alice", URL: "ftp://example.com", EventTypes: []string{pkg.EventTransferCompleted}})
+	require.ErrorIs(t, err, internal.ErrInvalidParams)
+
+	_, err = svc.Create(context.Background(), CreateWebhookParams{Owner: "alice", URL: "https://example.com", EventTypes: []string{pkg.EventUserCreated}})
+	require.ErrorIs(t, err, internal.ErrInvalidParams)
+
+	// only https outside development, and only public addresses
+	for _, url := range []string{
+		"http://example.com/hooks",
+		"https://localhost/hooks",
+		"https://127.0.0.1/hooks",
+		"https://[::1]/hooks",
+		"https://169.254.169.254/latest/meta-data",
+		"https://10.1.2.3/hooks",
+		"https://192.168.0.1/hooks",
+		"https://172.16.0.1:6379",
+		"https://0.0.0.0/hooks",
+		"https://[fd00::1]/hooks",
+		"https://internal.example.com/hooks",
+		"https://rebind.example.com/hooks",
+	} {
+		_, err = svc.Create(context.Background(), CreateWebhookParams{Owner: "alice", URL: url, EventTypes: []string{pkg.EventTransferCompleted}})
+		require.ErrorIs(t, err, internal.ErrInvalidParams, url)
+	}
+
+	svc = newTestWebhookService(newFakeWebhookRepository(), &fakeWebhookBroker{}, false, map[string]string{"example.com": "93.184.216.34"})
+	_, err = svc.Create(context.Background(), CreateWebhookParams{Owner: "alice", URL: "http://example.com/hooks", EventTypes: []string{pkg.EventTransferCompleted}})
+	require.NoError(t, err)
+}
+
+func TestDispatchWebhooks(t *testing.T) {
+	repo := newFakeWebhookRepository(
+		db.Webhook{ID: 1, Owner: "alice", EventTypes: []string{pkg.EventTransferCompleted}},
+		db.Webhook{ID: 2, Owner: "bob", EventTypes: []string{pkg.EventTransferCompleted}},
+		db.Webhook{ID: 3, Owner: "alice", EventTypes: []string{pkg.EventAccountCreated}},
+		db.Webhook{ID: 4, Owner: "carol", EventTypes: []string{pkg.EventTransferCompleted}},
+	)
+	broker := &fakeWebhookBroker{}
+	svc := NewWebhookService(repo, broker, true)
+
+	event := pkg.Event{ID: 7, Type: pkg.EventTransferCompleted, Data: json.RawMessage(`{"transfer_id":1}`)}
+	dispatched, err := svc.Dispatch(context.Background(), event, "alice", "bob")
+	require.NoError(t, err)
+	require.Equal(t, 2, dispatched)
+	require.Len(t, broker.enqueued, 2)
+	require.JSONEq(t, `{"id":7,"type":"transfer.completed","created_at":"0001-01-01T00:00:00Z","data":{"transfer_id":1}}`, string(repo.deliveries[1].Payload))
+
+	// a redelivered event task doesn't send the deliveries again once they were attempted
+	attempted := repo.deliveries[1]
+	attempted.Attempts = 1
+	repo.deliveries[1] = attempted
+	dispatched, err = svc.Dispatch(context.Background(), event, "alice", "bob")
+	require.NoError(t, err)
+	require.Equal(t, 1, dispatched)
+	require.Len(t, broker.enqueued, 3)
+	require.Equal(t, int64(2), broker.enqueued[2])
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	secret := "whsec_test"
+	var received http.Header
+	var body []byte
+	status := http.StatusNoContent
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	repo := newFakeWebhookRepository(db.Webhook{ID: 1, Owner: "alice", Url: receiver.URL, EventTypes: []string{pkg.EventAccountCredited}, Secret: secret})
+	svc := NewWebhookService(repo, &fakeWebhookBroker{}, false)
+	// the receiver listens on a loopback address, which deliveries are otherwise refused to
+	svc.isAllowedIP = func(addr netip.Addr) bool { return true }
+	event := pkg.Event{ID: 3, Type: pkg.EventAccountCredited, Data: json.RawMessage(`{"account_id":1}`)}
+	_, err := svc.Dispatch(context.Background(), event, "alice")
+	require.NoError(t, err)
+
+	// the receiver gets the event signed with the webhook secret
+	err = svc.Deliver(context.Background(), 1, false)
+	require.NoError(t, err)
+	require.Equal(t, "application/json", received.Get("Content-Type"))
+	require.Equal(t, pkg.EventAccountCredited, received.Get("Mybank-Event"))
+	require.Equal(t, "1", received.Get("Mybank-Delivery"))
+	require.NoError(t, pkg.VerifyWebhookSignature(secret, received.Get(pkg.WebhookSignatureHeader), body, time.Now(), time.Minute))
+	require.Equal(t, repo.deliveries[1].Payload, body)
+
+	delivery := repo.deliveries[1]
+	require.Equal(t, pkg.WebhookDeliveryStatusSucceeded, delivery.Status)
+	require.Equal(t, int32(http.StatusNoContent), delivery.ResponseStatus)
+	require.Equal(t, int32(1), delivery.Attempts)
+
+	// a succeeded delivery isn't sent twice
+	received = nil
+	require.NoError(t, svc.Deliver(context.Background(), 1, false))
+	require.Nil(t, received)
+
+	// failed attempts stay pending until the last one
+	_, err = svc.Dispatch(context.Background(), pkg.Event{ID: 4, Type: pkg.EventAccountCredited, Data: json.RawMessage(`{}`)}, "alice")
+	require.NoError(t, err)
+	status = http.StatusInternalServerError
+
+	err = svc.Deliver(context.Background(), 2, false)
+	require.ErrorContains(t, err, "unexpected response status 500")
+	require.Equal(t, pkg.WebhookDeliveryStatusPending, repo.deliveries[2].Status)
+	require.Equal(t, int32(http.StatusInternalServerError), repo.deliveries[2].ResponseStatus)
+
+	err = svc.Deliver(context.Background(), 2, true)
+	require.Error(t, err)
+	require.Equal(t, pkg.WebhookDeliveryStatusFailed, repo.deliveries[2].Status)
+	require.Equal(t, int32(2), repo.deliveries[2].Attempts)
+	require.Contains(t, repo.deliveries[2].LastError, "500")
+
+	// an unreachable receiver fails without a response status
+	receiver.Close()
+	_, err = svc.Dispatch(context.Background(), pkg.Event{ID: 5, Type: pkg.EventAccountCredited, Data: json.RawMessage(`{}`)}, "alice")
+	require.NoError(t, err)
+	require.Error(t, svc.Deliver(context.Background(), 3, false))
+	require.Zero(t, repo.deliveries[3].ResponseStatus)
+}
+
+func TestDeliverWebhookRefusesInternalAddresses(t *testing.T) {
+	var hits atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	// the webhook url is stored as is, as if its host resolved to a public address when the webhook was created and
+	// to a loopback one now
+	repo := newFakeWebhookRepository(db.Webhook{ID: 1, Owner: "alice", Url: receiver.URL, EventTypes: []string{pkg.EventAccountCredited}, Secret: "whsec_test"})
+	svc := NewWebhookService(repo, &fakeWebhookBroker{}, false)
+	_, err := svc.Dispatch(context.Background(), pkg.Event{ID: 1, Type: pkg.EventAccountCredited, Data: json.RawMessage(`{}`)}, "alice")
+	require.NoError(t, err)
+
+	err = svc.Deliver(context.Background(), 1, false)
+	require.ErrorContains(t, err, "is not public")
+	require.Zero(t, hits.Load())
+	require.Equal(t, pkg.WebhookDeliveryStatusPending, repo.deliveries[1].Status)
+	require.Zero(t, repo.deliveries[1].ResponseStatus)
+
+	// http urls are not sent to when https is required
+	svc = NewWebhookService(repo, &fakeWebhookBroker{}, true)
+	svc.isAllowedIP = func(addr netip.Addr) bool { return true }
+	err = svc.Deliver(context.Background(), 1, false)
+	require.ErrorContains(t, err, "not an https url")
+	require.Zero(t, hits.Load())
+}
+
+func TestDeliverWebhookDoesNotFollowRedirects(t *testing.T) {
+	var hits atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	repo := newFakeWebhookRepository(db.Webhook{ID: 1, Owner: "alice", Url: receiver.URL, EventTypes: []string{pkg.EventAccountCredited}, Secret: "whsec_test"})
+	svc := NewWebhookService(repo, &fakeWebhookBroker{}, false)
+	svc.isAllowedIP = func(addr netip.Addr) bool { return true }
+	_, err := svc.Dispatch(context.Background(), pkg.Event{ID: 1, Type: pkg.EventAccountCredited, Data: json.RawMessage(`{}`)}, "alice")
+	require.NoError(t, err)
+
+	err = svc.Deliver(context.Background(), 1, false)
+	require.ErrorContains(t, err, "unexpected response status 307")
+	require.Zero(t, hits.Load())
+	require.Equal(t, int32(http.StatusTemporaryRedirect), repo.deliveries[1].ResponseStatus)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":      true,
+		"2606:2800:220:1::1": true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"::ffff:127.0.0.1":   false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"10.0.0.1":           false,
+		"172.31.255.255":     false,
+		"192.168.1.1":        false,
+		"fc00::1":            false,
+		"0.0.0.0":            false,
+		"::":                 false,
+		"224.0.0.1":          false,
+		"100.64.0.1":         false,
+		"100.127.255.255":    false,
+		"100.128.0.1":        true,
+		"192.0.0.8":          false,
+		"192.0.1.1":          true,
+		"198.18.0.1":         false,
+		"198.19.255.255":     false,
+		"198.20.0.1":         true,
+		"240.0.0.1":          false,
+		"255.255.255.255":    false,
+		"64:ff9b::7f00:1":    false,
+		"64:ff9b::5db8:d822": false,
+		"2002:7f00:1::1":     false,
+		"2002:5db8:d822::1":  false,
+		"::ffff:100.64.0.1":  false,
+	} {
+		require.Equal(t, public, isPublicIP(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	require.Equal(t, 30*time.Second, WebhookRetryDelay(0))
+	require.Equal(t, time.Minute, WebhookRetryDelay(1))
+	require.Equal(t, 8*time.Minute, WebhookRetryDelay(4))
+	require.Equal(t, webhookMaxRetryDelay, WebhookRetryDelay(9))
+	require.Equal(t, webhookMaxRetryDelay, WebhookRetryDelay(100))
+}
@@ -0,0 +1,299 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+// camt053Namespace is the namespace of the ISO 20022 BankToCustomerStatement version the encoder follows
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+const (
+	isoDate     = "2006-01-02"
+	isoDateTime = "2006-01-02T15:04:05Z"
+)
+
+// The types below mirror the camt.053.001.02 schema, their fields must stay in the order of the XSD sequences
+
+type camtGroupHeader struct {
+	MsgID    string `xml:"MsgId"`
+	CreDtTm  string `xml:"CreDtTm"`
+	MsgPgntn struct {
+		PgNb      string `xml:"PgNb"`
+		LastPgInd bool   `xml:"LastPgInd"`
+	} `xml:"MsgPgntn"`
+}
+
+type camtFromToDate struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAccountID struct {
+	Othr struct {
+		ID string `xml:"Id"`
+	} `xml:"Othr"`
+}
+
+type camtAccount struct {
+	ID   camtAccountID `xml:"Id"`
+	Ccy  string        `xml:"Ccy,omitempty"`
+	Ownr *camtParty    `xml:"Ownr,omitempty"`
+}
+
+type camtParty struct {
+	Nm string `xml:"Nm"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtDate struct {
+	Dt   string `xml:"Dt,omitempty"`
+	DtTm string `xml:"DtTm,omitempty"`
+}
+
+type camtBalance struct {
+	Tp struct {
+		CdOrPrtry struct {
+			Cd string `xml:"Cd"`
+		} `xml:"CdOrPrtry"`
+	} `xml:"Tp"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Dt        camtDate   `xml:"Dt"`
+}
+
+type camtNumberAndSum struct {
+	NbOfNtries    string `xml:"NbOfNtries"`
+	Sum           string `xml:"Sum"`
+	TtlNetNtryAmt string `xml:"TtlNetNtryAmt,omitempty"`
+	CdtDbtInd     string `xml:"CdtDbtInd,omitempty"`
+}
+
+type camtTransactionsSummary struct {
+	TtlNtries    camtNumberAndSum `xml:"TtlNtries"`
+	TtlCdtNtries camtNumberAndSum `xml:"TtlCdtNtries"`
+	TtlDbtNtries camtNumberAndSum `xml:"TtlDbtNtries"`
+}
+
+type camtBankTransactionCode struct {
+	Domn struct {
+		Cd   string `xml:"Cd"`
+		Fmly struct {
+			Cd        string `xml:"Cd"`
+			SubFmlyCd string `xml:"SubFmlyCd"`
+		} `xml:"Fmly"`
+	} `xml:"Domn"`
+}
+
+type camtCashAccount struct {
+	ID camtAccountID `xml:"Id"`
+}
+
+type camtRelatedParties struct {
+	Dbtr     *camtParty       `xml:"Dbtr,omitempty"`
+	DbtrAcct *camtCashAccount `xml:"DbtrAcct,omitempty"`
+	Cdtr     *camtParty       `xml:"Cdtr,omitempty"`
+	CdtrAcct *camtCashAccount `xml:"CdtrAcct,omitempty"`
+}
+
+type camtTransactionDetails struct {
+	Refs struct {
+		AcctSvcrRef string `xml:"AcctSvcrRef"`
+	} `xml:"Refs"`
+	RltdPties camtRelatedParties `xml:"RltdPties"`
+}
+
+type camtEntry struct {
+	NtryRef     string                  `xml:"NtryRef"`
+	Amt         camtAmount              `xml:"Amt"`
+	CdtDbtInd   string                  `xml:"CdtDbtInd"`
+	Sts         string                  `xml:"Sts"`
+	BookgDt     camtDate                `xml:"BookgDt"`
+	ValDt       camtDate                `xml:"ValDt"`
+	AcctSvcrRef string                  `xml:"AcctSvcrRef"`
+	BkTxCd      camtBankTransactionCode `xml:"BkTxCd"`
+	NtryDtls    *struct {
+		TxDtls camtTransactionDetails `xml:"TxDtls"`
+	} `xml:"NtryDtls,omitempty"`
+	AddtlNtryInf string `xml:"AddtlNtryInf,omitempty"`
+}
+
+// party returns the party with the given name, or nil when there is no name since names can't be empty
+func party(name string) *camtParty {
+	if name == "" {
+		return nil
+	}
+	return &camtParty{Nm: truncate(name, 140)}
+}
+
+// Camt053Encoder writes a statement as an ISO 20022 camt.053.001.02 bank to customer statement
+type Camt053Encoder struct {
+	w        io.Writer
+	enc      *xml.Encoder
+	currency string
+}
+
+// NewCamt053Encoder creates a new camt.053 statement encoder
+func NewCamt053Encoder(w io.Writer) Encoder {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &Camt053Encoder{w: w, enc: enc}
+}
+
+// creditDebit returns the absolute amount along with its credit or debit indicator
+func creditDebit(amount int64) (int64, string) {
+	if amount < 0 {
+		return -amount, "DBIT"
+	}
+	return amount, "CRDT"
+}
+
+func (e *Camt053Encoder) amount(amount int64) camtAmount {
+	return camtAmount{Ccy: e.currency, Value: decimal(abs(amount), e.currency)}
+}
+
+func (e *Camt053Encoder) balance(code string, amount int64, date time.Time) camtBalance {
+	var bal camtBalance
+	bal.Tp.CdOrPrtry.Cd = code
+	bal.Amt = e.amount(amount)
+	_, bal.CdtDbtInd = creditDebit(amount)
+	bal.Dt.Dt = date.Format(isoDate)
+	return bal
+}
+
+func (e *Camt053Encoder) Begin(s Statement) error {
+	e.currency = s.Currency
+
+	if _, err := io.WriteString(e.w, xml.Header); err != nil {
+		return err
+	}
+
+	tokens := tokenWriter{enc: e.enc}
+	if err := e.enc.EncodeToken(xml.StartElement{
+		Name: xml.Name{Local: "Document"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace}},
+	}); err != nil {
+		return err
+	}
+	tokens.start("BkToCstmrStmt")
+
+	var grpHdr camtGroupHeader
+	grpHdr.MsgID = truncate(strconv.FormatInt(s.CreatedAt.UnixMilli(), 36)+"-"+strconv.FormatInt(s.AccountID, 36), 35)
+	grpHdr.CreDtTm = s.CreatedAt.UTC().Format(isoDateTime)
+	grpHdr.MsgPgntn.PgNb = "1"
+	grpHdr.MsgPgntn.LastPgInd = true
+	e.encode(&tokens, "GrpHdr", grpHdr)
+
+	tokens.start("Stmt")
+	tokens.element("Id", truncate(s.ID, 35))
+	tokens.element("CreDtTm", s.CreatedAt.UTC().Format(isoDateTime))
+	e.encode(&tokens, "FrToDt", camtFromToDate{
+		FrDtTm: s.From.UTC().Format(isoDateTime),
+		ToDtTm: s.To.AddDate(0, 0, 1).Add(-time.Second).UTC().Format(isoDateTime),
+	})
+
+	account := camtAccount{Ccy: s.Currency, Ownr: party(s.Owner)}
+	account.ID.Othr.ID = strconv.FormatInt(s.AccountID, 10)
+	e.encode(&tokens, "Acct", account)
+
+	e.encode(&tokens, "Bal", e.balance("OPBD", s.OpeningBalance, s.From))
+	e.encode(&tokens, "Bal", e.balance("CLBD", s.ClosingBalance, s.To))
+
+	net, netIndicator := creditDebit(s.CreditTotal - s.DebitTotal)
+	e.encode(&tokens, "TxsSummry", camtTransactionsSummary{
+		TtlNtries: camtNumberAndSum{
+			NbOfNtries:    strconv.FormatInt(s.CreditCount+s.DebitCount, 10),
+			Sum:           decimal(s.CreditTotal+s.DebitTotal, s.Currency),
+			TtlNetNtryAmt: decimal(net, s.Currency),
+			CdtDbtInd:     netIndicator,
+		},
+		TtlCdtNtries: camtNumberAndSum{
+			NbOfNtries: strconv.FormatInt(s.CreditCount, 10),
+			Sum:        decimal(s.CreditTotal, s.Currency),
+		},
+		TtlDbtNtries: camtNumberAndSum{
+			NbOfNtries: strconv.FormatInt(s.DebitCount, 10),
+			Sum:        decimal(s.DebitTotal, s.Currency),
+		},
+	})
+	return tokens.err
+}
+
+func (e *Camt053Encoder) Entry(entry Entry) error {
+	_, indicator := creditDebit(entry.Amount)
+	ref := strconv.FormatInt(entry.ID, 10)
+
+	ntry := camtEntry{
+		NtryRef:      ref,
+		Amt:          e.amount(entry.Amount),
+		CdtDbtInd:    indicator,
+		Sts:          "BOOK",
+		BookgDt:      camtDate{DtTm: entry.BookedAt.UTC().Format(isoDateTime)},
+		ValDt:        camtDate{Dt: entry.BookedAt.UTC().Format(isoDate)},
+		AcctSvcrRef:  ref,
+		AddtlNtryInf: truncate(entry.Description, 500),
+	}
+
+	// payments domain, credit transfers for transfers between accounts and miscellaneous operations otherwise
+	ntry.BkTxCd.Domn.Cd = "PMNT"
+	switch {
+	case entry.TransferID != 0 && entry.Amount < 0:
+		ntry.BkTxCd.Domn.Fmly.Cd = "ICDT"
+		ntry.BkTxCd.Domn.Fmly.SubFmlyCd = "DMCT"
+	case entry.TransferID != 0:
+		ntry.BkTxCd.Domn.Fmly.Cd = "RCDT"
+		ntry.BkTxCd.Domn.Fmly.SubFmlyCd = "DMCT"
+	case entry.Amount < 0:
+		ntry.BkTxCd.Domn.Fmly.Cd = "MDOP"
+		ntry.BkTxCd.Domn.Fmly.SubFmlyCd = "OTHR"
+	default:
+		ntry.BkTxCd.Domn.Fmly.Cd = "MCOP"
+		ntry.BkTxCd.Domn.Fmly.SubFmlyCd = "OTHR"
+	}
+
+	if entry.TransferID != 0 {
+		var details camtTransactionDetails
+		details.Refs.AcctSvcrRef = strconv.FormatInt(entry.TransferID, 10)
+
+		counterparty := party(entry.CounterpartyOwner)
+		var counterpartyAccount camtCashAccount
+		counterpartyAccount.ID.Othr.ID = strconv.FormatInt(entry.CounterpartyAccountID, 10)
+		if entry.Amount < 0 {
+			details.RltdPties.Cdtr = counterparty
+			details.RltdPties.CdtrAcct = &counterpartyAccount
+		} else {
+			details.RltdPties.Dbtr = counterparty
+			details.RltdPties.DbtrAcct = &counterpartyAccount
+		}
+
+		ntry.NtryDtls = &struct {
+			TxDtls camtTransactionDetails `xml:"TxDtls"`
+		}{TxDtls: details}
+	}
+
+	return e.enc.EncodeElement(ntry, xml.StartElement{Name: xml.Name{Local: "Ntry"}})
+}
+
+func (e *Camt053Encoder) End() error {
+	tokens := tokenWriter{enc: e.enc}
+	tokens.end("Stmt")
+	tokens.end("BkToCstmrStmt")
+	tokens.end("Document")
+	if tokens.err != nil {
+		return tokens.err
+	}
+	return e.enc.Close()
+}
+
+// encode writes v as an element named name, unless a previous token failed
+func (e *Camt053Encoder) encode(tokens *tokenWriter, name string, v any) {
+	if tokens.err == nil {
+		tokens.err = e.enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
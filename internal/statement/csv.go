@@ -0,0 +1,65 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"booked_at",
+	"entry_id",
+	"description",
+	"transfer_id",
+	"counterparty_account_id",
+	"counterparty",
+	"amount",
+	"currency",
+	"balance",
+}
+
+// CSVEncoder writes a statement as one CSV row per entry, with the running balance after each entry
+type CSVEncoder struct {
+	w        *csv.Writer
+	currency string
+	balance  int64
+}
+
+// NewCSVEncoder creates a new CSV statement encoder
+func NewCSVEncoder(w io.Writer) Encoder {
+	return &CSVEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *CSVEncoder) Begin(s Statement) error {
+	e.currency = s.Currency
+	e.balance = s.OpeningBalance
+	return e.w.Write(csvHeader)
+}
+
+func (e *CSVEncoder) Entry(entry Entry) error {
+	e.balance += entry.Amount
+
+	var transferID, counterpartyAccountID string
+	if entry.TransferID != 0 {
+		transferID = strconv.FormatInt(entry.TransferID, 10)
+		counterpartyAccountID = strconv.FormatInt(entry.CounterpartyAccountID, 10)
+	}
+
+	return e.w.Write([]string{
+		entry.BookedAt.UTC().Format(time.RFC3339),
+		strconv.FormatInt(entry.ID, 10),
+		entry.Description,
+		transferID,
+		counterpartyAccountID,
+		entry.CounterpartyOwner,
+		decimal(entry.Amount, e.currency),
+		e.currency,
+		decimal(e.balance, e.currency),
+	})
+}
+
+func (e *CSVEncoder) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
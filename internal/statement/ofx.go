@@ -0,0 +1,148 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ofxBankID identifies the bank in BANKACCTFROM, there is no routing number for mybank accounts
+const ofxBankID = "MYBANK"
+
+// ofxHeader is the XML declaration followed by the OFX processing instruction every OFX 2 file starts with
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// OFXEncoder writes a statement as an OFX 2.2 bank statement response
+type OFXEncoder struct {
+	w        io.Writer
+	enc      *xml.Encoder
+	currency string
+	s        Statement
+}
+
+// NewOFXEncoder creates a new OFX statement encoder
+func NewOFXEncoder(w io.Writer) Encoder {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &OFXEncoder{w: w, enc: enc}
+}
+
+// ofxTime formats t as an OFX datetime, always in UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+func (e *OFXEncoder) Begin(s Statement) error {
+	e.s = s
+	e.currency = s.Currency
+
+	if _, err := io.WriteString(e.w, ofxHeader); err != nil {
+		return err
+	}
+
+	tokens := tokenWriter{enc: e.enc}
+	tokens.start("OFX")
+	tokens.start("SIGNONMSGSRSV1")
+	tokens.start("SONRS")
+	e.status(&tokens)
+	tokens.element("DTSERVER", ofxTime(s.CreatedAt))
+	tokens.element("LANGUAGE", "ENG")
+	tokens.end("SONRS")
+	tokens.end("SIGNONMSGSRSV1")
+
+	tokens.start("BANKMSGSRSV1")
+	tokens.start("STMTTRNRS")
+	tokens.element("TRNUID", "0")
+	e.status(&tokens)
+	tokens.start("STMTRS")
+	tokens.element("CURDEF", s.Currency)
+	tokens.start("BANKACCTFROM")
+	tokens.element("BANKID", ofxBankID)
+	tokens.element("ACCTID", strconv.FormatInt(s.AccountID, 10))
+	tokens.element("ACCTTYPE", "CHECKING")
+	tokens.end("BANKACCTFROM")
+	tokens.start("BANKTRANLIST")
+	tokens.element("DTSTART", ofxTime(s.From))
+	tokens.element("DTEND", ofxTime(s.To.AddDate(0, 0, 1)))
+	return tokens.err
+}
+
+func (e *OFXEncoder) Entry(entry Entry) error {
+	trnType := "CREDIT"
+	if entry.Amount < 0 {
+		trnType = "DEBIT"
+	}
+	if entry.TransferID != 0 {
+		trnType = "XFER"
+	}
+
+	tokens := tokenWriter{enc: e.enc}
+	tokens.start("STMTTRN")
+	tokens.element("TRNTYPE", trnType)
+	tokens.element("DTPOSTED", ofxTime(entry.BookedAt))
+	tokens.element("TRNAMT", decimal(entry.Amount, e.currency))
+	tokens.element("FITID", strconv.FormatInt(entry.ID, 10))
+	if entry.CounterpartyOwner != "" {
+		tokens.element("NAME", truncate(entry.CounterpartyOwner, 32))
+	}
+	if entry.Description != "" {
+		tokens.element("MEMO", truncate(entry.Description, 255))
+	}
+	tokens.end("STMTTRN")
+	if tokens.err != nil {
+		return tokens.err
+	}
+	return e.enc.Flush()
+}
+
+func (e *OFXEncoder) End() error {
+	tokens := tokenWriter{enc: e.enc}
+	tokens.end("BANKTRANLIST")
+	tokens.start("LEDGERBAL")
+	tokens.element("BALAMT", decimal(e.s.ClosingBalance, e.currency))
+	tokens.element("DTASOF", ofxTime(e.s.To.AddDate(0, 0, 1)))
+	tokens.end("LEDGERBAL")
+	tokens.end("STMTRS")
+	tokens.end("STMTTRNRS")
+	tokens.end("BANKMSGSRSV1")
+	tokens.end("OFX")
+	if tokens.err != nil {
+		return tokens.err
+	}
+	return e.enc.Close()
+}
+
+// status writes a successful OFX status aggregate
+func (e *OFXEncoder) status(tokens *tokenWriter) {
+	tokens.start("STATUS")
+	tokens.element("CODE", "0")
+	tokens.element("SEVERITY", "INFO")
+	tokens.end("STATUS")
+}
+
+// tokenWriter writes XML tokens and keeps the first error, so that long sequences don't need a check per token
+type tokenWriter struct {
+	enc *xml.Encoder
+	err error
+}
+
+func (t *tokenWriter) start(name string) {
+	if t.err == nil {
+		t.err = t.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (t *tokenWriter) end(name string) {
+	if t.err == nil {
+		t.err = t.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (t *tokenWriter) element(name string, value string) {
+	if t.err == nil {
+		t.err = t.enc.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
@@ -0,0 +1,144 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// column widths of the entries table in mm, they add up to the width of an A4 page without margins
+const (
+	pdfDateWidth        = 28
+	pdfDescriptionWidth = 92
+	pdfAmountWidth      = 35
+	pdfBalanceWidth     = 35
+	pdfRowHeight        = 6
+)
+
+// PDFEncoder writes a statement as a printable A4 PDF document.
+// Unlike the other encoders the document is kept in memory until End, PDF files can't be written as a stream.
+type PDFEncoder struct {
+	w        io.Writer
+	pdf      *fpdf.Fpdf
+	tr       func(string) string
+	bankName string
+	s        Statement
+	balance  int64
+}
+
+// NewPDFEncoder creates a new PDF statement encoder, bankName is printed on the top of the statement
+func NewPDFEncoder(w io.Writer, bankName string) Encoder {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	return &PDFEncoder{
+		w:        w,
+		pdf:      pdf,
+		tr:       pdf.UnicodeTranslatorFromDescriptor(""),
+		bankName: bankName,
+	}
+}
+
+func (e *PDFEncoder) Begin(s Statement) error {
+	e.s = s
+	e.balance = s.OpeningBalance
+
+	pdf := e.pdf
+	title := fmt.Sprintf("Statement of account #%d", s.AccountID)
+	pdf.SetTitle(title, true)
+	pdf.SetAuthor(e.bankName, true)
+	pdf.SetCreator(e.bankName, true)
+	pdf.SetCreationDate(s.CreatedAt)
+	pdf.SetModificationDate(s.CreatedAt)
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+
+	// the entries table continues on the following pages under a repeated header
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() > 1 {
+			e.tableHeader()
+		}
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, e.tr(fmt.Sprintf("%s - %s", e.bankName, s.ID)), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, e.tr(e.bankName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, e.tr(title), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range [][2]string{
+		{"Account holder", s.Owner},
+		{"Currency", s.Currency},
+		{"Period", fmt.Sprintf("%s to %s", s.From.Format("2 January 2006"), s.To.Format("2 January 2006"))},
+		{"Issued", s.CreatedAt.UTC().Format("2 January 2006 15:04 MST")},
+		{"Opening balance", e.amount(s.OpeningBalance)},
+		{"Closing balance", e.amount(s.ClosingBalance)},
+		{"Credits", fmt.Sprintf("%d for %s", s.CreditCount, e.amount(s.CreditTotal))},
+		{"Debits", fmt.Sprintf("%d for %s", s.DebitCount, e.amount(s.DebitTotal))},
+	} {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(40, 6, e.tr(line[0]), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, e.tr(line[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	e.tableHeader()
+	e.row(s.From.Format("2006-01-02"), "Opening balance", "", e.amount(s.OpeningBalance), true)
+	return pdf.Error()
+}
+
+func (e *PDFEncoder) Entry(entry Entry) error {
+	e.balance += entry.Amount
+
+	description := entry.Description
+	if entry.CounterpartyOwner != "" {
+		description = fmt.Sprintf("%s - %s", description, entry.CounterpartyOwner)
+	}
+
+	e.row(entry.BookedAt.UTC().Format("2006-01-02"), truncate(description, 55), e.amount(entry.Amount), e.amount(e.balance), false)
+	return e.pdf.Error()
+}
+
+func (e *PDFEncoder) End() error {
+	e.row(e.s.To.Format("2006-01-02"), "Closing balance", "", e.amount(e.s.ClosingBalance), true)
+	return e.pdf.Output(e.w)
+}
+
+// tableHeader writes the header row of the entries table
+func (e *PDFEncoder) tableHeader() {
+	pdf := e.pdf
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	pdf.CellFormat(pdfDateWidth, pdfRowHeight+1, "Date", "B", 0, "L", true, 0, "")
+	pdf.CellFormat(pdfDescriptionWidth, pdfRowHeight+1, "Description", "B", 0, "L", true, 0, "")
+	pdf.CellFormat(pdfAmountWidth, pdfRowHeight+1, "Amount", "B", 0, "R", true, 0, "")
+	pdf.CellFormat(pdfBalanceWidth, pdfRowHeight+1, "Balance", "B", 1, "R", true, 0, "")
+}
+
+// row writes a row of the entries table, bold rows are used for the opening and closing balances
+func (e *PDFEncoder) row(date string, description string, amount string, balance string, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	pdf := e.pdf
+	pdf.SetFont("Helvetica", style, 9)
+	pdf.CellFormat(pdfDateWidth, pdfRowHeight, date, "", 0, "L", false, 0, "")
+	pdf.CellFormat(pdfDescriptionWidth, pdfRowHeight, e.tr(description), "", 0, "L", false, 0, "")
+	pdf.CellFormat(pdfAmountWidth, pdfRowHeight, amount, "", 0, "R", false, 0, "")
+	pdf.CellFormat(pdfBalanceWidth, pdfRowHeight, balance, "", 1, "R", false, 0, "")
+}
+
+// amount formats an amount with the currency code, symbols outside of the PDF core fonts can't be printed
+func (e *PDFEncoder) amount(amount int64) string {
+	return decimal(amount, e.s.Currency) + " " + e.s.Currency
+}
@@ -0,0 +1,107 @@
+// Package statement renders account statements in the file formats understood by accounting software.
+// Encoders write entries one by one as they are read, so that statements of any size use constant memory.
+package statement
+
+import (
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+)
+
+// Statement is everything a statement shows besides its entries. Amounts are in minor units.
+type Statement struct {
+	ID        string
+	AccountID int64
+	Owner     string
+	Currency  string
+	// From and To are the first and last day of the statement, both included
+	From           time.Time
+	To             time.Time
+	CreatedAt      time.Time
+	OpeningBalance int64
+	ClosingBalance int64
+	CreditCount    int64
+	CreditTotal    int64
+	DebitCount     int64
+	DebitTotal     int64
+}
+
+// Entry is a booking on the account, credits are positive and debits are negative.
+// TransferID and the counterparty are only set for transfers between accounts.
+type Entry struct {
+	ID                    int64
+	Amount                int64
+	BookedAt              time.Time
+	Description           string
+	TransferID            int64
+	CounterpartyAccountID int64
+	CounterpartyOwner     string
+}
+
+// Encoder writes a statement, Begin must be called once, then Entry for each entry in booking order and finally End
+type Encoder interface {
+	// Begin writes everything that comes before the entries
+	Begin(s Statement) error
+	// Entry writes a single entry
+	Entry(e Entry) error
+	// End writes everything that comes after the entries and flushes the output
+	End() error
+}
+
+// Format is a statement file format
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	NewEncoder  func(w io.Writer) Encoder
+}
+
+var formats = map[string]Format{
+	"csv": {
+		Name:        "csv",
+		ContentType: "text/csv; charset=utf-8",
+		Extension:   "csv",
+		NewEncoder:  NewCSVEncoder,
+	},
+	"ofx": {
+		Name:        "ofx",
+		ContentType: "application/x-ofx",
+		Extension:   "ofx",
+		NewEncoder:  NewOFXEncoder,
+	},
+	"camt053": {
+		Name:        "camt053",
+		ContentType: "application/xml",
+		Extension:   "xml",
+		NewEncoder:  NewCamt053Encoder,
+	},
+}
+
+// LookupFormat returns the format with the given name
+func LookupFormat(name string) (Format, bool) {
+	format, ok := formats[name]
+	return format, ok
+}
+
+// decimal formats an amount in minor units as a decimal string in major units, e.g. "-10.50"
+func decimal(amount int64, currency string) string {
+	return pkg.NewMoney(amount, currency).Decimal()
+}
+
+// abs returns the absolute value of an amount, the formats that carry a credit or debit indicator need it
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+// truncate shortens s to at most n characters, the formats limit the length of most text fields
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
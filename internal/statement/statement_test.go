@@ -0,0 +1,273 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testStatement() (Statement, []Entry) {
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{
+			ID:          11,
+			Amount:      10000,
+			BookedAt:    from.Add(2 * time.Hour),
+			Description: "Deposit",
+		},
+		{
+			ID:                    12,
+			Amount:                -2550,
+			BookedAt:              from.Add(26 * time.Hour),
+			Description:           "Transfer to account #7",
+			TransferID:            3,
+			CounterpartyAccountID: 7,
+			CounterpartyOwner:     "alice",
+		},
+		{
+			ID:                    13,
+			Amount:                125,
+			BookedAt:              from.Add(50 * time.Hour),
+			Description:           "Transfer from account #7 <&>",
+			TransferID:            4,
+			CounterpartyAccountID: 7,
+			CounterpartyOwner:     "alice",
+		},
+	}
+
+	return Statement{
+		ID:             "42-20240301-20240331",
+		AccountID:      42,
+		Owner:          "bob",
+		Currency:       "EUR",
+		From:           from,
+		To:             time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC),
+		CreatedAt:      time.Date(2024, time.April, 1, 8, 30, 0, 0, time.UTC),
+		OpeningBalance: 500,
+		ClosingBalance: 500 + 10000 - 2550 + 125,
+		CreditCount:    2,
+		CreditTotal:    10125,
+		DebitCount:     1,
+		DebitTotal:     2550,
+	}, entries
+}
+
+func encode(t *testing.T, format string) []byte {
+	f, ok := LookupFormat(format)
+	require.True(t, ok)
+
+	s, entries := testStatement()
+	var buf bytes.Buffer
+	enc := f.NewEncoder(&buf)
+	require.NoError(t, enc.Begin(s))
+	for _, entry := range entries {
+		require.NoError(t, enc.Entry(entry))
+	}
+	require.NoError(t, enc.End())
+	return buf.Bytes()
+}
+
+func TestLookupFormat(t *testing.T) {
+	for _, name := range []string{"csv", "ofx", "camt053"} {
+		format, ok := LookupFormat(name)
+		require.True(t, ok)
+		require.Equal(t, name, format.Name)
+		require.NotEmpty(t, format.ContentType)
+		require.NotEmpty(t, format.Extension)
+	}
+
+	_, ok := LookupFormat("pdf")
+	require.False(t, ok)
+}
+
+func TestCSVEncoder(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(encode(t, "csv"))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	require.Equal(t, csvHeader, records[0])
+
+	require.Equal(t, []string{"2024-03-01T02:00:00Z", "11", "Deposit", "", "", "", "100.00", "EUR", "105.00"}, records[1])
+	require.Equal(t, []string{"2024-03-02T02:00:00Z", "12", "Transfer to account #7", "3", "7", "alice", "-25.50", "EUR", "79.50"}, records[2])
+	// the last running balance is the closing balance
+	require.Equal(t, "80.75", records[3][8])
+}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	Stmt    struct {
+		Currency string `xml:"CURDEF"`
+		Account  struct {
+			ID string `xml:"ACCTID"`
+		} `xml:"BANKACCTFROM"`
+		TranList struct {
+			Start        string `xml:"DTSTART"`
+			End          string `xml:"DTEND"`
+			Transactions []struct {
+				Type   string `xml:"TRNTYPE"`
+				Posted string `xml:"DTPOSTED"`
+				Amount string `xml:"TRNAMT"`
+				FITID  string `xml:"FITID"`
+				Name   string `xml:"NAME"`
+				Memo   string `xml:"MEMO"`
+			} `xml:"STMTTRN"`
+		} `xml:"BANKTRANLIST"`
+		LedgerBalance struct {
+			Amount string `xml:"BALAMT"`
+		} `xml:"LEDGERBAL"`
+	} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+}
+
+func TestOFXEncoder(t *testing.T) {
+	out := encode(t, "ofx")
+	require.True(t, bytes.Contains(out, []byte(`<?OFX OFXHEADER="200" VERSION="220"`)))
+
+	var doc ofxDocument
+	require.NoError(t, xml.Unmarshal(out, &doc))
+	require.Equal(t, "EUR", doc.Stmt.Currency)
+	require.Equal(t, "42", doc.Stmt.Account.ID)
+	require.Equal(t, "20240301000000.000[0:GMT]", doc.Stmt.TranList.Start)
+	require.Equal(t, "20240401000000.000[0:GMT]", doc.Stmt.TranList.End)
+	require.Equal(t, "80.75", doc.Stmt.LedgerBalance.Amount)
+
+	transactions := doc.Stmt.TranList.Transactions
+	require.Len(t, transactions, 3)
+	require.Equal(t, "CREDIT", transactions[0].Type)
+	require.Equal(t, "100.00", transactions[0].Amount)
+	require.Empty(t, transactions[0].Name)
+	require.Equal(t, "XFER", transactions[1].Type)
+	require.Equal(t, "-25.50", transactions[1].Amount)
+	require.Equal(t, "12", transactions[1].FITID)
+	require.Equal(t, "alice", transactions[1].Name)
+	require.Equal(t, "Transfer from account #7 <&>", transactions[2].Memo)
+}
+
+// validateCamt053 validates out against the camt.053.001.02 schema in testdata with xmllint. The subtest is
+// skipped when xmllint is not installed, except on CI where a missing xmllint fails it.
+func validateCamt053(t *testing.T, out []byte) {
+	t.Run("schema", func(t *testing.T) {
+		xmllint, err := exec.LookPath("xmllint")
+		if err != nil {
+			if os.Getenv("CI") != "" {
+				t.Fatal("xmllint is required on CI to validate camt.053 statements")
+			}
+			t.Skip("xmllint is not installed")
+		}
+
+		file := filepath.Join(t.TempDir(), "statement.xml")
+		require.NoError(t, os.WriteFile(file, out, 0o600))
+
+		cmd := exec.Command(xmllint, "--noout", "--schema", filepath.Join("testdata", "camt.053.001.02.xsd"), file)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	})
+}
+
+type camtDocument struct {
+	Stmt struct {
+		Bal []struct {
+			Cd        string `xml:"Tp>CdOrPrtry>Cd"`
+			Amt       string `xml:"Amt"`
+			CdtDbtInd string `xml:"CdtDbtInd"`
+			Dt        string `xml:"Dt>Dt"`
+		} `xml:"Bal"`
+		Summary struct {
+			Count string `xml:"TtlNtries>NbOfNtries"`
+			Net   string `xml:"TtlNtries>TtlNetNtryAmt"`
+		} `xml:"TxsSummry"`
+		Ntry []struct {
+			Amt       string `xml:"Amt"`
+			CdtDbtInd string `xml:"CdtDbtInd"`
+			Family    string `xml:"BkTxCd>Domn>Fmly>Cd"`
+			Creditor  string `xml:"NtryDtls>TxDtls>RltdPties>Cdtr>Nm"`
+			Debtor    string `xml:"NtryDtls>TxDtls>RltdPties>Dbtr>Nm"`
+			Info      string `xml:"AddtlNtryInf"`
+		} `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+func TestCamt053Encoder(t *testing.T) {
+	out := encode(t, "camt053")
+	validateCamt053(t, out)
+
+	var doc camtDocument
+	require.NoError(t, xml.Unmarshal(out, &doc))
+
+	require.Len(t, doc.Stmt.Bal, 2)
+	require.Equal(t, "OPBD", doc.Stmt.Bal[0].Cd)
+	require.Equal(t, "5.00", doc.Stmt.Bal[0].Amt)
+	require.Equal(t, "2024-03-01", doc.Stmt.Bal[0].Dt)
+	require.Equal(t, "CLBD", doc.Stmt.Bal[1].Cd)
+	require.Equal(t, "80.75", doc.Stmt.Bal[1].Amt)
+	require.Equal(t, "2024-03-31", doc.Stmt.Bal[1].Dt)
+	require.Equal(t, "3", doc.Stmt.Summary.Count)
+	require.Equal(t, "75.75", doc.Stmt.Summary.Net)
+
+	require.Len(t, doc.Stmt.Ntry, 3)
+	require.Equal(t, "MCOP", doc.Stmt.Ntry[0].Family)
+	require.Empty(t, doc.Stmt.Ntry[0].Creditor)
+	require.Equal(t, "25.50", doc.Stmt.Ntry[1].Amt)
+	require.Equal(t, "DBIT", doc.Stmt.Ntry[1].CdtDbtInd)
+	require.Equal(t, "ICDT", doc.Stmt.Ntry[1].Family)
+	require.Equal(t, "alice", doc.Stmt.Ntry[1].Creditor)
+	require.Equal(t, "CRDT", doc.Stmt.Ntry[2].CdtDbtInd)
+	require.Equal(t, "RCDT", doc.Stmt.Ntry[2].Family)
+	require.Equal(t, "alice", doc.Stmt.Ntry[2].Debtor)
+	require.Equal(t, "Transfer from account #7 <&>", doc.Stmt.Ntry[2].Info)
+}
+
+func TestCamt053EncoderNegativeBalance(t *testing.T) {
+	s, _ := testStatement()
+	s.OpeningBalance = -150
+	s.ClosingBalance = -150
+	s.CreditCount, s.CreditTotal, s.DebitCount, s.DebitTotal = 0, 0, 0, 0
+	s.Owner = ""
+
+	var buf bytes.Buffer
+	enc := NewCamt053Encoder(&buf)
+	require.NoError(t, enc.Begin(s))
+	require.NoError(t, enc.End())
+	validateCamt053(t, buf.Bytes())
+
+	var doc camtDocument
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, "1.50", doc.Stmt.Bal[0].Amt)
+	require.Equal(t, "DBIT", doc.Stmt.Bal[0].CdtDbtInd)
+	require.Empty(t, doc.Stmt.Ntry)
+}
+
+func TestPDFEncoder(t *testing.T) {
+	s, entries := testStatement()
+
+	var buf bytes.Buffer
+	enc := NewPDFEncoder(&buf, "My Bank")
+	// uncompressed content streams keep the text searchable
+	enc.(*PDFEncoder).pdf.SetCompression(false)
+
+	require.NoError(t, enc.Begin(s))
+	// enough entries to need a second page
+	for i := 0; i < 20; i++ {
+		for _, entry := range entries {
+			require.NoError(t, enc.Entry(entry))
+		}
+	}
+	require.NoError(t, enc.End())
+
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "%PDF-"))
+	require.True(t, strings.HasSuffix(strings.TrimSpace(out), "%%EOF"))
+	require.Contains(t, out, "(My Bank)")
+	require.Contains(t, out, "(Statement of account #42)")
+	require.Contains(t, out, "(Opening balance)")
+	require.Contains(t, out, "(5.00 EUR)")
+	require.Contains(t, out, "(Transfer to account #7 - alice)")
+	require.Contains(t, out, "(Closing balance)")
+	require.Contains(t, out, "(Page 2/2)")
+}
@@ -0,0 +1,172 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// es256KeyFileExt is the extension of the ES256 key files, the file name without it is the key id
+const es256KeyFileExt = ".pem"
+
+// ES256Maker is a JSON Web Token maker that signs with an ECDSA P-256 private key. Every token carries the id of
+// the key that signed it in its kid header, so that tokens signed by a retired key are still accepted for as long as
+// its public key is kept, and other services can verify the tokens with the public keys served as a JWKS.
+type ES256Maker struct {
+	keys keyRing[*ecdsa.PrivateKey, *ecdsa.PublicKey]
+}
+
+// NewES256Maker creates a new ES256Maker with the keys of keyDir. Each <kid>.pem file holds either a private key
+// (PKCS #8 or SEC 1), which can sign and verify, or a public key (PKIX), which can only verify.
+// Tokens are signed with the private key signingKeyID, which can be left empty when keyDir holds a single private key.
+// A keyDir without private keys makes a maker that only verifies.
+func NewES256Maker(keyDir string, signingKeyID string) (*ES256Maker, error) {
+	keys, err := loadKeyRing(keyDir, es256KeyFileExt, signingKeyID, parseES256Key)
+	if err != nil {
+		return nil, err
+	}
+	return &ES256Maker{keys}, nil
+}
+
+// parseES256Key parses a PEM encoded P-256 key, which can only verify when data holds a public key
+func parseES256Key(data []byte) (*ecdsa.PrivateKey, *ecdsa.PublicKey, bool, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, false, errors.New("no PEM block")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, nil, false, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var privateKey *ecdsa.PrivateKey
+	var publicKey *ecdsa.PublicKey
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		privateKey = key
+		publicKey = &key.PublicKey
+	case *ecdsa.PublicKey:
+		publicKey = key
+	default:
+		return nil, nil, false, fmt.Errorf("unsupported key type %T, must be an ECDSA key", key)
+	}
+
+	if publicKey.Curve != elliptic.P256() {
+		return nil, nil, false, fmt.Errorf("unsupported curve %s, must be P-256", publicKey.Curve.Params().Name)
+	}
+
+	return privateKey, publicKey, privateKey != nil, nil
+}
+
+// CreateToken creates a new token for a specific username and duration
+func (maker *ES256Maker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	if !maker.keys.canSign {
+		return "", nil, ErrNoSigningKey
+	}
+
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodES256, payload)
+	jwtToken.Header["kid"] = maker.keys.signingKeyID
+	token, err := jwtToken.SignedString(maker.keys.signingKey)
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *ES256Maker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodES256 {
+			return nil, ErrInvalidToken
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		publicKey, ok := maker.keys.verificationKeys[kid]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return publicKey, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		var verr *jwt.ValidationError
+		ok := errors.As(err, &verr)
+		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
+
+// JSONWebKey is the public part of a verification key as described in RFC 7517
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JSONWebKeySet is a set of JSON Web Keys as described in RFC 7517
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys the maker verifies tokens with, sorted by key id
+func (maker *ES256Maker) JWKS() JSONWebKeySet {
+	keySet := JSONWebKeySet{
+		Keys: make([]JSONWebKey, 0, len(maker.keys.verificationKeys)),
+	}
+
+	for kid, publicKey := range maker.keys.verificationKeys {
+		// the coordinates are padded to the size of the curve as required by RFC 7518
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		keySet.Keys = append(keySet.Keys, JSONWebKey{
+			KeyType:   "EC",
+			Curve:     publicKey.Curve.Params().Name,
+			X:         base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, size))),
+			Y:         base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, size))),
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodES256.Alg(),
+		})
+	}
+
+	sort.Slice(keySet.Keys, func(i, j int) bool {
+		return keySet.Keys[i].KeyID < keySet.Keys[j].KeyID
+	})
+
+	return keySet
+}
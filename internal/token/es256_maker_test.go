@@ -0,0 +1,273 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+// writeES256Key generates a P-256 key and writes it to keyDir as <kid>.pem, only the public key if public is true
+func writeES256Key(t *testing.T, keyDir string, kid string, public bool) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	block := &pem.Block{Type: "PRIVATE KEY"}
+	if public {
+		block.Type = "PUBLIC KEY"
+		block.Bytes, err = x509.MarshalPKIXPublicKey(&key.PublicKey)
+	} else {
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(keyDir, kid+es256KeyFileExt), pem.EncodeToMemory(block), 0o600)
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestES256Maker(t *testing.T) {
+	keyDir := t.TempDir()
+	writeES256Key(t, keyDir, "key-1", false)
+
+	maker, err := NewES256Maker(keyDir, "")
+	require.NoError(t, err)
+
+	username := pkg.RandomOwner()
+	role := pkg.DepositorRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, &Payload{})
+	require.NoError(t, err)
+	require.Equal(t, "ES256", parsed.Header["alg"])
+	require.Equal(t, "key-1", parsed.Header["kid"])
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestExpiredES256Token(t *testing.T) {
+	keyDir := t.TempDir()
+	writeES256Key(t, keyDir, "key-1", false)
+
+	maker, err := NewES256Maker(keyDir, "")
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestES256MakerKeyRotation(t *testing.T) {
+	oldKeyDir := t.TempDir()
+	oldKey := writeES256Key(t, oldKeyDir, "key-1", false)
+
+	oldMaker, err := NewES256Maker(oldKeyDir, "")
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// the new key signs, the old one is kept only to verify the tokens it signed until they expire
+	keyDir := t.TempDir()
+	writeES256Key(t, keyDir, "key-2", false)
+	block := &pem.Block{Type: "PUBLIC KEY"}
+	block.Bytes, err = x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(keyDir, "key-1"+es256KeyFileExt), pem.EncodeToMemory(block), 0o600))
+
+	maker, err := NewES256Maker(keyDir, "key-2")
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	newToken, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(newToken, &Payload{})
+	require.NoError(t, err)
+	require.Equal(t, "key-2", parsed.Header["kid"])
+
+	// the old maker does not know the new key
+	_, err = oldMaker.VerifyToken(newToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestNewES256MakerSigningKey(t *testing.T) {
+	keyDir := t.TempDir()
+	writeES256Key(t, keyDir, "key-1", false)
+	writeES256Key(t, keyDir, "key-2", false)
+	writeES256Key(t, keyDir, "key-3", true)
+
+	// the signing key must be chosen when there are several private keys
+	_, err := NewES256Maker(keyDir, "")
+	require.Error(t, err)
+
+	// a public key cannot sign
+	_, err = NewES256Maker(keyDir, "key-3")
+	require.Error(t, err)
+
+	_, err = NewES256Maker(keyDir, "key-4")
+	require.Error(t, err)
+
+	_, err = NewES256Maker(t.TempDir(), "")
+	require.Error(t, err)
+
+	maker, err := NewES256Maker(keyDir, "key-1")
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// a maker with only public keys verifies but cannot create tokens
+	publicKeyDir := t.TempDir()
+	writeES256Key(t, publicKeyDir, "key-3", true)
+	verifier, err := NewES256Maker(publicKeyDir, "")
+	require.NoError(t, err)
+
+	_, _, err = verifier.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.ErrorIs(t, err, ErrNoSigningKey)
+
+	_, err = verifier.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestNewES256MakerInvalidKey(t *testing.T) {
+	keyDir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(keyDir, "key-1"+es256KeyFileExt), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0o600)
+	require.NoError(t, err)
+
+	_, err = NewES256Maker(keyDir, "")
+	require.ErrorContains(t, err, "P-256")
+
+	err = os.WriteFile(filepath.Join(keyDir, "key-1"+es256KeyFileExt), []byte("not a key"), 0o600)
+	require.NoError(t, err)
+
+	_, err = NewES256Maker(keyDir, "")
+	require.Error(t, err)
+}
+
+func TestInvalidES256Token(t *testing.T) {
+	keyDir := t.TempDir()
+	key := writeES256Key(t, keyDir, "key-1", false)
+
+	maker, err := NewES256Maker(keyDir, "")
+	require.NoError(t, err)
+
+	payload, err := NewPayload(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// alg none
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
+	jwtToken.Header["kid"] = "key-1"
+	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// HMAC signed with the public key
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	jwtToken = jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	jwtToken.Header["kid"] = "key-1"
+	token, err = jwtToken.SignedString(publicKeyBytes)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// unknown key id
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwtToken = jwt.NewWithClaims(jwt.SigningMethodES256, payload)
+	jwtToken.Header["kid"] = "key-2"
+	token, err = jwtToken.SignedString(otherKey)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// known key id, wrong key
+	jwtToken.Header["kid"] = "key-1"
+	token, err = jwtToken.SignedString(otherKey)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestES256MakerJWKS(t *testing.T) {
+	keyDir := t.TempDir()
+	key := writeES256Key(t, keyDir, "key-2", false)
+	writeES256Key(t, keyDir, "key-1", true)
+
+	maker, err := NewES256Maker(keyDir, "key-2")
+	require.NoError(t, err)
+
+	keySet := maker.JWKS()
+	require.Len(t, keySet.Keys, 2)
+	require.Equal(t, "key-1", keySet.Keys[0].KeyID)
+	require.Equal(t, "key-2", keySet.Keys[1].KeyID)
+
+	jwk := keySet.Keys[1]
+	require.Equal(t, "EC", jwk.KeyType)
+	require.Equal(t, "P-256", jwk.Curve)
+	require.Equal(t, "sig", jwk.Use)
+	require.Equal(t, "ES256", jwk.Algorithm)
+
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	require.NoError(t, err)
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	require.NoError(t, err)
+	require.Len(t, x, 32)
+	require.Len(t, y, 32)
+	require.Equal(t, key.PublicKey.X.FillBytes(make([]byte, 32)), x)
+	require.Equal(t, key.PublicKey.Y.FillBytes(make([]byte, 32)), y)
+}
+
+func TestJWTMakerRejectsES256Token(t *testing.T) {
+	keyDir := t.TempDir()
+	writeES256Key(t, keyDir, "key-1", false)
+
+	maker, err := NewES256Maker(keyDir, "")
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	jwtMaker, err := NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+
+	_, err = jwtMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
@@ -0,0 +1,91 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoSigningKey is returned by CreateToken when the maker only holds verification keys
+var ErrNoSigningKey = errors.New("no signing key")
+
+// VerifyOnly is the signing key id of a maker that only verifies tokens, such as the maker of a previous token format
+const VerifyOnly = "-"
+
+// keyRing holds the keys a maker loaded from a key directory, where each key is a <kid><ext> file
+type keyRing[S any, V any] struct {
+	signingKeyID string
+	signingKey   S
+	canSign      bool
+	// verificationKeys are the keys that verify tokens by key id, every loaded key is one of them
+	verificationKeys map[string]V
+}
+
+// parseKeyFunc parses the content of a key file, canSign is false when it only holds a verification key
+type parseKeyFunc[S any, V any] func(data []byte) (signingKey S, verificationKey V, canSign bool, err error)
+
+// loadKeyRing loads the <kid><ext> files of keyDir. New tokens are signed with the key signingKeyID, which can be
+// left empty when keyDir holds a single key that can sign. A keyDir without keys that can sign, or a signingKeyID of
+// VerifyOnly, makes a key ring that only verifies.
+func loadKeyRing[S any, V any](keyDir string, ext string, signingKeyID string, parse parseKeyFunc[S, V]) (keyRing[S, V], error) {
+	ring := keyRing[S, V]{}
+
+	files, err := filepath.Glob(filepath.Join(keyDir, "*"+ext))
+	if err != nil {
+		return ring, err
+	}
+	if len(files) == 0 {
+		return ring, fmt.Errorf("no %s key files in %s", ext, keyDir)
+	}
+
+	ring.verificationKeys = make(map[string]V, len(files))
+	signingKeys := make(map[string]S)
+
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ext)
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return ring, fmt.Errorf("cannot read key %s: %w", kid, err)
+		}
+
+		signingKey, verificationKey, canSign, err := parse(data)
+		if err != nil {
+			return ring, fmt.Errorf("cannot parse key %s: %w", kid, err)
+		}
+
+		if canSign {
+			signingKeys[kid] = signingKey
+		}
+		ring.verificationKeys[kid] = verificationKey
+	}
+
+	if signingKeyID == VerifyOnly {
+		return ring, nil
+	}
+
+	if signingKeyID == "" {
+		switch len(signingKeys) {
+		case 0:
+			return ring, nil
+		case 1:
+			for kid := range signingKeys {
+				signingKeyID = kid
+			}
+		default:
+			return ring, fmt.Errorf("%d signing keys in %s, the signing key id must be set", len(signingKeys), keyDir)
+		}
+	}
+
+	signingKey, ok := signingKeys[signingKeyID]
+	if !ok {
+		return ring, fmt.Errorf("no signing key %s in %s", signingKeyID, keyDir)
+	}
+	ring.signingKeyID = signingKeyID
+	ring.signingKey = signingKey
+	ring.canSign = true
+
+	return ring, nil
+}
@@ -0,0 +1,79 @@
+package token
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// loginChallengeKeyLabel separates the login challenge signing key from the other keys derived from the signer secret
+const loginChallengeKeyLabel = "mybank login challenge v1"
+
+// LoginChallengePayload is the content of a login challenge, handed out when the password of a user with
+// two-factor authentication on was correct
+type LoginChallengePayload struct {
+	// ID tells the challenge apart from the other challenges of the user, the codes tried with it are counted by it
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// Valid checks if the login challenge has expired
+func (payload *LoginChallengePayload) Valid() error {
+	if time.Now().After(payload.ExpiredAt) {
+		return ErrExpiredToken
+	}
+	return nil
+}
+
+// LoginChallengeSigner creates and verifies login challenges with a key derived from the server's signer secret
+type LoginChallengeSigner struct {
+	signer hmacSigner
+}
+
+// NewLoginChallengeSigner creates a new LoginChallengeSigner, the login challenges signed with one of
+// previousSecretKeys are still accepted but no new ones are signed with them
+func NewLoginChallengeSigner(secretKey string, previousSecretKeys ...string) (*LoginChallengeSigner, error) {
+	signer, err := newHMACSigner(secretKey, loginChallengeKeyLabel, previousSecretKeys...)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginChallengeSigner{signer: signer}, nil
+}
+
+// Create returns a login challenge for username that is valid for duration
+func (signer *LoginChallengeSigner) Create(username string, duration time.Duration) (string, *LoginChallengePayload, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	payload := &LoginChallengePayload{
+		ID:        id,
+		Username:  username,
+		IssuedAt:  now,
+		ExpiredAt: now.Add(duration),
+	}
+
+	challenge, err := signer.signer.sign(payload)
+	if err != nil {
+		return "", nil, err
+	}
+	return challenge, payload, nil
+}
+
+// Verify checks the signature and expiry of a login challenge and returns its payload
+func (signer *LoginChallengeSigner) Verify(challenge string) (*LoginChallengePayload, error) {
+	payload := &LoginChallengePayload{}
+	if err := signer.signer.verify(challenge, payload); err != nil {
+		return nil, err
+	}
+
+	if err := payload.Valid(); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
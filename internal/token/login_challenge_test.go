@@ -0,0 +1,48 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginChallengeSigner(t *testing.T) {
+	secretKey := pkg.RandomString(32)
+	signer, err := NewLoginChallengeSigner(secretKey)
+	require.NoError(t, err)
+
+	username := pkg.RandomOwner()
+	challenge, payload, err := signer.Create(username, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, challenge)
+
+	verified, err := signer.Verify(challenge)
+	require.NoError(t, err)
+	require.Equal(t, username, verified.Username)
+	require.NotEqual(t, uuid.Nil, verified.ID)
+	require.Equal(t, payload.ID, verified.ID)
+	require.WithinDuration(t, payload.ExpiredAt, verified.ExpiredAt, time.Second)
+
+	// signed with the same secret, but for another purpose
+	linkSigner, err := NewPaymentLinkSigner(secretKey)
+	require.NoError(t, err)
+	link, err := linkSigner.Sign(PaymentLinkPayload{ExpiredAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	_, err = signer.Verify(link)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestExpiredLoginChallenge(t *testing.T) {
+	signer, err := NewLoginChallengeSigner(pkg.RandomString(32))
+	require.NoError(t, err)
+
+	challenge, _, err := signer.Create(pkg.RandomOwner(), -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := signer.Verify(challenge)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
+}
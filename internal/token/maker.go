@@ -4,6 +4,30 @@ import (
 	"time"
 )
 
+// Token formats that can be selected through config
+const (
+	// FormatJWT is FormatJWTES256 when there is a token key dir and FormatJWTHS256 otherwise
+	FormatJWT = "jwt"
+	// FormatJWTHS256 are JSON Web Tokens signed with HS256 and the token secret
+	FormatJWTHS256 = "jwt-hs256"
+	// FormatJWTES256 are JSON Web Tokens signed with ES256 and the keys of the token key dir
+	FormatJWTES256       = "jwt-es256"
+	FormatPasetoV2Local  = "paseto.v2.local"
+	FormatPasetoV4Local  = "paseto.v4.local"
+	FormatPasetoV4Public = "paseto.v4.public"
+)
+
+// ResolveFormat returns the format FormatJWT stands for given the token key dir, other formats are returned as is
+func ResolveFormat(format string, tokenKeyDir string) string {
+	if format != FormatJWT {
+		return format
+	}
+	if tokenKeyDir == "" {
+		return FormatJWTHS256
+	}
+	return FormatJWTES256
+}
+
 // Maker is an interface for managing tokens
 type Maker interface {
 	// CreateToken creates a new token for a specific username, role and duration
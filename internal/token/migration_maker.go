@@ -0,0 +1,45 @@
+package token
+
+import (
+	"errors"
+	"time"
+)
+
+// MigrationMaker issues tokens with one maker and also accepts the tokens of others, so that the token format can be
+// switched without logging everyone out: the tokens issued before the switch keep working until they expire
+type MigrationMaker struct {
+	issuer   Maker
+	accepted []Maker
+}
+
+// NewMigrationMaker creates a new MigrationMaker that creates tokens with issuer and verifies them with issuer and then
+// with each of accepted
+func NewMigrationMaker(issuer Maker, accepted ...Maker) Maker {
+	return &MigrationMaker{
+		issuer:   issuer,
+		accepted: accepted,
+	}
+}
+
+// CreateToken creates a new token for a specific username and duration
+func (maker *MigrationMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	return maker.issuer.CreateToken(username, role, duration)
+}
+
+// VerifyToken checks if the token is valid or not. Makers reject the tokens of other formats as invalid, so any other
+// error, such as an expired token, comes from the maker of the token's format and is returned as is.
+func (maker *MigrationMaker) VerifyToken(token string) (*Payload, error) {
+	payload, err := maker.issuer.VerifyToken(token)
+	if !errors.Is(err, ErrInvalidToken) {
+		return payload, err
+	}
+
+	for _, accepted := range maker.accepted {
+		payload, err = accepted.VerifyToken(token)
+		if !errors.Is(err, ErrInvalidToken) {
+			return payload, err
+		}
+	}
+
+	return nil, ErrInvalidToken
+}
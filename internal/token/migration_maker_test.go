@@ -0,0 +1,123 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationMaker(t *testing.T) {
+	jwtMaker, err := NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+
+	keyDir := t.TempDir()
+	writePasetoV4PublicKey(t, keyDir, "key-1", false)
+	pasetoMaker, err := NewPasetoV4PublicMaker(keyDir, "")
+	require.NoError(t, err)
+
+	maker := NewMigrationMaker(pasetoMaker, jwtMaker)
+
+	// only the new format is issued
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, "v4.public."))
+
+	_, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+
+	// tokens of the previous format are still accepted
+	username := pkg.RandomOwner()
+	oldToken, _, err := jwtMaker.CreateToken(username, pkg.BankerRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, pkg.BankerRole, payload.Role)
+
+	expiredToken, _, err := jwtMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(expiredToken)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+
+	otherJWTMaker, err := NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	otherToken, _, err := otherJWTMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(otherToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestMigrationMakerHS256ToES256(t *testing.T) {
+	secret := pkg.RandomString(32)
+	hs256Maker, err := NewJWTMaker(secret)
+	require.NoError(t, err)
+
+	keyDir := t.TempDir()
+	writeES256Key(t, keyDir, "key-1", false)
+	es256Maker, err := NewES256Maker(keyDir, "")
+	require.NoError(t, err)
+
+	maker := NewMigrationMaker(es256Maker, hs256Maker)
+
+	// new tokens are signed with ES256
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	_, err = hs256Maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	_, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+
+	// tokens signed with the secret before the switch are still accepted
+	username := pkg.RandomOwner()
+	oldToken, _, err := hs256Maker.CreateToken(username, pkg.BankerRole, time.Minute)
+	require.NoError(t, err)
+	payload, err := maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+
+	expiredToken, _, err := hs256Maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(expiredToken)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+
+	otherMaker, err := NewJWTMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+	otherToken, _, err := otherMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(otherToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestResolveFormat(t *testing.T) {
+	require.Equal(t, FormatJWTHS256, ResolveFormat(FormatJWT, ""))
+	require.Equal(t, FormatJWTES256, ResolveFormat(FormatJWT, "keys"))
+	require.Equal(t, FormatJWTHS256, ResolveFormat(FormatJWTHS256, "keys"))
+	require.Equal(t, FormatPasetoV4Local, ResolveFormat(FormatPasetoV4Local, "keys"))
+}
+
+func TestVerifyOnlyMaker(t *testing.T) {
+	keyDir := t.TempDir()
+	writePasetoV4LocalKey(t, keyDir, "key-1")
+	writePasetoV4LocalKey(t, keyDir, "key-2")
+
+	maker, err := NewPasetoV4LocalMaker(keyDir, "key-1")
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	verifier, err := NewPasetoV4LocalMaker(keyDir, VerifyOnly)
+	require.NoError(t, err)
+
+	_, err = verifier.VerifyToken(token)
+	require.NoError(t, err)
+
+	_, _, err = verifier.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.ErrorIs(t, err, ErrNoSigningKey)
+}
@@ -0,0 +1,383 @@
+package token
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+// The PASETO v4 makers follow https://github.com/paseto-standard/paseto-spec/blob/master/docs/01-Protocol-Versions/Version4.md.
+// Every token names the key that made it in a {"kid":"..."} JSON footer, which is authenticated along with the payload,
+// so that tokens made with a retired key are still accepted for as long as the key is kept.
+const (
+	pasetoV4LocalHeader  = "v4.local."
+	pasetoV4PublicHeader = "v4.public."
+
+	// pasetoV4LocalKeyFileExt is the extension of the v4.local key files, which hold a hex encoded 32 byte key
+	pasetoV4LocalKeyFileExt = ".key"
+	// pasetoV4PublicKeyFileExt is the extension of the v4.public key files, which hold a PEM encoded Ed25519 key
+	pasetoV4PublicKeyFileExt = ".pem"
+
+	pasetoV4NonceSize = 32
+	pasetoV4MACSize   = 32
+)
+
+// pasetoFooter is the footer of the PASETO v4 tokens
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// pae is the pre-authentication encoding of PASETO, which packs pieces so that they cannot be confused with others
+func pae(pieces ...[]byte) []byte {
+	var buf bytes.Buffer
+	// the most significant bit of every length is cleared for interoperability with languages without unsigned ints
+	_ = binary.Write(&buf, binary.LittleEndian, uint64(len(pieces))&^(1<<63))
+	for _, piece := range pieces {
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(piece))&^(1<<63))
+		buf.Write(piece)
+	}
+	return buf.Bytes()
+}
+
+// encodePasetoFooter encodes the footer naming the key kid
+func encodePasetoFooter(kid string) ([]byte, error) {
+	return json.Marshal(pasetoFooter{KeyID: kid})
+}
+
+// parsePasetoToken checks the header of token and splits it into its decoded body and footer, which is empty if the
+// token has none
+func parsePasetoToken(token string, header string) (body []byte, footer []byte, err error) {
+	if !strings.HasPrefix(token, header) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, header), ".")
+	if len(parts) > 2 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	body, err = base64.RawURLEncoding.Strict().DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if len(parts) == 2 {
+		footer, err = base64.RawURLEncoding.Strict().DecodeString(parts[1])
+		if err != nil {
+			return nil, nil, ErrInvalidToken
+		}
+	}
+
+	return body, footer, nil
+}
+
+// splitPasetoToken parses token like parsePasetoToken and returns the key id of its footer, which our tokens must
+// have. Nothing is authenticated yet, the footer is only trusted to pick the key the token is checked with.
+func splitPasetoToken(token string, header string) (body []byte, footer []byte, kid string, err error) {
+	body, footer, err = parsePasetoToken(token, header)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var decodedFooter pasetoFooter
+	if err = json.Unmarshal(footer, &decodedFooter); err != nil || decodedFooter.KeyID == "" {
+		return nil, nil, "", ErrInvalidToken
+	}
+
+	return body, footer, decodedFooter.KeyID, nil
+}
+
+// joinPasetoToken encodes the parts of a token, the footer is left out when empty
+func joinPasetoToken(header string, body []byte, footer []byte) string {
+	token := header + base64.RawURLEncoding.EncodeToString(body)
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+// decodePasetoPayload decodes the payload of a token that was authenticated and checks that it has not expired
+func decodePasetoPayload(message []byte) (*Payload, error) {
+	payload := &Payload{}
+	if err := json.Unmarshal(message, payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := payload.Valid(); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// PasetoV4LocalMaker is a PASETO v4.local token maker, the tokens are encrypted and authenticated with a symmetric
+// key so that their payload cannot be read by clients
+type PasetoV4LocalMaker struct {
+	keys keyRing[[]byte, []byte]
+}
+
+// NewPasetoV4LocalMaker creates a new PasetoV4LocalMaker with the keys of keyDir. Each <kid>.key file holds a hex
+// encoded 32 byte key. Tokens are made with the key signingKeyID, which can be left empty when keyDir holds a single key.
+func NewPasetoV4LocalMaker(keyDir string, signingKeyID string) (*PasetoV4LocalMaker, error) {
+	keys, err := loadKeyRing(keyDir, pasetoV4LocalKeyFileExt, signingKeyID, parsePasetoV4LocalKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PasetoV4LocalMaker{keys}, nil
+}
+
+// parsePasetoV4LocalKey parses a hex encoded 32 byte key
+func parsePasetoV4LocalKey(data []byte) ([]byte, []byte, bool, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if len(key) != chacha20.KeySize {
+		return nil, nil, false, fmt.Errorf("invalid key size: must be exactly %d bytes", chacha20.KeySize)
+	}
+	return key, key, true, nil
+}
+
+// pasetoV4LocalKeys derives the encryption key, the XChaCha20 nonce and the authentication key of a token from the
+// key and the token nonce
+func pasetoV4LocalKeys(key []byte, nonce []byte) (encryptionKey []byte, counterNonce []byte, authKey []byte) {
+	hash, _ := blake2b.New(chacha20.KeySize+chacha20.NonceSizeX, key)
+	hash.Write([]byte("paseto-encryption-key"))
+	hash.Write(nonce)
+	tmp := hash.Sum(nil)
+
+	hash, _ = blake2b.New(pasetoV4MACSize, key)
+	hash.Write([]byte("paseto-auth-key-for-aead"))
+	hash.Write(nonce)
+
+	return tmp[:chacha20.KeySize], tmp[chacha20.KeySize:], hash.Sum(nil)
+}
+
+// pasetoV4LocalMAC authenticates the header, nonce, ciphertext, footer and implicit assertion of a token
+func pasetoV4LocalMAC(authKey []byte, nonce []byte, ciphertext []byte, footer []byte, implicit []byte) []byte {
+	hash, _ := blake2b.New(pasetoV4MACSize, authKey)
+	hash.Write(pae([]byte(pasetoV4LocalHeader), nonce, ciphertext, footer, implicit))
+	return hash.Sum(nil)
+}
+
+// pasetoV4LocalEncrypt encrypts message into a v4.local token with the given nonce, which must be random
+func pasetoV4LocalEncrypt(key []byte, nonce []byte, message []byte, footer []byte, implicit []byte) (string, error) {
+	encryptionKey, counterNonce, authKey := pasetoV4LocalKeys(key, nonce)
+	cipher, err := chacha20.NewUnauthenticatedCipher(encryptionKey, counterNonce)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, len(message))
+	cipher.XORKeyStream(ciphertext, message)
+
+	mac := pasetoV4LocalMAC(authKey, nonce, ciphertext, footer, implicit)
+
+	body := make([]byte, 0, len(nonce)+len(ciphertext)+len(mac))
+	body = append(append(append(body, nonce...), ciphertext...), mac...)
+	return joinPasetoToken(pasetoV4LocalHeader, body, footer), nil
+}
+
+// pasetoV4LocalDecrypt authenticates the body and footer of a v4.local token and decrypts its message
+func pasetoV4LocalDecrypt(key []byte, body []byte, footer []byte, implicit []byte) ([]byte, error) {
+	if len(body) < pasetoV4NonceSize+pasetoV4MACSize {
+		return nil, ErrInvalidToken
+	}
+
+	nonce := body[:pasetoV4NonceSize]
+	ciphertext := body[pasetoV4NonceSize : len(body)-pasetoV4MACSize]
+	mac := body[len(body)-pasetoV4MACSize:]
+
+	encryptionKey, counterNonce, authKey := pasetoV4LocalKeys(key, nonce)
+	if subtle.ConstantTimeCompare(mac, pasetoV4LocalMAC(authKey, nonce, ciphertext, footer, implicit)) != 1 {
+		return nil, ErrInvalidToken
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(encryptionKey, counterNonce)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	message := make([]byte, len(ciphertext))
+	cipher.XORKeyStream(message, ciphertext)
+	return message, nil
+}
+
+// CreateToken creates a new token for a specific username and duration
+func (maker *PasetoV4LocalMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	if !maker.keys.canSign {
+		return "", nil, ErrNoSigningKey
+	}
+
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+
+	footer, err := encodePasetoFooter(maker.keys.signingKeyID)
+	if err != nil {
+		return "", payload, err
+	}
+
+	nonce := make([]byte, pasetoV4NonceSize)
+	if _, err = rand.Read(nonce); err != nil {
+		return "", payload, err
+	}
+
+	token, err := pasetoV4LocalEncrypt(maker.keys.signingKey, nonce, message, footer, nil)
+	if err != nil {
+		return "", payload, err
+	}
+	return token, payload, nil
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoV4LocalMaker) VerifyToken(token string) (*Payload, error) {
+	body, footer, kid, err := splitPasetoToken(token, pasetoV4LocalHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := maker.keys.verificationKeys[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	message, err := pasetoV4LocalDecrypt(key, body, footer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePasetoPayload(message)
+}
+
+// PasetoV4PublicMaker is a PASETO v4.public token maker, the tokens are signed with an Ed25519 private key so that
+// other services can verify them with the public key alone
+type PasetoV4PublicMaker struct {
+	keys keyRing[ed25519.PrivateKey, ed25519.PublicKey]
+}
+
+// NewPasetoV4PublicMaker creates a new PasetoV4PublicMaker with the keys of keyDir. Each <kid>.pem file holds either
+// an Ed25519 private key (PKCS #8), which can sign and verify, or a public key (PKIX), which can only verify.
+// Tokens are signed with the private key signingKeyID, which can be left empty when keyDir holds a single private key.
+func NewPasetoV4PublicMaker(keyDir string, signingKeyID string) (*PasetoV4PublicMaker, error) {
+	keys, err := loadKeyRing(keyDir, pasetoV4PublicKeyFileExt, signingKeyID, parsePasetoV4PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PasetoV4PublicMaker{keys}, nil
+}
+
+// parsePasetoV4PublicKey parses a PEM encoded Ed25519 key, which can only verify when data holds a public key
+func parsePasetoV4PublicKey(data []byte) (ed25519.PrivateKey, ed25519.PublicKey, bool, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, false, errors.New("no PEM block")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, nil, false, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, key.Public().(ed25519.PublicKey), true, nil
+	case ed25519.PublicKey:
+		return nil, key, false, nil
+	default:
+		return nil, nil, false, fmt.Errorf("unsupported key type %T, must be an Ed25519 key", key)
+	}
+}
+
+// pasetoV4PublicSign signs message into a v4.public token
+func pasetoV4PublicSign(privateKey ed25519.PrivateKey, message []byte, footer []byte, implicit []byte) string {
+	signature := ed25519.Sign(privateKey, pae([]byte(pasetoV4PublicHeader), message, footer, implicit))
+
+	body := make([]byte, 0, len(message)+len(signature))
+	body = append(append(body, message...), signature...)
+	return joinPasetoToken(pasetoV4PublicHeader, body, footer)
+}
+
+// pasetoV4PublicVerify checks the signature of the body and footer of a v4.public token and returns its message
+func pasetoV4PublicVerify(publicKey ed25519.PublicKey, body []byte, footer []byte, implicit []byte) ([]byte, error) {
+	if len(body) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(publicKey, pae([]byte(pasetoV4PublicHeader), message, footer, implicit), signature) {
+		return nil, ErrInvalidToken
+	}
+	return message, nil
+}
+
+// CreateToken creates a new token for a specific username and duration
+func (maker *PasetoV4PublicMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	if !maker.keys.canSign {
+		return "", nil, ErrNoSigningKey
+	}
+
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+
+	footer, err := encodePasetoFooter(maker.keys.signingKeyID)
+	if err != nil {
+		return "", payload, err
+	}
+
+	return pasetoV4PublicSign(maker.keys.signingKey, message, footer, nil), payload, nil
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoV4PublicMaker) VerifyToken(token string) (*Payload, error) {
+	body, footer, kid, err := splitPasetoToken(token, pasetoV4PublicHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := maker.keys.verificationKeys[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	message, err := pasetoV4PublicVerify(publicKey, body, footer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePasetoPayload(message)
+}
@@ -0,0 +1,483 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+// writePasetoV4LocalKey generates a v4.local key and writes it to keyDir as <kid>.key
+func writePasetoV4LocalKey(t *testing.T, keyDir string, kid string) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(keyDir, kid+pasetoV4LocalKeyFileExt), []byte(hex.EncodeToString(key)+"\n"), 0o600)
+	require.NoError(t, err)
+}
+
+// writePasetoV4PublicKey generates an Ed25519 key and writes it to keyDir as <kid>.pem, only the public key if public
+// is true
+func writePasetoV4PublicKey(t *testing.T, keyDir string, kid string, public bool) ed25519.PrivateKey {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block := &pem.Block{Type: "PRIVATE KEY"}
+	if public {
+		block.Type = "PUBLIC KEY"
+		block.Bytes, err = x509.MarshalPKIXPublicKey(publicKey)
+	} else {
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(privateKey)
+	}
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(keyDir, kid+pasetoV4PublicKeyFileExt), pem.EncodeToMemory(block), 0o600)
+	require.NoError(t, err)
+
+	return privateKey
+}
+
+// pasetoTokenFooter decodes the footer of a PASETO token
+func pasetoTokenFooter(t *testing.T, token string) string {
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 4)
+
+	footer, err := base64.RawURLEncoding.DecodeString(parts[3])
+	require.NoError(t, err)
+	return string(footer)
+}
+
+// tamperPasetoToken flips a bit of the body of a PASETO token
+func tamperPasetoToken(t *testing.T, token string) string {
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 4)
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	body[len(body)/2] ^= 1
+	parts[2] = base64.RawURLEncoding.EncodeToString(body)
+
+	return strings.Join(parts, ".")
+}
+
+// tamperPasetoTokenTag flips a bit of the last byte of the body of a PASETO token, which is part of its tag
+func tamperPasetoTokenTag(t *testing.T, token string) string {
+	parts := strings.Split(token, ".")
+	require.GreaterOrEqual(t, len(parts), 3)
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	body[len(body)-1] ^= 1
+	parts[2] = base64.RawURLEncoding.EncodeToString(body)
+
+	return strings.Join(parts, ".")
+}
+
+func TestPasetoV4LocalMaker(t *testing.T) {
+	keyDir := t.TempDir()
+	writePasetoV4LocalKey(t, keyDir, "key-1")
+
+	maker, err := NewPasetoV4LocalMaker(keyDir, "")
+	require.NoError(t, err)
+
+	username := pkg.RandomOwner()
+	role := pkg.DepositorRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+	require.True(t, strings.HasPrefix(token, "v4.local."))
+	require.Equal(t, `{"kid":"key-1"}`, pasetoTokenFooter(t, token))
+	// the payload is encrypted
+	require.NotContains(t, token, base64.RawURLEncoding.EncodeToString([]byte(username)))
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	_, err = maker.VerifyToken(tamperPasetoToken(t, token))
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestExpiredPasetoV4LocalToken(t *testing.T) {
+	keyDir := t.TempDir()
+	writePasetoV4LocalKey(t, keyDir, "key-1")
+
+	maker, err := NewPasetoV4LocalMaker(keyDir, "")
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoV4LocalMakerKeyRotation(t *testing.T) {
+	keyDir := t.TempDir()
+	writePasetoV4LocalKey(t, keyDir, "key-1")
+
+	oldMaker, err := NewPasetoV4LocalMaker(keyDir, "")
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	writePasetoV4LocalKey(t, keyDir, "key-2")
+
+	_, err = NewPasetoV4LocalMaker(keyDir, "")
+	require.Error(t, err)
+
+	maker, err := NewPasetoV4LocalMaker(keyDir, "key-2")
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	newToken, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, `{"kid":"key-2"}`, pasetoTokenFooter(t, newToken))
+
+	_, err = oldMaker.VerifyToken(newToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// the footer is authenticated, pointing it at another key does not make the token valid
+	parts := strings.Split(newToken, ".")
+	parts[3] = base64.RawURLEncoding.EncodeToString([]byte(`{"kid":"key-1"}`))
+	_, err = maker.VerifyToken(strings.Join(parts, "."))
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestNewPasetoV4LocalMakerInvalidKey(t *testing.T) {
+	keyDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(keyDir, "key-1"+pasetoV4LocalKeyFileExt), []byte(hex.EncodeToString([]byte(pkg.RandomString(16)))), 0o600)
+	require.NoError(t, err)
+
+	_, err = NewPasetoV4LocalMaker(keyDir, "")
+	require.Error(t, err)
+
+	err = os.WriteFile(filepath.Join(keyDir, "key-1"+pasetoV4LocalKeyFileExt), []byte(pkg.RandomString(64)), 0o600)
+	require.NoError(t, err)
+
+	_, err = NewPasetoV4LocalMaker(keyDir, "")
+	require.Error(t, err)
+}
+
+func TestPasetoV4PublicMaker(t *testing.T) {
+	keyDir := t.TempDir()
+	writePasetoV4PublicKey(t, keyDir, "key-1", false)
+
+	maker, err := NewPasetoV4PublicMaker(keyDir, "")
+	require.NoError(t, err)
+
+	username := pkg.RandomOwner()
+	role := pkg.DepositorRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+	require.True(t, strings.HasPrefix(token, "v4.public."))
+	require.Equal(t, `{"kid":"key-1"}`, pasetoTokenFooter(t, token))
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	_, err = maker.VerifyToken(tamperPasetoToken(t, token))
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestExpiredPasetoV4PublicToken(t *testing.T) {
+	keyDir := t.TempDir()
+	writePasetoV4PublicKey(t, keyDir, "key-1", false)
+
+	maker, err := NewPasetoV4PublicMaker(keyDir, "")
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoV4PublicMakerKeyRotation(t *testing.T) {
+	oldKeyDir := t.TempDir()
+	oldKey := writePasetoV4PublicKey(t, oldKeyDir, "key-1", false)
+
+	oldMaker, err := NewPasetoV4PublicMaker(oldKeyDir, "")
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// the new key signs, the old one is kept only to verify the tokens it signed until they expire
+	keyDir := t.TempDir()
+	writePasetoV4PublicKey(t, keyDir, "key-2", false)
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(oldKey.Public())
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(keyDir, "key-1"+pasetoV4PublicKeyFileExt), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}), 0o600)
+	require.NoError(t, err)
+
+	maker, err := NewPasetoV4PublicMaker(keyDir, "")
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	newToken, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, `{"kid":"key-2"}`, pasetoTokenFooter(t, newToken))
+
+	_, err = oldMaker.VerifyToken(newToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// a maker with only public keys verifies but cannot create tokens
+	publicKeyDir := t.TempDir()
+	err = os.WriteFile(filepath.Join(publicKeyDir, "key-1"+pasetoV4PublicKeyFileExt), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}), 0o600)
+	require.NoError(t, err)
+
+	verifier, err := NewPasetoV4PublicMaker(publicKeyDir, "")
+	require.NoError(t, err)
+
+	_, err = verifier.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	_, _, err = verifier.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+// pasetoV4SpecLocalKey is the key of the v4.local test vectors of the PASETO spec
+const pasetoV4SpecLocalKey = "707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f"
+
+// pasetoV4SpecMessages are the messages of the test vectors of the PASETO spec
+const (
+	pasetoV4SpecSecretMessage = `{"data":"this is a secret message","exp":"2022-01-01T00:00:00+00:00"}`
+	pasetoV4SpecHiddenMessage = `{"data":"this is a hidden message","exp":"2022-01-01T00:00:00+00:00"}`
+	pasetoV4SpecKeyFooter     = `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`
+)
+
+func TestPasetoV4LocalSpecVectors(t *testing.T) {
+	key, err := hex.DecodeString(pasetoV4SpecLocalKey)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		nonce    string
+		message  string
+		footer   string
+		implicit string
+		token    string
+	}{
+		{
+			name:    "4-E-1",
+			nonce:   "0000000000000000000000000000000000000000000000000000000000000000",
+			message: pasetoV4SpecSecretMessage,
+			token: "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqM" +
+				"fGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg",
+		},
+		{
+			name:    "4-E-2",
+			nonce:   "0000000000000000000000000000000000000000000000000000000000000000",
+			message: pasetoV4SpecHiddenMessage,
+			token: "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvS2csCgglvpk5HC0e8kApeaqM" +
+				"fGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XIemu9chy3WVKvRBfg6t8wwYHK0ArLxxfZP73W_vfwt5A",
+		},
+		{
+			name:    "4-E-5",
+			nonce:   "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8",
+			message: pasetoV4SpecSecretMessage,
+			footer:  pasetoV4SpecKeyFooter,
+			token: "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0" +
+				"KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4x-RMNXtQNbz7FvFZ_G-lFpk5RG3EOrwDL6CgDqcerSQ.eyJraWQiOiJ6" +
+				"VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+		{
+			name:    "4-E-6",
+			nonce:   "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8",
+			message: pasetoV4SpecHiddenMessage,
+			footer:  pasetoV4SpecKeyFooter,
+			token: "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0" +
+				"KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6pWSA5HX2wjb3P-xLQg5K5feUCX4P2fpVK3ZLWFbMSxQ.eyJraWQiOiJ6" +
+				"VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+		{
+			name:     "4-E-9",
+			nonce:    "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8",
+			message:  pasetoV4SpecHiddenMessage,
+			footer:   "arbitrary-string-that-isn't-json",
+			implicit: `{"test-vector":"4-E-9"}`,
+			token: "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0" +
+				"KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6tybdlmnMwcDMw0YxA_gFSE_IUWl78aMtOepFYSWYfQA.YXJiaXRyYXJ5" +
+				"LXN0cmluZy10aGF0LWlzbid0LWpzb24",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nonce, err := hex.DecodeString(tc.nonce)
+			require.NoError(t, err)
+
+			token, err := pasetoV4LocalEncrypt(key, nonce, []byte(tc.message), []byte(tc.footer), []byte(tc.implicit))
+			require.NoError(t, err)
+			require.Equal(t, tc.token, token)
+
+			body, footer, err := parsePasetoToken(tc.token, pasetoV4LocalHeader)
+			require.NoError(t, err)
+			require.Equal(t, tc.footer, string(footer))
+
+			message, err := pasetoV4LocalDecrypt(key, body, footer, []byte(tc.implicit))
+			require.NoError(t, err)
+			require.Equal(t, tc.message, string(message))
+		})
+	}
+}
+
+func TestPasetoV4PublicSpecVector(t *testing.T) {
+	// test vector 4-S-1 of the PASETO spec, which has no footer
+	secretKey, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774" +
+		"1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+
+	message := `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`
+	token := pasetoV4PublicSign(secretKey, []byte(message), nil, nil)
+	require.Equal(t, "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9"+
+		"bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA", token)
+
+	body, footer, err := parsePasetoToken(token, pasetoV4PublicHeader)
+	require.NoError(t, err)
+	verified, err := pasetoV4PublicVerify(ed25519.PrivateKey(secretKey).Public().(ed25519.PublicKey), body, footer, nil)
+	require.NoError(t, err)
+	require.Equal(t, message, string(verified))
+}
+
+// TestPasetoV4SpecFailures covers the failures of the 4-F test vectors of the PASETO spec, built from the tokens of
+// the vectors above: each of them must be rejected
+func TestPasetoV4SpecFailures(t *testing.T) {
+	localKey, err := hex.DecodeString(pasetoV4SpecLocalKey)
+	require.NoError(t, err)
+	publicSecretKey, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774" +
+		"1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+	publicKey := ed25519.PrivateKey(publicSecretKey).Public().(ed25519.PublicKey)
+
+	localToken, err := pasetoV4LocalEncrypt(localKey, make([]byte, pasetoV4NonceSize), []byte(pasetoV4SpecSecretMessage),
+		[]byte(pasetoV4SpecKeyFooter), []byte(`{"test-vector":"4-F"}`))
+	require.NoError(t, err)
+	publicToken := pasetoV4PublicSign(publicSecretKey, []byte(pasetoV4SpecSecretMessage), []byte(pasetoV4SpecKeyFooter), nil)
+
+	decrypt := func(token string, key []byte, implicit string) error {
+		body, footer, err := parsePasetoToken(token, pasetoV4LocalHeader)
+		if err != nil {
+			return err
+		}
+		_, err = pasetoV4LocalDecrypt(key, body, footer, []byte(implicit))
+		return err
+	}
+	verify := func(token string) error {
+		body, footer, err := parsePasetoToken(token, pasetoV4PublicHeader)
+		if err != nil {
+			return err
+		}
+		_, err = pasetoV4PublicVerify(publicKey, body, footer, nil)
+		return err
+	}
+
+	// the tokens are valid as they are
+	require.NoError(t, decrypt(localToken, localKey, `{"test-vector":"4-F"}`))
+	require.NoError(t, verify(publicToken))
+
+	otherKey := make([]byte, len(localKey))
+	copy(otherKey, localKey)
+	otherKey[0] ^= 1
+
+	// the footer of the token pointed at another key
+	parts := strings.Split(localToken, ".")
+	require.Len(t, parts, 4)
+	parts[3] = base64.RawURLEncoding.EncodeToString([]byte(`{"kid":"other"}`))
+	modifiedFooterToken := strings.Join(parts, ".")
+
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		// a local token where a public one is expected and the other way round
+		{name: "public token as local", err: decrypt(publicToken, localKey, `{"test-vector":"4-F"}`)},
+		{name: "local token as public", err: verify(localToken)},
+		// a token of another version
+		{name: "other version", err: decrypt(strings.Replace(localToken, "v4.", "v3.", 1), localKey, `{"test-vector":"4-F"}`)},
+		{name: "modified tag", err: decrypt(tamperPasetoTokenTag(t, localToken), localKey, `{"test-vector":"4-F"}`)},
+		{name: "modified footer", err: decrypt(modifiedFooterToken, localKey, `{"test-vector":"4-F"}`)},
+		{name: "padded footer", err: decrypt(localToken+"=", localKey, `{"test-vector":"4-F"}`)},
+		{name: "missing implicit assertion", err: decrypt(localToken, localKey, "")},
+		{name: "wrong implicit assertion", err: decrypt(localToken, localKey, `{"test-vector":"4-E-9"}`)},
+		{name: "wrong key", err: decrypt(localToken, otherKey, `{"test-vector":"4-F"}`)},
+		{name: "truncated", err: decrypt(pasetoV4LocalHeader+base64.RawURLEncoding.EncodeToString(make([]byte, pasetoV4NonceSize)), localKey, "")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorIs(t, tc.err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestPasetoTokensAreNotInterchangeable(t *testing.T) {
+	localKeyDir := t.TempDir()
+	writePasetoV4LocalKey(t, localKeyDir, "key-1")
+	localMaker, err := NewPasetoV4LocalMaker(localKeyDir, "")
+	require.NoError(t, err)
+
+	publicKeyDir := t.TempDir()
+	writePasetoV4PublicKey(t, publicKeyDir, "key-1", false)
+	publicMaker, err := NewPasetoV4PublicMaker(publicKeyDir, "")
+	require.NoError(t, err)
+
+	v2Maker, err := NewPasetoMaker(pkg.RandomString(32))
+	require.NoError(t, err)
+
+	makers := []Maker{localMaker, publicMaker, v2Maker}
+	for i, maker := range makers {
+		token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+		require.NoError(t, err)
+
+		for j, other := range makers {
+			if i == j {
+				continue
+			}
+			_, err = other.VerifyToken(token)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package token
+
+import (
+	"time"
+)
+
+// paymentLinkKeyLabel separates the payment link signing key from the other keys derived from the signer secret
+const paymentLinkKeyLabel = "mybank payment link v1"
+
+// PaymentLinkPayload is the content of a signed payment link
+type PaymentLinkPayload struct {
+	LinkID      int64 `json:"link_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// Amount is zero when the payer chooses how much to pay
+	Amount    int64     `json:"amount,omitempty"`
+	Currency  string    `json:"currency"`
+	Reference string    `json:"reference,omitempty"`
+	SingleUse bool      `json:"single_use"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// Valid checks if the payment link has expired
+func (payload *PaymentLinkPayload) Valid() error {
+	if time.Now().After(payload.ExpiredAt) {
+		return ErrExpiredToken
+	}
+	return nil
+}
+
+// PaymentLinkSigner signs and verifies payment links with a key derived from the server's signer secret
+type PaymentLinkSigner struct {
+	signer hmacSigner
+}
+
+// NewPaymentLinkSigner creates a new PaymentLinkSigner, the payment links signed with one of
+// previousSecretKeys are still accepted but no new ones are signed with them
+func NewPaymentLinkSigner(secretKey string, previousSecretKeys ...string) (*PaymentLinkSigner, error) {
+	signer, err := newHMACSigner(secretKey, paymentLinkKeyLabel, previousSecretKeys...)
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentLinkSigner{signer: signer}, nil
+}
+
+// Sign returns the signed payment link for payload
+func (signer *PaymentLinkSigner) Sign(payload PaymentLinkPayload) (string, error) {
+	return signer.signer.sign(payload)
+}
+
+// Verify checks the signature and expiry of a signed payment link and returns its payload
+func (signer *PaymentLinkSigner) Verify(link string) (*PaymentLinkPayload, error) {
+	payload := &PaymentLinkPayload{}
+	if err := signer.signer.verify(link, payload); err != nil {
+		return nil, err
+	}
+
+	if err := payload.Valid(); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
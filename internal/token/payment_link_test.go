@@ -0,0 +1,151 @@
+package token
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marco-almeida/mybank/internal/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPaymentLinkPayload(duration time.Duration) PaymentLinkPayload {
+	return PaymentLinkPayload{
+		LinkID:      pkg.RandomInt(1, 1000),
+		ToAccountID: pkg.RandomInt(1, 1000),
+		Amount:      pkg.RandomMoney(),
+		Currency:    pkg.RandomCurrency(),
+		Reference:   pkg.RandomString(12),
+		SingleUse:   true,
+		ExpiredAt:   time.Now().Add(duration).Truncate(time.Second),
+	}
+}
+
+func TestPaymentLinkSigner(t *testing.T) {
+	signer, err := NewPaymentLinkSigner(pkg.RandomString(32))
+	require.NoError(t, err)
+
+	payload := randomPaymentLinkPayload(time.Minute)
+	link, err := signer.Sign(payload)
+	require.NoError(t, err)
+	require.NotEmpty(t, link)
+
+	// signing is deterministic, so the same link can be rendered again later
+	again, err := signer.Sign(payload)
+	require.NoError(t, err)
+	require.Equal(t, link, again)
+
+	verified, err := signer.Verify(link)
+	require.NoError(t, err)
+	require.Equal(t, payload.LinkID, verified.LinkID)
+	require.Equal(t, payload.ToAccountID, verified.ToAccountID)
+	require.Equal(t, payload.Amount, verified.Amount)
+	require.Equal(t, payload.Currency, verified.Currency)
+	require.Equal(t, payload.Reference, verified.Reference)
+	require.Equal(t, payload.SingleUse, verified.SingleUse)
+	require.WithinDuration(t, payload.ExpiredAt, verified.ExpiredAt, time.Second)
+}
+
+func TestExpiredPaymentLink(t *testing.T) {
+	signer, err := NewPaymentLinkSigner(pkg.RandomString(32))
+	require.NoError(t, err)
+
+	link, err := signer.Sign(randomPaymentLinkPayload(-time.Minute))
+	require.NoError(t, err)
+
+	payload, err := signer.Verify(link)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func TestTamperedPaymentLink(t *testing.T) {
+	signer, err := NewPaymentLinkSigner(pkg.RandomString(32))
+	require.NoError(t, err)
+
+	payload := randomPaymentLinkPayload(time.Minute)
+	link, err := signer.Sign(payload)
+	require.NoError(t, err)
+	_, signature, _ := strings.Cut(link, ".")
+
+	// a different amount under the original signature
+	payload.Amount++
+	tampered, err := signer.Sign(payload)
+	require.NoError(t, err)
+	encodedPayload, _, _ := strings.Cut(tampered, ".")
+
+	otherSigner, err := NewPaymentLinkSigner(pkg.RandomString(32))
+	require.NoError(t, err)
+	otherLink, err := otherSigner.Sign(payload)
+	require.NoError(t, err)
+
+	for _, link := range []string{
+		encodedPayload + "." + signature,
+		otherLink,
+		encodedPayload,
+		encodedPayload + ".",
+		base64.RawURLEncoding.EncodeToString([]byte("{}")) + "." + signature,
+	} {
+		payload, err := signer.Verify(link)
+		require.ErrorIs(t, err, ErrInvalidToken)
+		require.Nil(t, payload)
+	}
+}
+
+func TestPaymentLinkSignedWithPreviousSecret(t *testing.T) {
+	previousSecretKey := pkg.RandomString(32)
+	previousSigner, err := NewPaymentLinkSigner(previousSecretKey)
+	require.NoError(t, err)
+
+	payload := randomPaymentLinkPayload(time.Minute)
+	previousLink, err := previousSigner.Sign(payload)
+	require.NoError(t, err)
+
+	signer, err := NewPaymentLinkSigner(pkg.RandomString(32), previousSecretKey)
+	require.NoError(t, err)
+
+	// the links shared before the secret was changed still verify
+	verified, err := signer.Verify(previousLink)
+	require.NoError(t, err)
+	require.Equal(t, payload.LinkID, verified.LinkID)
+
+	// but new links are only signed with the new secret
+	link, err := signer.Sign(payload)
+	require.NoError(t, err)
+	require.NotEqual(t, previousLink, link)
+	_, err = previousSigner.Verify(link)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// a previous secret doesn't make the link outlive its expiry
+	expiredLink, err := previousSigner.Sign(randomPaymentLinkPayload(-time.Minute))
+	require.NoError(t, err)
+	_, err = signer.Verify(expiredLink)
+	require.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestPaymentLinkIsNotAnAccessToken(t *testing.T) {
+	secretKey := pkg.RandomString(32)
+
+	signer, err := NewPaymentLinkSigner(secretKey)
+	require.NoError(t, err)
+	maker, err := NewJWTMaker(secretKey)
+	require.NoError(t, err)
+
+	link, err := signer.Sign(randomPaymentLinkPayload(time.Minute))
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(link)
+	require.Error(t, err)
+
+	token, _, err := maker.CreateToken(pkg.RandomOwner(), pkg.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	_, err = signer.Verify(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestPaymentLinkSignerKeySize(t *testing.T) {
+	_, err := NewPaymentLinkSigner(pkg.RandomString(31))
+	require.Error(t, err)
+
+	_, err = NewPaymentLinkSigner(pkg.RandomString(32), pkg.RandomString(31))
+	require.Error(t, err)
+}
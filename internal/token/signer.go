@@ -0,0 +1,102 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// hmacSigner signs JSON payloads with a key derived from the server's signer secret and a label, so that what is
+// signed for one purpose can never be passed off as something else or as an access token.
+// A signed payload is the base64url encoded JSON payload and its HMAC-SHA256, separated by a dot.
+type hmacSigner struct {
+	key []byte
+	// previousKeys only verify, so that what was signed before the signer secret was changed stays valid
+	previousKeys [][]byte
+}
+
+func newHMACSigner(secretKey string, label string, previousSecretKeys ...string) (hmacSigner, error) {
+	key, err := deriveHMACKey(secretKey, label)
+	if err != nil {
+		return hmacSigner{}, err
+	}
+
+	signer := hmacSigner{key: key}
+	for _, previousSecretKey := range previousSecretKeys {
+		previousKey, err := deriveHMACKey(previousSecretKey, label)
+		if err != nil {
+			return hmacSigner{}, fmt.Errorf("previous key: %w", err)
+		}
+		signer.previousKeys = append(signer.previousKeys, previousKey)
+	}
+	return signer, nil
+}
+
+func deriveHMACKey(secretKey string, label string) ([]byte, error) {
+	if len(secretKey) < minSecretKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(label))
+	return mac.Sum(nil), nil
+}
+
+func (signer hmacSigner) sign(payload any) (string, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(jsonPayload)
+	return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(signer.sum(encodedPayload)), nil
+}
+
+// verify checks the signature of signed against the key and the previous keys and decodes its payload into payload,
+// it returns ErrInvalidToken if either fails
+func (signer hmacSigner) verify(signed string, payload any) error {
+	encodedPayload, encodedSignature, ok := strings.Cut(signed, ".")
+	if !ok {
+		return ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !signer.matches(signature, encodedPayload) {
+		return ErrInvalidToken
+	}
+
+	jsonPayload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	if err := json.Unmarshal(jsonPayload, payload); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func (signer hmacSigner) matches(signature []byte, encodedPayload string) bool {
+	if hmac.Equal(signature, signer.sum(encodedPayload)) {
+		return true
+	}
+	for _, previousKey := range signer.previousKeys {
+		if hmac.Equal(signature, hmacSum(previousKey, encodedPayload)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (signer hmacSigner) sum(encodedPayload string) []byte {
+	return hmacSum(signer.key, encodedPayload)
+}
+
+func hmacSum(key []byte, encodedPayload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encodedPayload))
+	return mac.Sum(nil)
+}